@@ -0,0 +1,157 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/akosgarai/opengl_playground/pkg/texturecontainer"
+)
+
+var (
+	format    = flag.String("format", "auto", "The format of the levels: rgba, bc1, bc3 or auto (bc1 for the opaque images, bc3 for the others).")
+	container = flag.String("container", "ktx2", "The container of the output files: ktx2 or dds.")
+	mipmaps   = flag.Bool("mipmaps", true, "Build the mipmap chain.")
+	force     = flag.Bool("force", false, "Overwrite the up to date output files.")
+	all       = flag.Bool("all", false, "Convert every image, not only the images in the assets directories.")
+)
+
+// opaque returns true if every pixel of the image is opaque.
+func opaque(img *image.RGBA) bool {
+	for i := 3; i < len(img.Pix); i += 4 {
+		if img.Pix[i] != 255 {
+			return false
+		}
+	}
+	return true
+}
+
+// levelFormat returns the format of the converted texture.
+func levelFormat(img *image.RGBA) (texturecontainer.Format, error) {
+	switch *format {
+	case "rgba":
+		return texturecontainer.FORMAT_RGBA8, nil
+	case "bc1":
+		return texturecontainer.FORMAT_BC1, nil
+	case "bc3":
+		return texturecontainer.FORMAT_BC3, nil
+	case "auto":
+		if opaque(img) {
+			return texturecontainer.FORMAT_BC1, nil
+		}
+		return texturecontainer.FORMAT_BC3, nil
+	}
+	return 0, fmt.Errorf("Unknown format '%s'.", *format)
+}
+
+// isImage returns true if the file is a png or jpeg image, that is in an assets directory.
+func isImage(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".jpg", ".jpeg":
+	default:
+		return false
+	}
+	if *all {
+		return true
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if dir == "assets" {
+			return true
+		}
+	}
+	return false
+}
+
+// upToDate returns true if the output file is newer than the input file.
+func upToDate(input, output string) bool {
+	inputInfo, err := os.Stat(input)
+	if err != nil {
+		return false
+	}
+	outputInfo, err := os.Stat(output)
+	if err != nil {
+		return false
+	}
+	return outputInfo.ModTime().After(inputInfo.ModTime())
+}
+
+// convert converts the image to the container next to it.
+func convert(input string) error {
+	output := strings.TrimSuffix(input, filepath.Ext(input)) + "." + *container
+	if !*force && upToDate(input, output) {
+		fmt.Printf("%s: up to date\n", output)
+		return nil
+	}
+	img, err := texturecontainer.DecodeImage(input)
+	if err != nil {
+		return err
+	}
+	f, err := levelFormat(img)
+	if err != nil {
+		return err
+	}
+	t, err := texturecontainer.FromImage(img, f, *mipmaps)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	switch *container {
+	case "ktx2":
+		err = texturecontainer.EncodeKTX2(file, t)
+	case "dds":
+		err = texturecontainer.EncodeDDS(file, t)
+	default:
+		err = fmt.Errorf("Unknown container '%s'.", *container)
+	}
+	if err != nil {
+		os.Remove(output)
+		return err
+	}
+	fmt.Printf("%s: %dx%d %s, levels: %d, %.2f MB\n", output, t.Width, t.Height, t.Format, len(t.Levels), float64(t.Size())/(1<<20))
+	return nil
+}
+
+// It walks the given directories (the current one by default) and converts
+// the images of the assets directories to ktx2 or dds containers, next to
+// the original images.
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [directories]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	roots := flag.Args()
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+	failed := false
+	for _, root := range roots {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || !isImage(path) {
+				return nil
+			}
+			if err := convert(path); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+				failed = true
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"runtime"
@@ -19,6 +20,8 @@ import (
 	"github.com/akosgarai/playground_engine/pkg/shader"
 	"github.com/akosgarai/playground_engine/pkg/window"
 
+	"github.com/akosgarai/opengl_playground/pkg/lod"
+
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
 )
@@ -34,15 +37,17 @@ const (
 
 var (
 	app            *application.Application
-	Ball           *mesh.ColorMesh
+	Ball           *lod.Model
 	Ground         *mesh.ColorMesh
 	SettingsScreen *screen.FormScreen
 	MenuScreen     *screen.MenuScreen
-	AppScreen      *screen.Screen
+	AppScreen      *lod.Screen
 	Settings       = config.New()
 
 	lastUpdate int64
 	startTime  int64
+	// The level of the ball in the previous frame.
+	ballLevel int
 
 	BallInitialDirection = mgl32.Vec3{0, 1, 0}
 
@@ -52,15 +57,22 @@ var (
 func init() {
 	runtime.LockOSThread()
 
-	var colorValidator, heightValidator model.FloatValidator
+	var colorValidator, heightValidator, lodSizeValidator, hysteresisValidator model.FloatValidator
+	var lodLevelsValidator model.IntValidator
 	colorValidator = func(f float32) bool { return f >= 0 && f <= 1 }
 	heightValidator = func(f float32) bool { return f >= 0.0 }
+	lodSizeValidator = func(f float32) bool { return f > 0.0 }
+	hysteresisValidator = func(f float32) bool { return f >= 0.0 && f < 1.0 }
+	lodLevelsValidator = func(i int) bool { return i >= 0 }
 	Settings.AddConfig("ClearCol", "BG color", "The clear color of the window. It is used as the color of the background.", mgl32.Vec3{0.3, 0.3, 0.3}, colorValidator)
 	Settings.AddConfig("SphereColor", "Sphere Color", "The color of the sphere.", mgl32.Vec3{1.0, 0.0, 0.0}, colorValidator)
 	Settings.AddConfig("SpherePosition", "Sphere position", "The position of the sphere item.", mgl32.Vec3{0.0, 5.0, 0.0}, nil)
 	Settings.AddConfig("SphereScale", "Sphere scale", "The scale of the sphere item.", mgl32.Vec3{2.0, 2.0, 2.0}, nil)
 	Settings.AddConfig("SphereSpeed", "Sphere speed", "The velocity of the sphere item.", float32(0.02), nil)
-	Settings.AddConfig("SpherePrecision", "Sphere precision", "The precision of the sphere item.", int(10), nil)
+	Settings.AddConfig("SpherePrecision", "Sphere precision", "The precision of the sphere item.", int(40), nil)
+	Settings.AddConfig("SphereLodLevels", "Sphere lods", "The number of the simplified levels of the sphere. Every level has half of the triangles of the previous one.", int(4), lodLevelsValidator)
+	Settings.AddConfig("SphereLodSize", "Lod size", "The projected size (1 is half of the screen height) where the first simplified level is displayed. The next levels are displayed at the half sizes.", float32(0.4), lodSizeValidator)
+	Settings.AddConfig("SphereLodHysteresis", "Lod hysteresis", "The projected size has to cross the threshold with this ratio to change the level of the sphere.", float32(0.15), hysteresisValidator)
 	Settings.AddConfig("SphereMaxHeight", "Sphere height", "The top position of the sphere item.", float32(10.0), heightValidator)
 	Settings.AddConfig("SquareColor", "Surface Color", "The color of the square surface.", mgl32.Vec3{0.0, 1.0, 0.0}, colorValidator)
 	Settings.AddConfig("SquareScale", "Surface scale", "The scale of the square surface.", mgl32.Vec3{40.0, 40.0, 40.0}, nil)
@@ -104,16 +116,42 @@ func CreateCameraFromSettings() *camera.DefaultCamera {
 	return camera
 }
 
-func CreateSphereMesh() *mesh.ColorMesh {
+// It generates the levels of the sphere and returns them in a lod model.
+func CreateSphereModel() *lod.Model {
 	s := sphere.New(Settings["SpherePrecision"].GetCurrentValue().(int))
 	cols := []mgl32.Vec3{Settings["SphereColor"].GetCurrentValue().(mgl32.Vec3)}
 	v, i, _ := s.ColoredMeshInput(cols)
-	m := mesh.NewColorMesh(v, i, cols, glWrapper)
-	m.SetPosition(Settings["SpherePosition"].GetCurrentValue().(mgl32.Vec3))
-	m.SetScale(Settings["SphereScale"].GetCurrentValue().(mgl32.Vec3))
-	m.SetDirection(BallInitialDirection)
-	m.SetSpeed(Settings["SphereSpeed"].GetCurrentValue().(float32))
-	return m
+	var ratios, thresholds []float32
+	ratio, threshold := float32(1.0), Settings["SphereLodSize"].GetCurrentValue().(float32)
+	for l := 0; l < Settings["SphereLodLevels"].GetCurrentValue().(int); l++ {
+		ratio /= 2
+		ratios = append(ratios, ratio)
+		thresholds = append(thresholds, threshold)
+		threshold /= 2
+	}
+	var meshes []interfaces.Mesh
+	for _, level := range lod.Generate(v, i, ratios) {
+		m := mesh.NewColorMesh(level.Vertices, level.Indices, cols, glWrapper)
+		m.SetPosition(Settings["SpherePosition"].GetCurrentValue().(mgl32.Vec3))
+		m.SetScale(Settings["SphereScale"].GetCurrentValue().(mgl32.Vec3))
+		m.SetDirection(BallInitialDirection)
+		m.SetSpeed(Settings["SphereSpeed"].GetCurrentValue().(float32))
+		meshes = append(meshes, m)
+	}
+	mod, err := lod.NewModel(meshes)
+	if err != nil {
+		panic(err)
+	}
+	if err := mod.SetThresholds(thresholds); err != nil {
+		panic(err)
+	}
+	if err := mod.SetHysteresis(Settings["SphereLodHysteresis"].GetCurrentValue().(float32)); err != nil {
+		panic(err)
+	}
+	// The sphere primitive is the unit sphere.
+	mod.SetBoundingSphere(mgl32.Vec3{0.0, 0.0, 0.0}, 1.0)
+	ballLevel = 0
+	return mod
 }
 
 // It generates a square.
@@ -158,6 +196,10 @@ func Update() {
 	}
 	lastUpdate = nowNano
 	app.Update(delta)
+	if Ball.GetLevel() != ballLevel {
+		ballLevel = Ball.GetLevel()
+		fmt.Printf("Ball level: %d / %d\n", ballLevel, Ball.GetLevelCount()-1)
+	}
 }
 
 func baseDir() string {
@@ -177,6 +219,8 @@ func createSettings(defaults config.Config) *screen.FormScreen {
 		"SpherePosition",
 		"SphereScale",
 		"SphereSpeed", "SpherePrecision",
+		"SphereLodLevels", "SphereLodSize",
+		"SphereLodHysteresis",
 		"SquareColor",
 		"SquareScale",
 		"SquarePosition",
@@ -234,20 +278,20 @@ func createMenu() *screen.MenuScreen {
 }
 func GenerateModel() *model.BaseModel {
 	mod := model.New()
-	Ball = CreateSphereMesh()
-	mod.AddMesh(Ball)
 	Ground = CreateSquareMesh()
 	mod.AddMesh(Ground)
 	return mod
 }
 
-func mainScreen() *screen.Screen {
-	scrn := screen.New()
+func mainScreen() *lod.Screen {
+	scrn := lod.NewScreen()
 	scrn.SetupCamera(CreateCameraFromSettings(), CameraMovementOptions())
 
 	shaderProgram := shader.NewShader(baseDir()+"/shaders/vertexshader.vert", baseDir()+"/shaders/fragmentshader.frag", glWrapper)
 	scrn.AddShader(shaderProgram)
 	scrn.AddModelToShader(GenerateModel(), shaderProgram)
+	Ball = CreateSphereModel()
+	scrn.AddModelToShader(Ball, shaderProgram)
 	scrn.Setup(setupApp)
 	return scrn
 }
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"runtime"
 	"time"
 
+	"github.com/akosgarai/opengl_playground/pkg/assetloader"
+	"github.com/akosgarai/opengl_playground/pkg/glext"
+	"github.com/akosgarai/opengl_playground/pkg/texturecache"
+	"github.com/akosgarai/opengl_playground/pkg/texturecontainer"
 	"github.com/akosgarai/playground_engine/pkg/application"
 	"github.com/akosgarai/playground_engine/pkg/camera"
 	"github.com/akosgarai/playground_engine/pkg/config"
@@ -31,6 +36,8 @@ const (
 	WindowHeight = 800
 	WindowTitle  = "Example - textured spheres"
 
+	FontFile = "/../../assets/fonts/Desyrel/desyrel.regular.ttf"
+
 	FORM_ENV_NAME = "SETTINGS"
 	ON_VALUE      = "on"
 )
@@ -47,10 +54,19 @@ var (
 	lastUpdate     int64
 	startTime      int64
 
+	// The textures are loaded by the loading screen, before the main screen is created.
+	SunTexture    texture.Textures
+	EarthTexture  texture.Textures
+	VenusTexture  texture.Textures
+	SkyboxTexture texture.Textures
+	AssetLoader   *assetloader.Loader
+	// The same image with the same parameters is uploaded only once (eg. the diffuse and specular textures).
+	TextureCache *texturecache.Cache
+
 	rotationAngle   = float32(0.0)
 	spherePrimitive = sphere.New(20)
 
-	glWrapper glwrapper.Wrapper
+	glWrapper glext.Wrapper
 )
 
 // Setup options for the camera
@@ -178,8 +194,11 @@ func Update() {
 	delta := float64(nowNano-lastUpdate) / float64(time.Millisecond)
 	lastUpdate = nowNano
 
-	updatePlanets(delta)
-	updateSun(delta)
+	// The planets are created after the loading screen.
+	if AppScreen != nil {
+		updatePlanets(delta)
+		updateSun(delta)
+	}
 
 	app.Update(delta)
 }
@@ -289,16 +308,10 @@ func mainScreen() *screen.Screen {
 	TexMatModel := model.New()
 	CmModel := model.New()
 	// sun texture
-	var sunTexture texture.Textures
-	sunTexture.AddTexture(baseDir()+"/assets/sun.jpg", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.diffuse", glWrapper)
-	sunTexture.AddTexture(baseDir()+"/assets/sun.jpg", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.specular", glWrapper)
-	Sun = TexturedSphere(sunTexture, Settings["SunPosition"].GetCurrentValue().(mgl32.Vec3), Settings["SunRadius"].GetCurrentValue().(float32))
+	Sun = TexturedSphere(SunTexture, Settings["SunPosition"].GetCurrentValue().(mgl32.Vec3), Settings["SunRadius"].GetCurrentValue().(float32))
 	TexModel.AddMesh(Sun)
-	// sun texture
-	var earthTexture texture.Textures
-	earthTexture.AddTexture(baseDir()+"/assets/earth.jpg", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.diffuse", glWrapper)
-	earthTexture.AddTexture(baseDir()+"/assets/earth.jpg", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.specular", glWrapper)
-	Earth = TexturedSphere(sunTexture, Settings["EarthPosition"].GetCurrentValue().(mgl32.Vec3), Settings["EarthRadius"].GetCurrentValue().(float32))
+	// earth
+	Earth = TexturedSphere(SunTexture, Settings["EarthPosition"].GetCurrentValue().(mgl32.Vec3), Settings["EarthRadius"].GetCurrentValue().(float32))
 	distance := Earth.GetPosition().Len()
 	Earth.SetSpeed((float32(2) * float32(3.1415) * distance) / Settings["EarthRoundSpeed"].GetCurrentValue().(float32))
 	Earth.SetDirection((mgl32.Vec3{0, 0, 1}).Normalize())
@@ -307,10 +320,7 @@ func mainScreen() *screen.Screen {
 	// other planet texture
 	shaderProgramTextureMaterial := shader.NewShader(baseDir()+"/shaders/texturemat.vert", baseDir()+"/shaders/texturemat.frag", glWrapper)
 	scrn.AddShader(shaderProgramTextureMaterial)
-	var materialTexture texture.Textures
-	materialTexture.AddTexture(baseDir()+"/assets/venus.jpg", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "tex.diffuse", glWrapper)
-	materialTexture.AddTexture(baseDir()+"/assets/venus.jpg", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "tex.specular", glWrapper)
-	MatPlanet = TexturedMaterialSphere(sunTexture, material.Gold, Settings["MaterialPosition"].GetCurrentValue().(mgl32.Vec3), Settings["MaterialRadius"].GetCurrentValue().(float32))
+	MatPlanet = TexturedMaterialSphere(SunTexture, material.Gold, Settings["MaterialPosition"].GetCurrentValue().(mgl32.Vec3), Settings["MaterialRadius"].GetCurrentValue().(float32))
 	distance = MatPlanet.GetPosition().Len()
 	MatPlanet.SetSpeed((float32(2) * float32(3.1415) * distance) / Settings["MaterialRoundSpeed"].GetCurrentValue().(float32))
 	MatPlanet.SetDirection((mgl32.Vec3{0, 0, 1}).Normalize())
@@ -319,9 +329,7 @@ func mainScreen() *screen.Screen {
 
 	shaderProgramCubeMap := shader.NewShader(baseDir()+"/shaders/cubeMap.vert", baseDir()+"/shaders/cubeMap.frag", glWrapper)
 	scrn.AddShader(shaderProgramCubeMap)
-	var cubeMapTexture texture.Textures
-	cubeMapTexture.AddCubeMapTexture(baseDir()+"/assets", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "skybox", glWrapper)
-	cubeMap := CubeMap(cubeMapTexture)
+	cubeMap := CubeMap(SkyboxTexture)
 	d := Settings["SkyboxDistance"].GetCurrentValue().(float32)
 	cubeMap.SetScale(mgl32.Vec3{d, d, d})
 	CmModel.AddMesh(cubeMap)
@@ -329,23 +337,29 @@ func mainScreen() *screen.Screen {
 	scrn.Setup(setupApp)
 	return scrn
 }
-func AddFormScreen() bool {
-	val := os.Getenv(FORM_ENV_NAME)
-	if val == ON_VALUE {
-		return true
-	}
-	return false
-}
 
-func main() {
-	app = application.New(glWrapper)
-	app.SetWindow(window.InitGlfw(WindowWidth, WindowHeight, WindowTitle))
-	defer glfw.Terminate()
-	glWrapper.InitOpenGL()
+// loadAssets inserts the textures of the main screen to the loader. The images
+// are decoded on the worker goroutines, the gl textures are created by the
+// loading screen.
+func loadAssets(l *assetloader.Loader) {
+	l.AddTexture(&SunTexture, texturecontainer.Resolve(baseDir()+"/assets/sun.jpg"), glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.diffuse", glWrapper)
+	l.AddTexture(&SunTexture, texturecontainer.Resolve(baseDir()+"/assets/sun.jpg"), glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.specular", glWrapper)
+	l.AddTexture(&EarthTexture, texturecontainer.Resolve(baseDir()+"/assets/earth.jpg"), glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.diffuse", glWrapper)
+	l.AddTexture(&EarthTexture, texturecontainer.Resolve(baseDir()+"/assets/earth.jpg"), glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.specular", glWrapper)
+	l.AddTexture(&VenusTexture, texturecontainer.Resolve(baseDir()+"/assets/venus.jpg"), glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "tex.diffuse", glWrapper)
+	l.AddTexture(&VenusTexture, texturecontainer.Resolve(baseDir()+"/assets/venus.jpg"), glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "tex.specular", glWrapper)
+	l.AddCubeMapTexture(&SkyboxTexture, baseDir()+"/assets", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "skybox", glWrapper)
+}
 
+// startApp is called when every asset is loaded. It creates the main screen and
+// the menu and settings screens, if the form screen is enabled.
+func startApp() {
+	if errs := AssetLoader.Errors(); len(errs) > 0 {
+		panic(errs[0])
+	}
+	fmt.Print(TextureCache.Log())
 	AppScreen = mainScreen()
 	app.AddScreen(AppScreen)
-	app.GetWindow().SetKeyCallback(app.KeyCallback)
 	if AddFormScreen() {
 		app.GetWindow().SetMouseButtonCallback(app.MouseButtonCallback)
 		app.GetWindow().SetCharCallback(app.CharCallback)
@@ -360,6 +374,31 @@ func main() {
 	}
 	lastUpdate = time.Now().UnixNano()
 	startTime = lastUpdate
+}
+func AddFormScreen() bool {
+	val := os.Getenv(FORM_ENV_NAME)
+	if val == ON_VALUE {
+		return true
+	}
+	return false
+}
+
+func main() {
+	app = application.New(glWrapper)
+	app.SetWindow(window.InitGlfw(WindowWidth, WindowHeight, WindowTitle))
+	defer glfw.Terminate()
+	glWrapper.InitOpenGL()
+
+	TextureCache = texturecache.New(glWrapper)
+	AssetLoader = assetloader.New(0)
+	AssetLoader.SetCache(TextureCache)
+	loadAssets(AssetLoader)
+	loadingScreen := assetloader.NewScreen(AssetLoader, baseDir()+FontFile, glWrapper, startApp)
+	app.AddScreen(loadingScreen)
+	app.ActivateScreen(loadingScreen)
+	app.GetWindow().SetKeyCallback(app.KeyCallback)
+	lastUpdate = time.Now().UnixNano()
+	startTime = lastUpdate
 
 	for !app.GetWindow().ShouldClose() {
 		glWrapper.Clear(glwrapper.COLOR_BUFFER_BIT | glwrapper.DEPTH_BUFFER_BIT)
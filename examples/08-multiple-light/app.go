@@ -6,6 +6,8 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/akosgarai/opengl_playground/pkg/glext"
+	"github.com/akosgarai/opengl_playground/pkg/texturecache"
 	"github.com/akosgarai/playground_engine/pkg/application"
 	"github.com/akosgarai/playground_engine/pkg/camera"
 	"github.com/akosgarai/playground_engine/pkg/config"
@@ -51,7 +53,11 @@ var (
 	AppScreen      *screen.Screen
 	Settings       = config.New()
 
-	glWrapper glwrapper.Wrapper
+	// The textures of the main screen. They are released when the main screen is rebuilt.
+	TextureCache   *texturecache.Cache
+	ScreenTextures []texture.Textures
+
+	glWrapper glext.Wrapper
 )
 
 func init() {
@@ -61,6 +67,9 @@ func init() {
 	colorValidator = func(f float32) bool { return f >= 0 && f <= 1 }
 	Settings.AddConfig("ClearCol", "BG color", "The clear color of the window. It is used as the color of the background.", mgl32.Vec3{0.0, 0.0, 0.0}, colorValidator)
 	Settings.AddConfig("SurfaceSize", "Surface size", "The size of the surface area. This value is used for scaling", float32(100.0), nil)
+	var qualityValidator model.IntValidator
+	qualityValidator = func(i int) bool { return i >= texturecache.QUALITY_LOW && i <= texturecache.QUALITY_HIGH }
+	Settings.AddConfig("TextureQuality", "Tex quality", "The quality of the textures. 0: without mipmaps, 1: mipmaps, 2: trilinear filtering with anisotropy.", int(texturecache.QUALITY_HIGH), qualityValidator)
 	// light sources
 	Settings.AddConfig("LSConstantTerm", "Constant term", "The constant term of the light equasion.", float32(1.0), nil)
 	Settings.AddConfig("LSLinearTerm", "Linear term", "The linear term of the light equasion.", float32(0.14), nil)
@@ -237,7 +246,7 @@ func setupApp(glWrapper interfaces.GLWrapper) {
 }
 func createSettings(defaults config.Config) *screen.FormScreen {
 	formItemOrders := []string{
-		"ClearCol",
+		"ClearCol", "TextureQuality",
 		"LSConstantTerm", "LSLinearTerm",
 		"LSQuadraticTerm",
 
@@ -321,6 +330,19 @@ func createMenu() *screen.MenuScreen {
 	return app.BuildMenuScreen(options)
 }
 
+// cachedTextures returns the diffuse and specular textures from the texture cache.
+// The mipmaps and the anisotropy depend on the quality setting.
+func cachedTextures(diffuse, specular string) texture.Textures {
+	var t texture.Textures
+	if err := TextureCache.AddTexture(&t, diffuse, glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.diffuse"); err != nil {
+		panic(err)
+	}
+	if err := TextureCache.AddTexture(&t, specular, glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.specular"); err != nil {
+		panic(err)
+	}
+	ScreenTextures = append(ScreenTextures, t)
+	return t
+}
 func mainScreen() *screen.Screen {
 	scrn := screen.New()
 	scrn.SetupCamera(CreateCameraFromSettings(), CameraMovementOptions())
@@ -354,18 +376,19 @@ func mainScreen() *screen.Screen {
 	TexModel := model.New()
 	MatModel := model.New()
 
+	// The quality is applied to the textures of the cache.
+	texturecache.SetQuality(Settings["TextureQuality"].GetCurrentValue().(int))
+	previousTextures := ScreenTextures
+	ScreenTextures = nil
+
 	// grass textures
-	var grassTexture texture.Textures
-	grassTexture.AddTexture(baseDir()+"/assets/grass.jpg", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.diffuse", glWrapper)
-	grassTexture.AddTexture(baseDir()+"/assets/grass.jpg", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.specular", glWrapper)
+	grassTexture := cachedTextures(baseDir()+"/assets/grass.jpg", baseDir()+"/assets/grass.jpg")
 
 	grassMesh := CreateGrassMesh(grassTexture)
 	TexModel.AddMesh(grassMesh)
 
 	// box textures
-	var boxTexture texture.Textures
-	boxTexture.AddTexture(baseDir()+"/assets/box-diffuse.png", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.diffuse", glWrapper)
-	boxTexture.AddTexture(baseDir()+"/assets/box-specular.png", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.specular", glWrapper)
+	boxTexture := cachedTextures(baseDir()+"/assets/box-diffuse.png", baseDir()+"/assets/box-specular.png")
 
 	// we have 3 boxes in the following coordinates.
 	boxPositions := []mgl32.Vec3{
@@ -385,6 +408,19 @@ func mainScreen() *screen.Screen {
 	scrn.AddShader(shaderProgramTextureMat)
 
 	lamp1 := TexturedStreetLamp()
+	// The textures of the lamp are created by the builder, the quality is applied to them.
+	for index := 0; ; index++ {
+		m, err := lamp1.GetMeshByIndex(index)
+		if err != nil {
+			break
+		}
+		switch tm := m.(type) {
+		case *mesh.TexturedMesh:
+			TextureCache.ApplyQuality(tm.Textures, glwrapper.LINEAR)
+		case *mesh.TexturedMaterialMesh:
+			TextureCache.ApplyQuality(tm.Textures, glwrapper.LINEAR)
+		}
+	}
 	scrn.AddModelToShader(lamp1, shaderProgramTextureMat)
 	lamp2 := StreetLamp()
 	scrn.AddModelToShader(lamp2, shaderProgramMaterial)
@@ -426,10 +462,13 @@ func mainScreen() *screen.Screen {
 	scrn.AddModelToShader(bug, shaderProgramMaterial)
 
 	// sun texture
-	var sunTexture texture.Textures
-	sunTexture.AddTexture(baseDir()+"/assets/sun.jpg", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.diffuse", glWrapper)
-	sunTexture.AddTexture(baseDir()+"/assets/sun.jpg", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.specular", glWrapper)
+	sunTexture := cachedTextures(baseDir()+"/assets/sun.jpg", baseDir()+"/assets/sun.jpg")
 	TexModel.AddMesh(TexturedBug(sunTexture))
+	// The textures of the previous screen are released after the new ones are
+	// added, so that the unchanged textures are not uploaded again.
+	for _, t := range previousTextures {
+		TextureCache.Release(t)
+	}
 	scrn.AddModelToShader(TexModel, shaderProgramTexture)
 	scrn.AddModelToShader(MatModel, shaderProgramMaterial)
 	scrn.Setup(setupApp)
@@ -449,6 +488,7 @@ func main() {
 	defer glfw.Terminate()
 	glWrapper.InitOpenGL()
 
+	TextureCache = texturecache.New(glWrapper)
 	AppScreen = mainScreen()
 	app.AddScreen(AppScreen)
 	app.GetWindow().SetKeyCallback(app.KeyCallback)
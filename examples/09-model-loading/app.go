@@ -6,7 +6,9 @@ import (
 	"math/rand"
 	"os"
 	"path"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/akosgarai/playground_engine/pkg/application"
@@ -16,11 +18,14 @@ import (
 	"github.com/akosgarai/playground_engine/pkg/light"
 	"github.com/akosgarai/playground_engine/pkg/mesh"
 	"github.com/akosgarai/playground_engine/pkg/model"
-	"github.com/akosgarai/playground_engine/pkg/modelimport"
 	"github.com/akosgarai/playground_engine/pkg/screen"
 	"github.com/akosgarai/playground_engine/pkg/shader"
 	"github.com/akosgarai/playground_engine/pkg/window"
 
+	"github.com/akosgarai/opengl_playground/pkg/assetloader"
+	"github.com/akosgarai/opengl_playground/pkg/gltfimport"
+	"github.com/akosgarai/opengl_playground/pkg/objimport"
+
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
 )
@@ -35,21 +40,144 @@ const (
 	CameraDistance        = float32(0.1)
 	DefaultModelDirectory = "examples/09-model-loading/assets"
 	DefaultModelFilename  = "object.obj"
+	FontFile              = "/../../assets/fonts/Desyrel/desyrel.regular.ttf"
+
+	// The keys of the part selection and transformation.
+	SelectNextPartKey = glfw.KeyN
+	MoveLeftKey       = glfw.KeyLeft
+	MoveRightKey      = glfw.KeyRight
+	MoveForwardKey    = glfw.KeyUp
+	MoveBackKey       = glfw.KeyDown
+	RotateKey         = glfw.KeyR
+	PartMoveSpeed     = float32(0.002)
+	PartRotationSpeed = float32(0.05)
+
+	// The names of the shaders, the meshes are grouped by them.
+	PointShaderName        = "point"
+	MaterialShaderName     = "material"
+	TextureColorShaderName = "texturecolor"
+	TextureMatShaderName   = "texturemat"
 )
 
+// MeshImporter is implemented by the wavefront and the gltf importers.
+// The Load and Upload functions are used by the asset loader.
+type MeshImporter interface {
+	Import()
+	Load() error
+	Upload()
+	GetMeshes() []interfaces.Mesh
+}
+
+// Part is a named part of the loaded scene (object / group of the wavefront
+// files, node of the gltf files). Its meshes are distributed between the
+// models of the shaders, so that the part could be selected and transformed
+// independently from the other parts.
+type Part struct {
+	Name   string
+	Meshes []interfaces.Mesh
+	// The models of the part, the keys are the shader names.
+	Models map[string]*model.BaseModel
+}
+
+// NewPart returns a part with the given meshes. The models are
+// created on demand, based on the type of the meshes.
+func NewPart(name string, meshes []interfaces.Mesh) *Part {
+	p := &Part{
+		Name:   name,
+		Meshes: meshes,
+		Models: make(map[string]*model.BaseModel),
+	}
+	for _, m := range meshes {
+		p.addMeshToRightModel(m)
+	}
+	return p
+}
+func (p *Part) addMeshToRightModel(m interfaces.Mesh) {
+	var shaderName string
+	switch m.(type) {
+	case *mesh.TexturedMaterialMesh:
+		shaderName = TextureMatShaderName
+	case *mesh.TexturedColoredMesh:
+		shaderName = TextureColorShaderName
+	case *mesh.MaterialMesh:
+		shaderName = MaterialShaderName
+	case *mesh.PointMesh:
+		shaderName = PointShaderName
+		pointMesh := m.(*mesh.PointMesh)
+		for index, _ := range pointMesh.Vertices {
+			pointMesh.Vertices[index].PointSize = float32(3 + rand.Intn(17))
+			pointMesh.Vertices[index].Color = mgl32.Vec3{rand.Float32(), rand.Float32(), rand.Float32()}
+		}
+	default:
+		return
+	}
+	if _, ok := p.Models[shaderName]; !ok {
+		p.Models[shaderName] = model.New()
+	}
+	p.Models[shaderName].AddMesh(m)
+}
+
+// Move translates the meshes of the part with the given vector.
+func (p *Part) Move(v mgl32.Vec3) {
+	for _, m := range p.Meshes {
+		m.SetPosition(m.GetPosition().Add(v))
+	}
+}
+
+// RotateY rotates the meshes of the part around their position.
+func (p *Part) RotateY(angleDeg float32) {
+	for _, m := range p.Meshes {
+		m.RotateY(angleDeg)
+	}
+}
+
+// PartsOf returns the named parts of the imported file. The wavefront
+// models and the gltf nodes are mapped to parts. For the other
+// importers every mesh is added to one part.
+func PartsOf(importer MeshImporter, prefix string) []*Part {
+	var result []*Part
+	switch imp := importer.(type) {
+	case *objimport.Import:
+		for _, m := range imp.GetModels() {
+			result = append(result, NewPart(prefix+m.Name(), m.Meshes))
+		}
+	case *gltfimport.Import:
+		for index, node := range imp.GetNodes() {
+			if len(node.Meshes) == 0 {
+				continue
+			}
+			name := node.Name
+			if name == "" {
+				name = fmt.Sprintf("node_%d", index)
+			}
+			result = append(result, NewPart(prefix+name, node.Meshes))
+		}
+	default:
+		result = append(result, NewPart(prefix+"default", importer.GetMeshes()))
+	}
+	return result
+}
+
 var (
 	app      *application.Application
-	Importer *modelimport.Import
+	Importer MeshImporter
+	// The model file of the single directory mode.
+	ModelFilename = DefaultModelFilename
+	// The importers of the multi directory mode, the keys are the directory names.
+	Importers   = make(map[string]MeshImporter)
+	Directories []string
+	AssetLoader *assetloader.Loader
+	AppScreen   *screen.Screen
+	Shaders     map[string]*shader.Shader
 
 	lastUpdate int64
 
 	glWrapper glwrapper.Wrapper
 
-	Model                 = model.New()
-	PointModel            = model.New()
-	MaterialModel         = model.New()
-	TexturedMaterialModel = model.New()
-	TexturedColorModel    = model.New()
+	Parts        []*Part
+	SelectedPart = -1
+	// The state of the select key in the previous update, for detecting the key press.
+	selectKeyWasDown = false
 
 	DirectionalLightDirection = (mgl32.Vec3{0.7, 0.7, 0.7}).Normalize()
 	DirectionalLightAmbient   = mgl32.Vec3{1.0, 1.0, 1.0}
@@ -97,6 +225,16 @@ var (
 	MultiDirectoryName = ""
 )
 
+// NewImporter returns the gltf importer for the .gltf and .glb files
+// and the wavefront importer for the others.
+func NewImporter(directory, filename string) MeshImporter {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".gltf", ".glb":
+		return gltfimport.New(directory, filename, glWrapper)
+	}
+	return objimport.New(directory, filename, glWrapper)
+}
+
 // Importer init. If we have 2 or more command line arguments,
 // the first one is used as model directory, the second one
 // as the model filename.
@@ -104,14 +242,15 @@ func Init() {
 	args := os.Args[1:]
 	if len(args) == 0 {
 		// load default model
-		Importer = modelimport.New(DefaultModelDirectory, DefaultModelFilename, glWrapper)
+		Importer = NewImporter(DefaultModelDirectory, DefaultModelFilename)
 	} else if len(args) == 1 {
 		// multi model mode. read every subdir. (exported directory handling)
 		SingleDirectory = false
 		MultiDirectoryName = args[0]
 	} else if len(args) > 1 {
 		// load the directory with the given filename.
-		Importer = modelimport.New(args[0], args[1], glWrapper)
+		ModelFilename = args[1]
+		Importer = NewImporter(args[0], args[1])
 	}
 }
 
@@ -143,22 +282,38 @@ func Update() {
 	lastUpdate = nowNano
 
 	app.Update(delta)
+	UpdateSelectedPart(delta)
 }
-func AddMeshToRightModel(m interfaces.Mesh) {
-	switch m.(type) {
-	case *mesh.TexturedMaterialMesh:
-		TexturedMaterialModel.AddMesh(m)
-	case *mesh.TexturedColoredMesh:
-		TexturedColorModel.AddMesh(m)
-	case *mesh.MaterialMesh:
-		MaterialModel.AddMesh(m)
-	case *mesh.PointMesh:
-		pointMesh := m.(*mesh.PointMesh)
-		for index, _ := range pointMesh.Vertices {
-			pointMesh.Vertices[index].PointSize = float32(3 + rand.Intn(17))
-			pointMesh.Vertices[index].Color = mgl32.Vec3{rand.Float32(), rand.Float32(), rand.Float32()}
-		}
-		PointModel.AddMesh(pointMesh)
+
+// UpdateSelectedPart handles the part selection and the transformation of the selected part.
+func UpdateSelectedPart(delta float64) {
+	selectKeyDown := app.GetKeyState(SelectNextPartKey)
+	if selectKeyDown && !selectKeyWasDown && len(Parts) > 0 {
+		SelectedPart = (SelectedPart + 1) % len(Parts)
+		fmt.Printf("Selected part: '%s'.\n", Parts[SelectedPart].Name)
+	}
+	selectKeyWasDown = selectKeyDown
+	if SelectedPart < 0 {
+		return
+	}
+	var direction mgl32.Vec3
+	if app.GetKeyState(MoveLeftKey) {
+		direction = direction.Add(mgl32.Vec3{-1, 0, 0})
+	}
+	if app.GetKeyState(MoveRightKey) {
+		direction = direction.Add(mgl32.Vec3{1, 0, 0})
+	}
+	if app.GetKeyState(MoveForwardKey) {
+		direction = direction.Add(mgl32.Vec3{0, 0, 1})
+	}
+	if app.GetKeyState(MoveBackKey) {
+		direction = direction.Add(mgl32.Vec3{0, 0, -1})
+	}
+	if direction.Len() > 0 {
+		Parts[SelectedPart].Move(direction.Mul(PartMoveSpeed * float32(delta)))
+	}
+	if app.GetKeyState(RotateKey) {
+		Parts[SelectedPart].RotateY(PartRotationSpeed * float32(delta))
 	}
 }
 func baseDir() string {
@@ -172,6 +327,46 @@ func setupApp(glWrapper interfaces.GLWrapper) {
 	glWrapper.ClearColor(0.0, 0.25, 0.5, 1.0)
 }
 
+// loadModels inserts the importers to the loader. The files are parsed
+// on the worker goroutines, the meshes are created by the loading screen.
+func loadModels(l *assetloader.Loader) {
+	if SingleDirectory {
+		l.AddImporter(ModelFilename, Importer)
+		return
+	}
+	files, err := ioutil.ReadDir(MultiDirectoryName)
+	if err != nil {
+		fmt.Println(err)
+	}
+	for _, f := range files {
+		Directories = append(Directories, f.Name())
+		Importers[f.Name()] = NewImporter(MultiDirectoryName+"/"+f.Name(), DefaultModelFilename)
+		l.AddImporter(f.Name()+"/"+DefaultModelFilename, Importers[f.Name()])
+	}
+}
+
+// startApp is called when every model is loaded. It creates the parts
+// of the models and activates the application screen.
+func startApp() {
+	for _, err := range AssetLoader.Errors() {
+		fmt.Println(err)
+	}
+	if SingleDirectory {
+		Parts = PartsOf(Importer, "")
+	} else {
+		for _, dir := range Directories {
+			Parts = append(Parts, PartsOf(Importers[dir], dir+"/")...)
+		}
+	}
+	for _, p := range Parts {
+		for shaderName, m := range p.Models {
+			AppScreen.AddModelToShader(m, Shaders[shaderName])
+		}
+	}
+	app.ActivateScreen(AppScreen)
+	lastUpdate = time.Now().UnixNano()
+}
+
 func main() {
 	Init()
 	runtime.LockOSThread()
@@ -181,48 +376,29 @@ func main() {
 	defer glfw.Terminate()
 	glWrapper.InitOpenGL()
 
-	scrn := screen.New()
-	scrn.SetupCamera(CreateCamera(), CameraMovementOptions())
-
-	pointShader := shader.NewShader(baseDir()+"/shaders/point.vert", baseDir()+"/shaders/point.frag", glWrapper)
-	scrn.AddShader(pointShader)
-	materialShader := shader.NewShader(baseDir()+"/shaders/material.vert", baseDir()+"/shaders/material.frag", glWrapper)
-	scrn.AddShader(materialShader)
-	texColShader := shader.NewShader(baseDir()+"/shaders/texturecolor.vert", baseDir()+"/shaders/texturecolor.frag", glWrapper)
-	scrn.AddShader(texColShader)
-	texMatShader := shader.NewShader(baseDir()+"/shaders/texturemat.vert", baseDir()+"/shaders/texturemat.frag", glWrapper)
-	scrn.AddShader(texMatShader)
-	scrn.AddDirectionalLightSource(DirectionalLightSource, [4]string{"dirLight[0].direction", "dirLight[0].ambient", "dirLight[0].diffuse", "dirLight[0].specular"})
-	scrn.AddPointLightSource(PointLightSource, [7]string{"pointLight[0].position", "pointLight[0].ambient", "pointLight[0].diffuse", "pointLight[0].specular", "pointLight[0].constant", "pointLight[0].linear", "pointLight[0].quadratic"})
-	scrn.AddSpotLightSource(SpotLightSource, [10]string{"spotLight[0].position", "spotLight[0].direction", "spotLight[0].ambient", "spotLight[0].diffuse", "spotLight[0].specular", "spotLight[0].constant", "spotLight[0].linear", "spotLight[0].quadratic", "spotLight[0].cutOff", "spotLight[0].outerCutOff"})
+	AppScreen = screen.New()
+	AppScreen.SetupCamera(CreateCamera(), CameraMovementOptions())
 
-	if SingleDirectory {
-		Importer.Import()
-		meshes := Importer.GetMeshes()
-		for _, m := range meshes {
-			AddMeshToRightModel(m)
-		}
-	} else {
-		files, err := ioutil.ReadDir(MultiDirectoryName)
-		if err != nil {
-			fmt.Println(err)
-		}
-		for _, f := range files {
-			Importer = modelimport.New(MultiDirectoryName+"/"+f.Name(), DefaultModelFilename, glWrapper)
-			Importer.Import()
-			meshes := Importer.GetMeshes()
-			for _, m := range meshes {
-				AddMeshToRightModel(m)
-			}
-		}
+	Shaders = map[string]*shader.Shader{
+		PointShaderName:        shader.NewShader(baseDir()+"/shaders/point.vert", baseDir()+"/shaders/point.frag", glWrapper),
+		MaterialShaderName:     shader.NewShader(baseDir()+"/shaders/material.vert", baseDir()+"/shaders/material.frag", glWrapper),
+		TextureColorShaderName: shader.NewShader(baseDir()+"/shaders/texturecolor.vert", baseDir()+"/shaders/texturecolor.frag", glWrapper),
+		TextureMatShaderName:   shader.NewShader(baseDir()+"/shaders/texturemat.vert", baseDir()+"/shaders/texturemat.frag", glWrapper),
 	}
-	scrn.AddModelToShader(TexturedMaterialModel, texMatShader)
-	scrn.AddModelToShader(TexturedColorModel, texColShader)
-	scrn.AddModelToShader(MaterialModel, materialShader)
-	scrn.AddModelToShader(PointModel, pointShader)
-	scrn.Setup(setupApp)
-	app.AddScreen(scrn)
-	app.ActivateScreen(scrn)
+	for _, sh := range Shaders {
+		AppScreen.AddShader(sh)
+	}
+	AppScreen.AddDirectionalLightSource(DirectionalLightSource, [4]string{"dirLight[0].direction", "dirLight[0].ambient", "dirLight[0].diffuse", "dirLight[0].specular"})
+	AppScreen.AddPointLightSource(PointLightSource, [7]string{"pointLight[0].position", "pointLight[0].ambient", "pointLight[0].diffuse", "pointLight[0].specular", "pointLight[0].constant", "pointLight[0].linear", "pointLight[0].quadratic"})
+	AppScreen.AddSpotLightSource(SpotLightSource, [10]string{"spotLight[0].position", "spotLight[0].direction", "spotLight[0].ambient", "spotLight[0].diffuse", "spotLight[0].specular", "spotLight[0].constant", "spotLight[0].linear", "spotLight[0].quadratic", "spotLight[0].cutOff", "spotLight[0].outerCutOff"})
+	AppScreen.Setup(setupApp)
+	app.AddScreen(AppScreen)
+
+	AssetLoader = assetloader.New(0)
+	loadModels(AssetLoader)
+	loadingScreen := assetloader.NewScreen(AssetLoader, baseDir()+FontFile, glWrapper, startApp)
+	app.AddScreen(loadingScreen)
+	app.ActivateScreen(loadingScreen)
 
 	lastUpdate = time.Now().UnixNano()
 	app.GetWindow().SetKeyCallback(app.KeyCallback)
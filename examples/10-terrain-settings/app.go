@@ -1,12 +1,16 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"runtime"
 	"strconv"
 	"time"
 
+	"github.com/akosgarai/opengl_playground/pkg/focus"
+	"github.com/akosgarai/opengl_playground/pkg/terrain"
+	"github.com/akosgarai/opengl_playground/pkg/tooltip"
 	"github.com/akosgarai/playground_engine/pkg/application"
 	"github.com/akosgarai/playground_engine/pkg/camera"
 	"github.com/akosgarai/playground_engine/pkg/config"
@@ -18,6 +22,7 @@ import (
 	"github.com/akosgarai/playground_engine/pkg/screen"
 	"github.com/akosgarai/playground_engine/pkg/shader"
 	"github.com/akosgarai/playground_engine/pkg/texture"
+	"github.com/akosgarai/playground_engine/pkg/theme"
 	"github.com/akosgarai/playground_engine/pkg/transformations"
 	"github.com/akosgarai/playground_engine/pkg/window"
 
@@ -27,17 +32,48 @@ import (
 
 const (
 	FontFile = "/assets/fonts/Desyrel/desyrel.regular.ttf"
+	// The terrain is exported to this directory with this name.
+	ExportDirectory = "export"
+	ExportName      = "terrain"
+	// The sculpted height map is saved to the export directory with this name.
+	SculptedName = "terrain_sculpted.png"
+
+	// The edit mode is toggled with this button. In edit mode the terrain is
+	// sculpted with the brush under the cursor, while the button is pressed.
+	EDIT_MODE_BUTTON      = glfw.KeyB
+	SCULPT_BUTTON         = glfw.MouseButtonLeft
+	UNDO_BUTTON           = glfw.KeyZ
+	SAVE_HEIGHTMAP_BUTTON = glfw.KeyH
+	// The brushes are selected with the number buttons.
+	RAISE_BRUSH_BUTTON   = glfw.Key1
+	LOWER_BRUSH_BUTTON   = glfw.Key2
+	SMOOTH_BRUSH_BUTTON  = glfw.Key3
+	FLATTEN_BRUSH_BUTTON = glfw.Key4
+	NOISE_BRUSH_BUTTON   = glfw.Key5
+	// The cursor ray is tested with the terrain within this distance.
+	SculptRayDistance = float32(500.0)
 )
 
 var (
 	app            *application.Application
-	SettingsScreen *screen.FormScreen
-	MenuScreen     *screen.MenuScreen
+	SettingsScreen *focus.FormScreen
+	MenuScreen     *focus.MenuScreen
 	AppScreen      *screen.Screen
 	glWrapper      glwrapper.Wrapper
 	lastUpdate     int64
 	startTime      int64
 	Settings       = config.New()
+	// The current terrain and the seed that was used for its generation.
+	Ground     *terrain.Terrain
+	GroundSeed int64
+	// The reflection and refraction renderer of the liquid, if it's turned on.
+	Reflections *terrain.Water
+	// The sculptor of the terrain and the state of the edit mode.
+	Sculptor          *terrain.Sculptor
+	EditMode          bool
+	EditButtonWasDown bool
+	UndoButtonWasDown bool
+	SaveButtonWasDown bool
 
 	Builder          *window.WindowBuilder
 	WindowWidth      = 800
@@ -102,9 +138,42 @@ func init() {
 	Settings.AddConfig("TerrainMaxHeight", "MaxH (f)", "The maximum height of the terrain surface.", float32(3.0), nil)
 	Settings.AddConfig("TerrainScale", "Scale (f)", "The generated terrain is scaled with the components of this vector.", mgl32.Vec3{5.0, 1.0, 5.0}, nil)
 	Settings.AddConfig("TerrainPos", "PosY (f)", "The center / middle point of the terrain mesh.", mgl32.Vec3{0.0, 1.003, 0.0}, nil)
+	var octavesValidator model.IntValidator
+	octavesValidator = func(i int) bool { return i >= 1 }
+	var positiveValidator model.FloatValidator
+	positiveValidator = func(f float32) bool { return f > 0.0 }
+	Settings.AddConfig("Generator", "Generator", "The height generator of the terrain. 'Iteration': the peak and cliff based random generation. 'Fbm': fractal noise. 'Ridged': ridged multifractal noise.", "Iteration", nil)
+	Settings.AddConfig("Noise", "Noise", "The noise of the fractal generators. 'Perlin' or 'Simplex'. It is seeded with the Seed.", "Simplex", nil)
+	Settings.AddConfig("Octaves", "Octaves (i)", "The number of the noise octaves of the fractal generators.", 6, octavesValidator)
+	Settings.AddConfig("Frequency", "Freq (f)", "The frequency of the first octave of the fractal generators. The unit is the tile.", float32(0.05), positiveValidator)
+	Settings.AddConfig("Lacunarity", "Lacun (f)", "The frequency multiplier between the octaves of the fractal generators.", float32(2.0), positiveValidator)
+	Settings.AddConfig("Gain", "Gain (f)", "The amplitude multiplier between the octaves of the fractal generators.", float32(0.5), positiveValidator)
+	Settings.AddConfig("WarpStrength", "Warp (f)", "The strength of the domain warping of the fractal generators in tiles. 0 turns it off.", float32(0.0), nil)
+	Settings.AddConfig("WarpFrequency", "WFreq (f)", "The frequency of the domain warping noise.", float32(0.02), positiveValidator)
+	var nonNegativeValidator model.IntValidator
+	nonNegativeValidator = func(i int) bool { return i >= 0 }
+	Settings.AddConfig("ErosionDroplets", "Rain (i)", "The number of the droplets of the hydraulic erosion. 0 turns it off.", 0, nonNegativeValidator)
+	Settings.AddConfig("ErosionSeed", "Rain seed (i64)", "The seed of the positions of the droplets of the hydraulic erosion. The same seed gives the same result.", int64(0), nil)
+	Settings.AddConfig("ThermalIterations", "Thermal (i)", "The number of the iterations of the thermal erosion. 0 turns it off.", 0, nonNegativeValidator)
+	Settings.AddConfig("ThermalTalus", "Talus (f)", "The maximal stable height difference between the neighbouring points of the thermal erosion.", float32(0.5), positiveValidator)
+	Settings.AddConfig("HeightMap", "Height map", "The grayscale png (8 or 16 bit) height map in the assets directory or an absolute path. If it's set, it is resampled to the rows and cols, the black is mapped to MinH, the white to MaxH, and the random generation is skipped.", "", nil)
 	Settings.AddConfig("Seed", "Seed (i64)", "This value is used as seed for the random number generation, if the random is not set.", int64(0), nil)
 	Settings.AddConfig("RandomSeed", "Rand Seed", "If this is set, the seed will be based on the current timestamp.", false, nil)
-	Settings.AddConfig("TerrainTexture", "Terr tex", "The texture of the terrain. Currently the 'Grass' is supported.", "Grass", nil)
+	Settings.AddConfig("TerrainTexture", "Terr tex", "The texture of the terrain. 'Grass': grass texture. 'Splat': sand, grass, rock and snow layers blended by height and slope.", "Grass", nil)
+	var levelValidator model.FloatValidator
+	levelValidator = func(f float32) bool { return f >= 0.0 && f <= 1.0 }
+	var blendValidator model.FloatValidator
+	blendValidator = func(f float32) bool { return f >= 0.0 }
+	Settings.AddConfig("GrassLevel", "Grass lev (f)", "The normalized height [0-1], where the grass layer begins above the sand. 0 is the MinH, 1 is the MaxH.", float32(0.15), levelValidator)
+	Settings.AddConfig("RockLevel", "Rock lev (f)", "The normalized height [0-1], where the rock layer begins above the grass.", float32(0.6), levelValidator)
+	Settings.AddConfig("SnowLevel", "Snow lev (f)", "The normalized height [0-1], where the snow layer begins above the rock.", float32(0.85), levelValidator)
+	Settings.AddConfig("HeightBlend", "H blend (f)", "The half width of the transition between the height layers in normalized height.", float32(0.05), blendValidator)
+	Settings.AddConfig("SlopeLevel", "Slope lev (f)", "The slope [0-1], where the surface becomes rocky. 0 is the flat, 1 is the vertical surface.", float32(0.45), levelValidator)
+	Settings.AddConfig("SlopeBlend", "S blend (f)", "The half width of the transition between the slope based rock and the other layers.", float32(0.1), blendValidator)
+	Settings.AddConfig("SplatTiling", "Tiling (f)", "The layer textures are repeated once in this many tiles.", float32(4.0), positiveValidator)
+	Settings.AddConfig("SplatMap", "Splat map", "The png splat map in the assets directory or an absolute path. Its red, green, blue, alpha channels are the weights of the sand, grass, rock, snow layers. If it's set, it's used instead of the levels.", "", nil)
+	Settings.AddConfig("FogMin", "Fog min (f)", "The distance, where the fog begins. The fog is turned off if it's 0.", float32(0.0), blendValidator)
+	Settings.AddConfig("FogMax", "Fog max (f)", "The distance, where the fog is full. The fog is turned off if it's 0. The color of the fog is the BG color.", float32(0.0), blendValidator)
 	Settings.AddConfig("NeedLiquid", "Has Liquid", "If this is set, water will also be generated to the terrain.", true, nil)
 	Settings.AddConfig("LiquidEta", "Leta (f)", "The refraction ratio between the air and the liquid surface.", float32(0.75), nil)
 	Settings.AddConfig("LiquidAmplitude", "Lampl (f)", "The amplitude of the waves (sin wave) in the liquid surface", float32(0.0625), nil)
@@ -112,6 +181,13 @@ func init() {
 	Settings.AddConfig("LiquidDetail", "Ldetail (i)", "The size of the liquid surface is the same as the terrain, but its height map is bigger this times.", 10, nil)
 	Settings.AddConfig("WaterLevel", "W Lev (f)", "The water level of the liquid surface.", float32(0.25), nil)
 	Settings.AddConfig("LiquidTexture", "Liq tex", "The texture of the liquid surface. Currently the 'Water' is supported.", "Water", nil)
+	Settings.AddConfig("LiquidReflect", "L reflect", "If this is set, the liquid reflects and refracts the terrain instead of using its texture. The eta drives the refraction.", true, nil)
+	Settings.AddConfig("WaveStrength", "W str (f)", "The distortion of the reflection and the refraction by the waves.", float32(0.02), blendValidator)
+	Settings.AddConfig("WaveSpeed", "W speed (f)", "The speed of the waves in wave map size / second.", float32(0.03), blendValidator)
+	Settings.AddConfig("WaveTiling", "W tiling (f)", "The size of the wave maps in world units.", float32(2.0), positiveValidator)
+	Settings.AddConfig("BrushRadius", "B radius (f)", "The radius of the sculpting brush in world units.", terrain.DefaultBrushRadius, positiveValidator)
+	Settings.AddConfig("BrushFalloff", "B falloff (f)", "The ratio of the brush radius [0-1], where its effect decreases. 0 is a hard edge.", terrain.DefaultBrushFalloff, levelValidator)
+	Settings.AddConfig("BrushStrength", "B strength (f)", "The height change of the raise, lower and noise brushes in one second, or the blend rate of the smooth and flatten brushes.", terrain.DefaultBrushStrength, positiveValidator)
 	Settings.AddConfig("Debug", "Debug mode", "Turn debug mode on - off. Currently it does nothing.", false, nil)
 	Settings.AddConfig("ClearCol", "BG color", "The clear color of the window. It is used as the color of the sky.", mgl32.Vec3{0.2, 0.3, 0.8}, nil)
 	// camera options:
@@ -183,7 +259,9 @@ func baseDir() string {
 	return path.Dir(filename)
 }
 
-func createSettings(defaults config.Config) *screen.FormScreen {
+// It creates the Settings screen. The descriptions of the settings are displayed
+// in tooltips.
+func createSettings(defaults config.Config) *focus.FormScreen {
 	formItemOrders := []string{
 		"Width", "Length",
 		"Iterations", "PeakProb",
@@ -191,12 +269,28 @@ func createSettings(defaults config.Config) *screen.FormScreen {
 		"TerrainMaxHeight",
 		"TerrainScale",
 		"TerrainPos",
+		"HeightMap",
+		"Generator", "Noise",
+		"Octaves", "Frequency",
+		"Lacunarity", "Gain",
+		"WarpStrength", "WarpFrequency",
+		"ErosionDroplets", "ErosionSeed",
+		"ThermalIterations", "ThermalTalus",
 		"RandomSeed", "Seed",
 		"TerrainTexture",
+		"GrassLevel", "RockLevel",
+		"SnowLevel", "HeightBlend",
+		"SlopeLevel", "SlopeBlend",
+		"SplatTiling", "SplatMap",
+		"FogMin", "FogMax",
 		"NeedLiquid", "LiquidEta",
 		"LiquidAmplitude", "LiquidFrequency",
 		"LiquidDetail", "WaterLevel",
-		"LiquidTexture",
+		"LiquidTexture", "LiquidReflect",
+		"WaveStrength", "WaveSpeed",
+		"WaveTiling",
+		"BrushRadius", "BrushFalloff",
+		"BrushStrength",
 		"ClearCol",
 		"Debug",
 		"WorldUp",
@@ -214,20 +308,89 @@ func createSettings(defaults config.Config) *screen.FormScreen {
 	builder.SetHeaderLabel("Settings")
 	builder.SetConfig(defaults)
 	builder.SetConfigOrder(formItemOrders)
-	return builder.Build()
+	return focus.NewFormScreen(tooltip.NewFormScreen(builder.Build(), theme.Default, glWrapper), formItemOrders, theme.Default)
+}
+
+// It creates the height generator from the settings. It returns nil for the
+// iteration based generation, that is the default algorithm of the builder.
+func createGenerator(conf config.Config, seed int64) terrain.HeightGenerator {
+	var noise terrain.Noise
+	switch conf["Noise"].GetCurrentValue().(string) {
+	case "Perlin", "perlin", "PERLIN":
+		noise = terrain.NewPerlin(seed)
+		break
+	default:
+		noise = terrain.NewSimplex(seed)
+		break
+	}
+	var generator terrain.HeightGenerator
+	switch conf["Generator"].GetCurrentValue().(string) {
+	case "Fbm", "fbm", "FBM":
+		fractal := terrain.NewFractal(noise)
+		fractal.Octaves = conf["Octaves"].GetCurrentValue().(int)
+		fractal.Frequency = conf["Frequency"].GetCurrentValue().(float32)
+		fractal.Lacunarity = conf["Lacunarity"].GetCurrentValue().(float32)
+		fractal.Gain = conf["Gain"].GetCurrentValue().(float32)
+		generator = fractal
+		break
+	case "Ridged", "ridged", "RIDGED":
+		ridged := terrain.NewRidged(noise)
+		ridged.Octaves = conf["Octaves"].GetCurrentValue().(int)
+		ridged.Frequency = conf["Frequency"].GetCurrentValue().(float32)
+		ridged.Lacunarity = conf["Lacunarity"].GetCurrentValue().(float32)
+		ridged.Gain = conf["Gain"].GetCurrentValue().(float32)
+		generator = ridged
+		break
+	default:
+		return nil
+	}
+	if strength := conf["WarpStrength"].GetCurrentValue().(float32); strength != 0 {
+		// The warp noise has different seed, so that it's independent from the terrain noise.
+		generator = terrain.NewDomainWarp(generator, terrain.NewSimplex(seed+1), strength, conf["WarpFrequency"].GetCurrentValue().(float32))
+	}
+	return generator
+}
+
+// It creates the splat material from the settings.
+func createSplat(conf config.Config) *terrain.Splat {
+	splat := terrain.NewSplat()
+	splat.GrassLevel = conf["GrassLevel"].GetCurrentValue().(float32)
+	splat.RockLevel = conf["RockLevel"].GetCurrentValue().(float32)
+	splat.SnowLevel = conf["SnowLevel"].GetCurrentValue().(float32)
+	splat.HeightBlend = conf["HeightBlend"].GetCurrentValue().(float32)
+	splat.SlopeLevel = conf["SlopeLevel"].GetCurrentValue().(float32)
+	splat.SlopeBlend = conf["SlopeBlend"].GetCurrentValue().(float32)
+	splat.Tiling = conf["SplatTiling"].GetCurrentValue().(float32)
+	return splat
 }
-func createGame(conf config.Config, form *screen.FormScreen) *screen.Screen {
+func createGame(conf config.Config, form *focus.FormScreen) *screen.Screen {
 	AppScreen := screen.New()
 	// Shader application for the textured meshes.
 	shaderProgramTexture := shader.NewTextureShaderBlendingWithFog(glWrapper)
 	AppScreen.AddShader(shaderProgramTexture)
 	// Shader application for the liquid surface
-	shaderProgramLiquid := shader.NewTextureShaderLiquidWithFog(glWrapper)
+	reflect := conf["NeedLiquid"].GetCurrentValue().(bool) && conf["LiquidReflect"].GetCurrentValue().(bool)
+	var shaderProgramLiquid *shader.Shader
+	if reflect {
+		shaderProgramLiquid = terrain.NewWaterShader(glWrapper)
+	} else {
+		shaderProgramLiquid = shader.NewTextureShaderLiquidWithFog(glWrapper)
+	}
 	AppScreen.AddShader(shaderProgramLiquid)
 
-	AppScreen.SetupCamera(CreateCameraFromSettings(conf), CameraMovementOptions())
+	camera := CreateCameraFromSettings(conf)
+	AppScreen.SetupCamera(camera, CameraMovementOptions())
+	if Reflections != nil {
+		Reflections.Delete()
+	}
+	Reflections = nil
 
-	gb := model.NewTerrainBuilder()
+	// The buffers of the previous terrain are freed, it isn't drawn anymore.
+	if Ground != nil {
+		Ground.Delete()
+		Ground = nil
+	}
+	gb := terrain.NewBuilder()
 	// terrain related ones
 	gb.SetWidth(conf["Width"].GetCurrentValue().(int))
 	gb.SetLength(conf["Length"].GetCurrentValue().(int))
@@ -238,15 +401,52 @@ func createGame(conf config.Config, form *screen.FormScreen) *screen.Screen {
 	gb.SetMinHeight(conf["TerrainMinHeight"].GetCurrentValue().(float32))
 	gb.SetMaxHeight(conf["TerrainMaxHeight"].GetCurrentValue().(float32))
 	gb.SetPosition(conf["TerrainPos"].GetCurrentValue().(mgl32.Vec3))
+	if heightMap := conf["HeightMap"].GetCurrentValue().(string); heightMap != "" {
+		if !path.IsAbs(heightMap) {
+			heightMap = baseDir() + "/assets/" + heightMap
+		}
+		// The terrain is generated randomly if the height map is missing.
+		if err := gb.SetHeightMapFile(heightMap); err != nil {
+			fmt.Println(err)
+		}
+	}
 	if conf["RandomSeed"].GetCurrentValue().(bool) {
-		seed := gb.RandomSeed()
-		form.SetFormItemValue(form.GetFormItem("Seed"), transformations.Integer64ToString(seed))
+		GroundSeed = gb.RandomSeed()
+		form.SetFormItemValue(form.GetFormItem("Seed"), transformations.Integer64ToString(GroundSeed))
 		form.SetFormItemValue(form.GetFormItem("RandomSeed"), false)
 	} else {
-		gb.SetSeed(conf["Seed"].GetCurrentValue().(int64))
+		GroundSeed = conf["Seed"].GetCurrentValue().(int64)
+		gb.SetSeed(GroundSeed)
+	}
+	if generator := createGenerator(conf, GroundSeed); generator != nil {
+		gb.SetHeightGenerator(generator)
+	}
+	// The erosion passes are applied after the generation, the hydraulic one first.
+	if droplets := conf["ErosionDroplets"].GetCurrentValue().(int); droplets > 0 {
+		gb.AddErosion(terrain.NewHydraulicErosion(droplets, conf["ErosionSeed"].GetCurrentValue().(int64)))
+	}
+	if iterations := conf["ThermalIterations"].GetCurrentValue().(int); iterations > 0 {
+		gb.AddErosion(terrain.NewThermalErosion(iterations, conf["ThermalTalus"].GetCurrentValue().(float32)))
 	}
 	gb.SetGlWrapper(glWrapper)
+	// The ground is drawn with the splat shader, if the splat texture is selected.
+	groundShader := shaderProgramTexture
 	switch conf["TerrainTexture"].GetCurrentValue().(string) {
+	case "Splat", "splat", "SPLAT":
+		groundShader = terrain.NewSplatShader(glWrapper)
+		AppScreen.AddShader(groundShader)
+		gb.SurfaceTextureSplat()
+		gb.SetSplat(createSplat(conf))
+		if splatMap := conf["SplatMap"].GetCurrentValue().(string); splatMap != "" {
+			if !path.IsAbs(splatMap) {
+				splatMap = baseDir() + "/assets/" + splatMap
+			}
+			// The layers are blended by the levels if the splat map is missing.
+			if err := gb.SetSplatMapFile(splatMap); err != nil {
+				fmt.Println(err)
+			}
+		}
+		break
 	case "Grass", "grass", "GRASS":
 		gb.SurfaceTextureGrass()
 		break
@@ -270,15 +470,35 @@ func createGame(conf config.Config, form *screen.FormScreen) *screen.Screen {
 		default:
 			gb.LiquidTextureWater()
 		}
-		Ground, Water := gb.BuildWithLiquid()
-		Water.SetTransparent(true)
+		var Water *terrain.Liquid
+		Ground, Water = gb.BuildWithLiquid()
+		if reflect {
+			// The water shader doesn't blend, the refraction texture contains the
+			// terrain under the water.
+			// The render targets have the size of the framebuffer, that is
+			// bigger than the window on HiDPI displays.
+			width, height := glfw.GetCurrentContext().GetFramebufferSize()
+			Reflections = terrain.NewWater(Water, camera, width, height, GroundSeed, glWrapper)
+			Reflections.SetWaveStrength(conf["WaveStrength"].GetCurrentValue().(float32))
+			Reflections.SetWaveSpeed(conf["WaveSpeed"].GetCurrentValue().(float32))
+			Reflections.SetWaveTiling(conf["WaveTiling"].GetCurrentValue().(float32))
+			AppScreen.SetupCamera(Reflections.GetCamera(), CameraMovementOptions())
+		} else {
+			Water.SetTransparent(true)
+		}
 
-		AppScreen.AddModelToShader(Ground, shaderProgramTexture)
+		AppScreen.AddModelToShader(Ground, groundShader)
 		AppScreen.AddModelToShader(Water, shaderProgramLiquid)
 	} else {
-		Ground := gb.Build()
-		AppScreen.AddModelToShader(Ground, shaderProgramTexture)
+		Ground = gb.Build()
+		AppScreen.AddModelToShader(Ground, groundShader)
 	}
+	Sculptor = terrain.NewSculptor(Ground, GroundSeed)
+	Sculptor.SetBrush(createBrush(conf, terrain.BrushRaise))
+	EditMode = false
+	AppScreen.SetUniformFloat("fog.minDistance", conf["FogMin"].GetCurrentValue().(float32))
+	AppScreen.SetUniformFloat("fog.maxDistance", conf["FogMax"].GetCurrentValue().(float32))
+	AppScreen.SetUniformVector("fog.color", conf["ClearCol"].GetCurrentValue().(mgl32.Vec3))
 
 	// directional light is coming from the up direction but not from too up.
 	DirectionalLightSource := light.NewDirectionalLight([4]mgl32.Vec3{
@@ -308,8 +528,123 @@ func Update() {
 	lastUpdate = nowNano
 	app.SetUniformFloat("time", float32(float64(nowNano-startTime)/float64(time.Second)))
 	app.Update(delta)
+	Sculpt(delta)
 }
-func createMenu() *screen.MenuScreen {
+
+// createBrush returns the sculpting brush of the given mode with the settings.
+func createBrush(conf config.Config, mode int) *terrain.Brush {
+	brush := terrain.NewBrush(mode)
+	brush.Radius = conf["BrushRadius"].GetCurrentValue().(float32)
+	brush.Falloff = conf["BrushFalloff"].GetCurrentValue().(float32)
+	brush.Strength = conf["BrushStrength"].GetCurrentValue().(float32)
+	return brush
+}
+
+// cursorRay returns the origin and the direction of the ray, that goes through
+// the current cursor position. The cursor is unprojected to the near and to the
+// far clip plane.
+func cursorRay() (mgl32.Vec3, mgl32.Vec3) {
+	posX, posY := app.GetWindow().GetCursorPos()
+	windowWidth, windowHeight := app.GetWindow().GetSize()
+	mX, mY := transformations.MouseCoordinates(posX, posY, float64(windowWidth), float64(windowHeight))
+	cam := AppScreen.GetCamera()
+	trMat := cam.GetProjectionMatrix().Mul4(cam.GetViewMatrix()).Inv()
+	near := mgl32.TransformCoordinate(mgl32.Vec3{float32(mX), float32(mY), -1.0}, trMat)
+	far := mgl32.TransformCoordinate(mgl32.Vec3{float32(mX), float32(mY), 1.0}, trMat)
+	return near, far.Sub(near)
+}
+
+// Sculpt handles the edit mode. The brush is selected with the number buttons,
+// the terrain is sculpted where the cursor ray hits it, while the sculpt button
+// is pressed. Every press is a stroke, that could be undone.
+func Sculpt(delta float64) {
+	// The terrain is only edited on the world screen.
+	if Sculptor == nil || app.GetCamera() != AppScreen.GetCamera() {
+		return
+	}
+	editButtonDown := app.GetKeyState(EDIT_MODE_BUTTON)
+	if editButtonDown && !EditButtonWasDown {
+		EditMode = !EditMode
+		Sculptor.EndStroke()
+		fmt.Printf("Edit mode: %t\n", EditMode)
+	}
+	EditButtonWasDown = editButtonDown
+	if !EditMode {
+		return
+	}
+	mode := -1
+	if app.GetKeyState(RAISE_BRUSH_BUTTON) {
+		mode = terrain.BrushRaise
+	} else if app.GetKeyState(LOWER_BRUSH_BUTTON) {
+		mode = terrain.BrushLower
+	} else if app.GetKeyState(SMOOTH_BRUSH_BUTTON) {
+		mode = terrain.BrushSmooth
+	} else if app.GetKeyState(FLATTEN_BRUSH_BUTTON) {
+		mode = terrain.BrushFlatten
+	} else if app.GetKeyState(NOISE_BRUSH_BUTTON) {
+		mode = terrain.BrushNoise
+	}
+	if mode >= 0 {
+		Sculptor.EndStroke()
+		Sculptor.SetBrush(createBrush(Settings, mode))
+	}
+	undoButtonDown := app.GetKeyState(UNDO_BUTTON)
+	if undoButtonDown && !UndoButtonWasDown {
+		Sculptor.Undo()
+	}
+	UndoButtonWasDown = undoButtonDown
+	saveButtonDown := app.GetKeyState(SAVE_HEIGHTMAP_BUTTON)
+	if saveButtonDown && !SaveButtonWasDown {
+		SaveHeightMap()
+	}
+	SaveButtonWasDown = saveButtonDown
+	if !app.GetMouseButtonState(SCULPT_BUTTON) {
+		Sculptor.EndStroke()
+		return
+	}
+	origin, direction := cursorRay()
+	if hit, ok := Ground.RayIntersection(origin, direction, SculptRayDistance); ok {
+		Sculptor.Apply(hit, delta)
+	}
+}
+
+// SaveHeightMap writes the sculpted height map to the export directory. The
+// printed min and max heights are needed for loading it as **Height map**.
+func SaveHeightMap() {
+	if err := os.MkdirAll(ExportDirectory, 0755); err != nil {
+		fmt.Printf("Save failed. '%s'\n", err.Error())
+		return
+	}
+	filename := path.Join(ExportDirectory, SculptedName)
+	minH, maxH, err := Sculptor.SaveHeightMap(filename)
+	if err != nil {
+		fmt.Printf("Save failed. '%s'\n", err.Error())
+		return
+	}
+	fmt.Printf("Height map saved to '%s'. MinH: %f, MaxH: %f\n", filename, minH, maxH)
+}
+
+// ExportTerrain writes the current terrain to the export directory. The height map,
+// the normal map, the wavefront object and the settings are exported. The seed in
+// the settings is the resolved one, so that the terrain could be generated again.
+func ExportTerrain() {
+	if err := os.MkdirAll(ExportDirectory, 0755); err != nil {
+		fmt.Printf("Export failed. '%s'\n", err.Error())
+		return
+	}
+	settings := make(map[string]interface{})
+	for key, item := range Settings {
+		settings[key] = item.GetCurrentValue()
+	}
+	settings["Seed"] = GroundSeed
+	settings["RandomSeed"] = false
+	if err := terrain.Export(Ground, ExportDirectory, ExportName, settings); err != nil {
+		fmt.Printf("Export failed. '%s'\n", err.Error())
+		return
+	}
+	fmt.Printf("Terrain exported to '%s'.\n", path.Join(ExportDirectory, ExportName+".json"))
+}
+func createMenu() *focus.MenuScreen {
 	var tex texture.Textures
 	tex.AddTexture(baseDir()+"/assets/paper.jpg", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "paper", glWrapper)
 	builder := screen.NewMenuScreenBuilder()
@@ -351,6 +686,9 @@ func createMenu() *screen.MenuScreen {
 		MenuScreen.SetState("world-started", true)
 		MenuScreen.BuildScreen()
 	}
+	exportEvent := func() {
+		ExportTerrain()
+	}
 	settingsEvent := func() {
 		app.ActivateScreen(SettingsScreen)
 	}
@@ -366,11 +704,13 @@ func createMenu() *screen.MenuScreen {
 	builder.AddOption(*start) // start
 	restart := screen.NewMenuScreenOption("restart", contS, restartEvent)
 	builder.AddOption(*restart) // restart
+	export := screen.NewMenuScreenOption("export", contS, exportEvent)
+	builder.AddOption(*export) // export
 	settings := screen.NewMenuScreenOption("settings", contAll, settingsEvent)
 	builder.AddOption(*settings) // settings
 	exit := screen.NewMenuScreenOption("exit", contAll, exitEvent)
 	builder.AddOption(*exit) // exit
-	return builder.Build()
+	return focus.NewMenuScreen(builder.Build(), theme.Default)
 }
 
 func main() {
@@ -403,6 +743,11 @@ func main() {
 	startTime = lastUpdate
 
 	for !app.GetWindow().ShouldClose() {
+		// The reflection and the refraction are rendered only if the terrain is displayed.
+		if Reflections != nil && app.GetCamera() == Reflections.GetCamera() {
+			Reflections.SetSize(glfw.GetCurrentContext().GetFramebufferSize())
+			Reflections.Render(AppScreen)
+		}
 		glWrapper.Clear(glwrapper.COLOR_BUFFER_BIT | glwrapper.DEPTH_BUFFER_BIT)
 		app.Draw(glWrapper)
 		glfw.PollEvents()
@@ -7,6 +7,7 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/akosgarai/opengl_playground/pkg/terrain"
 	"github.com/akosgarai/playground_engine/pkg/application"
 	"github.com/akosgarai/playground_engine/pkg/camera"
 	"github.com/akosgarai/playground_engine/pkg/glwrapper"
@@ -50,15 +51,10 @@ const (
 	CameraDistance       = float32(0.1)
 )
 
-func generateHeightMap(width, length, iterations, peakProbability int, minH, maxH float32, seed int64) [][]float32 {
+// The heightMap is indexed with [l][w], it has length+1 rows and width+1 columns.
+func generateHeightMap(width, length, iterations, peakProbability int, minH, maxH float32, seed int64) terrain.HeightMap {
 	// init map with 0.0-s
-	var heightMap [][]float32
-	for w := 0; w <= width; w++ {
-		heightMap = append(heightMap, []float32{})
-		for l := 0; l <= length; l++ {
-			heightMap[w] = append(heightMap[w], 0.0)
-		}
-	}
+	heightMap := terrain.NewHeightMap(width, length, 0.0)
 	terrainMaxDiff := maxH - minH
 	iterationStep := terrainMaxDiff / float32(iterations)
 
@@ -85,7 +81,7 @@ func generateHeightMap(width, length, iterations, peakProbability int, minH, max
 	return heightMap
 }
 
-func adjacentElevation(w, h int, elevation float32, cliffProbability, width, height int, elements [][]float32) bool {
+func adjacentElevation(w, h int, elevation float32, cliffProbability, width, height int, elements terrain.HeightMap) bool {
 	for y := max(0, h-1); y <= min(height-1, h+1); y++ {
 		for x := max(0, w-1); x <= min(width-1, w+1); x++ {
 			if elements[y][x] == elevation {
@@ -131,37 +127,20 @@ func NewTerrain(width, length, iterations int, minH, maxH float32, seed int64, t
 	for l := 0; l <= length; l++ {
 		for w := 0; w <= width; w++ {
 			texIndex := (w % 2) + (l%2)*2
-			// The normal vector calculation is necessary to be implemented.
-			// How can I calculate the normal vector? it should be based on the height map.
-			// Get the heights in the neighbor points. Get the direction vectors and then
-			// it could be calculated.
-			var iL, iW int
-			if l == length {
-				iL = l
-			} else {
-				iL = l + 1
-			}
-			if w == width {
-				iW = w
-			} else {
-				iW = w + 1
-			}
+			// The normal vector is calculated from the heights of the neighbor points.
 			currentPos := mgl32.Vec3{-float32(width)/2.0 + float32(w), heightMap[l][w], -float32(length)/2.0 + float32(l)}
-			nextPosX := mgl32.Vec3{-float32(width)/2.0 + float32(iW), heightMap[l][iW], -float32(length)/2.0 + float32(l)}
-			nextPosY := mgl32.Vec3{-float32(width)/2.0 + float32(w), heightMap[iL][w], -float32(length)/2.0 + float32(iL)}
-			normal := nextPosX.Sub(currentPos).Cross(nextPosY.Sub(currentPos)).Normalize()
 			vertices = append(vertices, vertex.Vertex{
 				Position:  currentPos,
-				Normal:    normal,
+				Normal:    heightMap.Normal(w, l),
 				TexCoords: textureCoords[texIndex],
 			})
 		}
 	}
-	for w := 0; w <= width-1; w++ {
-		for l := 0; l <= length-1; l++ {
-			i0 := uint32(w*(length+1) + l)
+	for l := 0; l <= length-1; l++ {
+		for w := 0; w <= width-1; w++ {
+			i0 := uint32(l*(width+1) + w)
 			i1 := uint32(1) + i0
-			i2 := uint32(length+1) + i0
+			i2 := uint32(width+1) + i0
 			i3 := uint32(1) + i2
 			indices = append(indices, i0)
 			indices = append(indices, i1)
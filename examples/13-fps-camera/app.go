@@ -1,11 +1,23 @@
 package main
 
 import (
+	"fmt"
+	"math"
 	"os"
+	"path"
 	"runtime"
 	"strconv"
 	"time"
 
+	"github.com/akosgarai/opengl_playground/pkg/animation"
+	"github.com/akosgarai/opengl_playground/pkg/focus"
+	"github.com/akosgarai/opengl_playground/pkg/glext"
+	"github.com/akosgarai/opengl_playground/pkg/gltfimport"
+	"github.com/akosgarai/opengl_playground/pkg/scatter"
+	"github.com/akosgarai/opengl_playground/pkg/terrain"
+	"github.com/akosgarai/opengl_playground/pkg/texturecache"
+	"github.com/akosgarai/opengl_playground/pkg/texturecontainer"
+	"github.com/akosgarai/opengl_playground/pkg/tooltip"
 	"github.com/akosgarai/playground_engine/pkg/application"
 	"github.com/akosgarai/playground_engine/pkg/camera"
 	"github.com/akosgarai/playground_engine/pkg/config"
@@ -16,6 +28,8 @@ import (
 	"github.com/akosgarai/playground_engine/pkg/model"
 	"github.com/akosgarai/playground_engine/pkg/screen"
 	"github.com/akosgarai/playground_engine/pkg/shader"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+	"github.com/akosgarai/playground_engine/pkg/theme"
 	"github.com/akosgarai/playground_engine/pkg/window"
 
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -26,14 +40,24 @@ const (
 	WindowTitle       = "Example - FPS camera application"
 	LEFT_MOUSE_BUTTON = glfw.MouseButtonLeft
 	Epsilon           = float64(200)
+	GrassTexture      = "/assets/grass.jpg"
+	WalkerAsset       = "/assets/walker.gltf"
+	// The animation of the walker is controlled with these keys.
+	PauseAnimationKey  = glfw.KeyP
+	LoopAnimationKey   = glfw.KeyL
+	SlowerAnimationKey = glfw.KeyLeftBracket
+	FasterAnimationKey = glfw.KeyRightBracket
+	SkinningKey        = glfw.KeyK
+	// The speed multiplier is changed with this value.
+	AnimationSpeedStep = float32(0.25)
 )
 
 var (
-	glWrapper      glwrapper.Wrapper
+	glWrapper      glext.Wrapper
 	app            *application.Application
-	MenuScreen     *screen.MenuScreen
+	MenuScreen     *focus.MenuScreen
 	AppScreen      *screen.Screen
-	SettingsScreen *screen.FormScreen
+	SettingsScreen *focus.FormScreen
 	// window related variables
 	Builder          *window.WindowBuilder
 	WindowWidth      = 800
@@ -47,6 +71,28 @@ var (
 	startTime      int64
 	LampOn         bool
 	LampLastToggle float64
+	// The grass and the walker textures are shared between the application
+	// screens, they are uploaded once, the restart only increments their references.
+	TextureCache   *texturecache.Cache
+	GroundTextures texture.Textures
+	WalkerTextures texture.Textures
+	// The streamed ground follows this camera. The workers of the previous
+	// ground are stopped, when the ground is recreated.
+	StreamedGround *terrain.ChunkedTerrain
+	// The not streamed ground. Its buffers are deleted, when the ground is recreated.
+	StaticGround *terrain.Terrain
+	Camera       *terrain.WalkCamera
+	// The scattered grass, trees and rocks follow this camera.
+	Vegetation *scatter.Model
+	// The corners of the path of the walker. The Y coordinates are replaced
+	// with the height of the ground.
+	WalkerPath = []mgl32.Vec3{
+		mgl32.Vec3{-2.5, 0, -1},
+		mgl32.Vec3{-0.5, 0, -1},
+		mgl32.Vec3{-0.5, 0, 1},
+		mgl32.Vec3{-2.5, 0, 1},
+	}
+	keyWasDown = make(map[glfw.Key]bool)
 )
 
 func setupWindowBuilder() {
@@ -97,10 +143,43 @@ func addCameraConfigToSettings() {
 	Settings.AddConfig("CameraVelocity", "Cam Speed", "The movement velocity of the camera. If it moves, it moves with this speed.", float32(0.005), nil)
 	// - direction speed
 	Settings.AddConfig("CameraRotation", "Cam Rotate", "The rotation velocity of the camera. If it rotates, it rotates with this speed.", float32(0.5), nil)
+	// - walk mode
+	var nonNegativeValidator model.FloatValidator
+	nonNegativeValidator = func(f float32) bool { return f >= 0 }
+	Settings.AddConfig("CameraWalk", "Walk mode", "If this flag is active, the camera walks on the ground, otherwise it flies.", true, nil)
+	Settings.AddConfig("CameraEyeHeight", "Eye height", "The distance of the eye from the ground in walk mode.", float32(0.5), nonNegativeValidator)
+	Settings.AddConfig("CameraMaxSlope", "Max slope", "The steepest slope (height difference in unit distance), that the camera could walk up to.", float32(1.0), nonNegativeValidator)
+	Settings.AddConfig("CameraWaterLevel", "Water level", "The level of the water in walk mode. The up direction is -Y, so that the negative values are the higher levels.", float32(0.0), nil)
+	Settings.AddConfig("CameraWaterDepth", "Water depth", "The deepest water, that the camera could walk into.", float32(0.2), nonNegativeValidator)
 }
 func addTerrainConfigToSettings() {
 	Settings.AddConfig("GroundWidth", "Ground width", "The value is used for generating map. The GroundBuilder will generate width*width tiles total.", int(10), nil)
 	Settings.AddConfig("GroundScale", "Ground scale", "The value is used for generating map. The tile size will be scale*scale unit.", float32(2.0), nil)
+	Settings.AddConfig("GroundHeightMap", "Height map", "The grayscale png height map in the assets directory or an absolute path. It's resampled to the ground width. If it's empty, the ground is flat.", "", nil)
+	Settings.AddConfig("GroundMinHeight", "Ground min h.", "The height of the black pixels of the height map.", float32(0.0), nil)
+	Settings.AddConfig("GroundMaxHeight", "Ground max h.", "The height of the white pixels of the height map or the top of the streamed hills. The up direction is -Y, so that the negative values are the hills.", float32(-1.0), nil)
+	var positiveValidator model.IntValidator
+	positiveValidator = func(i int) bool { return i > 0 }
+	var radiusValidator model.IntValidator
+	radiusValidator = func(i int) bool { return i >= 0 }
+	Settings.AddConfig("GroundStreamed", "Streamed", "If this flag is active, the ground is endless. It's generated in chunks around the camera from the seeded noise, the width and the height map are not used.", true, nil)
+	Settings.AddConfig("GroundSeed", "Ground seed", "The seed of the noise of the streamed ground.", int64(0), nil)
+	Settings.AddConfig("GroundChunkSize", "Chunk size", "The number of the tiles in the side of a chunk of the streamed ground.", int(16), positiveValidator)
+	Settings.AddConfig("GroundChunkRadius", "Chunk radius", "The chunks within this distance (in chunks) from the camera are loaded.", int(2), radiusValidator)
+	Settings.AddConfig("GroundChunkWorkers", "Chunk workers", "The number of the goroutines, that generate the chunks.", int(2), positiveValidator)
+}
+func addScatterConfigToSettings() {
+	var nonNegativeValidator model.FloatValidator
+	nonNegativeValidator = func(f float32) bool { return f >= 0 }
+	Settings.AddConfig("ScatterEnabled", "Scatter", "If this flag is active, grass, trees and rocks are scattered on the ground.", true, nil)
+	Settings.AddConfig("ScatterSeed", "Scatter seed", "The seed of the placement and the density noise of the items.", int64(0), nil)
+	Settings.AddConfig("ScatterArea", "Scatter area", "The items are placed in the square with this half size around the origin.", float32(30.0), nonNegativeValidator)
+	Settings.AddConfig("ScatterGrassDensity", "Grass density", "The maximal number of the grass tufts in unit area.", float32(8.0), nonNegativeValidator)
+	Settings.AddConfig("ScatterTreeDensity", "Tree density", "The maximal number of the trees in unit area.", float32(0.05), nonNegativeValidator)
+	Settings.AddConfig("ScatterRockDensity", "Rock density", "The maximal number of the rocks in unit area.", float32(0.3), nonNegativeValidator)
+	Settings.AddConfig("ScatterMaxSlope", "Plant slope", "The steepest slope (height difference in unit distance), where the grass and the trees grow. The rocks are placed to the steeper slopes too.", float32(0.6), nonNegativeValidator)
+	Settings.AddConfig("ScatterGrassCull", "Grass cull d.", "The grass farther than this distance from the camera is not drawn.", float32(8.0), nonNegativeValidator)
+	Settings.AddConfig("ScatterCull", "Item cull d.", "The trees and the rocks farther than this distance from the camera are not drawn.", float32(20.0), nonNegativeValidator)
 }
 func addRoomConfigToSettings() {
 	Settings.AddConfig("RoomPosition", "Position", "The center point of the floor.", mgl32.Vec3{2, 0, 1}, nil)
@@ -153,6 +232,15 @@ func addDirectionalLightConfigToSettings() {
 	Settings.AddConfig("DLDirection", "DL direction", "The direction vector of the directional lightsource.", mgl32.Vec3{0.7, 0.7, 0.7}, nil)
 }
 
+func addWalkerConfigToSettings() {
+	Settings.AddConfig("WalkerGPUSkinning", "GPU skinning", "If this flag is active, the walker is skinned in the vertex shader, otherwise on the cpu.", true, nil)
+	Settings.AddConfig("WalkerAnimSpeed", "Anim speed", "The speed multiplier of the walker animations.", float32(1.0), nil)
+	Settings.AddConfig("WalkerVelocity", "Walk speed", "The movement velocity of the walker with 1 animation speed.", float32(0.0003), nil)
+	Settings.AddConfig("WalkerIdleTime", "Idle time", "The time in milliseconds, that the walker spends in the corners of its path.", float32(2000.0), nil)
+	Settings.AddConfig("WalkerFade", "Fade time", "The cross-fade duration between the walk and the idle animations in seconds.", float32(0.3), nil)
+	Settings.AddConfig("WalkerScale", "Walker scale", "The scale of the walker model.", float32(0.25), nil)
+}
+
 func init() {
 	// lock thread
 	runtime.LockOSThread()
@@ -162,16 +250,22 @@ func init() {
 	addCameraConfigToSettings()
 	// Terrain configuration with initial values
 	addTerrainConfigToSettings()
+	// Scatter configuration with initial values
+	addScatterConfigToSettings()
 	// Room configuration with initial values
 	addRoomConfigToSettings()
 	// Lamp configuration with initial values
 	addLampConfigToSettings()
 	// Directional light configuration with initial values
 	addDirectionalLightConfigToSettings()
+	// Walker configuration with initial values
+	addWalkerConfigToSettings()
 }
 
-// It creates a new fps camera with the necessary setup from settings screen
-func CreateCameraFromSettings() interfaces.Camera {
+// It creates a new fps camera with the necessary setup from settings screen. The
+// walk mode is set from the settings, the surface has to be set after the ground
+// is created. The up direction of the world is the -Y.
+func CreateCameraFromSettings() *terrain.WalkCamera {
 	cameraPosition := Settings["CameraPos"].GetCurrentValue().(mgl32.Vec3)
 	worldUp := Settings["WorldUp"].GetCurrentValue().(mgl32.Vec3)
 	yawAngle := Settings["CameraYaw"].GetCurrentValue().(float32)
@@ -185,7 +279,13 @@ func CreateCameraFromSettings() interfaces.Camera {
 	cam.SetupProjection(fov, float32(WindowWidth)/float32(WindowHeight), near, far)
 	cam.SetVelocity(moveSpeed)
 	cam.SetRotationStep(directionSpeed)
-	return cam
+	walkCam := terrain.NewWalkCamera(cam, nil)
+	walkCam.SetWalking(Settings["CameraWalk"].GetCurrentValue().(bool))
+	walkCam.SetUpNegativeY(true)
+	walkCam.SetEyeHeight(Settings["CameraEyeHeight"].GetCurrentValue().(float32))
+	walkCam.SetMaxSlope(Settings["CameraMaxSlope"].GetCurrentValue().(float32))
+	walkCam.SetWater(Settings["CameraWaterLevel"].GetCurrentValue().(float32), Settings["CameraWaterDepth"].GetCurrentValue().(float32))
+	return walkCam
 }
 
 // Setup options for the camera
@@ -201,9 +301,10 @@ func CameraMovementOptions() map[string]interface{} {
 	return cm
 }
 
-// It creates the terrain model. Currently the width and scale is manageable.
-func CreateGround() *model.Terrain {
-	gb := model.NewTerrainBuilder()
+// It creates the terrain model. The width, the scale and the height map is manageable.
+// If the streamed flag is set, the ground is an endless chunked terrain.
+func CreateGround() interfaces.Model {
+	gb := terrain.NewBuilder()
 	w := Settings["GroundWidth"].GetCurrentValue().(int)
 	gb.SetWidth(w)
 	gb.SetLength(w)
@@ -211,14 +312,98 @@ func CreateGround() *model.Terrain {
 	s := Settings["GroundScale"].GetCurrentValue().(float32)
 	gb.SetScale(mgl32.Vec3{s, 1, s})
 	gb.SetGlWrapper(glWrapper)
-	gb.SurfaceTextureGrass()
 	gb.SetPeakProbability(0)
 	gb.SetCliffProbability(0)
 	gb.SetMinHeight(0)
 	gb.SetMaxHeight(0)
 	gb.SetPosition(mgl32.Vec3{0.0, 0.0, 0.0})
 	gb.SetSeed(0)
-	return gb.Build()
+	// The textures of the builder are replaced with the cached ones.
+	var textures texture.Textures
+	for _, uniformName := range []string{"material.diffuse", "material.specular"} {
+		if err := TextureCache.AddTexture(&textures, texturecontainer.Resolve(baseDir()+GrassTexture), glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, uniformName); err != nil {
+			panic(err)
+		}
+	}
+	// The textures of the previous ground are released after the new ones are added,
+	// so that the resident texture is not deleted.
+	TextureCache.Release(GroundTextures)
+	GroundTextures = textures
+	if StreamedGround != nil {
+		StreamedGround.Close()
+		StreamedGround = nil
+	}
+	if StaticGround != nil {
+		StaticGround.Delete()
+		StaticGround = nil
+	}
+	if Settings["GroundStreamed"].GetCurrentValue().(bool) {
+		gb.SetSeed(Settings["GroundSeed"].GetCurrentValue().(int64))
+		gb.SetMinHeight(Settings["GroundMinHeight"].GetCurrentValue().(float32))
+		gb.SetMaxHeight(Settings["GroundMaxHeight"].GetCurrentValue().(float32))
+		gb.SetChunkSize(Settings["GroundChunkSize"].GetCurrentValue().(int))
+		gb.SetChunkRadius(Settings["GroundChunkRadius"].GetCurrentValue().(int))
+		gb.SetChunkWorkers(Settings["GroundChunkWorkers"].GetCurrentValue().(int))
+		StreamedGround = gb.BuildChunked()
+		StreamedGround.SetTextures(textures)
+		return StreamedGround
+	}
+	if heightMap := Settings["GroundHeightMap"].GetCurrentValue().(string); heightMap != "" {
+		if !path.IsAbs(heightMap) {
+			heightMap = baseDir() + "/assets/" + heightMap
+		}
+		// The ground is flat if the height map is missing.
+		if err := gb.SetHeightMapFile(heightMap); err != nil {
+			fmt.Println(err)
+		}
+		gb.SetMinHeight(Settings["GroundMinHeight"].GetCurrentValue().(float32))
+		gb.SetMaxHeight(Settings["GroundMaxHeight"].GetCurrentValue().(float32))
+	}
+	StaticGround = gb.Build()
+	StaticGround.SetTextures(textures)
+	return StaticGround
+}
+
+// It creates the scattered grass, trees and rocks on the ground. The items
+// avoid the water of the walk camera, the room, the lamp and the path of the walker.
+func CreateVegetation(ground terrain.Surface) *scatter.Model {
+	seed := Settings["ScatterSeed"].GetCurrentValue().(int64)
+	s := scatter.New(ground, seed)
+	s.SetUpNegativeY(true)
+	s.SetWater(Settings["CameraWaterLevel"].GetCurrentValue().(float32))
+	roomPosition := Settings["RoomPosition"].GetCurrentValue().(mgl32.Vec3)
+	s.AddFootprint(scatter.NewRectangle(mgl32.Vec2{roomPosition.X(), roomPosition.Z()},
+		Settings["RoomWidth"].GetCurrentValue().(float32),
+		Settings["RoomLength"].GetCurrentValue().(float32), 0.2))
+	lampPosition := Settings["LampPosition"].GetCurrentValue().(mgl32.Vec3)
+	s.AddFootprint(scatter.NewCircle(mgl32.Vec2{lampPosition.X(), lampPosition.Z()}, 0.3))
+	s.AddFootprint(scatter.NewRectangle(mgl32.Vec2{-1.5, 0}, 2, 2, 0.2))
+
+	area := Settings["ScatterArea"].GetCurrentValue().(float32)
+	min, max := mgl32.Vec2{-area, -area}, mgl32.Vec2{area, area}
+	maxSlope := Settings["ScatterMaxSlope"].GetCurrentValue().(float32)
+	grassCull := Settings["ScatterGrassCull"].GetCurrentValue().(float32)
+	cull := Settings["ScatterCull"].GetCurrentValue().(float32)
+	white := material.New(mgl32.Vec3{1, 1, 1}, mgl32.Vec3{1, 1, 1}, mgl32.Vec3{0.1, 0.1, 0.1}, 32)
+
+	vegetation := scatter.NewModel()
+	grass := scatter.NewRule(Settings["ScatterGrassDensity"].GetCurrentValue().(float32), seed+1)
+	grass.MaxSlope = maxSlope
+	grass.NoiseThreshold = 0.3
+	vegetation.AddItems(scatter.GrassTuft(0.12, mgl32.Vec3{0.3, 0.6, 0.15}), s.Place(grass, min, max), grassCull, white, glWrapper)
+	trees := scatter.NewRule(Settings["ScatterTreeDensity"].GetCurrentValue().(float32), seed+2)
+	trees.MinHeight = 0.2
+	trees.MaxSlope = maxSlope
+	trees.NoiseScale = 0.05
+	vegetation.AddItems(scatter.Tree(1.2, mgl32.Vec3{0.35, 0.22, 0.1}, mgl32.Vec3{0.1, 0.35, 0.15}), s.Place(trees, min, max), cull, white, glWrapper)
+	rocks := scatter.NewRule(Settings["ScatterRockDensity"].GetCurrentValue().(float32), seed+3)
+	rocks.MinSlope = maxSlope / 2
+	rocks.MaxSlope = 10
+	rocks.NoiseThreshold = 0.2
+	vegetation.AddItems(scatter.Rock(0.2, seed, mgl32.Vec3{0.5, 0.5, 0.48}), s.Place(rocks, min, max), cull, white, glWrapper)
+	vegetation.SetFocus(Camera.GetPosition())
+	fmt.Printf("Scattered items: %d\n", vegetation.GetInstanceCount())
+	return vegetation
 }
 
 // It creates the room model based on the Settings.
@@ -292,8 +477,168 @@ func GenerateStreetLamp() *model.StreetLamp {
 	return lamp
 }
 
+// Walker is an animated character, that walks along a closed path on the
+// ground. In the corners it cross-fades to the idle animation, then after the
+// idle time it turns to the next corner.
+type Walker struct {
+	*animation.Model
+	path   []mgl32.Vec3
+	ground terrain.Surface
+	// The index of the next corner of the path.
+	target   int
+	position mgl32.Vec3
+	// The walker faces to this direction.
+	direction mgl32.Vec3
+	// The remaining idle time in the current corner in milliseconds.
+	idle float64
+}
+
+// It imports the walker model and starts its walk animation. The walker
+// follows the height of the ground.
+func CreateWalker(ground terrain.Surface) *Walker {
+	importer := gltfimport.New(baseDir()+path.Dir(WalkerAsset), path.Base(WalkerAsset), glWrapper)
+	importer.SetCache(TextureCache)
+	importer.Import()
+	m, err := importer.NewAnimatedModel(0)
+	if err != nil {
+		panic(err)
+	}
+	// The textures of the previous walker are released after the new ones are added,
+	// so that the resident textures are not deleted.
+	var textures texture.Textures
+	for _, msh := range importer.GetSkinnedMeshes(0) {
+		textures = append(textures, msh.Textures...)
+	}
+	TextureCache.Release(WalkerTextures)
+	WalkerTextures = textures
+	mode := animation.SKINNING_CPU
+	if Settings["WalkerGPUSkinning"].GetCurrentValue().(bool) {
+		mode = animation.SKINNING_GPU
+	}
+	if err := m.SetSkinning(mode); err != nil {
+		fmt.Println(err)
+	}
+	player := m.GetPlayer()
+	player.SetSpeed(Settings["WalkerAnimSpeed"].GetCurrentValue().(float32))
+	if err := player.Play("walk", true); err != nil {
+		panic(err)
+	}
+	w := &Walker{
+		Model:     m,
+		path:      WalkerPath,
+		ground:    ground,
+		target:    1,
+		position:  WalkerPath[0],
+		direction: WalkerPath[1].Sub(WalkerPath[0]),
+	}
+	w.updateTransformation()
+	return w
+}
+
+// updateTransformation places the walker to its position on the ground, facing
+// to its direction. The model is flipped upside down, like the room, because the
+// up direction of the world is the -Y axis.
+func (w *Walker) updateTransformation() {
+	heading := float32(math.Atan2(float64(w.direction.X()), float64(w.direction.Z())))
+	s := Settings["WalkerScale"].GetCurrentValue().(float32)
+	y := w.position.Y()
+	if w.ground != nil {
+		if height, err := w.ground.HeightAtPos(w.position); err == nil {
+			y = height
+		}
+	}
+	transformation := mgl32.Translate3D(w.position.X(), y, w.position.Z()).
+		Mul4(mgl32.HomogRotate3DY(heading)).
+		Mul4(mgl32.HomogRotate3DZ(math.Pi)).
+		Mul4(mgl32.Scale3D(s, s, s))
+	w.SetTransformation(transformation)
+}
+
+// handleKeys applies the playback control keys.
+func (w *Walker) handleKeys() {
+	player := w.GetPlayer()
+	if keyPressed(PauseAnimationKey) {
+		if player.IsPaused() {
+			player.Resume()
+		} else {
+			player.Pause()
+		}
+	}
+	if keyPressed(LoopAnimationKey) {
+		player.SetLoop(!player.IsLooping())
+		fmt.Printf("Clip '%s' loop: %v\n", player.GetCurrent(), player.IsLooping())
+	}
+	if keyPressed(SlowerAnimationKey) {
+		player.SetSpeed(player.GetSpeed() - AnimationSpeedStep)
+		fmt.Printf("Animation speed: %.2f\n", player.GetSpeed())
+	}
+	if keyPressed(FasterAnimationKey) {
+		player.SetSpeed(player.GetSpeed() + AnimationSpeedStep)
+		fmt.Printf("Animation speed: %.2f\n", player.GetSpeed())
+	}
+	if keyPressed(SkinningKey) {
+		mode, name := animation.SKINNING_GPU, "gpu"
+		if w.GetSkinning() == animation.SKINNING_GPU {
+			mode, name = animation.SKINNING_CPU, "cpu"
+		}
+		if err := w.SetSkinning(mode); err != nil {
+			fmt.Println(err)
+		} else {
+			fmt.Printf("Skinning: %s\n", name)
+		}
+	}
+}
+
+// Update function handles the playback keys, moves the walker along its path,
+// places it on the ground, then it updates the animated model. The walker moves
+// only if the animation is played forward, with the velocity multiplied with
+// the animation speed. The ground is sampled in every update, because the
+// streamed ground could change under the walker.
+func (w *Walker) Update(dt float64) {
+	w.handleKeys()
+	player := w.GetPlayer()
+	speed := player.GetSpeed()
+	if !player.IsPaused() && speed > 0 {
+		w.move(dt * float64(speed))
+	}
+	w.updateTransformation()
+	w.Model.Update(dt)
+}
+func (w *Walker) move(dt float64) {
+	player := w.GetPlayer()
+	fade := Settings["WalkerFade"].GetCurrentValue().(float32)
+	if w.idle > 0 {
+		w.idle -= dt
+		if w.idle <= 0 {
+			player.CrossFade("walk", true, fade)
+		}
+		return
+	}
+	target := w.path[w.target]
+	direction := target.Sub(w.position)
+	step := Settings["WalkerVelocity"].GetCurrentValue().(float32) * float32(dt)
+	if direction.Len() <= step {
+		w.position = target
+		w.target = (w.target + 1) % len(w.path)
+		w.idle = float64(Settings["WalkerIdleTime"].GetCurrentValue().(float32))
+		player.CrossFade("idle", true, fade)
+		w.direction = w.path[w.target].Sub(w.position)
+		return
+	}
+	w.position = w.position.Add(direction.Normalize().Mul(step))
+	w.direction = direction
+}
+
+// keyPressed returns true if the key is down, but it was up in the previous update.
+func keyPressed(key glfw.Key) bool {
+	down := app.GetKeyState(key)
+	pressed := down && !keyWasDown[key]
+	keyWasDown[key] = down
+	return pressed
+}
+
 // It creates the menu screen.
-func CreateMenuScreen() *screen.MenuScreen {
+func CreateMenuScreen() *focus.MenuScreen {
 	showAll := func(m map[string]bool) bool {
 		return true
 	}
@@ -312,6 +657,7 @@ func CreateMenuScreen() *screen.MenuScreen {
 		startTime = lastUpdate
 		AppScreen = CreateApplicationScreen()
 		app.ActivateScreen(AppScreen)
+		fmt.Print(TextureCache.Log())
 	}
 	startEvent := func() {
 		// Disable mouse cursor
@@ -347,13 +693,25 @@ func CreateMenuScreen() *screen.MenuScreen {
 		*screen.NewMenuScreenOption("Settings", showAll, settingsEvent),
 		*screen.NewMenuScreenOption("Exit", showAll, exitEvent),
 	}
-	return app.BuildMenuScreen(options)
+	return focus.NewMenuScreen(app.BuildMenuScreen(options), theme.Default)
 }
 
-// It creates the Settings screen.
-func CreateSettingsScreen(defaults config.Config) *screen.FormScreen {
+// It creates the Settings screen. The descriptions of the settings are displayed
+// in tooltips.
+func CreateSettingsScreen(defaults config.Config) *focus.FormScreen {
 	formItemOrders := []string{
 		"GroundWidth", "GroundScale",
+		"GroundHeightMap",
+		"GroundMinHeight", "GroundMaxHeight",
+		"GroundStreamed", "GroundSeed",
+		"GroundChunkSize", "GroundChunkRadius",
+		"GroundChunkWorkers",
+
+		"ScatterEnabled", "ScatterSeed",
+		"ScatterArea", "ScatterGrassDensity",
+		"ScatterTreeDensity", "ScatterRockDensity",
+		"ScatterMaxSlope", "ScatterGrassCull",
+		"ScatterCull",
 
 		"RoomPosition",
 		"RoomWidth", "RoomLength",
@@ -380,24 +738,43 @@ func CreateSettingsScreen(defaults config.Config) *screen.FormScreen {
 		"DLSpecular",
 		"DLDirection",
 
+		"WalkerGPUSkinning", "WalkerAnimSpeed",
+		"WalkerVelocity", "WalkerIdleTime",
+		"WalkerFade", "WalkerScale",
+
 		"CameraPos",
 		"WorldUp",
 		"CameraYaw", "CameraPitch",
 		"CameraNear", "CameraFar",
 		"CameraFov", "CameraVelocity",
 		"CameraRotation",
+		"CameraWalk", "CameraEyeHeight",
+		"CameraMaxSlope", "CameraWaterLevel",
+		"CameraWaterDepth",
 	}
-	return app.BuildFormScreen(defaults, formItemOrders, "FPS editor")
+	form := tooltip.NewFormScreen(app.BuildFormScreen(defaults, formItemOrders, "FPS editor"), theme.Default, glWrapper)
+	return focus.NewFormScreen(form, formItemOrders, theme.Default)
 }
 
 func CreateApplicationScreen() *screen.Screen {
 	scrn := screen.New()
-	scrn.SetupCamera(CreateCameraFromSettings(), CameraMovementOptions())
+	Camera = CreateCameraFromSettings()
+	scrn.SetupCamera(Camera, CameraMovementOptions())
 	// Shader application for the textured meshes.
 	shaderProgramTexture := shader.NewTextureShader(glWrapper)
 	scrn.AddShader(shaderProgramTexture)
 
-	scrn.AddModelToShader(CreateGround(), shaderProgramTexture)
+	ground := CreateGround()
+	Camera.SetSurface(ground.(terrain.Surface))
+	scrn.AddModelToShader(ground, shaderProgramTexture)
+	Vegetation = nil
+	if Settings["ScatterEnabled"].GetCurrentValue().(bool) {
+		// Shader application for the instanced items.
+		shaderProgramScatter := scatter.NewInstancedShader(glWrapper)
+		scrn.AddShader(shaderProgramScatter)
+		Vegetation = CreateVegetation(ground.(terrain.Surface))
+		scrn.AddModelToShader(Vegetation, shaderProgramScatter)
+	}
 	var shaderProgramRoom *shader.Shader
 	if Settings["RoomTextured"].GetCurrentValue().(bool) {
 		// Shader application for the texture + blending
@@ -427,6 +804,11 @@ func CreateApplicationScreen() *screen.Screen {
 		"spotLight[0].cutOff", "spotLight[0].outerCutOff"})
 	scrn.AddModelToShader(lamp, shaderProgramLamp)
 
+	// Shader application for the skinned meshes. It handles both skinning modes.
+	shaderProgramWalker := animation.NewSkinnedShader(glWrapper)
+	scrn.AddShader(shaderProgramWalker)
+	scrn.AddModelToShader(CreateWalker(ground.(terrain.Surface)), shaderProgramWalker)
+
 	DirectionalLightSource := light.NewDirectionalLight([4]mgl32.Vec3{
 		Settings["DLDirection"].GetCurrentValue().(mgl32.Vec3),
 		Settings["DLAmbient"].GetCurrentValue().(mgl32.Vec3),
@@ -439,6 +821,10 @@ func CreateApplicationScreen() *screen.Screen {
 	return scrn
 }
 
+func baseDir() string {
+	_, filename, _, _ := runtime.Caller(1)
+	return path.Dir(filename)
+}
 func setupApp(glWrapper interfaces.GLWrapper) {
 	glWrapper.Enable(glwrapper.DEPTH_TEST)
 	glWrapper.DepthFunc(glwrapper.LESS)
@@ -449,6 +835,14 @@ func Update() {
 	delta := float64(nowNano-lastUpdate) / float64(time.Millisecond)
 	lastUpdate = nowNano
 	app.Update(delta)
+	if StreamedGround != nil {
+		StreamedGround.SetFocus(Camera.GetPosition())
+	}
+	if Vegetation != nil {
+		Vegetation.SetFocus(Camera.GetPosition())
+	}
+	// The ground could be loaded under the camera after its movement.
+	Camera.Follow()
 	LampLastToggle += delta
 	mdl, _, _ := app.GetClosestModelMeshDistance()
 	switch mdl.(type) {
@@ -482,6 +876,7 @@ func main() {
 	// Init opengl.
 	glWrapper.InitOpenGL()
 
+	TextureCache = texturecache.New(glWrapper)
 	app.AddScreen(CreateApplicationScreen())
 
 	MenuScreen = CreateMenuScreen()
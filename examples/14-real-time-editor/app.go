@@ -2,10 +2,13 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path"
 	"runtime"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/akosgarai/playground_engine/pkg/application"
@@ -16,6 +19,7 @@ import (
 	"github.com/akosgarai/playground_engine/pkg/material"
 	"github.com/akosgarai/playground_engine/pkg/mesh"
 	"github.com/akosgarai/playground_engine/pkg/model"
+	"github.com/akosgarai/playground_engine/pkg/primitives/boundingobject"
 	"github.com/akosgarai/playground_engine/pkg/primitives/rectangle"
 	"github.com/akosgarai/playground_engine/pkg/primitives/sphere"
 	"github.com/akosgarai/playground_engine/pkg/screen"
@@ -23,6 +27,8 @@ import (
 	"github.com/akosgarai/playground_engine/pkg/transformations"
 	"github.com/akosgarai/playground_engine/pkg/window"
 
+	"github.com/akosgarai/opengl_playground/pkg/tooltip"
+
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
 )
@@ -52,6 +58,9 @@ var (
 	glWrapper glwrapper.Wrapper
 	// menu screen flag
 	MenuScreenEnabled = true
+	// It is true, if the last escape press was handled by the editor screen.
+	// The release of the key is also skipped in this case.
+	EscapeHandled = false
 	// Button metric
 	Buttonframe        = mgl32.Vec2{0.1, 0.2}
 	Buttonsurface      = mgl32.Vec2{0.0925, 0.185}
@@ -64,8 +73,57 @@ var (
 	TextInputDefaultColor = []mgl32.Vec3{mgl32.Vec3{0.4, 0.4, 0.4}}
 	TextInputHoverColor   = []mgl32.Vec3{mgl32.Vec3{0.4, 0.8, 0.4}}
 	TextInputFieldColor   = []mgl32.Vec3{mgl32.Vec3{1.0, 1.0, 1.0}}
+	// Slider input variables
+	SliderDefaultDecimals = 3
+	SliderNudgeRatio      = float32(0.01)
+	SliderLogScaleOffset  = float32(0.001)
+	// Keyboard focus variables
+	KeyRepeatDelay    = float64(150)
+	FocusSurfaceColor = []mgl32.Vec3{mgl32.Vec3{0.6, 0.8, 1.0}}
+	// Tooltip variables. The delay is in ms, the sizes are in the
+	// coordinate system of the menu panel.
+	TooltipDelay              = float64(500)
+	TooltipMaxWidth           = float32(0.6)
+	TooltipLabelSize          = float32(0.0004)
+	TooltipLineHeight         = float32(0.05)
+	TooltipCursorOffset       = float32(0.05)
+	TooltipDistanceFromScreen = float32(0.05)
+	TooltipColor              = []mgl32.Vec3{mgl32.Vec3{1.0, 1.0, 0.8}}
+	MenuPanelHeight           = float32(2.0)
+	MenuPanelWidth            = float32(1.0)
+	ButtonDescriptions        = map[string]string{
+		"Material":  "Opens the material editor. The ambient, diffuse, specular color and the shininess of the material could be updated.",
+		"Inspector": "Opens the scene graph inspector. The shaders, models and meshes of the screen are listed in a tree.",
+		"Ambient":   "Edit the ambient color of the material.",
+		"Diffuse":   "Edit the diffuse color of the material.",
+		"Specular":  "Edit the specular color of the material.",
+		"Shininess": "Edit the shininess of the material.",
+		"Back":      "Navigates back to the previous screen.",
+		"Prev":      "Displays the previous page of the tree.",
+		"Next":      "Displays the next page of the tree.",
+		"+":         "Expands the node.",
+		"-":         "Collapses the node.",
+		"Position":  "Edit the position of the selected node.",
+		"Rotation":  "Edit the rotation of the selected node. The angles are in degrees.",
+		"Scale":     "Edit the scale of the selected node.",
+		"Bounding":  "Edit the parameters of the bounding object of the selected mesh.",
+		"Hide":      "Hides the selected node. It is still listed in the tree.",
+		"Show":      "Displays the hidden node again.",
+	}
+	// Scene graph inspector variables
+	TreeRowframe           = mgl32.Vec2{0.06, 0.6}
+	TreeRowsurface         = mgl32.Vec2{0.055, 0.59}
+	TreeTogglerframe       = mgl32.Vec2{0.06, 0.06}
+	TreeTogglersurface     = mgl32.Vec2{0.055, 0.055}
+	TreeRowDistance        = float32(0.07)
+	TreeIndentation        = float32(0.05)
+	TreeMaxIndentation     = 3
+	InspectorRowsPerPage   = 20
+	InspectorLabelSize     = float32(0.0004)
+	InspectorHiddenColor   = []mgl32.Vec3{mgl32.Vec3{0.6, 0.3, 0.3}}
+	InspectorSelectedColor = []mgl32.Vec3{mgl32.Vec3{0.4, 0.4, 0.8}}
 	// the states
-	AllScreenStates = []string{"Default", "Material", "MaterialAmbientForm", "MaterialDiffuseForm", "MaterialSpecularForm", "MaterialShininessForm"}
+	AllScreenStates = []string{"Default", "Material", "MaterialAmbientForm", "MaterialDiffuseForm", "MaterialSpecularForm", "MaterialShininessForm", "Inspector", "InspectorNode", "InspectorPositionForm", "InspectorRotationForm", "InspectorScaleForm", "InspectorBoundingForm"}
 	ScreenLabels    = map[string]string{
 		"Default":               "Editor application",
 		"Material":              "Material",
@@ -73,6 +131,12 @@ var (
 		"MaterialDiffuseForm":   "Material > Diffuse",
 		"MaterialSpecularForm":  "Material > Specualar",
 		"MaterialShininessForm": "Material > Shininess",
+		"Inspector":             "Inspector",
+		"InspectorNode":         "Inspector > Node",
+		"InspectorPositionForm": "Inspector > Position",
+		"InspectorRotationForm": "Inspector > Rotation",
+		"InspectorScaleForm":    "Inspector > Scale",
+		"InspectorBoundingForm": "Inspector > Bounding object",
 	}
 )
 
@@ -147,6 +211,19 @@ type SliderInput struct {
 	sliderMin      float32
 	sliderMax      float32
 	sliderCurrent  float32 // This is the current value of the slider input.
+	// The value is snapped to the multiples of the step (from the min value).
+	// The 0 step means continuous slider.
+	sliderStep float32
+	// In log scale the position of the slider maps logarithmically to the value.
+	logScale bool
+	// The position of the slider that follows the mouse. The slider mesh is
+	// drawn to the position of the snapped value.
+	sliderPosition float32
+	// The typed text of the value field. It is used in edit mode.
+	editing     bool
+	editBuffer  string
+	focused     bool
+	description string // It is displayed in the tooltip.
 }
 
 // NewSliderInput returns a slider input instance. The following inputs has to be set:
@@ -170,10 +247,26 @@ func NewSliderInput(sizeFrame, sizeSurface mgl32.Vec2, defaultCol, hoverCol, tiC
 		sliderMin:      min,
 		sliderMax:      max,
 		sliderCurrent:  (max-min)/2.0 + min,
+		sliderStep:     0.0,
+		logScale:       false,
 	}
+	si.sliderPosition = si.calculatePositionBasedOnCurrent().Z()
 	si.baseModelToDefaultState()
 	return si
 }
+
+// SetStep updates the step of the slider. The current value is snapped to the new step.
+func (si *SliderInput) SetStep(step float32) {
+	si.sliderStep = step
+	si.SetCurrentValue(si.snapValue(si.sliderCurrent))
+}
+
+// SetLogScale sets the mapping of the slider. It is useful for the intervals where
+// the small values needs more precision, like the shininess or the attenuation terms.
+func (si *SliderInput) SetLogScale(logScale bool) {
+	si.logScale = logScale
+	si.SetCurrentValue(si.sliderCurrent)
+}
 func (si *SliderInput) SetAspect(aspect float32) {
 	si.aspect = aspect
 }
@@ -202,6 +295,27 @@ func (si *SliderInput) Clear() {
 	si.baseModelToDefaultState()
 }
 
+// SetFocused updates the focus flag. The surface of the focused item is drawn
+// with the FocusSurfaceColor. It is applied on the next Hover or Clear call.
+func (si *SliderInput) SetFocused(focused bool) {
+	si.focused = focused
+}
+
+// FocusPosition returns the position of the item on the form.
+func (si *SliderInput) FocusPosition() mgl32.Vec3 {
+	return si.positionOnForm
+}
+
+// SetDescription sets the text of the tooltip.
+func (si *SliderInput) SetDescription(description string) {
+	si.description = description
+}
+
+// GetDescription returns the text of the tooltip.
+func (si *SliderInput) GetDescription() string {
+	return si.description
+}
+
 // The black line for the slider. It represents the min-max interval.
 // The length of it: 3/4 of the foreground width - width of the slider.
 // The width of the slider is interval length / 10
@@ -237,9 +351,13 @@ func (si *SliderInput) baseModelToState(state string) {
 	bg := mesh.NewColorMesh(V, I, bgColor, glWrapper)
 	bg.SetBoundingObject(BO)
 	bg.RotateY(-90)
+	fgColor := si.defaultColor
+	if si.focused {
+		fgColor = FocusSurfaceColor
+	}
 	fgRect := rectangle.NewExact(si.surfaceSize.Y()/si.aspect, si.surfaceSize.X()/si.aspect)
-	V, I, _ = fgRect.ColoredMeshInput(si.defaultColor)
-	fg := mesh.NewColorMesh(V, I, si.defaultColor, glWrapper)
+	V, I, _ = fgRect.ColoredMeshInput(fgColor)
+	fg := mesh.NewColorMesh(V, I, fgColor, glWrapper)
 	fg.SetPosition(mgl32.Vec3{0.0, -ForegroundDistanceFromBackground, 0.0})
 	fg.SetParent(bg)
 	// text input field
@@ -297,46 +415,187 @@ func (si *SliderInput) SliderCollision(coords mgl32.Vec3) bool {
 }
 func (si *SliderInput) SetCurrentValue(current float32) {
 	si.sliderCurrent = current
+	si.sliderPosition = si.calculatePositionBasedOnCurrent().Z()
+	si.updateSliderMesh()
+}
+
+// updateSliderMesh moves the slider mesh to the position of the current value.
+func (si *SliderInput) updateSliderMesh() {
 	msh, err := si.GetMeshByIndex(4)
 	if err != nil {
 		fmt.Println("Mesh is missing. Skipping position update.")
 		return
 	}
 	msh.SetPosition(si.calculatePositionBasedOnCurrent())
-
 }
 func (si *SliderInput) MoveSliderWith(x float32) {
-	slider, err := si.GetMeshByIndex(4)
-	if err != nil {
-		return
-	}
-	currentPosition := slider.GetPosition()
 	// The vertical position has to be kept above the black line, so that it might be updated with the max/min value.
 	sliderSpaceWidth := si.sliderSpaceWidth()
-	newVerticalCoordinateValue := currentPosition.Z() - x
+	newVerticalCoordinateValue := si.sliderPosition - x
 	if newVerticalCoordinateValue > sliderSpaceWidth/2 {
 		newVerticalCoordinateValue = sliderSpaceWidth / 2
 	}
 	if newVerticalCoordinateValue < -sliderSpaceWidth/2 {
 		newVerticalCoordinateValue = -sliderSpaceWidth / 2
 	}
-	newPosition := mgl32.Vec3{currentPosition.X(), currentPosition.Y(), newVerticalCoordinateValue}
-	slider.SetPosition(newPosition)
-	si.updateCurrentFromPosition(newPosition)
+	si.sliderPosition = newVerticalCoordinateValue
+	si.updateCurrentFromPosition(newVerticalCoordinateValue)
+	si.updateSliderMesh()
+}
+
+// Nudge moves the value with one step to the given direction (+1: increase, -1: decrease).
+// If the step is not set, the slider is moved with the 1/100 of its length.
+func (si *SliderInput) Nudge(direction int) {
+	if si.sliderStep > 0 {
+		si.SetCurrentValue(si.clampValue(si.snapValue(si.sliderCurrent + float32(direction)*si.sliderStep)))
+		return
+	}
+	ratio := si.valueToRatio(si.sliderCurrent) + float32(direction)*SliderNudgeRatio
+	si.SetCurrentValue(si.clampValue(si.ratioToValue(ratio)))
 }
 func (si *SliderInput) calculatePositionBasedOnCurrent() mgl32.Vec3 {
-	intervalLength := si.sliderMax - si.sliderMin
-	ratio := si.sliderCurrent / intervalLength
+	ratio := si.valueToRatio(si.sliderCurrent)
+	if ratio < 0 {
+		ratio = 0
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
 	sliderSpaceWidth := si.sliderSpaceWidth()
 	diffFromLeft := sliderSpaceWidth * ratio
 	return mgl32.Vec3{0.0, -ForegroundDistanceFromBackground, -sliderSpaceWidth/2 + diffFromLeft}
 }
-func (si *SliderInput) updateCurrentFromPosition(currentPosition mgl32.Vec3) {
+func (si *SliderInput) updateCurrentFromPosition(position float32) {
 	sliderSpaceWidth := si.sliderSpaceWidth()
-	diffFromMinimum := currentPosition.Z() + sliderSpaceWidth/2
+	diffFromMinimum := position + sliderSpaceWidth/2
 	ratio := diffFromMinimum / sliderSpaceWidth
-	value := (si.sliderMax-si.sliderMin)*ratio + si.sliderMin
-	si.sliderCurrent = value
+	si.sliderCurrent = si.clampValue(si.snapValue(si.ratioToValue(ratio)))
+}
+
+// valueToRatio returns the position of the value on the slider in the [0-1] interval.
+// In log scale the value is shifted with the SliderLogScaleOffset part of the interval,
+// so that the zero minimum could also be handled.
+func (si *SliderInput) valueToRatio(value float32) float32 {
+	intervalLength := si.sliderMax - si.sliderMin
+	if intervalLength == 0 {
+		return 0
+	}
+	if !si.logScale {
+		return (value - si.sliderMin) / intervalLength
+	}
+	offset := intervalLength * SliderLogScaleOffset
+	return float32(math.Log(float64(1+(value-si.sliderMin)/offset)) / math.Log(float64(1+intervalLength/offset)))
+}
+
+// ratioToValue is the inverse of the valueToRatio function.
+func (si *SliderInput) ratioToValue(ratio float32) float32 {
+	intervalLength := si.sliderMax - si.sliderMin
+	if !si.logScale {
+		return intervalLength*ratio + si.sliderMin
+	}
+	offset := intervalLength * SliderLogScaleOffset
+	return si.sliderMin + offset*float32(math.Exp(float64(ratio)*math.Log(float64(1+intervalLength/offset)))-1)
+}
+
+// snapValue returns the closest value to the given one, that is a multiple of the step from the min value.
+func (si *SliderInput) snapValue(value float32) float32 {
+	if si.sliderStep <= 0 {
+		return value
+	}
+	steps := math.Round(float64((value - si.sliderMin) / si.sliderStep))
+	return si.sliderMin + float32(steps)*si.sliderStep
+}
+
+// clampValue returns the value that is kept in the [min, max] interval.
+func (si *SliderInput) clampValue(value float32) float32 {
+	if value < si.sliderMin {
+		return si.sliderMin
+	}
+	if value > si.sliderMax {
+		return si.sliderMax
+	}
+	return value
+}
+
+// ValueText returns the text that is printed to the value field. In edit mode
+// it is the typed text with a cursor, otherwise the current value that is formatted
+// based on the step.
+func (si *SliderInput) ValueText() string {
+	if si.editing {
+		return si.editBuffer + "_"
+	}
+	decimals := SliderDefaultDecimals
+	if si.sliderStep > 0 {
+		decimals = int(math.Ceil(-math.Log10(float64(si.sliderStep)) - 1e-6))
+		if decimals < 0 {
+			decimals = 0
+		}
+	}
+	return strconv.FormatFloat(float64(si.sliderCurrent), 'f', decimals, 32)
+}
+
+// ValueFieldCollision returns true if the given coordinates are on the value field.
+func (si *SliderInput) ValueFieldCollision(coords mgl32.Vec3) bool {
+	field, err := si.GetMeshByIndex(2)
+	if err != nil {
+		fmt.Printf("Slider issue. %#v\n", err)
+		return false
+	}
+	coordsInFieldPlane := mgl32.Vec3{coords.X(), coords.Y() - ForegroundDistanceFromBackground - InputFieldDistanceFromForeground, coords.Z()}
+	msh, dist := si.ClosestMeshTo(coordsInFieldPlane)
+	if dist > CollisionEpsilon {
+		return false
+	}
+	return msh == field
+}
+
+// IsEditing returns true if the value field is in edit mode.
+func (si *SliderInput) IsEditing() bool {
+	return si.editing
+}
+
+// StartEditing turns the value field to edit mode. The typing starts with empty field.
+func (si *SliderInput) StartEditing() {
+	si.editing = true
+	si.editBuffer = ""
+}
+
+// AppendChar inserts the character to the end of the typed text. Only the
+// characters of the numbers are accepted.
+func (si *SliderInput) AppendChar(char rune) {
+	if !si.editing {
+		return
+	}
+	if (char >= '0' && char <= '9') || char == '.' || (char == '-' && si.editBuffer == "") {
+		si.editBuffer = si.editBuffer + string(char)
+	}
+}
+
+// DeleteLastChar removes the last character of the typed text.
+func (si *SliderInput) DeleteLastChar() {
+	if si.editing && len(si.editBuffer) > 0 {
+		si.editBuffer = si.editBuffer[:len(si.editBuffer)-1]
+	}
+}
+
+// CancelEditing leaves the edit mode without changing the value.
+func (si *SliderInput) CancelEditing() {
+	si.editing = false
+}
+
+// CommitEditing leaves the edit mode. If the typed text is a valid number,
+// it is snapped, clamped and set as current value, and true is returned.
+func (si *SliderInput) CommitEditing() bool {
+	if !si.editing {
+		return false
+	}
+	si.editing = false
+	value, err := strconv.ParseFloat(si.editBuffer, 32)
+	if err != nil {
+		return false
+	}
+	si.SetCurrentValue(si.clampValue(si.snapValue(float32(value))))
+	return true
 }
 
 // It is the representation of the text input ui item.
@@ -478,6 +737,8 @@ type Button struct {
 	aspect         float32
 	clicked        bool
 	clickCallback  func()
+	focused        bool
+	description    string // It is displayed in the tooltip.
 }
 
 // PinToScreen sets the parent of the bg mesh to the given one and updates its position.
@@ -504,8 +765,8 @@ func (b *Button) baseModelToHoverState() {
 	bg.SetBoundingObject(BO)
 	bg.RotateY(-90)
 	fgRect := rectangle.NewExact(b.surfaceSize.Y()/b.aspect, b.surfaceSize.X()/b.aspect)
-	V, I, _ = fgRect.ColoredMeshInput(b.defaultColor)
-	fg := mesh.NewColorMesh(V, I, b.defaultColor, glWrapper)
+	V, I, _ = fgRect.ColoredMeshInput(b.surfaceColor())
+	fg := mesh.NewColorMesh(V, I, b.surfaceColor(), glWrapper)
 	fg.SetPosition(mgl32.Vec3{0.0, -ForegroundDistanceFromBackground, 0.0})
 	fg.SetParent(bg)
 	m := model.New()
@@ -525,8 +786,8 @@ func (b *Button) baseModelToDefaultState() {
 	bg.SetBoundingObject(BO)
 	bg.RotateY(-90)
 	fgRect := rectangle.NewExact(b.surfaceSize.Y()/b.aspect, b.surfaceSize.X()/b.aspect)
-	V, I, _ = fgRect.ColoredMeshInput(b.defaultColor)
-	fg := mesh.NewColorMesh(V, I, b.defaultColor, glWrapper)
+	V, I, _ = fgRect.ColoredMeshInput(b.surfaceColor())
+	fg := mesh.NewColorMesh(V, I, b.surfaceColor(), glWrapper)
 	fg.SetPosition(mgl32.Vec3{0.0, -ForegroundDistanceFromBackground, 0.0})
 	fg.SetParent(bg)
 	m := model.New()
@@ -539,6 +800,41 @@ func (b *Button) baseModelToDefaultState() {
 		b.SetLabelSurface(fg)
 	}
 }
+
+// surfaceColor returns the color of the foreground mesh. It depends on the focus.
+func (b *Button) surfaceColor() []mgl32.Vec3 {
+	if b.focused {
+		return FocusSurfaceColor
+	}
+	return b.defaultColor
+}
+
+// SetFocused updates the focus flag. The surface of the focused item is drawn
+// with the FocusSurfaceColor. It is applied on the next Hover or Clear call.
+func (b *Button) SetFocused(focused bool) {
+	b.focused = focused
+}
+
+// FocusPosition returns the position of the item on the form.
+func (b *Button) FocusPosition() mgl32.Vec3 {
+	return b.positionOnForm
+}
+
+// SetDescription sets the text of the tooltip.
+func (b *Button) SetDescription(description string) {
+	b.description = description
+}
+
+// GetDescription returns the text of the tooltip.
+func (b *Button) GetDescription() string {
+	return b.description
+}
+
+// Activate calls the click callback of the button.
+func (b *Button) Activate() {
+	b.clicked = false
+	b.clickCallback()
+}
 func (b *Button) SetAspect(aspect float32) {
 	b.aspect = aspect
 }
@@ -570,6 +866,582 @@ func NewButton(sizeFrame, sizeSurface mgl32.Vec2, defaultCol, hoverCol []mgl32.V
 	return btn
 }
 
+// Focusable is implemented by the UI items that could get the keyboard focus.
+type Focusable interface {
+	interfaces.Model
+	SetFocused(bool)
+	FocusPosition() mgl32.Vec3
+}
+
+// FocusManager stores the focusable items of the current state in the traversal
+// order and the index of the focused one. The order follows the position on the
+// form: top to bottom, then left to right.
+type FocusManager struct {
+	items   []Focusable
+	current int // -1 means that nothing is focused.
+}
+
+// NewFocusManager returns a focus manager without items.
+func NewFocusManager() *FocusManager {
+	return &FocusManager{
+		items:   []Focusable{},
+		current: -1,
+	}
+}
+
+// SetItems replaces the items with the focusable ones from the given models.
+// If keepIndex is true and the manager has focused item, the item with the same
+// index is focused after the replace, otherwise the focus is removed.
+func (fm *FocusManager) SetItems(models []interfaces.Model, keepIndex bool) {
+	index := fm.current
+	fm.Focus(nil)
+	fm.items = []Focusable{}
+	for _, m := range models {
+		if item, ok := m.(Focusable); ok {
+			fm.items = append(fm.items, item)
+		}
+	}
+	// The panel is rotated, so that the -X is the up direction, the +Z is the right direction.
+	sort.SliceStable(fm.items, func(i, j int) bool {
+		pi := fm.items[i].FocusPosition()
+		pj := fm.items[j].FocusPosition()
+		if pi.X() != pj.X() {
+			return pi.X() < pj.X()
+		}
+		return pi.Z() < pj.Z()
+	})
+	if keepIndex && index >= 0 && len(fm.items) > 0 {
+		if index >= len(fm.items) {
+			index = len(fm.items) - 1
+		}
+		fm.Focus(fm.items[index])
+	}
+}
+
+// Focused returns the focused item. If nothing is focused, it returns nil.
+func (fm *FocusManager) Focused() Focusable {
+	if fm.current < 0 {
+		return nil
+	}
+	return fm.items[fm.current]
+}
+
+// Focus moves the focus to the given item. If the item is not managed (eg. nil),
+// the focus is removed.
+func (fm *FocusManager) Focus(item Focusable) {
+	if focused := fm.Focused(); focused != nil {
+		focused.SetFocused(false)
+	}
+	fm.current = -1
+	for i, _ := range fm.items {
+		if fm.items[i] == item {
+			fm.current = i
+			item.SetFocused(true)
+			return
+		}
+	}
+}
+
+// Neighbour returns the next (direction: 1) or the previous (direction: -1) item
+// of the focused one. After the last item it starts again from the first one, before
+// the first item it continues with the last one. Without items it returns nil.
+func (fm *FocusManager) Neighbour(direction int) Focusable {
+	if len(fm.items) == 0 {
+		return nil
+	}
+	index := fm.current + direction
+	if fm.current < 0 && direction < 0 {
+		index = len(fm.items) - 1
+	}
+	return fm.items[(index+len(fm.items))%len(fm.items)]
+}
+
+// It is the representation of a read-only text area. It is based on
+// one rectangle, that is the surface of the printed lines. It doesn't have
+// bounding object, so that it doesn't handle the hover events.
+type InfoPanel struct {
+	*model.BaseModel
+	lines      []string
+	color      mgl32.Vec3 // The lines are printed with this color.
+	size       float32    // The size of the text.
+	lineHeight float32
+	frameSize  mgl32.Vec2
+	aspect     float32
+}
+
+// NewInfoPanel returns an info panel instance. The size of the surface mesh,
+// the color of the surface and the text, the size of the text and the distance
+// between the lines has to be set.
+func NewInfoPanel(sizeFrame mgl32.Vec2, surfaceCol []mgl32.Vec3, textCol mgl32.Vec3, scrn interfaces.Mesh, pos mgl32.Vec3, aspect, size, lineHeight float32) *InfoPanel {
+	rect := rectangle.NewExact(sizeFrame.Y()/aspect, sizeFrame.X()/aspect)
+	V, I, _ := rect.ColoredMeshInput(surfaceCol)
+	surface := mesh.NewColorMesh(V, I, surfaceCol, glWrapper)
+	surface.RotateY(-90)
+	surface.SetParent(scrn)
+	surface.SetPosition(mgl32.Vec3{pos.X() / aspect, pos.Y(), pos.Z() / aspect})
+	m := model.New()
+	m.AddMesh(surface)
+	return &InfoPanel{
+		BaseModel:  m,
+		lines:      []string{},
+		color:      textCol,
+		size:       size,
+		lineHeight: lineHeight,
+		frameSize:  sizeFrame,
+		aspect:     aspect,
+	}
+}
+
+// SetLines updates the printed lines.
+func (ip *InfoPanel) SetLines(lines []string) {
+	ip.lines = lines
+}
+
+// GetSurface returns the surface of the lines.
+func (ip *InfoPanel) GetSurface() interfaces.Mesh {
+	msh, _ := ip.GetMeshByIndex(0)
+	return msh
+}
+
+// Print prints the lines to the surface with the given charset, starting from the top left corner.
+func (ip *InfoPanel) Print(cs *model.Charset, wrapper interfaces.GLWrapper) {
+	left := -ip.frameSize.Y()/2/ip.aspect + ip.lineHeight/2/ip.aspect
+	top := ip.frameSize.X() / 2 / ip.aspect
+	for i, line := range ip.lines {
+		cs.PrintTo(line, left, top-float32(i+1)*ip.lineHeight/ip.aspect, -0.01, ip.size/ip.aspect, wrapper, ip.GetSurface(), []mgl32.Vec3{ip.color})
+	}
+}
+
+// Describable is implemented by the UI items that have description. The
+// form items of the engine are also implementing it.
+type Describable interface {
+	GetDescription() string
+}
+
+// Tooltip stores the state of the description box. The box is displayed,
+// if the mouse stays over the same item for the TooltipDelay.
+type Tooltip struct {
+	target    interfaces.Model
+	hoverTime float64
+	panel     *InfoPanel // It is nil, if the tooltip is hidden.
+}
+
+// InspectorNode is one row of the scene graph tree. The kind of the node
+// is "shader", "model" or "mesh". The shader is set for every kind, the
+// model is set for the model and mesh nodes, the mesh only for the mesh nodes.
+type InspectorNode struct {
+	kind     string
+	shader   interfaces.Shader
+	model    interfaces.Model
+	mesh     interfaces.Mesh
+	depth    int
+	label    string
+	hasChild bool
+}
+
+// Key returns the object that is represented by the node. It is used for
+// storing the expanded state of the node.
+func (n *InspectorNode) Key() interface{} {
+	switch n.kind {
+	case "shader":
+		return n.shader
+	case "model":
+		return n.model
+	}
+	return n.mesh
+}
+
+// SceneInspector maintains the shader -> model -> mesh hierarchy of a screen.
+// The screen doesn't provide access to its shaders and models, so that the
+// inspector has to be notified about every change. It also stores the state
+// of the tree (expanded nodes, current page) and the visibility of the nodes.
+type SceneInspector struct {
+	shaders  []interfaces.Shader
+	models   map[interfaces.Shader][]interfaces.Model
+	expanded map[interface{}]bool
+	page     int
+	// hidden models are detached from the screen, hidden meshes are scaled
+	// to zero. The original scale of the hidden meshes is stored here.
+	hiddenModels map[interfaces.Model]bool
+	hiddenMeshes map[interfaces.Mesh]mgl32.Vec3
+	// The model interface doesn't provide the rotation angles, so that they are
+	// tracked from the moment when the model is rotated in the inspector.
+	modelAngles map[interfaces.Model]mgl32.Vec3
+}
+
+// NewSceneInspector returns an inspector without any shader.
+func NewSceneInspector() *SceneInspector {
+	return &SceneInspector{
+		shaders:      []interfaces.Shader{},
+		models:       make(map[interfaces.Shader][]interfaces.Model),
+		expanded:     make(map[interface{}]bool),
+		page:         0,
+		hiddenModels: make(map[interfaces.Model]bool),
+		hiddenMeshes: make(map[interfaces.Mesh]mgl32.Vec3),
+		modelAngles:  make(map[interfaces.Model]mgl32.Vec3),
+	}
+}
+
+// AddShader registers the shader.
+func (si *SceneInspector) AddShader(sh interfaces.Shader) {
+	if _, ok := si.models[sh]; !ok {
+		si.shaders = append(si.shaders, sh)
+	}
+	si.models[sh] = []interfaces.Model{}
+}
+
+// AddModelToShader registers the model under the shader.
+func (si *SceneInspector) AddModelToShader(m interfaces.Model, sh interfaces.Shader) {
+	si.models[sh] = append(si.models[sh], m)
+}
+
+// RemoveModelFromShader removes the model from the shader.
+func (si *SceneInspector) RemoveModelFromShader(m interfaces.Model, sh interfaces.Shader) {
+	for index, val := range si.models[sh] {
+		if val == m {
+			si.models[sh] = append(si.models[sh][:index], si.models[sh][index+1:]...)
+			return
+		}
+	}
+}
+
+// Toggle changes the expanded state of the node.
+func (si *SceneInspector) Toggle(node *InspectorNode) {
+	si.expanded[node.Key()] = !si.expanded[node.Key()]
+}
+
+// IsExpanded returns true if the node is expanded.
+func (si *SceneInspector) IsExpanded(node *InspectorNode) bool {
+	return si.expanded[node.Key()]
+}
+
+// IsHidden returns true, if the node is hidden. A shader node is hidden if
+// every model of the shader is hidden.
+func (si *SceneInspector) IsHidden(node *InspectorNode) bool {
+	switch node.kind {
+	case "shader":
+		if len(si.models[node.shader]) == 0 {
+			return false
+		}
+		for _, m := range si.models[node.shader] {
+			if !si.hiddenModels[m] {
+				return false
+			}
+		}
+		return true
+	case "model":
+		return si.hiddenModels[node.model]
+	}
+	_, ok := si.hiddenMeshes[node.mesh]
+	return ok
+}
+
+// Nodes returns the visible rows of the tree. The shaders that are
+// accepted by the skip function are not listed.
+func (si *SceneInspector) Nodes(skip func(interfaces.Shader) bool) []*InspectorNode {
+	var nodes []*InspectorNode
+	for _, sh := range si.shaders {
+		if skip(sh) {
+			continue
+		}
+		shaderNode := &InspectorNode{kind: "shader", shader: sh, depth: 0, label: fmt.Sprintf("Shader %d", sh.GetId()), hasChild: len(si.models[sh]) > 0}
+		nodes = append(nodes, shaderNode)
+		if !si.IsExpanded(shaderNode) {
+			continue
+		}
+		for modelIndex, m := range si.models[sh] {
+			meshes := modelMeshes(m)
+			modelNode := &InspectorNode{kind: "model", shader: sh, model: m, depth: 1, label: fmt.Sprintf("Model %d %s", modelIndex, typeName(m)), hasChild: len(meshes) > 0}
+			nodes = append(nodes, modelNode)
+			if !si.IsExpanded(modelNode) {
+				continue
+			}
+			for _, msh := range rootMeshes(meshes) {
+				nodes = append(nodes, si.meshNodes(sh, m, msh, meshes, 2)...)
+			}
+		}
+	}
+	return nodes
+}
+
+// meshNodes returns the node of the given mesh and the nodes of its
+// children if the mesh node is expanded.
+func (si *SceneInspector) meshNodes(sh interfaces.Shader, m interfaces.Model, msh interfaces.Mesh, meshes []interfaces.Mesh, depth int) []*InspectorNode {
+	children := childMeshes(msh, meshes)
+	meshIndex := 0
+	for index, _ := range meshes {
+		if meshes[index] == msh {
+			meshIndex = index
+		}
+	}
+	node := &InspectorNode{kind: "mesh", shader: sh, model: m, mesh: msh, depth: depth, label: fmt.Sprintf("Mesh %d %s", meshIndex, typeName(msh)), hasChild: len(children) > 0}
+	nodes := []*InspectorNode{node}
+	if !si.IsExpanded(node) {
+		return nodes
+	}
+	for _, child := range children {
+		nodes = append(nodes, si.meshNodes(sh, m, child, meshes, depth+1)...)
+	}
+	return nodes
+}
+
+// typeName returns the type of the given item without the package path.
+func typeName(item interface{}) string {
+	name := fmt.Sprintf("%T", item)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// modelMeshes returns the meshes of the model. The model interface doesn't
+// provide the meshes, so that we have to use the GetMeshByIndex function.
+func modelMeshes(m interfaces.Model) []interfaces.Mesh {
+	var meshes []interfaces.Mesh
+	indexable, ok := m.(interface {
+		GetMeshByIndex(int) (interfaces.Mesh, error)
+	})
+	if !ok {
+		return meshes
+	}
+	for i := 0; ; i++ {
+		msh, err := indexable.GetMeshByIndex(i)
+		if err != nil {
+			break
+		}
+		meshes = append(meshes, msh)
+	}
+	return meshes
+}
+
+// rootMeshes returns the meshes that don't have parent in the given list.
+func rootMeshes(meshes []interfaces.Mesh) []interfaces.Mesh {
+	var roots []interfaces.Mesh
+	for _, msh := range meshes {
+		if msh.IsParentMesh() || !containsMesh(meshes, msh.GetParent()) {
+			roots = append(roots, msh)
+		}
+	}
+	return roots
+}
+
+// childMeshes returns the meshes from the list that have the given parent.
+func childMeshes(parent interfaces.Mesh, meshes []interfaces.Mesh) []interfaces.Mesh {
+	var children []interfaces.Mesh
+	for _, msh := range meshes {
+		if !msh.IsParentMesh() && msh.GetParent() == parent {
+			children = append(children, msh)
+		}
+	}
+	return children
+}
+func containsMesh(meshes []interfaces.Mesh, msh interfaces.Mesh) bool {
+	for index, _ := range meshes {
+		if meshes[index] == msh {
+			return true
+		}
+	}
+	return false
+}
+
+// meshScale returns the scale of the mesh. The mesh interface provides
+// only the scale transformation that contains the scale of the parents,
+// so that it has to be divided with the scale of the parent.
+func meshScale(msh interfaces.Mesh) mgl32.Vec3 {
+	tr := msh.ScaleTransformation()
+	scale := mgl32.Vec3{tr[0], tr[5], tr[10]}
+	if msh.IsParentMesh() {
+		return scale
+	}
+	parentTr := msh.GetParent().ScaleTransformation()
+	for i := 0; i < 3; i++ {
+		if parentTr[i*5] != 0 {
+			scale[i] = scale[i] / parentTr[i*5]
+		}
+	}
+	return scale
+}
+
+// setMeshScale updates the scale of the mesh, if it is supported by the mesh.
+func setMeshScale(msh interfaces.Mesh, scale mgl32.Vec3) {
+	if scalable, ok := msh.(interface{ SetScale(mgl32.Vec3) }); ok {
+		scalable.SetScale(scale)
+	}
+}
+
+// meshAngles returns the rotation of the mesh as x, y, z angles.
+func meshAngles(msh interfaces.Mesh) mgl32.Vec3 {
+	pitch, yaw, roll := msh.GetAngles()
+	return mgl32.Vec3{pitch, yaw, roll}
+}
+
+// setMeshAngles rotates the mesh to the given x, y, z angles.
+func setMeshAngles(msh interfaces.Mesh, angles mgl32.Vec3) {
+	current := meshAngles(msh)
+	msh.RotateX(angles.X() - current.X())
+	msh.RotateY(angles.Y() - current.Y())
+	msh.RotateZ(angles.Z() - current.Z())
+}
+
+// meshMaterial returns the material of the mesh and true. If the mesh doesn't have
+// material, it returns nil, false.
+func meshMaterial(msh interfaces.Mesh) (*material.Material, bool) {
+	switch msh.(type) {
+	case *mesh.MaterialMesh:
+		return msh.(*mesh.MaterialMesh).Material, true
+	case *mesh.TexturedMaterialMesh:
+		return msh.(*mesh.TexturedMaterialMesh).Material, true
+	}
+	return nil, false
+}
+
+// setMeshMaterial updates the material of the mesh, if the mesh has material.
+func setMeshMaterial(msh interfaces.Mesh, mat *material.Material) {
+	switch msh.(type) {
+	case *mesh.MaterialMesh:
+		msh.(*mesh.MaterialMesh).Material = mat
+		break
+	case *mesh.TexturedMaterialMesh:
+		msh.(*mesh.TexturedMaterialMesh).Material = mat
+		break
+	}
+}
+
+// NodePosition returns the position of the node. In case of model node, it
+// is the position of its first root mesh.
+func (si *SceneInspector) NodePosition(node *InspectorNode) mgl32.Vec3 {
+	switch node.kind {
+	case "model":
+		roots := rootMeshes(modelMeshes(node.model))
+		if len(roots) > 0 {
+			return roots[0].GetPosition()
+		}
+		return mgl32.Vec3{}
+	case "mesh":
+		return node.mesh.GetPosition()
+	}
+	return mgl32.Vec3{}
+}
+
+// SetNodePosition moves the node to the given position. In case of model node,
+// every root mesh is moved with the same vector.
+func (si *SceneInspector) SetNodePosition(node *InspectorNode, pos mgl32.Vec3) {
+	switch node.kind {
+	case "model":
+		delta := pos.Sub(si.NodePosition(node))
+		for _, msh := range rootMeshes(modelMeshes(node.model)) {
+			msh.SetPosition(msh.GetPosition().Add(delta))
+		}
+		break
+	case "mesh":
+		node.mesh.SetPosition(pos)
+		break
+	}
+}
+
+// NodeAngles returns the rotation angles of the node.
+func (si *SceneInspector) NodeAngles(node *InspectorNode) mgl32.Vec3 {
+	switch node.kind {
+	case "model":
+		return si.modelAngles[node.model]
+	case "mesh":
+		return meshAngles(node.mesh)
+	}
+	return mgl32.Vec3{}
+}
+
+// SetNodeAngles rotates the node to the given angles. In case of model node,
+// the model rotation functions are used.
+func (si *SceneInspector) SetNodeAngles(node *InspectorNode, angles mgl32.Vec3) {
+	switch node.kind {
+	case "model":
+		delta := angles.Sub(si.modelAngles[node.model])
+		node.model.RotateX(delta.X())
+		node.model.RotateY(delta.Y())
+		if rotatable, ok := node.model.(interface{ RotateZ(float32) }); ok {
+			rotatable.RotateZ(delta.Z())
+		}
+		si.modelAngles[node.model] = angles
+		break
+	case "mesh":
+		setMeshAngles(node.mesh, angles)
+		break
+	}
+}
+
+// NodeScale returns the scale of the node. In case of model node, it
+// is the scale of its first root mesh.
+func (si *SceneInspector) NodeScale(node *InspectorNode) mgl32.Vec3 {
+	switch node.kind {
+	case "model":
+		roots := rootMeshes(modelMeshes(node.model))
+		if len(roots) > 0 {
+			return meshScale(roots[0])
+		}
+		return mgl32.Vec3{1, 1, 1}
+	case "mesh":
+		if scale, ok := si.hiddenMeshes[node.mesh]; ok {
+			return scale
+		}
+		return meshScale(node.mesh)
+	}
+	return mgl32.Vec3{1, 1, 1}
+}
+
+// SetNodeScale updates the scale of the node. In case of model node, it is
+// applied to every root mesh. The scale of the hidden meshes is stored, and
+// applied when the mesh becomes visible.
+func (si *SceneInspector) SetNodeScale(node *InspectorNode, scale mgl32.Vec3) {
+	switch node.kind {
+	case "model":
+		for _, msh := range rootMeshes(modelMeshes(node.model)) {
+			if _, ok := si.hiddenMeshes[msh]; ok {
+				si.hiddenMeshes[msh] = scale
+				continue
+			}
+			setMeshScale(msh, scale)
+		}
+		break
+	case "mesh":
+		if _, ok := si.hiddenMeshes[node.mesh]; ok {
+			si.hiddenMeshes[node.mesh] = scale
+			return
+		}
+		setMeshScale(node.mesh, scale)
+		break
+	}
+}
+
+// SetNodeBoundingObject replaces the bounding object of the mesh node. The given
+// params are in the transformed (scaled, rotated) space, like the params returned by
+// the GetBoundingObject function, so that the transformations are reverted here.
+func (si *SceneInspector) SetNodeBoundingObject(node *InspectorNode, params map[string]float32) {
+	if node.kind != "mesh" || !node.mesh.IsBoundingObjectSet() {
+		return
+	}
+	settable, ok := node.mesh.(interface {
+		SetBoundingObject(*boundingobject.BoundingObject)
+	})
+	if !ok {
+		return
+	}
+	scaleTr := node.mesh.ScaleTransformation()
+	if scaleTr.Det() == 0 {
+		fmt.Println("The mesh is scaled to zero. Skipping bounding object update.")
+		return
+	}
+	boType := node.mesh.GetBoundingObject().Type()
+	rawParams := make(map[string]float32)
+	if boType == "Sphere" {
+		rawParams["radius"] = params["radius"] / transformations.Float32Abs(scaleTr[0])
+	} else if boType == "AABB" {
+		sideLengths := mgl32.Vec3{params["width"], params["height"], params["length"]}
+		unscaled := mgl32.TransformCoordinate(sideLengths, scaleTr.Inv())
+		unrotated := mgl32.TransformCoordinate(unscaled, node.mesh.RotationTransformation().Inv())
+		rawParams["width"] = transformations.Float32Abs(unrotated.X())
+		rawParams["height"] = transformations.Float32Abs(unrotated.Y())
+		rawParams["length"] = transformations.Float32Abs(unrotated.Z())
+	}
+	settable.SetBoundingObject(boundingobject.New(boType, rawParams))
+}
+
 // It represents our editor.
 type EditorScreen struct {
 	*screen.Screen
@@ -582,9 +1454,31 @@ type EditorScreen struct {
 	// text is printed to the top of the screen mesh.
 	// Material[Amibent|Diffuse|Specular|Shininess]Form state - the back button is printed,
 	// the Material > Color comp. text is printed to the top of the screen mesh.
+	// Inspector state - the scene graph tree is shown. InspectorNode state - the
+	// transform, material, bounding object and visibility buttons of the selected node
+	// are shown. Inspector[Position|Rotation|Scale|Bounding]Form state - the sliders
+	// of the selected node are shown.
 	state string
 	// the sphere model for easy access.
 	sphereModel *model.BaseModel
+	// The material forms are editing the material of this mesh.
+	materialMesh interfaces.Mesh
+	// The back button of the material state navigates to this state.
+	materialBackState string
+	// the menu panel model, that is the first item of every state.
+	menuPanel  *model.BaseModel
+	fontShader *shader.Shader
+	// The inspector stores the scene graph of the screen.
+	inspector    *SceneInspector
+	selectedNode *InspectorNode
+	// The focused item gets the keyboard events. The dragged slider follows
+	// the mouse until the left button is released.
+	focus          *FocusManager
+	draggedSlider  *SliderInput
+	mouseWasDown   bool
+	keyRepeatTimer float64
+	// The description of the hovered item is displayed in the tooltip.
+	tooltip *Tooltip
 }
 
 func NewEditorScreen() *EditorScreen {
@@ -593,11 +1487,23 @@ func NewEditorScreen() *EditorScreen {
 	wX, wY := app.GetWindow().GetSize()
 	scrn.SetWindowSize(float32(wX), float32(wY))
 	scrn.SetupCamera(CreateCamera(), CameraMovementOptions())
+	es := &EditorScreen{
+		Screen:            scrn,
+		menuShader:        shader.NewShader(baseDir()+"/shaders/vertexshader.vert", baseDir()+"/shaders/fragmentshader.frag", glWrapper),
+		charset:           nil,
+		state:             "Default",
+		materialBackState: "Default",
+		inspector:         NewSceneInspector(),
+		focus:             NewFocusManager(),
+		tooltip:           &Tooltip{},
+	}
 	shaderProgram := shader.NewMaterialShader(glWrapper)
-	scrn.AddShader(shaderProgram)
+	es.AddShader(shaderProgram)
 	ModelSphere := model.New()
 	ModelSphere.AddMesh(CreateJadeSphere())
-	scrn.AddModelToShader(ModelSphere, shaderProgram)
+	es.AddModelToShader(ModelSphere, shaderProgram)
+	es.sphereModel = ModelSphere
+	es.materialMesh, _ = ModelSphere.GetMeshByIndex(0)
 	DirectionalLightSource := light.NewDirectionalLight([4]mgl32.Vec3{
 		mgl32.Vec3{0.0, 1.0, 0.0},
 		mgl32.Vec3{1.0, 1.0, 1.0},
@@ -614,13 +1520,7 @@ func NewEditorScreen() *EditorScreen {
 	screenLabelMesh.SetParent(screenMesh)
 	ModelMenu.AddMesh(screenMesh)
 	ModelMenu.AddMesh(screenLabelMesh)
-	es := &EditorScreen{
-		Screen:      scrn,
-		menuShader:  shader.NewShader(baseDir()+"/shaders/vertexshader.vert", baseDir()+"/shaders/fragmentshader.frag", glWrapper),
-		charset:     nil,
-		state:       "Default",
-		sphereModel: ModelSphere,
-	}
+	es.menuPanel = ModelMenu
 	MenuModels["Default"] = append(MenuModels["Default"], ModelMenu)
 	MenuModels["Material"] = append(MenuModels["Material"], ModelMenu)
 	MenuModels["MaterialAmbientForm"] = append(MenuModels["MaterialAmbientForm"], ModelMenu)
@@ -634,8 +1534,20 @@ func NewEditorScreen() *EditorScreen {
 		panic(err)
 	}
 	btn.SetLabel(NewLabel("Material", mgl32.Vec3{0, 0, 0.05}, mgl32.Vec3{0, 0, -FormItemsDistanceFromScreen}, 0.0005, s))
-	btn.clickCallback = es.SetStateMaterial
+	btn.SetDescription(ButtonDescriptions["Material"])
+	btn.clickCallback = es.SetStateSphereMaterial
 	MenuModels["Default"] = append(MenuModels["Default"], btn)
+	// Inspector button Default State
+	btnInspector := NewButton(Buttonframe, Buttonsurface, buttonDefaultColor, buttonHoverColor, screenMesh, mgl32.Vec3{0.9, -FormItemsDistanceFromScreen, -0.15}, aspectRatio)
+	s, err = btnInspector.GetMeshByIndex(1)
+	if err != nil {
+		fmt.Println("Something terrible happened on btn branch.")
+		panic(err)
+	}
+	btnInspector.SetLabel(NewLabel("Inspector", mgl32.Vec3{0, 0, 0.05}, mgl32.Vec3{0, 0, -FormItemsDistanceFromScreen}, 0.0005, s))
+	btnInspector.SetDescription(ButtonDescriptions["Inspector"])
+	btnInspector.clickCallback = es.SetStateInspector
+	MenuModels["Default"] = append(MenuModels["Default"], btnInspector)
 	// Ambient button Material State
 	btnAmbient := NewButton(Buttonframe, Buttonsurface, buttonDefaultColor, buttonHoverColor, screenMesh, mgl32.Vec3{0.9, -FormItemsDistanceFromScreen, -0.35}, aspectRatio)
 	s, err = btnAmbient.GetMeshByIndex(1)
@@ -644,6 +1556,7 @@ func NewEditorScreen() *EditorScreen {
 		panic(err)
 	}
 	btnAmbient.SetLabel(NewLabel("Ambient", mgl32.Vec3{0, 0, 0.05}, mgl32.Vec3{0, 0, -FormItemsDistanceFromScreen}, 0.0005, s))
+	btnAmbient.SetDescription(ButtonDescriptions["Ambient"])
 	btnAmbient.clickCallback = es.SetStateMaterialAmbientForm
 	MenuModels["Material"] = append(MenuModels["Material"], btnAmbient)
 	// Diffuse button Material State
@@ -654,6 +1567,7 @@ func NewEditorScreen() *EditorScreen {
 		panic(err)
 	}
 	btnDiffuse.SetLabel(NewLabel("Diffuse", mgl32.Vec3{0, 0, 0.05}, mgl32.Vec3{0, 0, -FormItemsDistanceFromScreen}, 0.0005, s))
+	btnDiffuse.SetDescription(ButtonDescriptions["Diffuse"])
 	btnDiffuse.clickCallback = es.SetStateMaterialDiffuseForm
 	MenuModels["Material"] = append(MenuModels["Material"], btnDiffuse)
 	// Specular button Material State
@@ -664,6 +1578,7 @@ func NewEditorScreen() *EditorScreen {
 		panic(err)
 	}
 	btnSpecular.SetLabel(NewLabel("Specular", mgl32.Vec3{0, 0, 0.05}, mgl32.Vec3{0, 0, -FormItemsDistanceFromScreen}, 0.0005, s))
+	btnSpecular.SetDescription(ButtonDescriptions["Specular"])
 	btnSpecular.clickCallback = es.SetStateMaterialSpecularForm
 	MenuModels["Material"] = append(MenuModels["Material"], btnSpecular)
 	// Shininess button Material State
@@ -674,6 +1589,7 @@ func NewEditorScreen() *EditorScreen {
 		panic(err)
 	}
 	btnShininess.SetLabel(NewLabel("Shininess", mgl32.Vec3{0, 0, 0.05}, mgl32.Vec3{0, 0, -FormItemsDistanceFromScreen}, 0.0005, s))
+	btnShininess.SetDescription(ButtonDescriptions["Shininess"])
 	btnShininess.clickCallback = es.SetStateMaterialShininessForm
 	MenuModels["Material"] = append(MenuModels["Material"], btnShininess)
 	// back button Material State
@@ -684,7 +1600,8 @@ func NewEditorScreen() *EditorScreen {
 		panic(err)
 	}
 	btnBack.SetLabel(NewLabel("Back", mgl32.Vec3{0, 0, 0.05}, mgl32.Vec3{0, 0, -FormItemsDistanceFromScreen}, 0.0005, s))
-	btnBack.clickCallback = es.SetStateDefault
+	btnBack.SetDescription(ButtonDescriptions["Back"])
+	btnBack.clickCallback = es.leaveMaterial
 	MenuModels["Material"] = append(MenuModels["Material"], btnBack)
 	// back button To Material State from color forms.
 	btnBackForm := NewButton(Buttonframe, Buttonsurface, buttonDefaultColor, buttonHoverColor, screenMesh, mgl32.Vec3{0.9, -FormItemsDistanceFromScreen, 0.35}, aspectRatio)
@@ -694,6 +1611,7 @@ func NewEditorScreen() *EditorScreen {
 		panic(err)
 	}
 	btnBackForm.SetLabel(NewLabel("Back", mgl32.Vec3{0, 0, 0.05}, mgl32.Vec3{0, 0, -FormItemsDistanceFromScreen}, 0.0005, s))
+	btnBackForm.SetDescription(ButtonDescriptions["Back"])
 	btnBackForm.clickCallback = es.SetStateMaterial
 	MenuModels["MaterialAmbientForm"] = append(MenuModels["MaterialAmbientForm"], btnBackForm)
 	MenuModels["MaterialDiffuseForm"] = append(MenuModels["MaterialDiffuseForm"], btnBackForm)
@@ -706,7 +1624,9 @@ func NewEditorScreen() *EditorScreen {
 		fmt.Println("Something terrible happened on si branch.")
 		panic(err)
 	}
+	siRed.SetStep(0.01)
 	siRed.SetLabel(NewLabel("Red", mgl32.Vec3{0, 0, 0.05}, mgl32.Vec3{0, TextInputField.X() / aspectRatio / 2, -0.01}, 0.0005, s))
+	siRed.SetDescription("The red component of the color.")
 	MenuModels["MaterialAmbientForm"] = append(MenuModels["MaterialAmbientForm"], siRed)
 	MenuModels["MaterialDiffuseForm"] = append(MenuModels["MaterialDiffuseForm"], siRed)
 	MenuModels["MaterialSpecularForm"] = append(MenuModels["MaterialSpecularForm"], siRed)
@@ -716,7 +1636,9 @@ func NewEditorScreen() *EditorScreen {
 		fmt.Println("Something terrible happened on si branch.")
 		panic(err)
 	}
+	siGreen.SetStep(0.01)
 	siGreen.SetLabel(NewLabel("Green", mgl32.Vec3{0, 0, 0.05}, mgl32.Vec3{0, TextInputField.X() / aspectRatio / 2, -0.01}, 0.0005, s))
+	siGreen.SetDescription("The green component of the color.")
 	MenuModels["MaterialAmbientForm"] = append(MenuModels["MaterialAmbientForm"], siGreen)
 	MenuModels["MaterialDiffuseForm"] = append(MenuModels["MaterialDiffuseForm"], siGreen)
 	MenuModels["MaterialSpecularForm"] = append(MenuModels["MaterialSpecularForm"], siGreen)
@@ -726,7 +1648,9 @@ func NewEditorScreen() *EditorScreen {
 		fmt.Println("Something terrible happened on si branch.")
 		panic(err)
 	}
+	siBlue.SetStep(0.01)
 	siBlue.SetLabel(NewLabel("Blue", mgl32.Vec3{0, 0, 0.05}, mgl32.Vec3{0, TextInputField.X() / aspectRatio / 2, -0.01}, 0.0005, s))
+	siBlue.SetDescription("The blue component of the color.")
 	MenuModels["MaterialAmbientForm"] = append(MenuModels["MaterialAmbientForm"], siBlue)
 	MenuModels["MaterialDiffuseForm"] = append(MenuModels["MaterialDiffuseForm"], siBlue)
 	MenuModels["MaterialSpecularForm"] = append(MenuModels["MaterialSpecularForm"], siBlue)
@@ -737,16 +1661,20 @@ func NewEditorScreen() *EditorScreen {
 		fmt.Println("Something terrible happened on si branch.")
 		panic(err)
 	}
+	siShininess.SetStep(1)
+	siShininess.SetLogScale(true)
 	siShininess.SetLabel(NewLabel("Shininess", mgl32.Vec3{0, 0, 0.05}, mgl32.Vec3{0, TextInputField.X() / aspectRatio / 2, -0.01}, 0.0005, s))
+	siShininess.SetDescription("The shininess of the material. The slider uses logarithmic scale, the small values are more precise.")
 	MenuModels["MaterialShininessForm"] = append(MenuModels["MaterialShininessForm"], siShininess)
 	es.menuModels = MenuModels
 	es.AddShader(es.menuShader)
 	es.Setup(es.setupApp)
 	es.defaultCharset()
 	// font shader
-	fontShader := shader.NewShader(baseDir()+"/shaders/font.vert", baseDir()+"/shaders/font.frag", es.GetWrapper())
-	es.AddShader(fontShader)
-	es.AddModelToShader(es.charset, fontShader)
+	es.fontShader = shader.NewShader(baseDir()+"/shaders/font.vert", baseDir()+"/shaders/font.frag", es.GetWrapper())
+	es.AddShader(es.fontShader)
+	es.AddModelToShader(es.charset, es.fontShader)
+	es.focus.SetItems(es.menuModels[es.state], false)
 	return es
 }
 
@@ -762,60 +1690,83 @@ func (scrn *EditorScreen) SetStateDefault() {
 func (scrn *EditorScreen) SetStateMaterial() {
 	scrn.setState("Material")
 }
-func (scrn *EditorScreen) SetStateMaterialAmbientForm() {
+
+// SetStateSphereMaterial opens the material state for the sphere.
+func (scrn *EditorScreen) SetStateSphereMaterial() {
 	msh, err := scrn.sphereModel.GetMeshByIndex(0)
 	if err != nil {
 		fmt.Println("Mesh is missing. Skipping update.")
 		return
 	}
-	colorComponent := msh.(*mesh.MaterialMesh).Material.GetAmbient()
+	scrn.materialMesh = msh
+	scrn.materialBackState = "Default"
+	scrn.SetStateMaterial()
+}
+func (scrn *EditorScreen) leaveMaterial() {
+	if scrn.materialBackState == "InspectorNode" {
+		// The details of the node has to be refreshed.
+		scrn.SetStateInspectorNode(scrn.selectedNode)
+		return
+	}
+	scrn.setState(scrn.materialBackState)
+}
+func (scrn *EditorScreen) SetStateMaterialAmbientForm() {
+	mat, ok := meshMaterial(scrn.materialMesh)
+	if !ok {
+		fmt.Println("Material is missing. Skipping update.")
+		return
+	}
+	colorComponent := mat.GetAmbient()
 	scrn.menuModels["MaterialAmbientForm"][2].(*SliderInput).SetCurrentValue(colorComponent.X())
 	scrn.menuModels["MaterialAmbientForm"][3].(*SliderInput).SetCurrentValue(colorComponent.Y())
 	scrn.menuModels["MaterialAmbientForm"][4].(*SliderInput).SetCurrentValue(colorComponent.Z())
 	scrn.setState("MaterialAmbientForm")
 }
 func (scrn *EditorScreen) SetStateMaterialDiffuseForm() {
-	msh, err := scrn.sphereModel.GetMeshByIndex(0)
-	if err != nil {
-		fmt.Println("Mesh is missing. Skipping update.")
+	mat, ok := meshMaterial(scrn.materialMesh)
+	if !ok {
+		fmt.Println("Material is missing. Skipping update.")
 		return
 	}
-	colorComponent := msh.(*mesh.MaterialMesh).Material.GetDiffuse()
+	colorComponent := mat.GetDiffuse()
 	scrn.menuModels["MaterialDiffuseForm"][2].(*SliderInput).SetCurrentValue(colorComponent.X())
 	scrn.menuModels["MaterialDiffuseForm"][3].(*SliderInput).SetCurrentValue(colorComponent.Y())
 	scrn.menuModels["MaterialDiffuseForm"][4].(*SliderInput).SetCurrentValue(colorComponent.Z())
 	scrn.setState("MaterialDiffuseForm")
 }
 func (scrn *EditorScreen) SetStateMaterialSpecularForm() {
-	msh, err := scrn.sphereModel.GetMeshByIndex(0)
-	if err != nil {
-		fmt.Println("Mesh is missing. Skipping update.")
+	mat, ok := meshMaterial(scrn.materialMesh)
+	if !ok {
+		fmt.Println("Material is missing. Skipping update.")
 		return
 	}
-	colorComponent := msh.(*mesh.MaterialMesh).Material.GetSpecular()
+	colorComponent := mat.GetSpecular()
 	scrn.menuModels["MaterialSpecularForm"][2].(*SliderInput).SetCurrentValue(colorComponent.X())
 	scrn.menuModels["MaterialSpecularForm"][3].(*SliderInput).SetCurrentValue(colorComponent.Y())
 	scrn.menuModels["MaterialSpecularForm"][4].(*SliderInput).SetCurrentValue(colorComponent.Z())
 	scrn.setState("MaterialSpecularForm")
 }
 func (scrn *EditorScreen) SetStateMaterialShininessForm() {
-	msh, err := scrn.sphereModel.GetMeshByIndex(0)
-	if err != nil {
-		fmt.Println("Mesh is missing. Skipping update.")
+	mat, ok := meshMaterial(scrn.materialMesh)
+	if !ok {
+		fmt.Println("Material is missing. Skipping update.")
 		return
 	}
-	colorComponent := msh.(*mesh.MaterialMesh).Material.GetShininess()
+	colorComponent := mat.GetShininess()
 	scrn.menuModels["MaterialShininessForm"][2].(*SliderInput).SetCurrentValue(colorComponent)
 	scrn.setState("MaterialShininessForm")
 }
 func (scrn *EditorScreen) setState(newState string) {
+	scrn.setFocus(nil)
 	scrn.RemoveMenuPanel()
 	scrn.state = newState
 	scrn.AddMenuPanel()
+	scrn.focus.SetItems(scrn.menuModels[scrn.state], false)
 }
 
-// RemoveMenuPanel removes the menu form from the screen.
+// RemoveMenuPanel removes the menu form and the tooltip from the screen.
 func (scrn *EditorScreen) RemoveMenuPanel() {
+	scrn.hideTooltip()
 	for index, _ := range scrn.menuModels[scrn.state] {
 		switch scrn.menuModels[scrn.state][index].(type) {
 		case *Button:
@@ -844,6 +1795,10 @@ func (scrn *EditorScreen) RemoveMenuPanel() {
 				scrn.charset.CleanSurface(msh)
 			}
 			break
+		case *InfoPanel:
+			item := scrn.menuModels[scrn.state][index].(*InfoPanel)
+			scrn.charset.CleanSurface(item.GetSurface())
+			break
 		default:
 			// default case for the menu panel. We have to clean it.
 			msh, err := scrn.menuModels[scrn.state][index].(*model.BaseModel).GetMeshByIndex(1)
@@ -886,6 +1841,10 @@ func (scrn *EditorScreen) MenuItemsDefaultState() {
 			}
 			item.Clear()
 			break
+		case *InfoPanel:
+			item := scrn.menuModels[scrn.state][index].(*InfoPanel)
+			scrn.charset.CleanSurface(item.GetSurface())
+			break
 		}
 	}
 }
@@ -909,6 +1868,19 @@ func (scrn *EditorScreen) Update(dt float64, p interfaces.Pointer, keyStore inte
 	scrn.UpdateWithDistance(dt, mCoords)
 	closestModel, _, dist := scrn.GetClosestModelMeshDistance()
 	scrn.MenuItemsDefaultState()
+	mouseDown := buttonStore.Get(LEFT_MOUSE_BUTTON)
+	// The click is the first frame when the button is pressed.
+	clicked := mouseDown && !scrn.mouseWasDown
+	scrn.mouseWasDown = mouseDown
+	if !mouseDown {
+		scrn.draggedSlider = nil
+	}
+	if scrn.draggedSlider != nil {
+		dX, _ := p.GetDelta()
+		scrn.draggedSlider.MoveSliderWith(float32(dX))
+		scrn.sliderValueChanged()
+	}
+	itemClicked := false
 	switch closestModel.(type) {
 	case (*Button):
 		if dist < CollisionEpsilon {
@@ -917,10 +1889,15 @@ func (scrn *EditorScreen) Update(dt float64, p interfaces.Pointer, keyStore inte
 				scrn.charset.CleanSurface(btn.GetLabelSurface())
 			}
 			btn.Hover()
+			if clicked {
+				itemClicked = true
+				scrn.setFocus(btn)
+			}
 			if buttonStore.Get(LEFT_MOUSE_BUTTON) {
 				btn.clicked = true
 			}
 			if btn.clicked && !buttonStore.Get(LEFT_MOUSE_BUTTON) {
+				btn.clicked = false
 				btn.clickCallback()
 			}
 
@@ -950,11 +1927,16 @@ func (scrn *EditorScreen) Update(dt float64, p interfaces.Pointer, keyStore inte
 				scrn.charset.CleanSurface(msh)
 			}
 			si.Hover()
-			// If the left mouse button is pressed, we have to detect collision against the slider.
-			if buttonStore.Get(LEFT_MOUSE_BUTTON) && si.SliderCollision(mCoords) {
-				dX, _ := p.GetDelta()
-				si.MoveSliderWith(float32(dX))
-				scrn.updateMaterialColorComponent()
+			// The click focuses the slider. If it is on the slider, the dragging starts,
+			// if it is on the value field, the typing starts.
+			if clicked {
+				itemClicked = true
+				scrn.setFocus(si)
+				if si.SliderCollision(mCoords) {
+					scrn.draggedSlider = si
+				} else if si.ValueFieldCollision(mCoords) {
+					si.StartEditing()
+				}
 			}
 		}
 		scrn.releaseButtons()
@@ -962,6 +1944,17 @@ func (scrn *EditorScreen) Update(dt float64, p interfaces.Pointer, keyStore inte
 	default:
 		scrn.releaseButtons()
 	}
+	if clicked && !itemClicked {
+		scrn.setFocus(nil)
+	}
+	var hovered interfaces.Model
+	if dist < CollisionEpsilon {
+		hovered = closestModel
+	}
+	scrn.updateTooltip(dt, hovered, mCoords, mouseDown)
+	if MenuScreenEnabled {
+		scrn.handleFocusKeys(dt, keyStore)
+	}
 	if MenuScreenEnabled {
 		for index, _ := range scrn.menuModels[scrn.state] {
 			switch scrn.menuModels[scrn.state][index].(type) {
@@ -987,12 +1980,15 @@ func (scrn *EditorScreen) Update(dt float64, p interfaces.Pointer, keyStore inte
 					// print the current value
 					msh, err := item.GetMeshByIndex(2)
 					if err == nil {
-						valueText := transformations.Float32ToStringExact(item.sliderCurrent)
+						valueText := item.ValueText()
 						w, _ := scrn.charset.TextContainerSize(valueText, item.GetLabelSize()/item.aspect)
-						scrn.charset.PrintTo(transformations.Float32ToStringExact(item.sliderCurrent), -w/2, 0, pos.Z(), item.GetLabelSize()/item.aspect, scrn.GetWrapper(), msh, []mgl32.Vec3{item.GetLabelColor()})
+						scrn.charset.PrintTo(valueText, -w/2, 0, pos.Z(), item.GetLabelSize()/item.aspect, scrn.GetWrapper(), msh, []mgl32.Vec3{item.GetLabelColor()})
 					}
 				}
 				break
+			case *InfoPanel:
+				scrn.menuModels[scrn.state][index].(*InfoPanel).Print(scrn.charset, scrn.GetWrapper())
+				break
 			default:
 				// default case for the state label.
 				// Print the state label to the top.
@@ -1008,44 +2004,623 @@ func (scrn *EditorScreen) Update(dt float64, p interfaces.Pointer, keyStore inte
 	}
 }
 func (scrn *EditorScreen) updateMaterialColorComponent() {
-	sphereMesh, err := scrn.sphereModel.GetMeshByIndex(0)
-	if err != nil {
-		fmt.Println("Mesh is missing, skip material update.")
+	origMaterial, ok := meshMaterial(scrn.materialMesh)
+	if !ok {
+		fmt.Println("Material is missing, skip material update.")
 		return
 	}
-	origMaterial := sphereMesh.(*mesh.MaterialMesh).Material
 	switch scrn.state {
 	case "MaterialAmbientForm":
 		red := scrn.menuModels[scrn.state][2].(*SliderInput).sliderCurrent
 		green := scrn.menuModels[scrn.state][3].(*SliderInput).sliderCurrent
 		blue := scrn.menuModels[scrn.state][4].(*SliderInput).sliderCurrent
 		newMaterial := material.New(mgl32.Vec3{red, green, blue}, origMaterial.GetDiffuse(), origMaterial.GetSpecular(), origMaterial.GetShininess())
-		sphereMesh.(*mesh.MaterialMesh).Material = newMaterial
+		setMeshMaterial(scrn.materialMesh, newMaterial)
 		break
 	case "MaterialDiffuseForm":
 		red := scrn.menuModels[scrn.state][2].(*SliderInput).sliderCurrent
 		green := scrn.menuModels[scrn.state][3].(*SliderInput).sliderCurrent
 		blue := scrn.menuModels[scrn.state][4].(*SliderInput).sliderCurrent
 		newMaterial := material.New(origMaterial.GetAmbient(), mgl32.Vec3{red, green, blue}, origMaterial.GetSpecular(), origMaterial.GetShininess())
-		sphereMesh.(*mesh.MaterialMesh).Material = newMaterial
+		setMeshMaterial(scrn.materialMesh, newMaterial)
 		break
 	case "MaterialSpecularForm":
 		red := scrn.menuModels[scrn.state][2].(*SliderInput).sliderCurrent
 		green := scrn.menuModels[scrn.state][3].(*SliderInput).sliderCurrent
 		blue := scrn.menuModels[scrn.state][4].(*SliderInput).sliderCurrent
 		newMaterial := material.New(origMaterial.GetAmbient(), origMaterial.GetDiffuse(), mgl32.Vec3{red, green, blue}, origMaterial.GetShininess())
-		sphereMesh.(*mesh.MaterialMesh).Material = newMaterial
+		setMeshMaterial(scrn.materialMesh, newMaterial)
 		break
 	case "MaterialShininessForm":
 		shininess := scrn.menuModels[scrn.state][2].(*SliderInput).sliderCurrent
 		newMaterial := material.New(origMaterial.GetAmbient(), origMaterial.GetDiffuse(), origMaterial.GetSpecular(), shininess)
-		sphereMesh.(*mesh.MaterialMesh).Material = newMaterial
+		setMeshMaterial(scrn.materialMesh, newMaterial)
 		break
 	default:
 		fmt.Println("Irrelevant state, skipping update.")
 		break
 	}
 }
+
+// sliderValueChanged applies the values of the current form to the edited object.
+func (scrn *EditorScreen) sliderValueChanged() {
+	switch scrn.state {
+	case "InspectorPositionForm", "InspectorRotationForm", "InspectorScaleForm", "InspectorBoundingForm":
+		scrn.updateInspectedNode()
+		break
+	default:
+		scrn.updateMaterialColorComponent()
+		break
+	}
+}
+
+// updateTooltip displays the description of the hovered item, if the mouse stays
+// over it for the TooltipDelay. The tooltip is hidden when the mouse leaves the
+// item or the mouse button is pressed.
+func (scrn *EditorScreen) updateTooltip(dt float64, hovered interfaces.Model, cursor mgl32.Vec3, mouseDown bool) {
+	item, ok := hovered.(Describable)
+	if !ok || item.GetDescription() == "" || mouseDown {
+		scrn.hideTooltip()
+		scrn.tooltip.target = nil
+		return
+	}
+	if scrn.tooltip.target != hovered {
+		scrn.hideTooltip()
+		scrn.tooltip.target = hovered
+		scrn.tooltip.hoverTime = 0
+		return
+	}
+	scrn.tooltip.hoverTime += dt
+	if scrn.tooltip.panel == nil && scrn.tooltip.hoverTime >= TooltipDelay {
+		scrn.showTooltip(item.GetDescription(), cursor)
+	}
+}
+
+// showTooltip builds the tooltip panel next to the cursor. The description is
+// wrapped to the TooltipMaxWidth. The panel is displayed under or over the cursor
+// (on the side of the center of the menu panel) and it is kept inside the menu panel.
+func (scrn *EditorScreen) showTooltip(description string, cursor mgl32.Vec3) {
+	aspectRatio := scrn.GetAspectRatio()
+	textScale := TooltipLabelSize / aspectRatio
+	lines := tooltip.WrapTextToLines(scrn.charset, description, textScale, TooltipMaxWidth/aspectRatio)
+	textWidth := float32(0.0)
+	for _, line := range lines {
+		if w := scrn.charset.TextWidth(line, textScale); w > textWidth {
+			textWidth = w
+		}
+	}
+	// The x component of the size is the vertical, the y component is the horizontal length.
+	// The half line height is the padding around the text.
+	size := mgl32.Vec2{(float32(len(lines)) + 0.5) * TooltipLineHeight, textWidth*aspectRatio + TooltipLineHeight}
+	// The cursor position on the menu panel.
+	x := cursor.X() * aspectRatio
+	z := cursor.Z()*aspectRatio - MenuPanelWidth/2
+	if x < 0 {
+		x += TooltipCursorOffset + size.X()/2
+	} else {
+		x -= TooltipCursorOffset + size.X()/2
+	}
+	x = tooltip.Clamp(x, -MenuPanelHeight/2+size.X()/2, MenuPanelHeight/2-size.X()/2)
+	z = tooltip.Clamp(z, -MenuPanelWidth/2+size.Y()/2, MenuPanelWidth/2-size.Y()/2)
+	screenMesh, err := scrn.menuPanel.GetMeshByIndex(0)
+	if err != nil {
+		fmt.Println("Something terrible happened on menu panel branch.")
+		panic(err)
+	}
+	panel := NewInfoPanel(size, TooltipColor, mgl32.Vec3{0, 0, 0.05}, screenMesh, mgl32.Vec3{x, -TooltipDistanceFromScreen, z}, aspectRatio, TooltipLabelSize, TooltipLineHeight)
+	panel.SetLines(lines)
+	scrn.AddModelToShader(panel, scrn.menuShader)
+	panel.Print(scrn.charset, scrn.GetWrapper())
+	scrn.tooltip.panel = panel
+}
+
+// hideTooltip removes the tooltip panel from the screen.
+func (scrn *EditorScreen) hideTooltip() {
+	if scrn.tooltip.panel == nil {
+		return
+	}
+	scrn.charset.CleanSurface(scrn.tooltip.panel.GetSurface())
+	scrn.RemoveModelFromShader(scrn.tooltip.panel, scrn.menuShader)
+	scrn.tooltip.panel = nil
+}
+
+// setFocus moves the keyboard focus to the given item. The nil input removes
+// the focus. If the previous item was a slider in edit mode, the typed value is applied.
+func (scrn *EditorScreen) setFocus(item Focusable) {
+	if si, ok := scrn.focus.Focused().(*SliderInput); ok && Focusable(si) != item && si.IsEditing() {
+		if si.CommitEditing() {
+			scrn.sliderValueChanged()
+		}
+	}
+	scrn.focus.Focus(item)
+}
+
+// handleFocusKeys handles the keyboard events of the focused item. The tab,
+// shift + tab, down, up keys are moving the focus. In case of focused button
+// the enter activates it. In case of focused slider, the left, right arrows are
+// moving it with one step and the enter starts the typing. In edit mode the enter
+// applies the typed value and the backspace deletes the last character. The held
+// keys are repeated with the KeyRepeatDelay.
+func (scrn *EditorScreen) handleFocusKeys(dt float64, keyStore interfaces.RoKeyStore) {
+	scrn.keyRepeatTimer += dt
+	if scrn.keyRepeatTimer < KeyRepeatDelay {
+		return
+	}
+	shift := keyStore.Get(glfw.KeyLeftShift) || keyStore.Get(glfw.KeyRightShift)
+	if (keyStore.Get(glfw.KeyTab) && shift) || keyStore.Get(glfw.KeyUp) {
+		scrn.setFocus(scrn.focus.Neighbour(-1))
+		scrn.keyRepeatTimer = 0
+		return
+	}
+	if keyStore.Get(glfw.KeyTab) || keyStore.Get(glfw.KeyDown) {
+		scrn.setFocus(scrn.focus.Neighbour(1))
+		scrn.keyRepeatTimer = 0
+		return
+	}
+	enter := keyStore.Get(glfw.KeyEnter) || keyStore.Get(glfw.KeyKPEnter)
+	switch scrn.focus.Focused().(type) {
+	case *Button:
+		if enter {
+			scrn.keyRepeatTimer = 0
+			scrn.focus.Focused().(*Button).Activate()
+		}
+		break
+	case *SliderInput:
+		si := scrn.focus.Focused().(*SliderInput)
+		if si.IsEditing() {
+			if enter {
+				if si.CommitEditing() {
+					scrn.sliderValueChanged()
+				}
+				scrn.keyRepeatTimer = 0
+			} else if keyStore.Get(glfw.KeyBackspace) {
+				si.DeleteLastChar()
+				scrn.keyRepeatTimer = 0
+			}
+			break
+		}
+		if enter {
+			si.StartEditing()
+			scrn.keyRepeatTimer = 0
+		} else if keyStore.Get(glfw.KeyRight) {
+			si.Nudge(1)
+			scrn.sliderValueChanged()
+			scrn.keyRepeatTimer = 0
+		} else if keyStore.Get(glfw.KeyLeft) {
+			si.Nudge(-1)
+			scrn.sliderValueChanged()
+			scrn.keyRepeatTimer = 0
+		}
+		break
+	}
+}
+
+// Back navigates to the previous state. In edit mode it leaves the typing without
+// applying the typed value. It returns false if the current state doesn't have previous
+// state, so that the event could be handled by the application.
+func (scrn *EditorScreen) Back() bool {
+	if si, ok := scrn.focus.Focused().(*SliderInput); ok && si.IsEditing() {
+		si.CancelEditing()
+		return true
+	}
+	switch scrn.state {
+	case "Material":
+		scrn.leaveMaterial()
+		break
+	case "MaterialAmbientForm", "MaterialDiffuseForm", "MaterialSpecularForm", "MaterialShininessForm":
+		scrn.SetStateMaterial()
+		break
+	case "Inspector":
+		scrn.SetStateDefault()
+		break
+	case "InspectorNode":
+		scrn.SetStateInspector()
+		break
+	case "InspectorPositionForm", "InspectorRotationForm", "InspectorScaleForm", "InspectorBoundingForm":
+		scrn.SetStateInspectorNode(scrn.selectedNode)
+		break
+	default:
+		return false
+	}
+	return true
+}
+
+// CharCallback passes the typed characters to the focused slider.
+func (scrn *EditorScreen) CharCallback(char rune, w interfaces.GLWrapper) {
+	if si, ok := scrn.focus.Focused().(*SliderInput); ok {
+		si.AppendChar(char)
+	}
+}
+
+// AddShader inserts the shader to the screen and registers it in the inspector.
+func (scrn *EditorScreen) AddShader(sh interfaces.Shader) {
+	scrn.Screen.AddShader(sh)
+	scrn.inspector.AddShader(sh)
+}
+
+// AddModelToShader attaches the model to the shader and registers it in the inspector.
+func (scrn *EditorScreen) AddModelToShader(m interfaces.Model, sh interfaces.Shader) {
+	scrn.Screen.AddModelToShader(m, sh)
+	scrn.inspector.AddModelToShader(m, sh)
+}
+
+// RemoveModelFromShader detaches the model from the shader and removes it from the inspector.
+func (scrn *EditorScreen) RemoveModelFromShader(m interfaces.Model, sh interfaces.Shader) {
+	scrn.Screen.RemoveModelFromShader(m, sh)
+	scrn.inspector.RemoveModelFromShader(m, sh)
+}
+
+// isEditorShader returns true if the given shader is used for drawing the editor itself.
+// These shaders are not listed in the inspector.
+func (scrn *EditorScreen) isEditorShader(sh interfaces.Shader) bool {
+	return sh == scrn.menuShader || sh == scrn.fontShader
+}
+
+// setStateModels replaces the models of the given state. If it is the
+// current state, the menu panel is also rebuilt.
+func (scrn *EditorScreen) setStateModels(state string, models []interfaces.Model) {
+	if scrn.state != state {
+		scrn.menuModels[state] = models
+		return
+	}
+	scrn.RemoveMenuPanel()
+	scrn.menuModels[state] = models
+	scrn.AddMenuPanel()
+	// The rebuilt items are new instances, the focus is kept on the same position.
+	scrn.focus.SetItems(scrn.menuModels[state], true)
+}
+
+// newMenuButton returns a button that is pinned to the menu panel. If the charset is
+// already loaded, the label is centered, otherwise it is done by the defaultCharset function.
+func (scrn *EditorScreen) newMenuButton(text string, frame, surface mgl32.Vec2, defaultCol []mgl32.Vec3, pos mgl32.Vec3, labelSize float32, callback func()) *Button {
+	aspectRatio := scrn.GetAspectRatio()
+	screenMesh, err := scrn.menuPanel.GetMeshByIndex(0)
+	if err != nil {
+		fmt.Println("Something terrible happened on menu panel branch.")
+		panic(err)
+	}
+	btn := NewButton(frame, surface, defaultCol, buttonHoverColor, screenMesh, pos, aspectRatio)
+	s, err := btn.GetMeshByIndex(1)
+	if err != nil {
+		fmt.Println("Something terrible happened on btn branch.")
+		panic(err)
+	}
+	labelPosition := mgl32.Vec3{0, 0, -FormItemsDistanceFromScreen}
+	if scrn.charset != nil {
+		w, h := scrn.charset.TextContainerSize(text, labelSize)
+		labelPosition = mgl32.Vec3{-w / 2 / aspectRatio, -h / 4, -FormItemsDistanceFromScreen}
+	}
+	btn.SetLabel(NewLabel(text, mgl32.Vec3{0, 0, 0.05}, labelPosition, labelSize, s))
+	btn.SetDescription(ButtonDescriptions[text])
+	btn.clickCallback = callback
+	return btn
+}
+
+// newMenuSliderInput returns a slider input that is pinned to the menu panel.
+func (scrn *EditorScreen) newMenuSliderInput(text, description string, pos mgl32.Vec3, min, max, current, step float32) *SliderInput {
+	aspectRatio := scrn.GetAspectRatio()
+	screenMesh, err := scrn.menuPanel.GetMeshByIndex(0)
+	if err != nil {
+		fmt.Println("Something terrible happened on menu panel branch.")
+		panic(err)
+	}
+	si := NewSliderInput(TextInputframe, TextInputsurface, TextInputDefaultColor, TextInputHoverColor, TextInputFieldColor, screenMesh, pos, aspectRatio, min, max)
+	s, err := si.GetMeshByIndex(1)
+	if err != nil {
+		fmt.Println("Something terrible happened on si branch.")
+		panic(err)
+	}
+	labelPosition := mgl32.Vec3{0, TextInputField.X() / aspectRatio / 2, -0.01}
+	if scrn.charset != nil {
+		w, _ := scrn.charset.TextContainerSize(text, 0.0005)
+		labelPosition = mgl32.Vec3{-w / 2 / aspectRatio, labelPosition.Y(), labelPosition.Z()}
+	}
+	si.SetLabel(NewLabel(text, mgl32.Vec3{0, 0, 0.05}, labelPosition, 0.0005, s))
+	si.SetDescription(description)
+	si.SetStep(step)
+	si.SetCurrentValue(current)
+	return si
+}
+
+// SetStateInspector displays the scene graph tree.
+func (scrn *EditorScreen) SetStateInspector() {
+	scrn.buildInspectorTree()
+	scrn.setState("Inspector")
+}
+
+// buildInspectorTree builds the rows of the current page of the scene graph tree.
+// Every row is a button that selects the node. The nodes with children also get a
+// toggle button, that expands or collapses the node.
+func (scrn *EditorScreen) buildInspectorTree() {
+	nodes := scrn.inspector.Nodes(scrn.isEditorShader)
+	pages := (len(nodes)-1)/InspectorRowsPerPage + 1
+	if scrn.inspector.page >= pages {
+		scrn.inspector.page = pages - 1
+	}
+	page := scrn.inspector.page
+	models := []interfaces.Model{scrn.menuPanel}
+	models = append(models, scrn.newMenuButton("Back", Buttonframe, Buttonsurface, buttonDefaultColor, mgl32.Vec3{0.9, -FormItemsDistanceFromScreen, 0.35}, 0.0005, scrn.SetStateDefault))
+	first := page * InspectorRowsPerPage
+	for i := first; i < len(nodes) && i < first+InspectorRowsPerPage; i++ {
+		node := nodes[i]
+		rowX := -0.75 + float32(i-first)*TreeRowDistance
+		depth := node.depth
+		if depth > TreeMaxIndentation {
+			depth = TreeMaxIndentation
+		}
+		indentation := float32(depth) * TreeIndentation
+		if node.hasChild {
+			togglerText := "+"
+			if scrn.inspector.IsExpanded(node) {
+				togglerText = "-"
+			}
+			models = append(models, scrn.newMenuButton(togglerText, TreeTogglerframe, TreeTogglersurface, buttonDefaultColor, mgl32.Vec3{rowX, -FormItemsDistanceFromScreen, -0.42 + indentation}, InspectorLabelSize, func() {
+				scrn.inspector.Toggle(node)
+				scrn.buildInspectorTree()
+			}))
+		}
+		rowColor := buttonDefaultColor
+		if scrn.inspector.IsHidden(node) {
+			rowColor = InspectorHiddenColor
+		}
+		if scrn.selectedNode != nil && scrn.selectedNode.Key() == node.Key() {
+			rowColor = InspectorSelectedColor
+		}
+		row := scrn.newMenuButton(node.label, TreeRowframe, TreeRowsurface, rowColor, mgl32.Vec3{rowX, -FormItemsDistanceFromScreen, -0.08 + indentation}, InspectorLabelSize, func() {
+			scrn.SetStateInspectorNode(node)
+		})
+		// The row labels are aligned to the left side.
+		labelPosition := row.GetLabelPosition()
+		row.SetLabel(NewLabel(node.label, row.GetLabelColor(), mgl32.Vec3{-TreeRowsurface.Y()/2/row.aspect + TreeIndentation/4/row.aspect, labelPosition.Y(), labelPosition.Z()}, row.GetLabelSize(), row.GetLabelSurface()))
+		models = append(models, row)
+	}
+	if page > 0 {
+		models = append(models, scrn.newMenuButton("Prev", Buttonframe, Buttonsurface, buttonDefaultColor, mgl32.Vec3{0.9, -FormItemsDistanceFromScreen, -0.35}, 0.0005, func() {
+			scrn.inspector.page--
+			scrn.buildInspectorTree()
+		}))
+	}
+	if page < pages-1 {
+		models = append(models, scrn.newMenuButton("Next", Buttonframe, Buttonsurface, buttonDefaultColor, mgl32.Vec3{0.9, -FormItemsDistanceFromScreen, -0.15}, 0.0005, func() {
+			scrn.inspector.page++
+			scrn.buildInspectorTree()
+		}))
+	}
+	scrn.setStateModels("Inspector", models)
+}
+
+// SetStateInspectorNode selects the given node and displays its details
+// and the buttons of the editable properties.
+func (scrn *EditorScreen) SetStateInspectorNode(node *InspectorNode) {
+	scrn.selectedNode = node
+	ScreenLabels["InspectorNode"] = "Inspector > " + node.label
+	screenMesh, err := scrn.menuPanel.GetMeshByIndex(0)
+	if err != nil {
+		fmt.Println("Something terrible happened on menu panel branch.")
+		panic(err)
+	}
+	details := NewInfoPanel(mgl32.Vec2{1.3, 0.9}, []mgl32.Vec3{mgl32.Vec3{0.8, 0.8, 0.8}}, mgl32.Vec3{0, 0, 0.05}, screenMesh, mgl32.Vec3{-0.1, -FormItemsDistanceFromScreen, 0.0}, scrn.GetAspectRatio(), InspectorLabelSize, 0.06)
+	details.SetLines(scrn.inspectorNodeDetails(node))
+	models := []interfaces.Model{scrn.menuPanel, details}
+	models = append(models, scrn.newMenuButton("Back", Buttonframe, Buttonsurface, buttonDefaultColor, mgl32.Vec3{0.9, -FormItemsDistanceFromScreen, 0.35}, 0.0005, scrn.SetStateInspector))
+	if node.kind != "shader" {
+		models = append(models, scrn.newMenuButton("Position", Buttonframe, Buttonsurface, buttonDefaultColor, mgl32.Vec3{0.8, -FormItemsDistanceFromScreen, -0.35}, 0.0005, scrn.SetStateInspectorPositionForm))
+		models = append(models, scrn.newMenuButton("Rotation", Buttonframe, Buttonsurface, buttonDefaultColor, mgl32.Vec3{0.8, -FormItemsDistanceFromScreen, -0.15}, 0.0005, scrn.SetStateInspectorRotationForm))
+		models = append(models, scrn.newMenuButton("Scale", Buttonframe, Buttonsurface, buttonDefaultColor, mgl32.Vec3{0.8, -FormItemsDistanceFromScreen, 0.05}, 0.0005, scrn.SetStateInspectorScaleForm))
+	}
+	if node.kind == "mesh" {
+		if _, ok := meshMaterial(node.mesh); ok {
+			models = append(models, scrn.newMenuButton("Material", Buttonframe, Buttonsurface, buttonDefaultColor, mgl32.Vec3{0.9, -FormItemsDistanceFromScreen, -0.35}, 0.0005, func() {
+				scrn.materialMesh = node.mesh
+				scrn.materialBackState = "InspectorNode"
+				scrn.SetStateMaterial()
+			}))
+		}
+		if node.mesh.IsBoundingObjectSet() {
+			models = append(models, scrn.newMenuButton("Bounding", Buttonframe, Buttonsurface, buttonDefaultColor, mgl32.Vec3{0.9, -FormItemsDistanceFromScreen, -0.15}, 0.0005, scrn.SetStateInspectorBoundingForm))
+		}
+	}
+	visibilityText := "Hide"
+	if scrn.inspector.IsHidden(node) {
+		visibilityText = "Show"
+	}
+	models = append(models, scrn.newMenuButton(visibilityText, Buttonframe, Buttonsurface, buttonDefaultColor, mgl32.Vec3{0.9, -FormItemsDistanceFromScreen, 0.05}, 0.0005, func() {
+		scrn.toggleNodeVisibility(node)
+		scrn.SetStateInspectorNode(node)
+	}))
+	if scrn.state == "InspectorNode" {
+		scrn.setStateModels("InspectorNode", models)
+		return
+	}
+	scrn.menuModels["InspectorNode"] = models
+	scrn.setState("InspectorNode")
+}
+
+// vectorToLabel returns the string representation of the vector for the inspector.
+func vectorToLabel(v mgl32.Vec3) string {
+	return fmt.Sprintf("%.3f, %.3f, %.3f", v.X(), v.Y(), v.Z())
+}
+
+// inspectorNodeDetails returns the printable properties of the node.
+func (scrn *EditorScreen) inspectorNodeDetails(node *InspectorNode) []string {
+	visible := "yes"
+	if scrn.inspector.IsHidden(node) {
+		visible = "no"
+	}
+	switch node.kind {
+	case "shader":
+		return []string{
+			fmt.Sprintf("Program id: %d", node.shader.GetId()),
+			fmt.Sprintf("Models: %d", len(scrn.inspector.models[node.shader])),
+			"Visible: " + visible,
+		}
+	case "model":
+		return []string{
+			"Type: " + typeName(node.model),
+			fmt.Sprintf("Meshes: %d", len(modelMeshes(node.model))),
+			"Position: " + vectorToLabel(scrn.inspector.NodePosition(node)),
+			"Rotation: " + vectorToLabel(scrn.inspector.NodeAngles(node)),
+			"Scale: " + vectorToLabel(scrn.inspector.NodeScale(node)),
+			fmt.Sprintf("Transparent: %t", node.model.IsTransparent()),
+			"Visible: " + visible,
+		}
+	}
+	lines := []string{
+		"Type: " + typeName(node.mesh),
+		"Position: " + vectorToLabel(scrn.inspector.NodePosition(node)),
+		"Rotation: " + vectorToLabel(scrn.inspector.NodeAngles(node)),
+		"Scale: " + vectorToLabel(scrn.inspector.NodeScale(node)),
+	}
+	if node.mesh.IsParentMesh() {
+		lines = append(lines, "Parent: -")
+	} else {
+		lines = append(lines, "Parent: "+typeName(node.mesh.GetParent()))
+	}
+	if mat, ok := meshMaterial(node.mesh); ok {
+		lines = append(lines, "Ambient: "+vectorToLabel(mat.GetAmbient()))
+		lines = append(lines, "Diffuse: "+vectorToLabel(mat.GetDiffuse()))
+		lines = append(lines, "Specular: "+vectorToLabel(mat.GetSpecular()))
+		lines = append(lines, fmt.Sprintf("Shininess: %.3f", mat.GetShininess()))
+	}
+	if node.mesh.IsBoundingObjectSet() {
+		bo := node.mesh.GetBoundingObject()
+		params := bo.Params()
+		if bo.Type() == "Sphere" {
+			lines = append(lines, fmt.Sprintf("Bounding object: Sphere, radius: %.3f", params["radius"]))
+		} else {
+			lines = append(lines, fmt.Sprintf("Bounding object: AABB, %.3f x %.3f x %.3f", params["width"], params["height"], params["length"]))
+		}
+	} else {
+		lines = append(lines, "Bounding object: -")
+	}
+	return append(lines, "Visible: "+visible)
+}
+
+// toggleNodeVisibility hides the visible and shows the hidden nodes. The models are
+// detached from the screen, the meshes are scaled to zero, the shaders hide or show
+// all of their models.
+func (scrn *EditorScreen) toggleNodeVisibility(node *InspectorNode) {
+	hidden := scrn.inspector.IsHidden(node)
+	switch node.kind {
+	case "shader":
+		for _, m := range scrn.inspector.models[node.shader] {
+			scrn.setModelVisibility(m, node.shader, hidden)
+		}
+		break
+	case "model":
+		scrn.setModelVisibility(node.model, node.shader, hidden)
+		break
+	case "mesh":
+		if hidden {
+			setMeshScale(node.mesh, scrn.inspector.hiddenMeshes[node.mesh])
+			delete(scrn.inspector.hiddenMeshes, node.mesh)
+		} else {
+			scrn.inspector.hiddenMeshes[node.mesh] = meshScale(node.mesh)
+			setMeshScale(node.mesh, mgl32.Vec3{0, 0, 0})
+		}
+		break
+	}
+}
+
+// setModelVisibility attaches the model to the shader or detaches it from the shader.
+// The inspector keeps the model, so that it could be displayed later.
+func (scrn *EditorScreen) setModelVisibility(m interfaces.Model, sh interfaces.Shader, visible bool) {
+	if visible == !scrn.inspector.hiddenModels[m] {
+		return
+	}
+	if visible {
+		scrn.Screen.AddModelToShader(m, sh)
+		delete(scrn.inspector.hiddenModels, m)
+		return
+	}
+	scrn.Screen.RemoveModelFromShader(m, sh)
+	scrn.inspector.hiddenModels[m] = true
+}
+
+// setInspectorForm builds the form state with sliders. The back button navigates to the node state.
+func (scrn *EditorScreen) setInspectorForm(state string, labels, descriptions []string, values, mins, maxs []float32, step float32) {
+	models := []interfaces.Model{scrn.menuPanel}
+	models = append(models, scrn.newMenuButton("Back", Buttonframe, Buttonsurface, buttonDefaultColor, mgl32.Vec3{0.9, -FormItemsDistanceFromScreen, 0.35}, 0.0005, func() {
+		scrn.SetStateInspectorNode(scrn.selectedNode)
+	}))
+	for i, _ := range labels {
+		models = append(models, scrn.newMenuSliderInput(labels[i], descriptions[i], mgl32.Vec3{-0.5 + float32(i)*0.3, -FormItemsDistanceFromScreen, 0.0}, mins[i], maxs[i], values[i], step))
+	}
+	scrn.menuModels[state] = models
+	scrn.setState(state)
+}
+
+// SetStateInspectorPositionForm displays the position sliders of the selected node.
+// The interval of the sliders is the current position +- 1.
+func (scrn *EditorScreen) SetStateInspectorPositionForm() {
+	pos := scrn.inspector.NodePosition(scrn.selectedNode)
+	scrn.setInspectorForm("InspectorPositionForm", []string{"X", "Y", "Z"}, []string{"The x coordinate of the node.", "The y coordinate of the node.", "The z coordinate of the node."}, []float32{pos.X(), pos.Y(), pos.Z()}, []float32{pos.X() - 1, pos.Y() - 1, pos.Z() - 1}, []float32{pos.X() + 1, pos.Y() + 1, pos.Z() + 1}, 0.01)
+}
+
+// SetStateInspectorRotationForm displays the rotation sliders of the selected node.
+func (scrn *EditorScreen) SetStateInspectorRotationForm() {
+	angles := scrn.inspector.NodeAngles(scrn.selectedNode)
+	scrn.setInspectorForm("InspectorRotationForm", []string{"X", "Y", "Z"}, []string{"The rotation angle on the x axis in degrees.", "The rotation angle on the y axis in degrees.", "The rotation angle on the z axis in degrees."}, []float32{angles.X(), angles.Y(), angles.Z()}, []float32{-180, -180, -180}, []float32{180, 180, 180}, 1)
+}
+
+// SetStateInspectorScaleForm displays the scale sliders of the selected node.
+// The interval of the sliders is [0, 2 * current scale].
+func (scrn *EditorScreen) SetStateInspectorScaleForm() {
+	scale := scrn.inspector.NodeScale(scrn.selectedNode)
+	scrn.setInspectorForm("InspectorScaleForm", []string{"X", "Y", "Z"}, []string{"The scale factor on the x axis.", "The scale factor on the y axis.", "The scale factor on the z axis."}, []float32{scale.X(), scale.Y(), scale.Z()}, []float32{0, 0, 0}, []float32{inspectorSliderMax(scale.X()), inspectorSliderMax(scale.Y()), inspectorSliderMax(scale.Z())}, 0.01)
+}
+
+// SetStateInspectorBoundingForm displays the sliders of the bounding object params.
+func (scrn *EditorScreen) SetStateInspectorBoundingForm() {
+	bo := scrn.selectedNode.mesh.GetBoundingObject()
+	params := bo.Params()
+	if bo.Type() == "Sphere" {
+		scrn.setInspectorForm("InspectorBoundingForm", []string{"Radius"}, []string{"The radius of the bounding sphere."}, []float32{params["radius"]}, []float32{0}, []float32{inspectorSliderMax(params["radius"])}, 0.01)
+		return
+	}
+	scrn.setInspectorForm("InspectorBoundingForm", []string{"Width", "Height", "Length"}, []string{"The size of the bounding box on the x axis.", "The size of the bounding box on the y axis.", "The size of the bounding box on the z axis."}, []float32{params["width"], params["height"], params["length"]}, []float32{0, 0, 0}, []float32{inspectorSliderMax(params["width"]), inspectorSliderMax(params["height"]), inspectorSliderMax(params["length"])}, 0.01)
+}
+
+// inspectorSliderMax returns the max value of the sliders that start from zero.
+func inspectorSliderMax(current float32) float32 {
+	if current < 0.5 {
+		return 1.0
+	}
+	return 2 * current
+}
+
+// updateInspectedNode applies the slider values of the current form to the selected node.
+func (scrn *EditorScreen) updateInspectedNode() {
+	if scrn.selectedNode == nil {
+		return
+	}
+	var values []float32
+	for index := 2; index < len(scrn.menuModels[scrn.state]); index++ {
+		values = append(values, scrn.menuModels[scrn.state][index].(*SliderInput).sliderCurrent)
+	}
+	switch scrn.state {
+	case "InspectorPositionForm":
+		scrn.inspector.SetNodePosition(scrn.selectedNode, mgl32.Vec3{values[0], values[1], values[2]})
+		break
+	case "InspectorRotationForm":
+		scrn.inspector.SetNodeAngles(scrn.selectedNode, mgl32.Vec3{values[0], values[1], values[2]})
+		break
+	case "InspectorScaleForm":
+		scrn.inspector.SetNodeScale(scrn.selectedNode, mgl32.Vec3{values[0], values[1], values[2]})
+		break
+	case "InspectorBoundingForm":
+		params := make(map[string]float32)
+		if len(values) == 1 {
+			params["radius"] = values[0]
+		} else {
+			params["width"] = values[0]
+			params["height"] = values[1]
+			params["length"] = values[2]
+		}
+		scrn.inspector.SetNodeBoundingObject(scrn.selectedNode, params)
+		break
+	}
+}
 func (scrn *EditorScreen) defaultCharset() {
 	cs, err := model.LoadCharset("assets/fonts/Desyrel/desyrel.regular.ttf", 32, 127, 20.0, 300, scrn.GetWrapper())
 	if err != nil {
@@ -1109,6 +2684,25 @@ func (scrn *EditorScreen) ResizeEvent(wW, wH float32) {
 	}
 }
 
+// KeyCallback passes the escape key to the editor screen, so that it could navigate
+// back. If the editor doesn't handle the press, the press, the repeat and the release
+// events are passed to the application callback, like the other keys.
+func KeyCallback(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if key == glfw.KeyEscape && MenuScreenEnabled {
+		if action == glfw.Press {
+			EscapeHandled = AppScreen.Back()
+		}
+		handled := EscapeHandled
+		if action == glfw.Release {
+			EscapeHandled = false
+		}
+		if handled {
+			return
+		}
+	}
+	app.KeyCallback(w, key, scancode, action, mods)
+}
+
 // type SizeCallback func(w *Window, width int, height int)
 func ResizeCallback(w *glfw.Window, width, height int) {
 	AppScreen.ResizeEvent(float32(width), float32(height))
@@ -1125,8 +2719,9 @@ func init() {
 	app.AddScreen(AppScreen)
 	app.ActivateScreen(AppScreen)
 	// register keyboard button callback
-	app.GetWindow().SetKeyCallback(app.KeyCallback)
+	app.GetWindow().SetKeyCallback(KeyCallback)
 	app.GetWindow().SetMouseButtonCallback(app.MouseButtonCallback)
+	app.GetWindow().SetCharCallback(app.CharCallback)
 	app.GetWindow().SetSizeCallback(ResizeCallback)
 	lastUpdate = time.Now().UnixNano() / int64(time.Millisecond)
 	lastToggle = lastUpdate
@@ -1214,11 +2809,11 @@ func CreateJadeSphere() *mesh.MaterialMesh {
 	return JadeSphere
 }
 func CreateMenuRectangle(aspect float32) *mesh.ColorMesh {
-	rect := rectangle.NewExact(2.0/aspect, 1.0/aspect)
+	rect := rectangle.NewExact(MenuPanelHeight/aspect, MenuPanelWidth/aspect)
 	colors := []mgl32.Vec3{mgl32.Vec3{0.0, 0.0, 1.0}}
 	v, i, _ := rect.ColoredMeshInput(colors)
 	menu := mesh.NewColorMesh(v, i, colors, glWrapper)
-	menu.SetPosition(mgl32.Vec3{0.0, 0.0, 0.5 / aspect})
+	menu.SetPosition(mgl32.Vec3{0.0, 0.0, MenuPanelWidth / 2 / aspect})
 	return menu
 }
 func CreateMenuLabelRectangle(aspect float32) *mesh.ColorMesh {
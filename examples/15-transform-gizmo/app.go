@@ -0,0 +1,934 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"runtime"
+	"time"
+
+	"github.com/akosgarai/playground_engine/pkg/application"
+	"github.com/akosgarai/playground_engine/pkg/camera"
+	"github.com/akosgarai/playground_engine/pkg/config"
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/light"
+	"github.com/akosgarai/playground_engine/pkg/mesh"
+	"github.com/akosgarai/playground_engine/pkg/model"
+	"github.com/akosgarai/playground_engine/pkg/primitives/cuboid"
+	"github.com/akosgarai/playground_engine/pkg/screen"
+	"github.com/akosgarai/playground_engine/pkg/shader"
+	"github.com/akosgarai/playground_engine/pkg/theme"
+	"github.com/akosgarai/playground_engine/pkg/transformations"
+	"github.com/akosgarai/playground_engine/pkg/window"
+
+	"github.com/akosgarai/opengl_playground/pkg/focus"
+	"github.com/akosgarai/opengl_playground/pkg/objexport"
+	"github.com/akosgarai/opengl_playground/pkg/tooltip"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	WindowWidth  = 800
+	WindowHeight = 800
+	WindowTitle  = "Example - transform gizmo."
+
+	TRANSLATE_MODE_BUTTON = glfw.Key1
+	ROTATE_MODE_BUTTON    = glfw.Key2
+	SCALE_MODE_BUTTON     = glfw.Key3
+	EXPORT_BUTTON         = glfw.KeyX
+	PICK_BUTTON           = glfw.MouseButtonLeft
+
+	// The scene is exported to this directory (relative to the working directory) with this name.
+	ExportDirectory = "export"
+	ExportName      = "scene"
+
+	GizmoModeTranslate = "translate"
+	GizmoModeRotate    = "rotate"
+	GizmoModeScale     = "scale"
+)
+
+var (
+	app            *application.Application
+	SettingsScreen *focus.FormScreen
+	MenuScreen     *focus.MenuScreen
+	AppScreen      *screen.Screen
+	Settings       = config.New()
+
+	glWrapper glwrapper.Wrapper
+
+	lastUpdate int64
+	startTime  int64
+
+	// The models that could be selected with the mouse.
+	Pickable []interfaces.Model
+	Ground   *model.Terrain
+	Gizmo    *TransformGizmo
+	// It stores the state of the pick button from the previous frame, so that
+	// the press event could be separated from the hold.
+	PickButtonWasDown = false
+	// It stores the state of the export button from the previous frame.
+	ExportButtonWasDown = false
+
+	DirectionalLightDirection = (mgl32.Vec3{0.5, 0.5, -0.7}).Normalize()
+	DirectionalLightAmbient   = mgl32.Vec3{0.3, 0.3, 0.3}
+	DirectionalLightDiffuse   = mgl32.Vec3{0.3, 0.3, 0.3}
+	DirectionalLightSpecular  = mgl32.Vec3{0.3, 0.3, 0.3}
+
+	// The axises of the gizmo handles and their colors.
+	GizmoAxises = [3]mgl32.Vec3{
+		mgl32.Vec3{1, 0, 0},
+		mgl32.Vec3{0, 1, 0},
+		mgl32.Vec3{0, 0, 1},
+	}
+	GizmoColors = [3]mgl32.Vec3{
+		mgl32.Vec3{1, 0, 0},
+		mgl32.Vec3{0, 1, 0},
+		mgl32.Vec3{0, 0, 1},
+	}
+	// The length and the thickness of the handles in unit distance from the camera.
+	GizmoHandleLength    = float32(0.25)
+	GizmoHandleThickness = float32(0.02)
+	// The minimal value of the scale factor, to prevent the flipped meshes.
+	GizmoMinScale = float32(0.05)
+)
+
+func init() {
+	runtime.LockOSThread()
+
+	Settings.AddConfig("RoomPosition", "Room pos.", "The center point of the floor of the room.", mgl32.Vec3{0, 0, 0}, nil)
+	Settings.AddConfig("LampPosition", "Lamp pos.", "The bottom of the street lamp.", mgl32.Vec3{1.5, 0.0, 0.0}, nil)
+	Settings.AddConfig("BugPosition", "Bug pos.", "The center point of the bug.", mgl32.Vec3{-1.0, -0.5, 0.0}, nil)
+	// gizmo options:
+	Settings.AddConfig("GizmoSize", "Gizmo size", "The size of the gizmo handles. It is multiplied with the camera distance, so that the gizmo has the same size on the screen.", float32(1.0), nil)
+	Settings.AddConfig("SelectMesh", "Select mesh", "If this flag is active, the gizmo transforms the picked mesh, otherwise the whole model.", false, nil)
+	Settings.AddConfig("GridSnap", "Grid snap", "If this flag is active, the translation is snapped to the grid.", false, nil)
+	Settings.AddConfig("GridStep", "Grid step", "The distance of the grid lines.", float32(0.25), nil)
+	Settings.AddConfig("AngleSnap", "Angle snap", "If this flag is active, the rotation is snapped to the angle step.", false, nil)
+	Settings.AddConfig("AngleStep", "Angle step", "The rotation angle step in degrees.", float32(15.0), nil)
+	Settings.AddConfig("ScaleSnap", "Scale snap", "If this flag is active, the scale factor is snapped to the scale step.", false, nil)
+	Settings.AddConfig("ScaleStep", "Scale step", "The scale factor step.", float32(0.25), nil)
+	// export options:
+	Settings.AddConfig("ExportWorldSpace", "Export world", "If this flag is active, the exported vertices are transformed to world space, otherwise they are exported in mesh space.", true, nil)
+	// camera options:
+	// - position
+	Settings.AddConfig("CameraPos", "Cam position", "The initial position of the camera.", mgl32.Vec3{0.0, -0.5, 3.0}, nil)
+	// - up direction
+	Settings.AddConfig("WorldUp", "World up dir", "The up direction in the world.", mgl32.Vec3{0, 1, 0}, nil)
+	// - pitch, yaw
+	Settings.AddConfig("CameraYaw", "Cam Yaw", "The yaw (angle) of the camera. Rotation on the Z axis.", float32(-90.0), nil)
+	Settings.AddConfig("CameraPitch", "Cam Pitch", "The pitch (angle) of the camera. Rotation on the Y axis.", float32(0.0), nil)
+	// - fov, far, near clip
+	Settings.AddConfig("CameraNear", "Cam Near", "The near clip plane of the camera.", float32(0.001), nil)
+	Settings.AddConfig("CameraFar", "Cam Far", "The far clip plane of the camera.", float32(20.0), nil)
+	Settings.AddConfig("CameraFov", "Cam Fov", "The field of view (angle) of the camera.", float32(45.0), nil)
+	// - move speed
+	Settings.AddConfig("CameraVelocity", "Cam Speed", "The movement velocity of the camera. If it moves, it moves with this speed.", float32(0.005), nil)
+	// - direction speed
+	Settings.AddConfig("CameraRotation", "Cam Rotate", "The rotation velocity of the camera. If it rotates, it rotates with this speed.", float32(0.05), nil)
+	// - rotate on edge distance.
+	Settings.AddConfig("CameraRotationEdge", "Cam Edge", "The rotation cam be triggered if the mouse is near to the edge of the screen.", float32(0.1), nil)
+}
+
+// Ray is a half line that starts from the origin point and goes to the
+// direction. The direction is normalized.
+type Ray struct {
+	origin    mgl32.Vec3
+	direction mgl32.Vec3
+}
+
+// NewRayFromCursor returns the ray that goes through the given mouse coordinates.
+// The mouse coordinates are unprojected to the near and to the far clip plane
+// and the ray goes from the near point to the far one.
+func NewRayFromCursor(cam interfaces.Camera, mX, mY float64) *Ray {
+	trMat := cam.GetProjectionMatrix().Mul4(cam.GetViewMatrix()).Inv()
+	near := mgl32.TransformCoordinate(mgl32.Vec3{float32(mX), float32(mY), -1.0}, trMat)
+	far := mgl32.TransformCoordinate(mgl32.Vec3{float32(mX), float32(mY), 1.0}, trMat)
+	return &Ray{
+		origin:    near,
+		direction: far.Sub(near).Normalize(),
+	}
+}
+
+// PointAt returns the point of the ray with the given distance from the origin.
+func (r *Ray) PointAt(t float32) mgl32.Vec3 {
+	return r.origin.Add(r.direction.Mul(t))
+}
+
+// IntersectAABB returns the distance of the closest intersection point of the ray
+// and the axis aligned bounding box. The box is described with its center point and
+// with the width, height, length params. If the ray misses the box, the second
+// return value is false. It is based on the slab method.
+func (r *Ray) IntersectAABB(center mgl32.Vec3, params map[string]float32) (float32, bool) {
+	halfSize := mgl32.Vec3{params["width"] / 2, params["height"] / 2, params["length"] / 2}
+	tMin := float32(math.Inf(-1))
+	tMax := float32(math.Inf(1))
+	for i := 0; i < 3; i++ {
+		minSide := center[i] - halfSize[i]
+		maxSide := center[i] + halfSize[i]
+		if r.direction[i] == 0 {
+			// The ray is parallel with the slab, it has to start between the sides.
+			if r.origin[i] < minSide || r.origin[i] > maxSide {
+				return 0, false
+			}
+			continue
+		}
+		t1 := (minSide - r.origin[i]) / r.direction[i]
+		t2 := (maxSide - r.origin[i]) / r.direction[i]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > tMin {
+			tMin = t1
+		}
+		if t2 < tMax {
+			tMax = t2
+		}
+		if tMin > tMax {
+			return 0, false
+		}
+	}
+	if tMax < 0 {
+		return 0, false
+	}
+	if tMin < 0 {
+		return 0, true
+	}
+	return tMin, true
+}
+
+// IntersectSphere returns the distance of the closest intersection point of the ray
+// and the sphere. If the ray misses the sphere, the second return value is false.
+func (r *Ray) IntersectSphere(center mgl32.Vec3, radius float32) (float32, bool) {
+	oc := r.origin.Sub(center)
+	b := oc.Dot(r.direction)
+	c := oc.Dot(oc) - radius*radius
+	discriminant := b*b - c
+	if discriminant < 0 {
+		return 0, false
+	}
+	sqrtDiscriminant := float32(math.Sqrt(float64(discriminant)))
+	if t := -b - sqrtDiscriminant; t >= 0 {
+		return t, true
+	}
+	// The origin of the ray is inside the sphere.
+	if -b+sqrtDiscriminant >= 0 {
+		return 0, true
+	}
+	return 0, false
+}
+
+// IntersectMesh returns the distance of the intersection point of the ray and
+// the bounding object of the mesh. If the bounding object is not set or the ray
+// misses it, the second return value is false.
+func (r *Ray) IntersectMesh(msh interfaces.Mesh) (float32, bool) {
+	if !msh.IsBoundingObjectSet() {
+		return 0, false
+	}
+	bo := msh.GetBoundingObject()
+	center := meshWorldPosition(msh)
+	switch bo.Type() {
+	case "AABB":
+		return r.IntersectAABB(center, bo.Params())
+		break
+	case "Sphere":
+		return r.IntersectSphere(center, bo.Params()["radius"])
+		break
+	}
+	return 0, false
+}
+
+// IntersectPlane returns the distance of the intersection point of the ray and
+// the plane that is described with a point and a normal vector. If the ray is
+// parallel with the plane or the plane is behind the ray, the second return
+// value is false.
+func (r *Ray) IntersectPlane(point, normal mgl32.Vec3) (float32, bool) {
+	denominator := normal.Dot(r.direction)
+	if transformations.Float32Abs(denominator) < 1e-6 {
+		return 0, false
+	}
+	t := point.Sub(r.origin).Dot(normal) / denominator
+	if t < 0 {
+		return 0, false
+	}
+	return t, true
+}
+
+// ClosestOnLine returns the parameter of the point of the line (point + s*direction)
+// that is the closest to the ray. The direction has to be normalized. If the ray
+// is parallel with the line, the second return value is false.
+func (r *Ray) ClosestOnLine(point, direction mgl32.Vec3) (float32, bool) {
+	w0 := point.Sub(r.origin)
+	b := direction.Dot(r.direction)
+	d := direction.Dot(w0)
+	e := r.direction.Dot(w0)
+	denominator := 1 - b*b
+	if denominator < 1e-6 {
+		return 0, false
+	}
+	return (b*e - d) / denominator, true
+}
+
+// GizmoTarget is the thing that is transformed with the gizmo. It is a whole
+// model or a single mesh of the model.
+type GizmoTarget struct {
+	mdl interfaces.Model
+	msh interfaces.Mesh
+}
+
+// Meshes returns the meshes that are affected by the transformations.
+func (t *GizmoTarget) Meshes() []interfaces.Mesh {
+	if t.msh != nil {
+		return []interfaces.Mesh{t.msh}
+	}
+	return modelMeshes(t.mdl)
+}
+
+// Pivot returns the center point of the transformations. In case of mesh target
+// it is the position of the mesh, in case of model target it is the position of
+// the first root mesh.
+func (t *GizmoTarget) Pivot() mgl32.Vec3 {
+	if t.msh != nil {
+		return meshWorldPosition(t.msh)
+	}
+	for _, msh := range modelMeshes(t.mdl) {
+		if msh.IsParentMesh() {
+			return msh.GetPosition()
+		}
+	}
+	return mgl32.Vec3{0, 0, 0}
+}
+
+// Rotate rotates the target around the given axis with the given angle (deg).
+func (t *GizmoTarget) Rotate(axis int, angleDeg float32) {
+	if t.msh != nil {
+		switch axis {
+		case 0:
+			t.msh.RotateX(angleDeg)
+			break
+		case 1:
+			t.msh.RotateY(angleDeg)
+			break
+		case 2:
+			t.msh.RotateZ(angleDeg)
+			break
+		}
+		return
+	}
+	switch axis {
+	case 0:
+		t.mdl.RotateX(angleDeg)
+		break
+	case 1:
+		t.mdl.RotateY(angleDeg)
+		break
+	case 2:
+		if rotatable, ok := t.mdl.(interface{ RotateZ(float32) }); ok {
+			rotatable.RotateZ(angleDeg)
+		}
+		break
+	}
+}
+
+// TransformGizmo is a model with three axis handles. The handles are drawn over
+// the scene, and they could be dragged with the mouse for moving, rotating or
+// scaling the target.
+type TransformGizmo struct {
+	*model.BaseModel
+	handles    [3]*mesh.ColorMesh
+	target     *GizmoTarget
+	mode       string
+	activeAxis int
+	// The state of the drag, that is stored when the dragging starts.
+	dragStartParam    float32
+	dragStartPivot    mgl32.Vec3
+	dragStartPosition map[interfaces.Mesh]mgl32.Vec3
+	dragStartScale    map[interfaces.Mesh]mgl32.Vec3
+	// The rotation that is tracked during the drag (raw) and the rotation that
+	// has been applied to the target (snapped).
+	dragAngle        float32
+	dragAppliedAngle float32
+}
+
+// NewTransformGizmo returns a gizmo in translate mode without target.
+func NewTransformGizmo() *TransformGizmo {
+	m := model.New()
+	g := &TransformGizmo{
+		BaseModel:  m,
+		mode:       GizmoModeTranslate,
+		activeAxis: -1,
+	}
+	for i := 0; i < 3; i++ {
+		size := mgl32.Vec3{GizmoHandleThickness, GizmoHandleThickness, GizmoHandleThickness}
+		size[i] = GizmoHandleLength
+		handle := cuboid.New(size.X(), size.Z(), size.Y())
+		V, I, bo := handle.ColoredMeshInput([]mgl32.Vec3{GizmoColors[i]})
+		handleMesh := mesh.NewColorMesh(V, I, []mgl32.Vec3{GizmoColors[i]}, glWrapper)
+		handleMesh.SetBoundingObject(bo)
+		g.handles[i] = handleMesh
+		g.AddMesh(handleMesh)
+	}
+	// The gizmo is drawn in the transparent round, after the scene.
+	g.SetTransparent(true)
+	return g
+}
+
+// Draw function draws the handles over the scene. It clears the depth buffer
+// before the draw, so that the handles are visible even if they are inside
+// the target. If the target is not set, it doesn't draw anything.
+func (g *TransformGizmo) Draw(s interfaces.Shader) {
+	if g.target == nil {
+		return
+	}
+	glWrapper.Clear(glwrapper.DEPTH_BUFFER_BIT)
+	g.BaseModel.Draw(s)
+}
+
+// SetTarget updates the target of the gizmo. The nil target hides the gizmo.
+func (g *TransformGizmo) SetTarget(t *GizmoTarget) {
+	g.target = t
+	g.activeAxis = -1
+}
+
+// SetMode updates the mode of the gizmo. It stops the current drag.
+func (g *TransformGizmo) SetMode(mode string) {
+	g.mode = mode
+	g.activeAxis = -1
+}
+
+// IsDragging returns true if a handle is dragged.
+func (g *TransformGizmo) IsDragging() bool {
+	return g.activeAxis >= 0
+}
+
+// UpdateHandles sets the position and the size of the handles. The handles are
+// placed to the pivot of the target and they are scaled with the camera distance,
+// so that they have the same size on the screen.
+func (g *TransformGizmo) UpdateHandles(cameraPosition mgl32.Vec3) {
+	if g.target == nil {
+		return
+	}
+	pivot := g.target.Pivot()
+	size := g.size(cameraPosition)
+	for i := 0; i < 3; i++ {
+		g.handles[i].SetScale(mgl32.Vec3{size, size, size})
+		g.handles[i].SetPosition(pivot.Add(GizmoAxises[i].Mul(GizmoHandleLength * size / 2)))
+	}
+}
+func (g *TransformGizmo) size(cameraPosition mgl32.Vec3) float32 {
+	return g.target.Pivot().Sub(cameraPosition).Len() * Settings["GizmoSize"].GetCurrentValue().(float32)
+}
+
+// PickHandle returns the index of the closest handle that is hit by the ray.
+// If the ray misses the handles, it returns -1.
+func (g *TransformGizmo) PickHandle(r *Ray) int {
+	if g.target == nil {
+		return -1
+	}
+	closest := -1
+	closestDistance := float32(math.MaxFloat32)
+	for i := 0; i < 3; i++ {
+		if distance, ok := r.IntersectMesh(g.handles[i]); ok && distance < closestDistance {
+			closest = i
+			closestDistance = distance
+		}
+	}
+	return closest
+}
+
+// StartDrag starts the dragging of the given handle. It stores the current
+// transformation of the target, so that the drag could be calculated from it.
+func (g *TransformGizmo) StartDrag(axis int, r *Ray) bool {
+	pivot := g.target.Pivot()
+	var param float32
+	var ok bool
+	if g.mode == GizmoModeRotate {
+		param, ok = g.angleOnPlane(axis, pivot, r)
+	} else {
+		param, ok = r.ClosestOnLine(pivot, GizmoAxises[axis])
+	}
+	if !ok {
+		return false
+	}
+	g.activeAxis = axis
+	g.dragStartParam = param
+	g.dragStartPivot = pivot
+	g.dragAngle = 0
+	g.dragAppliedAngle = 0
+	g.dragStartPosition = make(map[interfaces.Mesh]mgl32.Vec3)
+	g.dragStartScale = make(map[interfaces.Mesh]mgl32.Vec3)
+	for _, msh := range g.target.Meshes() {
+		g.dragStartPosition[msh] = msh.GetPosition()
+		g.dragStartScale[msh] = meshScale(msh)
+	}
+	return true
+}
+
+// Drag updates the target based on the ray of the current cursor position.
+func (g *TransformGizmo) Drag(r *Ray, cameraPosition mgl32.Vec3) {
+	if !g.IsDragging() {
+		return
+	}
+	switch g.mode {
+	case GizmoModeTranslate:
+		g.dragTranslate(r)
+		break
+	case GizmoModeRotate:
+		g.dragRotate(r)
+		break
+	case GizmoModeScale:
+		g.dragScale(r, cameraPosition)
+		break
+	}
+}
+
+// StopDrag finishes the current drag.
+func (g *TransformGizmo) StopDrag() {
+	g.activeAxis = -1
+}
+
+// dragTranslate moves the target along the active axis. In grid snap mode, the
+// pivot of the target is snapped to the grid.
+func (g *TransformGizmo) dragTranslate(r *Ray) {
+	param, ok := r.ClosestOnLine(g.dragStartPivot, GizmoAxises[g.activeAxis])
+	if !ok {
+		return
+	}
+	delta := param - g.dragStartParam
+	if Settings["GridSnap"].GetCurrentValue().(bool) {
+		start := g.dragStartPivot[g.activeAxis]
+		delta = snap(start+delta, Settings["GridStep"].GetCurrentValue().(float32)) - start
+	}
+	translation := GizmoAxises[g.activeAxis].Mul(delta)
+	for _, msh := range g.target.Meshes() {
+		if g.target.msh != nil || msh.IsParentMesh() {
+			msh.SetPosition(g.dragStartPosition[msh].Add(translation))
+		}
+	}
+}
+
+// dragRotate rotates the target around the active axis. The angle is calculated
+// from the intersection point of the ray and the plane that is perpendicular to the axis.
+func (g *TransformGizmo) dragRotate(r *Ray) {
+	angle, ok := g.angleOnPlane(g.activeAxis, g.dragStartPivot, r)
+	if !ok {
+		return
+	}
+	// the delta is normalized to the [-180, 180] interval, to handle the jump at the
+	// end of the atan2 range.
+	delta := angle - g.dragStartParam
+	for delta > 180 {
+		delta -= 360
+	}
+	for delta < -180 {
+		delta += 360
+	}
+	g.dragStartParam = angle
+	g.dragAngle += delta
+	applied := g.dragAngle
+	if Settings["AngleSnap"].GetCurrentValue().(bool) {
+		applied = snap(applied, Settings["AngleStep"].GetCurrentValue().(float32))
+	}
+	if applied != g.dragAppliedAngle {
+		g.target.Rotate(g.activeAxis, applied-g.dragAppliedAngle)
+		g.dragAppliedAngle = applied
+	}
+}
+
+// dragScale scales the target on the active axis. The scale factor is the ratio
+// of the dragged distance and the length of the handle. In case of model target,
+// the positions of the child meshes are also scaled, so that the model keeps its shape.
+func (g *TransformGizmo) dragScale(r *Ray, cameraPosition mgl32.Vec3) {
+	param, ok := r.ClosestOnLine(g.dragStartPivot, GizmoAxises[g.activeAxis])
+	if !ok {
+		return
+	}
+	factor := 1 + (param-g.dragStartParam)/(GizmoHandleLength*g.size(cameraPosition))
+	if Settings["ScaleSnap"].GetCurrentValue().(bool) {
+		factor = snap(factor, Settings["ScaleStep"].GetCurrentValue().(float32))
+	}
+	if factor < GizmoMinScale {
+		factor = GizmoMinScale
+	}
+	ratio := mgl32.Vec3{1, 1, 1}
+	ratio[g.activeAxis] = factor
+	for _, msh := range g.target.Meshes() {
+		if g.target.msh != nil || msh.IsParentMesh() {
+			setMeshScale(msh, mulVec3(g.dragStartScale[msh], ratio))
+		} else {
+			msh.SetPosition(mulVec3(g.dragStartPosition[msh], ratio))
+		}
+	}
+}
+
+// angleOnPlane returns the angle (deg) of the intersection point of the ray and
+// the plane that goes through the pivot and perpendicular to the axis. The angle
+// is measured in the right handed system of the axis.
+func (g *TransformGizmo) angleOnPlane(axis int, pivot mgl32.Vec3, r *Ray) (float32, bool) {
+	t, ok := r.IntersectPlane(pivot, GizmoAxises[axis])
+	if !ok {
+		return 0, false
+	}
+	v := r.PointAt(t).Sub(pivot)
+	u := v.Dot(GizmoAxises[(axis+1)%3])
+	w := v.Dot(GizmoAxises[(axis+2)%3])
+	return mgl32.RadToDeg(float32(math.Atan2(float64(w), float64(u)))), true
+}
+
+// modelMeshes returns the meshes of the given model.
+func modelMeshes(mdl interfaces.Model) []interfaces.Mesh {
+	var meshes []interfaces.Mesh
+	indexable, ok := mdl.(interface {
+		GetMeshByIndex(int) (interfaces.Mesh, error)
+	})
+	if !ok {
+		return meshes
+	}
+	for i := 0; ; i++ {
+		msh, err := indexable.GetMeshByIndex(i)
+		if err != nil {
+			break
+		}
+		meshes = append(meshes, msh)
+	}
+	return meshes
+}
+
+// meshWorldPosition returns the position of the mesh in the world coordinate
+// system. The position of the child meshes are relative to the parent position.
+func meshWorldPosition(msh interfaces.Mesh) mgl32.Vec3 {
+	return msh.TranslationTransformation().Col(3).Vec3()
+}
+
+// meshScale returns the own scale of the mesh. The scale transformation contains
+// the scale of the parent, so that it has to be removed.
+func meshScale(msh interfaces.Mesh) mgl32.Vec3 {
+	tr := msh.ScaleTransformation()
+	scale := mgl32.Vec3{tr.At(0, 0), tr.At(1, 1), tr.At(2, 2)}
+	if !msh.IsParentMesh() {
+		parentTr := msh.GetParent().ScaleTransformation()
+		scale = mgl32.Vec3{scale.X() / parentTr.At(0, 0), scale.Y() / parentTr.At(1, 1), scale.Z() / parentTr.At(2, 2)}
+	}
+	return scale
+}
+
+// setMeshScale updates the scale of the mesh, if the mesh is scalable.
+func setMeshScale(msh interfaces.Mesh, scale mgl32.Vec3) {
+	if scalable, ok := msh.(interface{ SetScale(mgl32.Vec3) }); ok {
+		scalable.SetScale(scale)
+	}
+}
+
+// mulVec3 returns the component-wise product of the vectors.
+func mulVec3(a, b mgl32.Vec3) mgl32.Vec3 {
+	return mgl32.Vec3{a.X() * b.X(), a.Y() * b.Y(), a.Z() * b.Z()}
+}
+
+// snap returns the closest multiple of the step to the value.
+func snap(value, step float32) float32 {
+	if step <= 0 {
+		return value
+	}
+	return float32(math.Round(float64(value/step))) * step
+}
+
+// PickTarget returns the target that is hit by the ray. If the ray doesn't hit
+// any of the pickable models, it returns nil.
+func PickTarget(r *Ray) *GizmoTarget {
+	var target *GizmoTarget
+	closestDistance := float32(math.MaxFloat32)
+	for _, mdl := range Pickable {
+		for _, msh := range modelMeshes(mdl) {
+			if distance, ok := r.IntersectMesh(msh); ok && distance < closestDistance {
+				closestDistance = distance
+				target = &GizmoTarget{mdl: mdl}
+				if Settings["SelectMesh"].GetCurrentValue().(bool) {
+					target.msh = msh
+				}
+			}
+		}
+	}
+	return target
+}
+
+// cursorRay returns the ray that goes through the current cursor position.
+func cursorRay() *Ray {
+	posX, posY := app.GetWindow().GetCursorPos()
+	windowWidth, windowHeight := app.GetWindow().GetSize()
+	mX, mY := transformations.MouseCoordinates(posX, posY, float64(windowWidth), float64(windowHeight))
+	return NewRayFromCursor(AppScreen.GetCamera(), mX, mY)
+}
+
+func createSettings(defaults config.Config) *focus.FormScreen {
+	formItemOrders := []string{
+		"RoomPosition",
+		"LampPosition",
+		"BugPosition",
+		"GizmoSize", "SelectMesh",
+		"GridSnap", "GridStep",
+		"AngleSnap", "AngleStep",
+		"ScaleSnap", "ScaleStep",
+		"ExportWorldSpace",
+
+		"CameraPos",
+		"WorldUp",
+		"CameraYaw", "CameraPitch",
+		"CameraNear", "CameraFar",
+		"CameraFov", "CameraVelocity",
+		"CameraRotation", "CameraRotationEdge",
+	}
+	form := tooltip.NewFormScreen(app.BuildFormScreen(defaults, formItemOrders, "Transform gizmo"), theme.Default, glWrapper)
+	return focus.NewFormScreen(form, formItemOrders, theme.Default)
+}
+
+func createMenu() *focus.MenuScreen {
+	showAll := func(m map[string]bool) bool {
+		return true
+	}
+	showIfStarted := func(m map[string]bool) bool {
+		return m["world-started"]
+	}
+	showIfNotStarted := func(m map[string]bool) bool {
+		return !m["world-started"]
+	}
+	restartEvent := func() {
+		lastUpdate = time.Now().UnixNano()
+		startTime = lastUpdate
+		AppScreen = mainScreen()
+		app.ActivateScreen(AppScreen)
+	}
+	startEvent := func() {
+		lastUpdate = time.Now().UnixNano()
+		startTime = lastUpdate
+		AppScreen = mainScreen()
+		app.ActivateScreen(AppScreen)
+		MenuScreen.SetState("world-started", true)
+		MenuScreen.BuildScreen()
+	}
+	settingsEvent := func() {
+		app.ActivateScreen(SettingsScreen)
+	}
+	exitEvent := func() {
+		app.GetWindow().SetShouldClose(true)
+	}
+	continueEvent := func() {
+		app.ActivateScreen(AppScreen)
+	}
+	options := []screen.Option{
+		*screen.NewMenuScreenOption("Continue", showIfStarted, continueEvent),
+		*screen.NewMenuScreenOption("Start", showIfNotStarted, startEvent),
+		*screen.NewMenuScreenOption("Restart", showIfStarted, restartEvent),
+		*screen.NewMenuScreenOption("Settings", showAll, settingsEvent),
+		*screen.NewMenuScreenOption("Exit", showAll, exitEvent),
+	}
+	return focus.NewMenuScreen(app.BuildMenuScreen(options), theme.Default)
+}
+func Update() {
+	nowNano := time.Now().UnixNano()
+	delta := float64(nowNano-lastUpdate) / float64(time.Millisecond)
+	lastUpdate = nowNano
+	app.Update(delta)
+	// The gizmo is only handled on the world screen.
+	if app.GetCamera() != AppScreen.GetCamera() {
+		PickButtonWasDown = false
+		return
+	}
+	if app.GetKeyState(TRANSLATE_MODE_BUTTON) {
+		Gizmo.SetMode(GizmoModeTranslate)
+	} else if app.GetKeyState(ROTATE_MODE_BUTTON) {
+		Gizmo.SetMode(GizmoModeRotate)
+	} else if app.GetKeyState(SCALE_MODE_BUTTON) {
+		Gizmo.SetMode(GizmoModeScale)
+	}
+	exportButtonDown := app.GetKeyState(EXPORT_BUTTON)
+	if exportButtonDown && !ExportButtonWasDown {
+		ExportScene()
+	}
+	ExportButtonWasDown = exportButtonDown
+	cameraPosition := AppScreen.GetCamera().GetPosition()
+	Gizmo.UpdateHandles(cameraPosition)
+	pickButtonDown := app.GetMouseButtonState(PICK_BUTTON)
+	if pickButtonDown && !PickButtonWasDown {
+		ray := cursorRay()
+		// The handles are in front of everything, so that they are tested first.
+		if axis := Gizmo.PickHandle(ray); axis >= 0 {
+			Gizmo.StartDrag(axis, ray)
+		} else {
+			Gizmo.SetTarget(PickTarget(ray))
+		}
+	} else if pickButtonDown && Gizmo.IsDragging() {
+		Gizmo.Drag(cursorRay(), cameraPosition)
+		Gizmo.UpdateHandles(cameraPosition)
+	} else if !pickButtonDown && Gizmo.IsDragging() {
+		Gizmo.StopDrag()
+	}
+	PickButtonWasDown = pickButtonDown
+}
+
+// ExportScene writes the ground and the pickable models to the export
+// directory as wavefront object and material files.
+func ExportScene() {
+	if err := os.MkdirAll(ExportDirectory, 0755); err != nil {
+		fmt.Printf("Export failed. '%s'\n", err.Error())
+		return
+	}
+	exporter := objexport.New()
+	exporter.SetWorldSpace(Settings["ExportWorldSpace"].GetCurrentValue().(bool))
+	exporter.AddModel("Ground", Ground)
+	for i, mdl := range Pickable {
+		exporter.AddModel(fmt.Sprintf("Model_%d", i), mdl)
+	}
+	if err := exporter.Export(ExportDirectory, ExportName); err != nil {
+		fmt.Printf("Export failed. '%s'\n", err.Error())
+		return
+	}
+	fmt.Printf("Scene exported to '%s'.\n", path.Join(ExportDirectory, ExportName+".obj"))
+}
+func CreateGround() *model.Terrain {
+	gb := model.NewTerrainBuilder()
+	gb.SetWidth(4)
+	gb.SetLength(4)
+	gb.SetIterations(10)
+	gb.SetScale(mgl32.Vec3{5, 1, 5})
+	gb.SetGlWrapper(glWrapper)
+	gb.SurfaceTextureGrass()
+	gb.SetPeakProbability(0)
+	gb.SetCliffProbability(0)
+	gb.SetMinHeight(0)
+	gb.SetMaxHeight(0)
+	gb.SetPosition(mgl32.Vec3{0.0, 0.0, 0.0})
+	gb.SetSeed(0)
+	Ground := gb.Build()
+	return Ground
+}
+func setupApp(glWrapper interfaces.GLWrapper) {
+	glWrapper.Enable(glwrapper.DEPTH_TEST)
+	glWrapper.DepthFunc(glwrapper.LESS)
+	glWrapper.ClearColor(0.0, 0.25, 0.5, 1.0)
+}
+func GenerateRoom() *model.Room {
+	builder := model.NewRoomBuilder()
+	builder.SetWrapper(glWrapper)
+	builder.SetPosition(Settings["RoomPosition"].GetCurrentValue().(mgl32.Vec3))
+	builder.SetRotation(0, 0, 180)
+	builder.WithClosedDoor()
+	return builder.BuildMaterial()
+}
+func GenerateStreetLamp() *model.StreetLamp {
+	builder := model.NewStreetLampBuilder()
+	builder.SetWrapper(glWrapper)
+	builder.SetPosition(Settings["LampPosition"].GetCurrentValue().(mgl32.Vec3))
+	builder.SetPoleLength(1.5)
+	builder.SetRotation(90, 0, 0)
+	builder.SetLampOn(false)
+	return builder.BuildMaterial()
+}
+func GenerateBug() *model.Bug {
+	builder := model.NewBugBuilder()
+	builder.SetWrapper(glWrapper)
+	builder.SetPosition(Settings["BugPosition"].GetCurrentValue().(mgl32.Vec3))
+	builder.SetScale(mgl32.Vec3{0.2, 0.2, 0.2})
+	builder.SetWithLight(false)
+	return builder.BuildMaterial()
+}
+func mainScreen() *screen.Screen {
+	scrn := screen.New()
+	scrn.SetupCamera(CreateCameraFromSettings(), CameraMovementOptions())
+	// Shader application for the textured meshes.
+	shaderProgramTexture := shader.NewTextureShader(glWrapper)
+	scrn.AddShader(shaderProgramTexture)
+	Ground = CreateGround()
+	scrn.AddModelToShader(Ground, shaderProgramTexture)
+
+	// Shader application for the material objects
+	shaderProgramMaterial := shader.NewMaterialShader(glWrapper)
+	scrn.AddShader(shaderProgramMaterial)
+	Pickable = []interfaces.Model{GenerateRoom(), GenerateStreetLamp(), GenerateBug()}
+	for _, mdl := range Pickable {
+		scrn.AddModelToShader(mdl, shaderProgramMaterial)
+	}
+
+	// Shader application for the gizmo.
+	shaderProgramGizmo := shader.NewShader(baseDir()+"/shaders/vertexshader.vert", baseDir()+"/shaders/fragmentshader.frag", glWrapper)
+	scrn.AddShader(shaderProgramGizmo)
+	Gizmo = NewTransformGizmo()
+	scrn.AddModelToShader(Gizmo, shaderProgramGizmo)
+
+	// directional light is coming from the up direction but not from too up.
+	DirectionalLightSource := light.NewDirectionalLight([4]mgl32.Vec3{
+		DirectionalLightDirection,
+		DirectionalLightAmbient,
+		DirectionalLightDiffuse,
+		DirectionalLightSpecular,
+	})
+	// Add the lightources to the application
+	scrn.AddDirectionalLightSource(DirectionalLightSource, [4]string{"dirLight[0].direction", "dirLight[0].ambient", "dirLight[0].diffuse", "dirLight[0].specular"})
+	scrn.Setup(setupApp)
+	return scrn
+}
+
+// It creates a new camera with the necessary setup from settings screen
+func CreateCameraFromSettings() interfaces.Camera {
+	cameraPosition := Settings["CameraPos"].GetCurrentValue().(mgl32.Vec3)
+	worldUp := Settings["WorldUp"].GetCurrentValue().(mgl32.Vec3)
+	yawAngle := Settings["CameraYaw"].GetCurrentValue().(float32)
+	pitchAngle := Settings["CameraPitch"].GetCurrentValue().(float32)
+	fov := Settings["CameraFov"].GetCurrentValue().(float32)
+	near := Settings["CameraNear"].GetCurrentValue().(float32)
+	far := Settings["CameraFar"].GetCurrentValue().(float32)
+	moveSpeed := Settings["CameraVelocity"].GetCurrentValue().(float32)
+	directionSpeed := Settings["CameraRotation"].GetCurrentValue().(float32)
+	cam := camera.NewCamera(cameraPosition, worldUp, yawAngle, pitchAngle)
+	cam.SetupProjection(fov, float32(WindowWidth)/float32(WindowHeight), near, far)
+	cam.SetVelocity(moveSpeed)
+	cam.SetRotationStep(directionSpeed)
+	return cam
+}
+
+// Setup options for the camera
+func CameraMovementOptions() map[string]interface{} {
+	cm := make(map[string]interface{})
+	cm["forward"] = []glfw.Key{glfw.KeyW}
+	cm["back"] = []glfw.Key{glfw.KeyS}
+	cm["up"] = []glfw.Key{glfw.KeyQ}
+	cm["down"] = []glfw.Key{glfw.KeyE}
+	cm["left"] = []glfw.Key{glfw.KeyA}
+	cm["right"] = []glfw.Key{glfw.KeyD}
+	cm["rotateOnEdgeDistance"] = Settings["CameraRotationEdge"].GetCurrentValue().(float32)
+	cm["mode"] = "default"
+	return cm
+}
+func baseDir() string {
+	_, filename, _, _ := runtime.Caller(1)
+	return path.Dir(filename)
+}
+
+func main() {
+	app = application.New(glWrapper)
+	app.SetWindow(window.InitGlfw(WindowWidth, WindowHeight, WindowTitle))
+	defer glfw.Terminate()
+	glWrapper.InitOpenGL()
+
+	AppScreen = mainScreen()
+	app.AddScreen(AppScreen)
+	app.GetWindow().SetKeyCallback(app.KeyCallback)
+	app.GetWindow().SetMouseButtonCallback(app.MouseButtonCallback)
+	app.GetWindow().SetCharCallback(app.CharCallback)
+	MenuScreen = createMenu()
+	app.AddScreen(MenuScreen)
+	app.MenuScreen(MenuScreen)
+	SettingsScreen = createSettings(Settings)
+	app.AddScreen(SettingsScreen)
+	app.ActivateScreen(MenuScreen)
+
+	for !app.GetWindow().ShouldClose() {
+		glWrapper.Clear(glwrapper.COLOR_BUFFER_BIT | glwrapper.DEPTH_BUFFER_BIT)
+		app.Draw(glWrapper)
+		Update()
+		glfw.PollEvents()
+		app.GetWindow().SwapBuffers()
+	}
+}
@@ -0,0 +1,942 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/akosgarai/playground_engine/pkg/application"
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/light"
+	"github.com/akosgarai/playground_engine/pkg/material"
+	"github.com/akosgarai/playground_engine/pkg/mesh"
+	"github.com/akosgarai/playground_engine/pkg/model"
+	"github.com/akosgarai/playground_engine/pkg/primitives/rectangle"
+	"github.com/akosgarai/playground_engine/pkg/primitives/vertex"
+	"github.com/akosgarai/playground_engine/pkg/screen"
+	"github.com/akosgarai/playground_engine/pkg/shader"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+	"github.com/akosgarai/playground_engine/pkg/window"
+
+	"github.com/akosgarai/opengl_playground/pkg/gltfimport"
+	"github.com/akosgarai/opengl_playground/pkg/objimport"
+
+	"github.com/akosgarai/coldet"
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	WindowWidth  = 800
+	WindowHeight = 800
+	WindowTitle  = "Model viewer"
+
+	LEFT_MOUSE_BUTTON = glfw.MouseButtonLeft
+
+	// The keys of the view modes and the camera reset.
+	ShadedViewKey    = glfw.Key1
+	WireframeViewKey = glfw.Key2
+	NormalsViewKey   = glfw.Key3
+	UVCheckerViewKey = glfw.Key4
+	FrameModelKey    = glfw.KeyF
+
+	// The keyboard movement of the orbit camera is handled as radians (orbit)
+	// and as fraction of the distance (zoom) per millisecond.
+	CameraMoveSpeed = float32(0.001)
+	// The mouse drag is handled as radians per pixel.
+	CameraDragSpeed = float32(0.01)
+	// The scroll zooms with this fraction of the distance per step.
+	CameraScrollSpeed = float32(0.1)
+	CameraFov         = float32(45)
+	// The default direction of the camera from the target.
+	CameraDefaultYaw   = float32(math.Pi / 4)
+	CameraDefaultPitch = float32(math.Pi / 8)
+	CameraMaxPitch     = float32(math.Pi/2 - 0.01)
+	// The bounding sphere of the model fills the 1/FrameMargin part of the screen.
+	FrameMargin = float32(1.2)
+	// The radius that is used for the empty and the flat models.
+	MinimumRadius = float32(0.001)
+
+	// The length of the normal lines in the percentage of the bounding sphere radius.
+	NormalLineLength = float32(0.05)
+	UVCheckerSize    = 512
+	UVCheckerCells   = 8
+
+	HudFontFile   = "/../../assets/fonts/Desyrel/desyrel.regular.ttf"
+	HudLineHeight = float32(0.05)
+	HudMargin     = float32(0.02)
+	// The texts are printed a bit over the panel.
+	HudTextZ = float32(0.01)
+
+	// The names of the shaders, the meshes are grouped by them.
+	PointShaderName        = "point"
+	MaterialShaderName     = "material"
+	TextureColorShaderName = "texturecolor"
+	TextureMatShaderName   = "texturemat"
+)
+
+// ViewMode is the display mode of the loaded model.
+type ViewMode int
+
+// The view modes are in the same order as their keys.
+const (
+	ShadedView ViewMode = iota
+	WireframeView
+	NormalsView
+	UVCheckerView
+)
+
+// String returns the name of the view mode, it is displayed on the stats panel.
+func (v ViewMode) String() string {
+	switch v {
+	case WireframeView:
+		return "wireframe"
+	case NormalsView:
+		return "normals"
+	case UVCheckerView:
+		return "uv checker"
+	}
+	return "shaded"
+}
+
+// MeshImporter is implemented by the wavefront and the gltf importers.
+type MeshImporter interface {
+	Import()
+	GetMeshes() []interfaces.Mesh
+}
+
+// OrbitCamera is a camera that is rotating around a target point. The position
+// is calculated from the distance, the yaw and the pitch angle (radians). The
+// Walk function zooms, the Strafe and the Lift functions orbit around the target.
+type OrbitCamera struct {
+	target      mgl32.Vec3
+	distance    float32
+	minDistance float32
+	maxDistance float32
+	yaw         float32
+	pitch       float32
+
+	fov         float32
+	aspectRatio float32
+	near        float32
+	far         float32
+
+	velocity     float32
+	rotationStep float32
+}
+
+// NewOrbitCamera returns an orbit camera that is looking to the origo from the unit distance.
+func NewOrbitCamera(fov, aspectRatio float32) *OrbitCamera {
+	c := &OrbitCamera{
+		fov:          fov,
+		aspectRatio:  aspectRatio,
+		velocity:     CameraMoveSpeed,
+		rotationStep: CameraMoveSpeed,
+	}
+	c.Frame(mgl32.Vec3{0, 0, 0}, 1)
+	return c
+}
+
+// Frame sets the target to the center and the distance from the radius, so that
+// the whole bounding sphere is visible. The clip planes and the zoom limits
+// are calculated from the radius, the orbit angles are reset.
+func (c *OrbitCamera) Frame(center mgl32.Vec3, radius float32) {
+	if radius < MinimumRadius {
+		radius = MinimumRadius
+	}
+	halfFov := float64(mgl32.DegToRad(c.fov)) / 2
+	if c.aspectRatio < 1 {
+		halfFov = math.Atan(math.Tan(halfFov) * float64(c.aspectRatio))
+	}
+	c.target = center
+	c.distance = radius / float32(math.Sin(halfFov)) * FrameMargin
+	c.minDistance = radius * 0.05
+	c.maxDistance = c.distance * 10
+	c.near = radius * 0.01
+	c.far = c.maxDistance + radius*2
+	c.yaw = CameraDefaultYaw
+	c.pitch = CameraDefaultPitch
+}
+
+// positionFrom returns the camera position of the given orbit parameters.
+func (c *OrbitCamera) positionFrom(distance, yaw, pitch float32) mgl32.Vec3 {
+	cosPitch := float32(math.Cos(float64(pitch)))
+	direction := mgl32.Vec3{
+		cosPitch * float32(math.Cos(float64(yaw))),
+		float32(math.Sin(float64(pitch))),
+		cosPitch * float32(math.Sin(float64(yaw))),
+	}
+	return c.target.Add(direction.Mul(distance))
+}
+
+// zoomedDistance returns the distance after the zoom with the given amount.
+func (c *OrbitCamera) zoomedDistance(amount float32) float32 {
+	distance := c.distance * (1 - amount)
+	if distance < c.minDistance {
+		return c.minDistance
+	}
+	if distance > c.maxDistance {
+		return c.maxDistance
+	}
+	return distance
+}
+
+// liftedPitch returns the pitch after the lift with the given amount. It is
+// limited, so that the camera could not turn over the poles.
+func (c *OrbitCamera) liftedPitch(amount float32) float32 {
+	pitch := c.pitch + amount
+	if pitch > CameraMaxPitch {
+		return CameraMaxPitch
+	}
+	if pitch < -CameraMaxPitch {
+		return -CameraMaxPitch
+	}
+	return pitch
+}
+
+// Log returns the string representation of the camera.
+func (c *OrbitCamera) Log() string {
+	return fmt.Sprintf("OrbitCamera:\n - target: %v\n - distance: %f\n - yaw: %f\n - pitch: %f\n", c.target, c.distance, c.yaw, c.pitch)
+}
+
+// GetViewMatrix returns the view matrix, the camera is looking to the target.
+func (c *OrbitCamera) GetViewMatrix() mgl32.Mat4 {
+	return mgl32.LookAtV(c.GetPosition(), c.target, mgl32.Vec3{0, 1, 0})
+}
+
+// GetProjectionMatrix returns the perspective projection matrix.
+func (c *OrbitCamera) GetProjectionMatrix() mgl32.Mat4 {
+	return mgl32.Perspective(mgl32.DegToRad(c.fov), c.aspectRatio, c.near, c.far)
+}
+
+// Walk zooms to the target with the given fraction of the distance.
+func (c *OrbitCamera) Walk(amount float32) {
+	c.distance = c.zoomedDistance(amount)
+}
+
+// Strafe orbits around the vertical axis with the given angle.
+func (c *OrbitCamera) Strafe(amount float32) {
+	c.yaw = c.yaw - amount
+}
+
+// Lift orbits up with the given angle.
+func (c *OrbitCamera) Lift(amount float32) {
+	c.pitch = c.liftedPitch(amount)
+}
+
+// UpdateDirection orbits the camera with the given angles.
+func (c *OrbitCamera) UpdateDirection(amountX, amountY float32) {
+	c.Strafe(amountX)
+	c.Lift(amountY)
+}
+
+// GetPosition returns the position of the camera.
+func (c *OrbitCamera) GetPosition() mgl32.Vec3 {
+	return c.positionFrom(c.distance, c.yaw, c.pitch)
+}
+
+// GetVelocity returns the velocity of the keyboard movement.
+func (c *OrbitCamera) GetVelocity() float32 {
+	return c.velocity
+}
+
+// GetRotationStep returns the rotation step of the keyboard rotation.
+func (c *OrbitCamera) GetRotationStep() float32 {
+	return c.rotationStep
+}
+
+// BoundingObjectAfterWalk returns the bounding object of the new position.
+func (c *OrbitCamera) BoundingObjectAfterWalk(amount float32) *coldet.Sphere {
+	return c.boundingSphere(c.positionFrom(c.zoomedDistance(amount), c.yaw, c.pitch))
+}
+
+// BoundingObjectAfterStrafe returns the bounding object of the new position.
+func (c *OrbitCamera) BoundingObjectAfterStrafe(amount float32) *coldet.Sphere {
+	return c.boundingSphere(c.positionFrom(c.distance, c.yaw-amount, c.pitch))
+}
+
+// BoundingObjectAfterLift returns the bounding object of the new position.
+func (c *OrbitCamera) BoundingObjectAfterLift(amount float32) *coldet.Sphere {
+	return c.boundingSphere(c.positionFrom(c.distance, c.yaw, c.liftedPitch(amount)))
+}
+func (c *OrbitCamera) boundingSphere(p mgl32.Vec3) *coldet.Sphere {
+	return coldet.NewBoundingSphere([3]float32{p.X(), p.Y(), p.Z()}, c.near)
+}
+
+// LineMesh is a mesh that is drawn as line segments. Every second vertex is
+// the end of a segment. The vertices are stored in the same format as in the
+// point mesh, so that it could be drawn with the point shader.
+type LineMesh struct {
+	*mesh.PointMesh
+	vao uint32
+}
+
+// NewLineMesh returns a line mesh with the given vertices.
+func NewLineMesh(v vertex.Vertices) *LineMesh {
+	m := &LineMesh{
+		PointMesh: mesh.NewPointMesh(glWrapper),
+	}
+	m.Vertices = v
+	m.setup()
+	return m
+}
+func (m *LineMesh) setup() {
+	m.vao = glWrapper.GenVertexArrays()
+	vbo := glWrapper.GenBuffers()
+
+	glWrapper.BindVertexArray(m.vao)
+
+	glWrapper.BindBuffer(glwrapper.ARRAY_BUFFER, vbo)
+	glWrapper.ArrayBufferData(m.Vertices.Get(vertex.POSITION_COLOR_SIZE))
+
+	// setup coordinates
+	glWrapper.VertexAttribPointer(0, 3, glwrapper.FLOAT, false, 4*7, glWrapper.PtrOffset(0))
+	// setup color vector
+	glWrapper.VertexAttribPointer(1, 3, glwrapper.FLOAT, false, 4*7, glWrapper.PtrOffset(4*3))
+	// setup point size
+	glWrapper.VertexAttribPointer(2, 1, glwrapper.FLOAT, false, 4*7, glWrapper.PtrOffset(4*6))
+
+	// close
+	glWrapper.BindVertexArray(0)
+}
+
+// Draw function binds the model uniform and draws the vertices as lines.
+func (m *LineMesh) Draw(shader interfaces.Shader) {
+	M := m.ModelTransformation()
+	shader.SetUniformMat4("model", M)
+	glWrapper.BindVertexArray(m.vao)
+	glWrapper.DrawArrays(gl.LINES, 0, int32(len(m.Vertices)))
+
+	glWrapper.BindVertexArray(0)
+}
+
+// BoundingBox is an axis aligned box in the world coordinate system.
+type BoundingBox struct {
+	Min   mgl32.Vec3
+	Max   mgl32.Vec3
+	empty bool
+}
+
+// NewBoundingBox returns an empty bounding box.
+func NewBoundingBox() *BoundingBox {
+	return &BoundingBox{empty: true}
+}
+
+// Add extends the box with the given point.
+func (b *BoundingBox) Add(p mgl32.Vec3) {
+	if b.empty {
+		b.Min = p
+		b.Max = p
+		b.empty = false
+		return
+	}
+	for i := 0; i < 3; i++ {
+		if p[i] < b.Min[i] {
+			b.Min[i] = p[i]
+		}
+		if p[i] > b.Max[i] {
+			b.Max[i] = p[i]
+		}
+	}
+}
+
+// Center returns the center point of the box.
+func (b *BoundingBox) Center() mgl32.Vec3 {
+	return b.Min.Add(b.Max).Mul(0.5)
+}
+
+// Radius returns the radius of the bounding sphere of the box.
+func (b *BoundingBox) Radius() float32 {
+	return b.Max.Sub(b.Min).Len() / 2
+}
+
+// ModelStats contains the numbers that are displayed on the stats panel.
+type ModelStats struct {
+	Vertices  int
+	Triangles int
+	Materials int
+	Textures  int
+}
+
+// ViewedModel is the loaded file with the models of the view modes.
+type ViewedModel struct {
+	Name   string
+	Meshes []interfaces.Mesh
+	// The models of the shaded view, the keys are the shader names.
+	Models map[string]*model.BaseModel
+	// The line models of the wireframe and the normals view.
+	Wireframe *model.BaseModel
+	Normals   *model.BaseModel
+	Bounds    *BoundingBox
+	Stats     ModelStats
+	// The original textures of the textured meshes, they are
+	// restored when the uv checker view is turned off.
+	textures map[interfaces.Mesh]texture.Textures
+}
+
+// NewImporter returns the gltf importer for the .gltf and .glb files
+// and the wavefront importer for the others.
+func NewImporter(directory, filename string) MeshImporter {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".gltf", ".glb":
+		return gltfimport.New(directory, filename, glWrapper)
+	}
+	return objimport.New(directory, filename, glWrapper)
+}
+
+// LoadModel imports the given file and sets up the models of the view modes.
+// The importers panic on the invalid files, it is returned as error, so that
+// the viewer could keep the current model.
+func LoadModel(filePath string) (result *ViewedModel, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Unable to load '%s': %v", filePath, r)
+		}
+	}()
+	importer := NewImporter(filepath.Dir(filePath), filepath.Base(filePath))
+	importer.Import()
+	meshes := importer.GetMeshes()
+	if len(meshes) == 0 {
+		return nil, fmt.Errorf("The '%s' file does not contain meshes.", filePath)
+	}
+	vm := &ViewedModel{
+		Name:      filepath.Base(filePath),
+		Meshes:    meshes,
+		Models:    make(map[string]*model.BaseModel),
+		Wireframe: model.New(),
+		Normals:   model.New(),
+		Bounds:    NewBoundingBox(),
+		textures:  make(map[interfaces.Mesh]texture.Textures),
+	}
+	for _, m := range meshes {
+		vm.addMeshToRightModel(m)
+		vm.Bounds.Add(m.GetPosition())
+		vertices, _ := meshGeometry(m)
+		M := m.ModelTransformation()
+		for _, v := range vertices {
+			vm.Bounds.Add(mgl32.TransformCoordinate(v.Position, M))
+		}
+	}
+	vm.Stats = meshStats(meshes)
+	normalLength := vm.Bounds.Radius() * NormalLineLength
+	for _, m := range meshes {
+		vm.addLineMeshes(m, normalLength)
+	}
+	return vm, nil
+}
+func (vm *ViewedModel) addMeshToRightModel(m interfaces.Mesh) {
+	var shaderName string
+	switch m.(type) {
+	case *mesh.TexturedMaterialMesh:
+		shaderName = TextureMatShaderName
+	case *mesh.TexturedColoredMesh:
+		shaderName = TextureColorShaderName
+	case *mesh.MaterialMesh:
+		shaderName = MaterialShaderName
+	case *mesh.PointMesh:
+		shaderName = PointShaderName
+	default:
+		return
+	}
+	if _, ok := vm.Models[shaderName]; !ok {
+		vm.Models[shaderName] = model.New()
+	}
+	vm.Models[shaderName].AddMesh(m)
+}
+
+// addLineMeshes builds the wireframe and the normal lines of the mesh. The
+// lines are in the coordinate system of the mesh, it is set as parent.
+func (vm *ViewedModel) addLineMeshes(m interfaces.Mesh, normalLength float32) {
+	vertices, indices := meshGeometry(m)
+	var edges, normals vertex.Vertices
+	added := make(map[[2]uint32]bool)
+	for i := 0; i+2 < len(indices); i += 3 {
+		for j := 0; j < 3; j++ {
+			a, b := indices[i+j], indices[i+(j+1)%3]
+			if a > b {
+				a, b = b, a
+			}
+			if added[[2]uint32{a, b}] {
+				continue
+			}
+			added[[2]uint32{a, b}] = true
+			edges = append(edges, lineVertex(vertices[a].Position, WireframeColor), lineVertex(vertices[b].Position, WireframeColor))
+		}
+	}
+	switch m.(type) {
+	case *mesh.MaterialMesh, *mesh.TexturedMaterialMesh:
+		for _, v := range vertices {
+			normals = append(normals, lineVertex(v.Position, NormalColor), lineVertex(v.Position.Add(v.Normal.Mul(normalLength)), NormalColor))
+		}
+	}
+	if len(edges) > 0 {
+		wireframe := NewLineMesh(edges)
+		wireframe.SetParent(m)
+		vm.Wireframe.AddMesh(wireframe)
+	}
+	if len(normals) > 0 {
+		normalLines := NewLineMesh(normals)
+		normalLines.SetParent(m)
+		vm.Normals.AddMesh(normalLines)
+	}
+}
+
+// SetCheckerTextures replaces the textures of the textured meshes with the
+// uv checker textures. If the checker is false, the original textures are restored.
+func (vm *ViewedModel) SetCheckerTextures(checker bool) {
+	for _, m := range vm.Meshes {
+		switch tm := m.(type) {
+		case *mesh.TexturedMaterialMesh:
+			if checker {
+				vm.textures[m] = tm.Textures
+				tm.Textures = UVCheckerTextures
+			} else if original, ok := vm.textures[m]; ok {
+				tm.Textures = original
+			}
+		case *mesh.TexturedColoredMesh:
+			if checker {
+				vm.textures[m] = tm.Textures
+				tm.Textures = UVCheckerTextures
+			} else if original, ok := vm.textures[m]; ok {
+				tm.Textures = original
+			}
+		}
+	}
+	if !checker {
+		vm.textures = make(map[interfaces.Mesh]texture.Textures)
+	}
+}
+
+// lineVertex returns a vertex of the line meshes.
+func lineVertex(p, col mgl32.Vec3) vertex.Vertex {
+	return vertex.Vertex{
+		Position:  p,
+		Color:     col,
+		PointSize: 1,
+	}
+}
+
+// meshGeometry returns the vertices and the indices of the mesh.
+// The point meshes don't have indices.
+func meshGeometry(m interfaces.Mesh) (vertex.Vertices, []uint32) {
+	switch msh := m.(type) {
+	case *mesh.TexturedMaterialMesh:
+		return msh.Vertices, msh.Indices
+	case *mesh.TexturedColoredMesh:
+		return msh.Vertices, msh.Indices
+	case *mesh.TexturedMesh:
+		return msh.Vertices, msh.Indices
+	case *mesh.MaterialMesh:
+		return msh.Vertices, msh.Indices
+	case *mesh.ColorMesh:
+		return msh.Vertices, msh.Indices
+	case *mesh.PointMesh:
+		return msh.Vertices, nil
+	}
+	return nil, nil
+}
+
+// meshStats counts the vertices, the triangles, the different materials and
+// the different texture files of the meshes. The importers create a new
+// material for every mesh, so that the materials are compared by value.
+func meshStats(meshes []interfaces.Mesh) ModelStats {
+	type materialKey struct {
+		ambient, diffuse, specular mgl32.Vec3
+		shininess                  float32
+	}
+	var stats ModelStats
+	materials := make(map[materialKey]bool)
+	textures := make(map[string]bool)
+	addMaterial := func(mat *material.Material) {
+		materials[materialKey{mat.GetAmbient(), mat.GetDiffuse(), mat.GetSpecular(), mat.GetShininess()}] = true
+	}
+	addTextures := func(tex texture.Textures) {
+		for _, t := range tex {
+			key := t.FilePath
+			if key == "" {
+				key = fmt.Sprintf("%p", t)
+			}
+			textures[key] = true
+		}
+	}
+	for _, m := range meshes {
+		vertices, indices := meshGeometry(m)
+		stats.Vertices += len(vertices)
+		stats.Triangles += len(indices) / 3
+		switch msh := m.(type) {
+		case *mesh.TexturedMaterialMesh:
+			addMaterial(msh.Material)
+			addTextures(msh.Textures)
+		case *mesh.TexturedColoredMesh:
+			addTextures(msh.Textures)
+		case *mesh.TexturedMesh:
+			addTextures(msh.Textures)
+		case *mesh.MaterialMesh:
+			addMaterial(msh.Material)
+		}
+	}
+	stats.Materials = len(materials)
+	stats.Textures = len(textures)
+	return stats
+}
+
+// UVCheckerImage returns a checker board image. The red component of the
+// cells grows with the u, the green component grows with the v coordinate,
+// so that the orientation of the mapping is also visible.
+func UVCheckerImage(size, cells int) *image.RGBA {
+	rgba := image.NewRGBA(image.Rect(0, 0, size, size))
+	cellSize := size / cells
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			cellX, cellY := x/cellSize, y/cellSize
+			r := uint8(255 * cellX / (cells - 1))
+			g := uint8(255 * (cells - 1 - cellY) / (cells - 1))
+			if (cellX+cellY)%2 == 0 {
+				rgba.Set(x, y, color.RGBA{r, g, 255, 255})
+			} else {
+				rgba.Set(x, y, color.RGBA{r / 3, g / 3, 64, 255})
+			}
+		}
+	}
+	return rgba
+}
+
+// NewUVCheckerTextures returns the checker board as diffuse and specular map.
+// The checker is repeated outside of the [0, 1] interval.
+func NewUVCheckerTextures() texture.Textures {
+	var tex texture.Textures
+	rgba := UVCheckerImage(UVCheckerSize, UVCheckerCells)
+	tex.AddTextureRGBA("uv-checker-gen", rgba, glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "tex.diffuse", glWrapper)
+	tex.AddTextureRGBA("uv-checker-gen", rgba, glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "tex.specular", glWrapper)
+	for _, t := range tex {
+		t.Bind()
+		glWrapper.TexParameteri(glwrapper.TEXTURE_2D, glwrapper.TEXTURE_WRAP_S, gl.REPEAT)
+		glWrapper.TexParameteri(glwrapper.TEXTURE_2D, glwrapper.TEXTURE_WRAP_T, gl.REPEAT)
+		t.UnBind()
+	}
+	return tex
+}
+
+// StatsPanel displays text lines in the top left corner of the screen. The panel
+// and the text are drawn with the hud shaders, they don't depend on the camera.
+type StatsPanel struct {
+	charset     *model.Charset
+	scale       float32
+	panelShader *shader.Shader
+	panel       *model.BaseModel // It is nil, if the panel is empty.
+}
+
+// NewStatsPanel loads the charset and sets up the hud shaders of the screen.
+func NewStatsPanel(scrn *screen.Screen) *StatsPanel {
+	cs, err := model.LoadCharset(baseDir()+HudFontFile, 32, 127, 40.0, 72, glWrapper)
+	if err != nil {
+		panic(err)
+	}
+	cs.SetTransparent(true)
+	_, lineHeight := cs.TextContainerSize("W", 1)
+	sp := &StatsPanel{
+		charset:     cs,
+		scale:       HudLineHeight / lineHeight,
+		panelShader: shader.NewShader(baseDir()+"/shaders/hud.vert", baseDir()+"/shaders/hud.frag", glWrapper),
+	}
+	fontShader := shader.NewShader(baseDir()+"/shaders/hudfont.vert", baseDir()+"/shaders/hudfont.frag", glWrapper)
+	scrn.AddShader(sp.panelShader)
+	scrn.AddShader(fontShader)
+	scrn.AddModelToShader(cs, fontShader)
+	return sp
+}
+
+// Print replaces the content of the panel with the given lines.
+func (sp *StatsPanel) Print(scrn *screen.Screen, lines []string) {
+	if sp.panel != nil {
+		if box, err := sp.panel.GetMeshByIndex(0); err == nil {
+			sp.charset.CleanSurface(box)
+		}
+		scrn.RemoveModelFromShader(sp.panel, sp.panelShader)
+		sp.panel = nil
+	}
+	if len(lines) == 0 {
+		return
+	}
+	wW, hW := sp.charset.TextContainerSize("W", sp.scale)
+	textWidth := float32(0.0)
+	for _, line := range lines {
+		if w := sp.charset.TextWidth(line, sp.scale); w > textWidth {
+			textWidth = w
+		}
+	}
+	width := textWidth + wW
+	height := (float32(len(lines))*1.5 + 0.5) * hW
+	v, i, _ := rectangle.NewExact(width, height).ColoredMeshInput([]mgl32.Vec3{HudPanelColor})
+	box := mesh.NewColorMesh(v, i, []mgl32.Vec3{HudPanelColor}, glWrapper)
+	// The rectangle is on the xz plane, it is rotated to the screen.
+	box.RotateX(-90)
+	box.SetPosition(mgl32.Vec3{-1 + HudMargin + width/2, 1 - HudMargin - height/2, 0})
+	for index, line := range lines {
+		lineVerticalPosition := height/2 - float32(index+1)*1.5*hW
+		sp.charset.PrintTo(line, (-width+wW)/2, lineVerticalPosition, HudTextZ, sp.scale, glWrapper, box, []mgl32.Vec3{HudTextColor})
+	}
+	sp.panel = model.New()
+	sp.panel.AddMesh(box)
+	scrn.AddModelToShader(sp.panel, sp.panelShader)
+}
+
+var (
+	app       *application.Application
+	glWrapper glwrapper.Wrapper
+	Screen    *screen.Screen
+	Camera    *OrbitCamera
+	Panel     *StatsPanel
+	Shaders   map[string]*shader.Shader
+
+	lastUpdate int64
+
+	Viewed      *ViewedModel
+	CurrentView = ShadedView
+	// The file that has been dropped to the window. It is loaded in the next update.
+	DroppedFile = ""
+	// The state of the toggle keys in the previous update, for detecting the key press.
+	keyWasDown = make(map[glfw.Key]bool)
+	// The cursor position of the previous update, for the mouse drag.
+	lastCursorX, lastCursorY float64
+
+	UVCheckerTextures texture.Textures
+
+	WireframeColor = mgl32.Vec3{0.9, 0.9, 0.9}
+	NormalColor    = mgl32.Vec3{0.2, 0.9, 0.9}
+	HudPanelColor  = mgl32.Vec3{0.1, 0.1, 0.15}
+	HudTextColor   = mgl32.Vec3{1.0, 1.0, 1.0}
+
+	DirectionalLightDirection = (mgl32.Vec3{-0.5, -1.0, -0.7}).Normalize()
+	DirectionalLightAmbient   = mgl32.Vec3{0.4, 0.4, 0.4}
+	DirectionalLightDiffuse   = mgl32.Vec3{0.8, 0.8, 0.8}
+	DirectionalLightSpecular  = mgl32.Vec3{0.5, 0.5, 0.5}
+
+	// The shaders also need point and spot light sources, they are turned off.
+	LightOff           = mgl32.Vec3{0, 0, 0}
+	LightConstantTerm  = float32(1.0)
+	LightLinearTerm    = float32(0.14)
+	LightQuadraticTerm = float32(0.07)
+	LightCutoff        = float32(4)
+	LightOuterCutoff   = float32(5)
+
+	DirectionalLightSource = light.NewDirectionalLight([4]mgl32.Vec3{
+		DirectionalLightDirection,
+		DirectionalLightAmbient,
+		DirectionalLightDiffuse,
+		DirectionalLightSpecular,
+	})
+	PointLightSource = light.NewPointLight([4]mgl32.Vec3{
+		LightOff,
+		LightOff,
+		LightOff,
+		LightOff},
+		[3]float32{LightConstantTerm, LightLinearTerm, LightQuadraticTerm})
+	SpotLightSource = light.NewSpotLight([5]mgl32.Vec3{
+		LightOff,
+		mgl32.Vec3{0, -1, 0},
+		LightOff,
+		LightOff,
+		LightOff},
+		[5]float32{LightConstantTerm, LightLinearTerm, LightQuadraticTerm, LightCutoff, LightOuterCutoff})
+)
+
+// Setup options for the camera. The mouse rotation on the edges is turned
+// off, the orbit is handled with mouse drag.
+func CameraMovementOptions() map[string]interface{} {
+	cm := make(map[string]interface{})
+	cm["forward"] = []glfw.Key{glfw.KeyW}
+	cm["back"] = []glfw.Key{glfw.KeyS}
+	cm["up"] = []glfw.Key{glfw.KeyQ}
+	cm["down"] = []glfw.Key{glfw.KeyE}
+	cm["left"] = []glfw.Key{glfw.KeyA}
+	cm["right"] = []glfw.Key{glfw.KeyD}
+	cm["rotateOnEdgeDistance"] = float32(0.0)
+	cm["mode"] = "default"
+	return cm
+}
+
+// OpenFile loads the given file and replaces the current model with it. If the
+// loading fails, the error is printed and the current model is kept.
+func OpenFile(filePath string) {
+	vm, err := LoadModel(filePath)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if Viewed != nil {
+		hideModel(Viewed)
+		Viewed.SetCheckerTextures(false)
+	}
+	Viewed = vm
+	Camera.Frame(Viewed.Bounds.Center(), Viewed.Bounds.Radius())
+	SetView(CurrentView)
+}
+
+// hideModel removes every model of the viewed model from the shaders.
+func hideModel(vm *ViewedModel) {
+	for shaderName, m := range vm.Models {
+		Screen.RemoveModelFromShader(m, Shaders[shaderName])
+	}
+	Screen.RemoveModelFromShader(vm.Wireframe, Shaders[PointShaderName])
+	Screen.RemoveModelFromShader(vm.Normals, Shaders[PointShaderName])
+}
+
+// SetView switches to the given view mode. The wireframe view hides the
+// triangle meshes, the normals view draws the normal lines over the shaded
+// model, the uv checker view replaces the textures of the textured meshes.
+func SetView(mode ViewMode) {
+	CurrentView = mode
+	if Viewed == nil {
+		UpdatePanel()
+		return
+	}
+	hideModel(Viewed)
+	Viewed.SetCheckerTextures(mode == UVCheckerView)
+	for shaderName, m := range Viewed.Models {
+		if mode != WireframeView || shaderName == PointShaderName {
+			Screen.AddModelToShader(m, Shaders[shaderName])
+		}
+	}
+	switch mode {
+	case WireframeView:
+		Screen.AddModelToShader(Viewed.Wireframe, Shaders[PointShaderName])
+	case NormalsView:
+		Screen.AddModelToShader(Viewed.Normals, Shaders[PointShaderName])
+	}
+	UpdatePanel()
+}
+
+// UpdatePanel prints the stats of the viewed model and the view mode.
+func UpdatePanel() {
+	if Viewed == nil {
+		Panel.Print(Screen, []string{
+			"Drop a model file to the window",
+			"View: " + CurrentView.String(),
+		})
+		return
+	}
+	Panel.Print(Screen, []string{
+		Viewed.Name,
+		fmt.Sprintf("Vertices: %d", Viewed.Stats.Vertices),
+		fmt.Sprintf("Triangles: %d", Viewed.Stats.Triangles),
+		fmt.Sprintf("Materials: %d", Viewed.Stats.Materials),
+		fmt.Sprintf("Textures: %d", Viewed.Stats.Textures),
+		"View: " + CurrentView.String(),
+	})
+}
+
+// keyPressed returns true if the key is down, but it was up in the previous update.
+func keyPressed(key glfw.Key) bool {
+	down := app.GetKeyState(key)
+	pressed := down && !keyWasDown[key]
+	keyWasDown[key] = down
+	return pressed
+}
+func Update() {
+	nowNano := time.Now().UnixNano()
+	delta := float64(nowNano-lastUpdate) / float64(time.Millisecond)
+	lastUpdate = nowNano
+
+	if DroppedFile != "" {
+		OpenFile(DroppedFile)
+		DroppedFile = ""
+	}
+	app.Update(delta)
+	viewKeys := []glfw.Key{ShadedViewKey, WireframeViewKey, NormalsViewKey, UVCheckerViewKey}
+	for index, key := range viewKeys {
+		if keyPressed(key) && ViewMode(index) != CurrentView {
+			SetView(ViewMode(index))
+		}
+	}
+	if keyPressed(FrameModelKey) && Viewed != nil {
+		Camera.Frame(Viewed.Bounds.Center(), Viewed.Bounds.Radius())
+	}
+	// Orbit with mouse drag.
+	cursorX, cursorY := app.GetWindow().GetCursorPos()
+	if app.GetMouseButtonState(LEFT_MOUSE_BUTTON) {
+		Camera.UpdateDirection(-float32(cursorX-lastCursorX)*CameraDragSpeed, float32(cursorY-lastCursorY)*CameraDragSpeed)
+	}
+	lastCursorX, lastCursorY = cursorX, cursorY
+}
+func baseDir() string {
+	_, filename, _, _ := runtime.Caller(1)
+	return path.Dir(filename)
+}
+
+func setupApp(glWrapper interfaces.GLWrapper) {
+	glWrapper.Enable(glwrapper.DEPTH_TEST)
+	glWrapper.DepthFunc(glwrapper.LESS)
+	glWrapper.Enable(glwrapper.BLEND)
+	glWrapper.BlendFunc(glwrapper.SRC_APLHA, glwrapper.ONE_MINUS_SRC_ALPHA)
+	glWrapper.ClearColor(0.3, 0.3, 0.3, 1.0)
+}
+
+func main() {
+	runtime.LockOSThread()
+
+	app = application.New(glWrapper)
+	glfwWindow := window.InitGlfw(WindowWidth, WindowHeight, WindowTitle)
+	app.SetWindow(glfwWindow)
+	defer glfw.Terminate()
+	glWrapper.InitOpenGL()
+
+	Screen = screen.New()
+	Camera = NewOrbitCamera(CameraFov, float32(WindowWidth)/float32(WindowHeight))
+	Screen.SetupCamera(Camera, CameraMovementOptions())
+
+	Shaders = map[string]*shader.Shader{
+		PointShaderName:        shader.NewShader(baseDir()+"/shaders/point.vert", baseDir()+"/shaders/point.frag", glWrapper),
+		MaterialShaderName:     shader.NewShader(baseDir()+"/shaders/material.vert", baseDir()+"/shaders/material.frag", glWrapper),
+		TextureColorShaderName: shader.NewShader(baseDir()+"/shaders/texturecolor.vert", baseDir()+"/shaders/texturecolor.frag", glWrapper),
+		TextureMatShaderName:   shader.NewShader(baseDir()+"/shaders/texturemat.vert", baseDir()+"/shaders/texturemat.frag", glWrapper),
+	}
+	for _, sh := range Shaders {
+		Screen.AddShader(sh)
+	}
+	Screen.AddDirectionalLightSource(DirectionalLightSource, [4]string{"dirLight[0].direction", "dirLight[0].ambient", "dirLight[0].diffuse", "dirLight[0].specular"})
+	Screen.AddPointLightSource(PointLightSource, [7]string{"pointLight[0].position", "pointLight[0].ambient", "pointLight[0].diffuse", "pointLight[0].specular", "pointLight[0].constant", "pointLight[0].linear", "pointLight[0].quadratic"})
+	Screen.AddSpotLightSource(SpotLightSource, [10]string{"spotLight[0].position", "spotLight[0].direction", "spotLight[0].ambient", "spotLight[0].diffuse", "spotLight[0].specular", "spotLight[0].constant", "spotLight[0].linear", "spotLight[0].quadratic", "spotLight[0].cutOff", "spotLight[0].outerCutOff"})
+	Panel = NewStatsPanel(Screen)
+	UVCheckerTextures = NewUVCheckerTextures()
+
+	Screen.Setup(setupApp)
+	app.AddScreen(Screen)
+	app.ActivateScreen(Screen)
+
+	if len(os.Args) > 1 {
+		OpenFile(os.Args[1])
+	}
+	if Viewed == nil {
+		UpdatePanel()
+	}
+
+	lastUpdate = time.Now().UnixNano()
+	app.GetWindow().SetKeyCallback(app.KeyCallback)
+	app.GetWindow().SetMouseButtonCallback(app.MouseButtonCallback)
+	glfwWindow.SetDropCallback(func(w *glfw.Window, names []string) {
+		if len(names) > 0 {
+			DroppedFile = names[0]
+		}
+	})
+	glfwWindow.SetScrollCallback(func(w *glfw.Window, xoff, yoff float64) {
+		Camera.Walk(float32(yoff) * CameraScrollSpeed)
+	})
+
+	for !app.GetWindow().ShouldClose() {
+		glWrapper.Clear(glwrapper.COLOR_BUFFER_BIT | glwrapper.DEPTH_BUFFER_BIT)
+		glfw.PollEvents()
+		Update()
+		app.Draw(glWrapper)
+		app.GetWindow().SwapBuffers()
+	}
+}
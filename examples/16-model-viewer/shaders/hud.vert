@@ -0,0 +1,17 @@
+#version 410
+layout(location = 0) in vec3 vVertex;
+layout(location = 1) in vec3 vColor;
+
+smooth out vec3 vSmoothColor;
+
+uniform mat4 model;
+
+// The hud is drawn in the normalized device coordinate system, the view and
+// the projection matrices are not used. The depth is pushed close to the near
+// plane, so that the hud is displayed over the model.
+void main()
+{
+    vSmoothColor = vColor;
+    vec4 position = model * vec4(vVertex, 1);
+    gl_Position = vec4(position.xy, position.z * 0.01 - 0.98, 1);
+}
@@ -0,0 +1,18 @@
+#version 410
+layout(location = 0) in vec3 vVertex;
+layout(location = 1) in vec3 vColor;
+layout(location = 2) in vec2 vTexCoord;
+
+out vec2 TexCoords;
+out vec3 Color;
+
+uniform mat4 model;
+
+// Same as the hud shader, but for the glyphs of the charset.
+void main()
+{
+    TexCoords = vTexCoord;
+    Color = vColor;
+    vec4 position = model * vec4(vVertex, 1);
+    gl_Position = vec4(position.xy, position.z * 0.01 - 0.98, 1);
+}
@@ -3,7 +3,9 @@ module github.com/akosgarai/opengl_playground
 go 1.13
 
 require (
+	github.com/akosgarai/coldet v0.0.0-20200702143610-ca4e38941f5b
 	github.com/akosgarai/playground_engine v0.0.0-20201109163842-e88aab102c47
+	github.com/go-gl/gl v0.0.0-20190320180904-bf2b1f2f34d7
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20200420212212-258d9bec320e
 	github.com/go-gl/mathgl v0.0.0-20190713194549-592312d8590a
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
@@ -0,0 +1,171 @@
+package animation
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// The animated properties of the joints.
+	PATH_TRANSLATION = "translation"
+	PATH_ROTATION    = "rotation"
+	PATH_SCALE       = "scale"
+
+	// The interpolation methods of the channels. They are the same as in the gltf spec.
+	INTERPOLATION_STEP        = "STEP"
+	INTERPOLATION_LINEAR      = "LINEAR"
+	INTERPOLATION_CUBICSPLINE = "CUBICSPLINE"
+)
+
+// Channel animates a property of a joint.
+type Channel struct {
+	// The target of the joint (see Joint.Target).
+	Target int
+	// The animated property, translation, rotation or scale.
+	Path string
+	// The interpolation between the keyframes.
+	Interpolation string
+	// The time of the keyframes in seconds, in ascending order.
+	Times []float32
+	// The values of the keyframes. The rotations are quaternions in x, y, z,
+	// w order. For the cubic spline interpolation every keyframe has three
+	// values, the in-tangent, the value and the out-tangent.
+	Values [][]float32
+}
+
+// validate checks the number and the size of the keyframe values.
+func (c *Channel) validate() error {
+	components := 3
+	switch c.Path {
+	case PATH_TRANSLATION, PATH_SCALE:
+	case PATH_ROTATION:
+		components = 4
+	default:
+		return fmt.Errorf("Unsupported channel path '%s'.", c.Path)
+	}
+	if len(c.Times) == 0 {
+		return errors.New("Missing keyframes.")
+	}
+	valuesPerKey := 1
+	switch c.Interpolation {
+	case INTERPOLATION_STEP, INTERPOLATION_LINEAR:
+	case INTERPOLATION_CUBICSPLINE:
+		valuesPerKey = 3
+	default:
+		return fmt.Errorf("Unsupported interpolation '%s'.", c.Interpolation)
+	}
+	if len(c.Values) != len(c.Times)*valuesPerKey {
+		return fmt.Errorf("The number of the values (%d) doesn't match the number of the keyframes (%d).", len(c.Values), len(c.Times))
+	}
+	for _, value := range c.Values {
+		if len(value) < components {
+			return errors.New("The keyframe value is too short.")
+		}
+	}
+	return nil
+}
+
+// value returns the value of the keyframe. For the cubic
+// spline interpolation it skips the tangents.
+func (c *Channel) value(key int) []float32 {
+	if c.Interpolation == INTERPOLATION_CUBICSPLINE {
+		return c.Values[key*3+1]
+	}
+	return c.Values[key]
+}
+
+// sample returns the interpolated value of the channel at the given time.
+// Before the first and after the last keyframe the values are clamped.
+func (c *Channel) sample(time float32, result []float32) []float32 {
+	last := len(c.Times) - 1
+	if time <= c.Times[0] {
+		return append(result[:0], c.value(0)...)
+	}
+	if time >= c.Times[last] {
+		return append(result[:0], c.value(last)...)
+	}
+	// The index of the first keyframe after the time.
+	next := sort.Search(len(c.Times), func(i int) bool { return c.Times[i] > time })
+	prev := next - 1
+	delta := c.Times[next] - c.Times[prev]
+	weight := (time - c.Times[prev]) / delta
+	result = result[:0]
+	switch c.Interpolation {
+	case INTERPOLATION_STEP:
+		return append(result, c.value(prev)...)
+	case INTERPOLATION_CUBICSPLINE:
+		// Hermite spline with the out-tangent of the previous and the
+		// in-tangent of the next keyframe, scaled with the keyframe delta.
+		t2, t3 := weight*weight, weight*weight*weight
+		p0, m0 := c.Values[prev*3+1], c.Values[prev*3+2]
+		p1, m1 := c.Values[next*3+1], c.Values[next*3]
+		for i := range p0 {
+			result = append(result, (2*t3-3*t2+1)*p0[i]+(t3-2*t2+weight)*delta*m0[i]+(-2*t3+3*t2)*p1[i]+(t3-t2)*delta*m1[i])
+		}
+		return result
+	}
+	a, b := c.value(prev), c.value(next)
+	if c.Path == PATH_ROTATION {
+		q := slerp(quat(a), quat(b), weight)
+		return append(result, q.V.X(), q.V.Y(), q.V.Z(), q.W)
+	}
+	for i := range a {
+		result = append(result, a[i]+(b[i]-a[i])*weight)
+	}
+	return result
+}
+
+// quat returns the quaternion of the x, y, z, w values.
+func quat(v []float32) mgl32.Quat {
+	return mgl32.Quat{W: v[3], V: mgl32.Vec3{v[0], v[1], v[2]}}
+}
+
+// Clip is a named animation, eg. walk or idle.
+type Clip struct {
+	Name     string
+	Channels []Channel
+	// The time of the last keyframe in seconds.
+	Duration float32
+}
+
+// NewClip returns a clip with the given channels. The duration is
+// the time of the last keyframe. It returns error if a channel is invalid.
+func NewClip(name string, channels []Channel) (*Clip, error) {
+	c := &Clip{Name: name, Channels: channels}
+	for index := range channels {
+		if err := channels[index].validate(); err != nil {
+			return nil, fmt.Errorf("Clip '%s' channel '%d': %s", name, index, err.Error())
+		}
+		if last := channels[index].Times[len(channels[index].Times)-1]; last > c.Duration {
+			c.Duration = last
+		}
+	}
+	return c, nil
+}
+
+// Sample sets the animated properties of the pose at the given time (in seconds).
+// The properties that are not animated by the clip are not changed, so that the
+// pose has to be initialized (eg. with the rest pose of the skeleton). The channels
+// that target joints that are missing from the skeleton are skipped.
+func (c *Clip) Sample(s *Skeleton, time float32, pose Pose) {
+	var value []float32
+	for index := range c.Channels {
+		channel := &c.Channels[index]
+		joint := s.JointIndex(channel.Target)
+		if joint < 0 {
+			continue
+		}
+		value = channel.sample(time, value)
+		switch channel.Path {
+		case PATH_TRANSLATION:
+			pose[joint].Translation = mgl32.Vec3{value[0], value[1], value[2]}
+		case PATH_ROTATION:
+			pose[joint].Rotation = quat(value).Normalize()
+		case PATH_SCALE:
+			pose[joint].Scale = mgl32.Vec3{value[0], value[1], value[2]}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package animation
+
+import (
+	"fmt"
+
+	"github.com/akosgarai/playground_engine/pkg/model"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Model is an animated model. Its skinned meshes are deformed by the pose
+// of the player, the other meshes are handled like in the base model.
+type Model struct {
+	*model.BaseModel
+	player *Player
+	meshes []*SkinnedMesh
+	// The skinning mode of the meshes.
+	skinning int
+	// The transformation that is applied to the joint matrices.
+	transformation mgl32.Mat4
+	matrices       []mgl32.Mat4
+}
+
+// NewModel returns an animated model that is driven by the given player.
+func NewModel(p *Player) *Model {
+	m := &Model{
+		BaseModel:      model.New(),
+		player:         p,
+		transformation: mgl32.Ident4(),
+		skinning:       SKINNING_CPU,
+	}
+	return m
+}
+
+// GetPlayer returns the player of the model.
+func (m *Model) GetPlayer() *Player {
+	return m.player
+}
+
+// AddSkinnedMesh inserts the skinned mesh to the model. The joint indices
+// of the mesh has to be the indices of the joints of the player skeleton.
+func (m *Model) AddSkinnedMesh(msh *SkinnedMesh) {
+	m.meshes = append(m.meshes, msh)
+	m.AddMesh(msh)
+	// The mesh falls back to cpu skinning if the skeleton has too many joints.
+	msh.SetSkinning(m.skinning)
+	m.updateMeshes()
+}
+
+// SetSkinning sets the skinning mode of every skinned mesh. It returns the
+// first error, the mode of the other meshes is set anyway.
+func (m *Model) SetSkinning(mode int) error {
+	if mode != SKINNING_CPU && mode != SKINNING_GPU {
+		return fmt.Errorf("Unknown skinning mode '%d'.", mode)
+	}
+	var result error
+	m.skinning = mode
+	for _, msh := range m.meshes {
+		if err := msh.SetSkinning(mode); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// GetSkinning returns the skinning mode of the model.
+func (m *Model) GetSkinning() int {
+	return m.skinning
+}
+
+// SetTransformation sets the transformation of the skeleton, eg. the position and
+// the heading of an animated character. It's applied to the joint matrices, so
+// that the skinned meshes don't need to be moved.
+func (m *Model) SetTransformation(t mgl32.Mat4) {
+	m.transformation = t
+	m.updateMeshes()
+}
+
+// GetTransformation returns the transformation of the skeleton.
+func (m *Model) GetTransformation() mgl32.Mat4 {
+	return m.transformation
+}
+
+// updateMeshes passes the joint matrices of the player to the skinned meshes.
+func (m *Model) updateMeshes() {
+	matrices := m.player.GetJointMatrices()
+	if len(m.matrices) != len(matrices) {
+		m.matrices = make([]mgl32.Mat4, len(matrices))
+	}
+	for index, matrix := range matrices {
+		m.matrices[index] = m.transformation.Mul4(matrix)
+	}
+	for _, msh := range m.meshes {
+		msh.SetJointMatrices(m.matrices)
+	}
+}
+
+// Update function advances the player, updates the skinned meshes
+// with the new pose, then it calls the Update function of the meshes.
+func (m *Model) Update(dt float64) {
+	m.player.Update(dt)
+	m.updateMeshes()
+	m.BaseModel.Update(dt)
+}
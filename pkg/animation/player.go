@@ -0,0 +1,236 @@
+package animation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// playback is the state of a playing clip.
+type playback struct {
+	clip *Clip
+	// The current time of the clip in seconds.
+	time float32
+	loop bool
+}
+
+// advance moves the time of the playback. The looping clips start again
+// from the beginning, the others stop at their last keyframe.
+func (p *playback) advance(seconds float32) {
+	p.time += seconds
+	duration := p.clip.Duration
+	if duration <= 0 {
+		p.time = 0
+		return
+	}
+	if p.loop {
+		p.time = float32(math.Mod(float64(p.time), float64(duration)))
+		if p.time < 0 {
+			p.time += duration
+		}
+		return
+	}
+	p.time = mgl32.Clamp(p.time, 0, duration)
+}
+
+// finished returns true if a not looping clip reached its end.
+func (p *playback) finished() bool {
+	return !p.loop && p.time >= p.clip.Duration
+}
+
+// Player plays the clips of a skeleton. It calculates the pose of the current
+// clip, and during a cross-fade it blends it with the pose of the previous clip.
+type Player struct {
+	skeleton *Skeleton
+	clips    map[string]*Clip
+	current  *playback
+	previous *playback
+	// The duration and the elapsed time of the cross-fade in seconds.
+	fadeDuration float32
+	fadeElapsed  float32
+	speed        float32
+	paused       bool
+
+	pose         Pose
+	previousPose Pose
+	matrices     []mgl32.Mat4
+}
+
+// NewPlayer returns a player for the given skeleton. Without clip the rest pose is used.
+func NewPlayer(s *Skeleton) *Player {
+	p := &Player{
+		skeleton: s,
+		clips:    make(map[string]*Clip),
+		speed:    1.0,
+	}
+	p.pose = s.RestPose()
+	p.previousPose = s.RestPose()
+	p.matrices = s.JointMatrices(p.pose, nil)
+	return p
+}
+
+// GetSkeleton returns the skeleton of the player.
+func (p *Player) GetSkeleton() *Skeleton {
+	return p.skeleton
+}
+
+// AddClip inserts the clip to the player. The clip with the same name is replaced.
+func (p *Player) AddClip(c *Clip) {
+	p.clips[c.Name] = c
+}
+
+// GetClipNames returns the names of the clips in alphabetical order.
+func (p *Player) GetClipNames() []string {
+	var names []string
+	for name := range p.clips {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Play starts the given clip from the beginning, without cross-fade.
+func (p *Player) Play(name string, loop bool) error {
+	clip, ok := p.clips[name]
+	if !ok {
+		return fmt.Errorf("Missing clip '%s'.", name)
+	}
+	p.current = &playback{clip: clip, loop: loop}
+	p.previous = nil
+	p.paused = false
+	p.updatePose()
+	return nil
+}
+
+// CrossFade starts the given clip from the beginning and blends it with the
+// current one during the given duration (in seconds). If the clip is already
+// playing, it's not restarted.
+func (p *Player) CrossFade(name string, loop bool, duration float32) error {
+	clip, ok := p.clips[name]
+	if !ok {
+		return fmt.Errorf("Missing clip '%s'.", name)
+	}
+	if p.current != nil && p.current.clip == clip {
+		p.current.loop = loop
+		return nil
+	}
+	if p.current == nil || duration <= 0 {
+		return p.Play(name, loop)
+	}
+	p.previous = p.current
+	p.current = &playback{clip: clip, loop: loop}
+	p.fadeDuration = duration
+	p.fadeElapsed = 0
+	p.paused = false
+	p.updatePose()
+	return nil
+}
+
+// Pause stops the time of the clips. The pose is kept.
+func (p *Player) Pause() {
+	p.paused = true
+}
+
+// Resume continues the paused clips.
+func (p *Player) Resume() {
+	p.paused = false
+}
+
+// IsPaused returns true if the player is paused.
+func (p *Player) IsPaused() bool {
+	return p.paused
+}
+
+// SetLoop sets the loop flag of the current clip.
+func (p *Player) SetLoop(loop bool) {
+	if p.current != nil {
+		p.current.loop = loop
+	}
+}
+
+// IsLooping returns the loop flag of the current clip.
+func (p *Player) IsLooping() bool {
+	return p.current != nil && p.current.loop
+}
+
+// SetSpeed sets the playback speed multiplier. The negative values play the clips backwards.
+func (p *Player) SetSpeed(speed float32) {
+	p.speed = speed
+}
+
+// GetSpeed returns the playback speed multiplier.
+func (p *Player) GetSpeed() float32 {
+	return p.speed
+}
+
+// GetCurrent returns the name of the current clip. It's empty if nothing is played.
+func (p *Player) GetCurrent() string {
+	if p.current == nil {
+		return ""
+	}
+	return p.current.clip.Name
+}
+
+// GetTime returns the time of the current clip in seconds.
+func (p *Player) GetTime() float32 {
+	if p.current == nil {
+		return 0
+	}
+	return p.current.time
+}
+
+// IsFinished returns true if the current clip is not looping and it reached its end.
+func (p *Player) IsFinished() bool {
+	return p.current == nil || p.current.finished()
+}
+
+// Update advances the clips with the delta time (in milliseconds, like the
+// Update functions of the engine) and recalculates the pose and the joint matrices.
+func (p *Player) Update(dt float64) {
+	if p.paused || p.current == nil {
+		return
+	}
+	seconds := float32(dt/1000.0) * p.speed
+	p.current.advance(seconds)
+	if p.previous != nil {
+		p.previous.advance(seconds)
+		p.fadeElapsed += float32(math.Abs(float64(seconds)))
+		if p.fadeElapsed >= p.fadeDuration {
+			p.previous = nil
+		}
+	}
+	p.updatePose()
+}
+
+// updatePose calculates the pose and the joint matrices from the state of the clips.
+func (p *Player) updatePose() {
+	p.sample(p.current, p.pose)
+	if p.previous != nil {
+		p.sample(p.previous, p.previousPose)
+		p.pose.Blend(p.previousPose, p.pose, p.fadeElapsed/p.fadeDuration)
+	}
+	p.matrices = p.skeleton.JointMatrices(p.pose, p.matrices)
+}
+
+// sample sets the pose of the playback. The joints that are not
+// animated by the clip are in rest pose.
+func (p *Player) sample(pb *playback, pose Pose) {
+	for index, joint := range p.skeleton.Joints {
+		pose[index] = joint.Rest
+	}
+	if pb != nil {
+		pb.clip.Sample(p.skeleton, pb.time, pose)
+	}
+}
+
+// GetPose returns the current pose.
+func (p *Player) GetPose() Pose {
+	return p.pose
+}
+
+// GetJointMatrices returns the skinning matrices of the current pose.
+func (p *Player) GetJointMatrices() []mgl32.Mat4 {
+	return p.matrices
+}
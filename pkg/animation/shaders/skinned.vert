@@ -0,0 +1,36 @@
+# version 410
+layout(location = 0) in vec3 vVertex;
+layout(location = 1) in vec3 vNormal;
+layout(location = 2) in vec2 vTexCoord;
+layout(location = 3) in vec4 vJoints;
+layout(location = 4) in vec4 vWeights;
+
+out vec3 FragPos;
+out vec3 Normal;
+out vec2 TexCoords;
+
+#define MAX_JOINTS 48
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+uniform mat4 jointMatrices[MAX_JOINTS];
+// 1: the vertices are skinned with the joint matrices,
+// 0: they are already skinned on the cpu.
+uniform int skinning;
+
+void main()
+{
+    mat4 skin = mat4(1.0);
+    if (skinning == 1) {
+        skin = vWeights.x * jointMatrices[int(vJoints.x)] +
+            vWeights.y * jointMatrices[int(vJoints.y)] +
+            vWeights.z * jointMatrices[int(vJoints.z)] +
+            vWeights.w * jointMatrices[int(vJoints.w)];
+    }
+    mat4 world = model * skin;
+    FragPos = vec3(world * vec4(vVertex, 1.0));
+    Normal = mat3(transpose(inverse(world))) * vNormal;
+    TexCoords = vTexCoord;
+    gl_Position = projection * view * vec4(FragPos,1.0);
+}
@@ -0,0 +1,126 @@
+package animation
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Joint is a bone of the skeleton.
+type Joint struct {
+	// The name of the joint, eg. the name of the gltf node.
+	Name string
+	// The identifier that is used by the channels of the clips to
+	// target the joint, eg. the index of the gltf node.
+	Target int
+	// The index of the parent joint in the skeleton. It's -1 for the root joints.
+	Parent int
+	// The local transformation of the joint in the rest pose.
+	Rest Transform
+	// The inverse of the world transformation of the joint in the bind pose.
+	// It transforms the vertices from the bind space to the space of the joint.
+	InverseBind mgl32.Mat4
+	// The world transformation of the parent of the root joints, if the parent
+	// is not a joint (eg. an armature node). It's ignored for the other joints.
+	Base mgl32.Mat4
+}
+
+// Skeleton is the joint hierarchy of a skinned mesh.
+type Skeleton struct {
+	Joints []Joint
+	// The joint indices in such order, that the parents are before their children.
+	order []int
+}
+
+// NewSkeleton returns a skeleton with the given joints. It returns error if
+// a parent index is invalid or the hierarchy contains cycle.
+func NewSkeleton(joints []Joint) (*Skeleton, error) {
+	s := &Skeleton{Joints: joints}
+	// 0: not visited, 1: in progress, 2: done.
+	state := make([]int, len(joints))
+	var visit func(index int) error
+	visit = func(index int) error {
+		switch state[index] {
+		case 1:
+			return fmt.Errorf("The joint '%d' is in a cycle.", index)
+		case 2:
+			return nil
+		}
+		state[index] = 1
+		if parent := joints[index].Parent; parent >= 0 {
+			if parent >= len(joints) {
+				return fmt.Errorf("Invalid parent '%d' of joint '%d'.", parent, index)
+			}
+			if err := visit(parent); err != nil {
+				return err
+			}
+		}
+		state[index] = 2
+		s.order = append(s.order, index)
+		return nil
+	}
+	for index := range joints {
+		if err := visit(index); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// JointIndex returns the index of the joint with the given target.
+// It returns -1 if the skeleton doesn't contain it.
+func (s *Skeleton) JointIndex(target int) int {
+	for index, joint := range s.Joints {
+		if joint.Target == target {
+			return index
+		}
+	}
+	return -1
+}
+
+// RestPose returns the local transformations of the rest pose.
+func (s *Skeleton) RestPose() Pose {
+	pose := make(Pose, len(s.Joints))
+	for index, joint := range s.Joints {
+		pose[index] = joint.Rest
+	}
+	return pose
+}
+
+// WorldTransformations returns the world transformations of the joints
+// in the given pose. The result slice is reused if it's long enough.
+func (s *Skeleton) WorldTransformations(p Pose, result []mgl32.Mat4) []mgl32.Mat4 {
+	if len(result) < len(s.Joints) {
+		result = make([]mgl32.Mat4, len(s.Joints))
+	}
+	for _, index := range s.order {
+		joint := &s.Joints[index]
+		if joint.Parent < 0 {
+			result[index] = joint.Base.Mul4(p[index].Mat4())
+		} else {
+			result[index] = result[joint.Parent].Mul4(p[index].Mat4())
+		}
+	}
+	return result[:len(s.Joints)]
+}
+
+// JointMatrices returns the skinning matrices of the given pose. A matrix transforms
+// the vertices from the bind space to the posed world space of its joint. The result
+// slice is reused if it's long enough.
+func (s *Skeleton) JointMatrices(p Pose, result []mgl32.Mat4) []mgl32.Mat4 {
+	result = s.WorldTransformations(p, result)
+	for index := range result {
+		result[index] = result[index].Mul4(s.Joints[index].InverseBind)
+	}
+	return result
+}
+
+// Pose is the local transformations of the joints of a skeleton.
+type Pose []Transform
+
+// Blend sets the pose to the interpolated pose between a and b.
+func (p Pose) Blend(a, b Pose, weight float32) {
+	for index := range p {
+		p[index] = Blend(a[index], b[index], weight)
+	}
+}
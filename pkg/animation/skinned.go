@@ -0,0 +1,246 @@
+package animation
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/material"
+	"github.com/akosgarai/playground_engine/pkg/mesh"
+	"github.com/akosgarai/playground_engine/pkg/primitives/vertex"
+	"github.com/akosgarai/playground_engine/pkg/shader"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// The skinning modes. With the cpu skinning the vertices are transformed
+	// on the cpu and the vertex buffer is updated in every frame, so that the
+	// mesh could be drawn with the texture material shader of the engine. With
+	// the gpu skinning the joint matrices are passed to the skinned shader.
+	SKINNING_CPU = iota
+	SKINNING_GPU
+
+	// The maximal number of the joints of the gpu skinning. It has to be
+	// the same as the MAX_JOINTS in the skinned vertex shader.
+	MAX_JOINTS = 48
+	// The number of the float values of a vertex in the vertex buffer.
+	// position (3), normal (3), tex coords (2), joints (4), weights (4).
+	vertexSize = 16
+)
+
+func baseDir() string {
+	_, filename, _, _ := runtime.Caller(1)
+	return path.Dir(filename)
+}
+
+// NewSkinnedShader returns the shader of the skinned meshes. It's the texture
+// material shader of the engine, extended with the gpu skinning.
+func NewSkinnedShader(wrapper interfaces.GLWrapper) *shader.Shader {
+	return shader.NewShader(baseDir()+"/shaders/skinned.vert", baseDir()+"/shaders/skinned.frag", wrapper)
+}
+
+// SkinnedMesh is a textured material mesh, that is deformed by the joints of a
+// skeleton. Every vertex is influenced by maximum 4 joints. The vertices are
+// stored in the bind pose, in the coordinate system of the mesh. The embedded
+// engine mesh is created without its gl setup, the buffers of the skinned mesh
+// are allocated once and the vertex buffer is updated in place.
+type SkinnedMesh struct {
+	*mesh.TexturedMaterialMesh
+	// The joint indices and the weights of the vertices.
+	Joints  [][4]int
+	Weights [][4]float32
+
+	skinning int
+	matrices []mgl32.Mat4
+	// The vertex buffer has to be updated before the next draw.
+	dirty   bool
+	buffer  []float32
+	vao     uint32
+	vbo     uint32
+	ebo     uint32
+	wrapper interfaces.GLWrapper
+}
+
+// NewSkinnedMesh returns a skinned mesh with cpu skinning. Every vertex needs
+// joint indices and weights. If the textures are missing, white textures are
+// used for the diffuse and the specular maps, so that the color of the mesh
+// is the color of the material.
+func NewSkinnedMesh(v vertex.Vertices, i []uint32, joints [][4]int, weights [][4]float32, t texture.Textures, mat *material.Material, wrapper interfaces.GLWrapper) (*SkinnedMesh, error) {
+	if len(joints) != len(v) || len(weights) != len(v) {
+		return nil, fmt.Errorf("The number of the joints (%d) and the weights (%d) has to be the same as the number of the vertices (%d).", len(joints), len(weights), len(v))
+	}
+	if len(t) == 0 {
+		t.TransparentTexture(1, 1, 255, "tex.diffuse", wrapper)
+		t.TransparentTexture(1, 1, 255, "tex.specular", wrapper)
+	}
+	m := &SkinnedMesh{
+		TexturedMaterialMesh: &mesh.TexturedMaterialMesh{
+			Mesh:     mesh.Mesh{Vertices: v},
+			Indices:  i,
+			Textures: t,
+			Material: mat,
+		},
+		Joints:   joints,
+		Weights:  weights,
+		skinning: SKINNING_CPU,
+		buffer:   make([]float32, len(v)*vertexSize),
+		wrapper:  wrapper,
+	}
+	m.SetScale(mgl32.Vec3{1, 1, 1})
+	m.fillBuffer()
+	m.setup()
+	return m, nil
+}
+func (m *SkinnedMesh) setup() {
+	m.vao = m.wrapper.GenVertexArrays()
+	m.vbo = m.wrapper.GenBuffers()
+	m.ebo = m.wrapper.GenBuffers()
+
+	m.wrapper.BindVertexArray(m.vao)
+
+	m.wrapper.BindBuffer(glwrapper.ARRAY_BUFFER, m.vbo)
+	m.wrapper.ArrayBufferData(m.buffer)
+
+	m.wrapper.BindBuffer(glwrapper.ELEMENT_ARRAY_BUFFER, m.ebo)
+	m.wrapper.ElementBufferData(m.Indices)
+
+	// setup coordinates
+	m.wrapper.VertexAttribPointer(0, 3, glwrapper.FLOAT, false, 4*vertexSize, m.wrapper.PtrOffset(0))
+	// setup normals
+	m.wrapper.VertexAttribPointer(1, 3, glwrapper.FLOAT, false, 4*vertexSize, m.wrapper.PtrOffset(4*3))
+	// setup texture position
+	m.wrapper.VertexAttribPointer(2, 2, glwrapper.FLOAT, false, 4*vertexSize, m.wrapper.PtrOffset(4*6))
+	// setup joint indices
+	m.wrapper.VertexAttribPointer(3, 4, glwrapper.FLOAT, false, 4*vertexSize, m.wrapper.PtrOffset(4*8))
+	// setup joint weights
+	m.wrapper.VertexAttribPointer(4, 4, glwrapper.FLOAT, false, 4*vertexSize, m.wrapper.PtrOffset(4*12))
+
+	// close
+	m.wrapper.BindVertexArray(0)
+}
+
+// fillBuffer sets the vertex buffer data. In cpu mode the positions and the normals
+// are skinned with the joint matrices, otherwise the bind pose is stored.
+func (m *SkinnedMesh) fillBuffer() {
+	cpu := m.skinning == SKINNING_CPU && len(m.matrices) > 0
+	var normalMatrices []mgl32.Mat3
+	if cpu {
+		normalMatrices = make([]mgl32.Mat3, len(m.matrices))
+		for index, matrix := range m.matrices {
+			normalMatrices[index] = matrix.Mat3().Inv().Transpose()
+		}
+	}
+	for index, v := range m.Vertices {
+		position, normal := v.Position, v.Normal
+		if cpu {
+			var skin mgl32.Mat4
+			var normalSkin mgl32.Mat3
+			for k := 0; k < 4; k++ {
+				weight := m.Weights[index][k]
+				joint := m.Joints[index][k]
+				if weight == 0 || joint < 0 || joint >= len(m.matrices) {
+					continue
+				}
+				skin = skin.Add(m.matrices[joint].Mul(weight))
+				normalSkin = normalSkin.Add(normalMatrices[joint].Mul(weight))
+			}
+			position = mgl32.TransformCoordinate(position, skin)
+			normal = normalSkin.Mul3x1(normal)
+			if normal.Len() > 0 {
+				normal = normal.Normalize()
+			}
+		}
+		data := m.buffer[index*vertexSize : (index+1)*vertexSize]
+		copy(data[0:3], position[:])
+		copy(data[3:6], normal[:])
+		copy(data[6:8], v.TexCoords[:])
+		for k := 0; k < 4; k++ {
+			data[8+k] = float32(m.Joints[index][k])
+			data[12+k] = m.Weights[index][k]
+		}
+	}
+	m.dirty = false
+}
+
+// SetSkinning sets the skinning mode. It returns error if the mode is unknown
+// or the gpu skinning is requested with more joint matrices than MAX_JOINTS.
+func (m *SkinnedMesh) SetSkinning(mode int) error {
+	switch mode {
+	case SKINNING_CPU:
+	case SKINNING_GPU:
+		if len(m.matrices) > MAX_JOINTS {
+			return fmt.Errorf("The gpu skinning supports maximum %d joints, the mesh has %d.", MAX_JOINTS, len(m.matrices))
+		}
+	default:
+		return fmt.Errorf("Unknown skinning mode '%d'.", mode)
+	}
+	if m.skinning != mode {
+		m.skinning = mode
+		m.dirty = true
+	}
+	return nil
+}
+
+// GetSkinning returns the skinning mode.
+func (m *SkinnedMesh) GetSkinning() int {
+	return m.skinning
+}
+
+// SetJointMatrices sets the skinning matrices of the joints. The matrices
+// transform the bind pose vertices to the posed coordinate system of the mesh.
+func (m *SkinnedMesh) SetJointMatrices(matrices []mgl32.Mat4) {
+	m.matrices = append(m.matrices[:0], matrices...)
+	if m.skinning == SKINNING_GPU && len(m.matrices) > MAX_JOINTS {
+		// The shader can't handle this number of joints.
+		m.skinning = SKINNING_CPU
+	}
+	if m.skinning == SKINNING_CPU {
+		m.dirty = true
+	}
+}
+
+// Draw function is responsible for the actual drawing. Its input is a shader.
+// It updates the vertex buffer if it's necessary, then it binds the textures,
+// the material, the model uniform and in gpu mode the joint matrices. Then it
+// binds the vertex array and draws the mesh with triangles. Finally it cleans up.
+func (m *SkinnedMesh) Draw(shader interfaces.Shader) {
+	if m.dirty && len(m.buffer) > 0 {
+		m.fillBuffer()
+		m.wrapper.BindBuffer(glwrapper.ARRAY_BUFFER, m.vbo)
+		gl.BufferSubData(gl.ARRAY_BUFFER, 0, 4*len(m.buffer), gl.Ptr(m.buffer))
+		m.wrapper.BindBuffer(glwrapper.ARRAY_BUFFER, 0)
+	}
+	for _, item := range m.Textures {
+		item.Bind()
+		shader.SetUniform1i(item.UniformName, int32(item.Id-glwrapper.TEXTURE0))
+	}
+	M := m.ModelTransformation()
+	shader.SetUniformMat4("model", M)
+	diffuse := m.Material.GetDiffuse()
+	ambient := m.Material.GetAmbient()
+	specular := m.Material.GetSpecular()
+	shininess := m.Material.GetShininess()
+	shader.SetUniform3f("material.diffuse", diffuse.X(), diffuse.Y(), diffuse.Z())
+	shader.SetUniform3f("material.ambient", ambient.X(), ambient.Y(), ambient.Z())
+	shader.SetUniform3f("material.specular", specular.X(), specular.Y(), specular.Z())
+	shader.SetUniform1f("material.shininess", shininess)
+	if m.skinning == SKINNING_GPU {
+		shader.SetUniform1i("skinning", 1)
+		for index, matrix := range m.matrices {
+			shader.SetUniformMat4(fmt.Sprintf("jointMatrices[%d]", index), matrix)
+		}
+	} else {
+		shader.SetUniform1i("skinning", 0)
+	}
+	m.wrapper.BindVertexArray(m.vao)
+	m.wrapper.DrawTriangleElements(int32(len(m.Indices)))
+
+	m.Textures.UnBind()
+	m.wrapper.BindVertexArray(0)
+	m.wrapper.ActiveTexture(0)
+}
@@ -0,0 +1,58 @@
+package animation
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Transform is the local transformation of a joint, decomposed to
+// translation, rotation and scale, so that it could be interpolated.
+type Transform struct {
+	Translation mgl32.Vec3
+	Rotation    mgl32.Quat
+	Scale       mgl32.Vec3
+}
+
+// IdentityTransform returns the transformation without translation, rotation and scale.
+func IdentityTransform() Transform {
+	return Transform{
+		Rotation: mgl32.QuatIdent(),
+		Scale:    mgl32.Vec3{1, 1, 1},
+	}
+}
+
+// Mat4 returns the transformation matrix. The scale is applied first,
+// then the rotation, and the translation is the last one.
+func (t Transform) Mat4() mgl32.Mat4 {
+	return mgl32.Translate3D(t.Translation.X(), t.Translation.Y(), t.Translation.Z()).
+		Mul4(t.Rotation.Normalize().Mat4()).
+		Mul4(mgl32.Scale3D(t.Scale.X(), t.Scale.Y(), t.Scale.Z()))
+}
+
+// Blend returns the interpolated transformation between a and b. The
+// translation and the scale are interpolated linearly, the rotation
+// with spherical linear interpolation on the shorter arc.
+func Blend(a, b Transform, weight float32) Transform {
+	if weight <= 0 {
+		return a
+	}
+	if weight >= 1 {
+		return b
+	}
+	return Transform{
+		Translation: lerpVec3(a.Translation, b.Translation, weight),
+		Rotation:    slerp(a.Rotation, b.Rotation, weight),
+		Scale:       lerpVec3(a.Scale, b.Scale, weight),
+	}
+}
+func lerpVec3(a, b mgl32.Vec3, weight float32) mgl32.Vec3 {
+	return a.Add(b.Sub(a).Mul(weight))
+}
+
+// slerp interpolates the rotations on the shorter arc. The mgl32 QuatSlerp
+// doesn't flip the second quaternion, so that it would take the long way.
+func slerp(a, b mgl32.Quat, weight float32) mgl32.Quat {
+	if a.Dot(b) < 0 {
+		b = b.Scale(-1)
+	}
+	return mgl32.QuatSlerp(a, b, weight).Normalize()
+}
@@ -0,0 +1,54 @@
+package assetloader
+
+import (
+	"image"
+
+	"github.com/akosgarai/opengl_playground/pkg/texturecache"
+	"github.com/akosgarai/opengl_playground/pkg/texturecontainer"
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+)
+
+// DecodeImage loads the image file (png or jpeg) and converts it to RGBA. The
+// base level of the dds and ktx2 containers is decoded on the cpu if it is
+// compressed. It doesn't call gl functions, so that it could be called from
+// any goroutine.
+func DecodeImage(filePath string) (*image.RGBA, error) {
+	if !texturecontainer.IsContainer(filePath) {
+		return texturecache.DecodeImage(filePath)
+	}
+	data, err := texturecontainer.Load(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return data.RGBA(0)
+}
+
+// AddCubeMapTextureRGBA sets up a cube map texture from the decoded faces and
+// appends it to the textures. The order of the faces is +x, -x, +y, -y, +z, -z.
+func AddCubeMapTextureRGBA(t *texture.Textures, filePath string, faces [6]*image.RGBA, wrapR, wrapS, wrapT, minificationFilter, magnificationFilter int32, uniformName string, wrapper interfaces.GLWrapper) {
+	var name uint32
+	wrapper.GenTextures(1, &name)
+	tex := &texture.Texture{
+		TextureName: name,
+		TargetId:    glwrapper.TEXTURE_CUBE_MAP,
+		Id:          glwrapper.TEXTURE0 + uint32(len(*t)),
+		UniformName: uniformName,
+		Wrapper:     wrapper,
+		FilePath:    filePath,
+	}
+	tex.Bind()
+	defer tex.UnBind()
+
+	for index, rgba := range faces {
+		tex.Wrapper.TexImage2D(glwrapper.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(index), 0, glwrapper.RGBA, int32(rgba.Rect.Size().X), int32(rgba.Rect.Size().Y), 0, glwrapper.RGBA, uint32(glwrapper.UNSIGNED_BYTE), tex.Wrapper.Ptr(rgba.Pix))
+	}
+	tex.Wrapper.TexParameteri(glwrapper.TEXTURE_CUBE_MAP, glwrapper.TEXTURE_WRAP_R, wrapR)
+	tex.Wrapper.TexParameteri(glwrapper.TEXTURE_CUBE_MAP, glwrapper.TEXTURE_WRAP_S, wrapS)
+	tex.Wrapper.TexParameteri(glwrapper.TEXTURE_CUBE_MAP, glwrapper.TEXTURE_WRAP_T, wrapT)
+	tex.Wrapper.TexParameteri(glwrapper.TEXTURE_CUBE_MAP, glwrapper.TEXTURE_MIN_FILTER, minificationFilter)
+	tex.Wrapper.TexParameteri(glwrapper.TEXTURE_CUBE_MAP, glwrapper.TEXTURE_MAG_FILTER, magnificationFilter)
+
+	*t = append(*t, tex)
+}
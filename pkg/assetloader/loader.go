@@ -0,0 +1,277 @@
+package assetloader
+
+import (
+	"fmt"
+	"image"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/akosgarai/opengl_playground/pkg/glext"
+	"github.com/akosgarai/opengl_playground/pkg/texturecache"
+	"github.com/akosgarai/opengl_playground/pkg/texturecontainer"
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+)
+
+const (
+	DEBUG = false
+	// The uploads of an Update call are stopped after this duration,
+	// so that the loading screen stays responsive.
+	DefaultUploadBudget = 10 * time.Millisecond
+)
+
+// LoadFunction is the cpu heavy part of the asset loading (eg. file parsing,
+// image decoding). It runs on a worker goroutine, so that it must not call
+// gl functions. Its result is passed to the UploadFunction.
+type LoadFunction func() (interface{}, error)
+
+// UploadFunction is the gl part of the asset loading. It runs on the thread
+// of the gl context with the result of the LoadFunction.
+type UploadFunction func(interface{}) error
+
+// Importer is implemented by the model importers that could parse
+// the files without gl calls.
+type Importer interface {
+	Load() error
+	Upload()
+}
+
+type task struct {
+	name   string
+	load   LoadFunction
+	upload UploadFunction
+}
+type result struct {
+	task *task
+	data interface{}
+	err  error
+}
+
+// Loader runs the load functions of the assets on worker goroutines and
+// the upload functions on the main thread, from the Update function.
+type Loader struct {
+	tasks        []*task
+	workers      int
+	results      chan result
+	started      bool
+	finished     int
+	mutex        sync.Mutex
+	loading      map[*task]bool
+	errors       []error
+	uploadBudget time.Duration
+	cache        *texturecache.Cache
+}
+
+// New returns a loader with the given number of workers. If the
+// number is less than 1, the number of the cpus is used.
+func New(workers int) *Loader {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	return &Loader{
+		workers:      workers,
+		uploadBudget: DefaultUploadBudget,
+	}
+}
+
+// SetUploadBudget sets the maximum duration of the uploads in an Update call.
+func (l *Loader) SetUploadBudget(d time.Duration) {
+	l.uploadBudget = d
+}
+
+// SetCache sets the texture cache of the loader. If it is set, the 2D textures
+// are added through the cache, and the resident images are not decoded again.
+// It has to be set before the textures are added.
+func (l *Loader) SetCache(c *texturecache.Cache) {
+	l.cache = c
+}
+
+// Add inserts a new asset to the loader. The assets have to be added before the Start.
+func (l *Loader) Add(name string, load LoadFunction, upload UploadFunction) {
+	if l.started {
+		panic("The assets have to be added before the loader is started.")
+	}
+	l.tasks = append(l.tasks, &task{name: name, load: load, upload: upload})
+}
+
+// AddTexture inserts a 2D texture to the loader. The image or the dds, ktx2
+// container is decoded on a worker, then it is uploaded with its levels and
+// appended to the given textures, through the cache, if it is set. The wrapper
+// deletes the texture if the upload fails.
+func (l *Loader) AddTexture(tex *texture.Textures, filePath string, wrapR, wrapS, minificationFilter, magnificationFilter int32, uniformName string, wrapper glext.GLWrapper) {
+	cache := l.cache
+	// The options are the same at the load and the upload.
+	opts := texturecache.GetOptions()
+	load := func() (interface{}, error) {
+		// The resident texture is reserved, so that it isn't deleted before the upload.
+		if cache != nil && cache.ReserveWithOptions(filePath, wrapR, wrapS, minificationFilter, magnificationFilter, opts) {
+			return (*texturecontainer.Texture)(nil), nil
+		}
+		return texturecontainer.Load(filePath)
+	}
+	upload := func(data interface{}) error {
+		if cache != nil {
+			if data.(*texturecontainer.Texture) == nil {
+				return cache.AddReservedWithOptions(tex, filePath, wrapR, wrapS, minificationFilter, magnificationFilter, uniformName, opts)
+			}
+			return cache.AddTextureContainerWithOptions(tex, filePath, data.(*texturecontainer.Texture), wrapR, wrapS, minificationFilter, magnificationFilter, uniformName, opts)
+		}
+		return data.(*texturecontainer.Texture).AddTo(tex, filePath, wrapR, wrapS, minificationFilter, magnificationFilter, uniformName, wrapper)
+	}
+	l.Add(filePath, load, upload)
+}
+
+// AddCubeMapTexture inserts a cube map texture to the loader. The file names
+// are the same as in the AddCubeMapTexture function of the texture package.
+func (l *Loader) AddCubeMapTexture(tex *texture.Textures, directoryPath string, wrapR, wrapS, wrapT, minificationFilter, magnificationFilter int32, uniformName string, wrapper interfaces.GLWrapper) {
+	fileNames := [6]string{"skybox-right.png", "skybox-left.png", "skybox-top.png", "skybox-bottom.png", "skybox-front.png", "skybox-back.png"}
+	l.AddCubeMapTextureWithFilenames(tex, directoryPath, fileNames, wrapR, wrapS, wrapT, minificationFilter, magnificationFilter, uniformName, wrapper)
+}
+
+// AddCubeMapTextureWithFilenames inserts a cube map texture to the loader. The
+// images of the faces are decoded on the same worker. If a face has converted
+// container next to it, the container is decoded instead of the image.
+func (l *Loader) AddCubeMapTextureWithFilenames(tex *texture.Textures, directoryPath string, files [6]string, wrapR, wrapS, wrapT, minificationFilter, magnificationFilter int32, uniformName string, wrapper interfaces.GLWrapper) {
+	load := func() (interface{}, error) {
+		var faces [6]*image.RGBA
+		for index, file := range files {
+			img, err := DecodeImage(texturecontainer.Resolve(directoryPath + "/" + file))
+			if err != nil {
+				return nil, err
+			}
+			faces[index] = img
+		}
+		return faces, nil
+	}
+	upload := func(data interface{}) error {
+		AddCubeMapTextureRGBA(tex, directoryPath, data.([6]*image.RGBA), wrapR, wrapS, wrapT, minificationFilter, magnificationFilter, uniformName, wrapper)
+		return nil
+	}
+	l.Add(directoryPath, load, upload)
+}
+
+// AddImporter inserts a model file to the loader. The Load function of the
+// importer is called on a worker, the Upload function on the main thread.
+func (l *Loader) AddImporter(name string, importer Importer) {
+	load := func() (interface{}, error) {
+		return nil, importer.Load()
+	}
+	upload := func(data interface{}) error {
+		importer.Upload()
+		return nil
+	}
+	l.Add(name, load, upload)
+}
+
+// Start starts the workers. The loading of the assets is started in the
+// order of their insertion, but they could be finished in different order.
+func (l *Loader) Start() {
+	if l.started {
+		return
+	}
+	l.started = true
+	l.loading = make(map[*task]bool)
+	jobs := make(chan *task, len(l.tasks))
+	l.results = make(chan result, len(l.tasks))
+	for _, t := range l.tasks {
+		jobs <- t
+	}
+	close(jobs)
+	for i := 0; i < l.workers; i++ {
+		go l.work(jobs)
+	}
+}
+
+// work runs the load functions until the jobs channel is empty. The panics
+// of the load functions are returned as errors.
+func (l *Loader) work(jobs <-chan *task) {
+	for t := range jobs {
+		l.results <- l.run(t)
+	}
+}
+func (l *Loader) run(t *task) (r result) {
+	r.task = t
+	l.mutex.Lock()
+	l.loading[t] = true
+	l.mutex.Unlock()
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.err = fmt.Errorf("%v", rec)
+		}
+	}()
+	if DEBUG {
+		fmt.Printf("Loading asset: '%s'.\n", t.name)
+	}
+	r.data, r.err = t.load()
+	return r
+}
+
+// Update uploads the loaded assets. It has to be called from the thread
+// of the gl context. It returns when every loaded asset is uploaded, or
+// the upload budget is spent.
+func (l *Loader) Update() {
+	if !l.started {
+		return
+	}
+	startTime := time.Now()
+	for l.finished < len(l.tasks) && time.Since(startTime) < l.uploadBudget {
+		select {
+		case r := <-l.results:
+			l.upload(r)
+		default:
+			return
+		}
+	}
+}
+func (l *Loader) upload(r result) {
+	defer func() {
+		l.mutex.Lock()
+		delete(l.loading, r.task)
+		l.mutex.Unlock()
+	}()
+	l.finished++
+	if r.err == nil {
+		if DEBUG {
+			fmt.Printf("Uploading asset: '%s'.\n", r.task.name)
+		}
+		r.err = r.task.upload(r.data)
+	}
+	if r.err != nil {
+		l.errors = append(l.errors, fmt.Errorf("%s: %s", r.task.name, r.err.Error()))
+	}
+}
+
+// Progress returns the ratio of the finished assets. It is 1 if
+// there is nothing to load.
+func (l *Loader) Progress() float32 {
+	if len(l.tasks) == 0 {
+		return 1
+	}
+	return float32(l.finished) / float32(len(l.tasks))
+}
+
+// Current returns the name of the asset that is currently loading. An asset is
+// loading from the start of its load function until the end of its upload. If
+// more assets are loading, the first inserted one is returned. It returns empty
+// string if nothing is loading.
+func (l *Loader) Current() string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, t := range l.tasks {
+		if l.loading[t] {
+			return t.name
+		}
+	}
+	return ""
+}
+
+// Done returns true if every asset is uploaded (or failed).
+func (l *Loader) Done() bool {
+	return l.started && l.finished == len(l.tasks)
+}
+
+// Errors returns the errors of the failed assets.
+func (l *Loader) Errors() []error {
+	return l.errors
+}
@@ -0,0 +1,144 @@
+package assetloader
+
+import (
+	"path"
+	"runtime"
+
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/mesh"
+	"github.com/akosgarai/playground_engine/pkg/model"
+	"github.com/akosgarai/playground_engine/pkg/primitives/rectangle"
+	"github.com/akosgarai/playground_engine/pkg/screen"
+	"github.com/akosgarai/playground_engine/pkg/shader"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+var (
+	BarWidth        = float32(1.2)
+	BarHeight       = float32(0.06)
+	BarPosition     = mgl32.Vec3{0.0, -0.1, 0.0}
+	BarColor        = mgl32.Vec3{0.2, 0.2, 0.25}
+	BarFillColor    = mgl32.Vec3{0.3, 0.6, 0.9}
+	LabelColor      = mgl32.Vec3{1.0, 1.0, 1.0}
+	LabelHeight     = float32(0.05)
+	BackgroundColor = mgl32.Vec3{0.1, 0.1, 0.1}
+)
+
+const (
+	// The fill and the label are moved a bit closer than the background of the bar.
+	fillZ  = float32(-0.005)
+	labelZ = float32(0.01)
+)
+
+func baseDir() string {
+	_, filename, _, _ := runtime.Caller(1)
+	return path.Dir(filename)
+}
+
+// Screen is the loading screen. It displays a progress bar and the name of the
+// last loaded asset. It doesn't have camera, so that the models are placed in
+// the normalized device coordinate system.
+type Screen struct {
+	*screen.Screen
+	loader   *Loader
+	charset  *model.Charset
+	scale    float32
+	bar      *mesh.ColorMesh
+	fill     *mesh.ColorMesh
+	label    string
+	onFinish func()
+	finished bool
+}
+
+// NewScreen returns a loading screen for the given loader. The loader is started
+// here. The charset is loaded from the fontFile. The onFinish function is called
+// once, from the Update function, when every asset is uploaded.
+func NewScreen(l *Loader, fontFile string, wrapper interfaces.GLWrapper, onFinish func()) *Screen {
+	cs, err := model.LoadCharset(fontFile, 32, 127, 40.0, 72, wrapper)
+	if err != nil {
+		panic(err)
+	}
+	cs.SetTransparent(true)
+	_, lineHeight := cs.TextContainerSize("W", 1)
+	s := &Screen{
+		Screen:   screen.New(),
+		loader:   l,
+		charset:  cs,
+		scale:    LabelHeight / lineHeight,
+		onFinish: onFinish,
+	}
+	s.SetWrapper(wrapper)
+
+	v, i, _ := rectangle.NewExact(BarWidth, BarHeight).ColoredMeshInput([]mgl32.Vec3{BarColor})
+	bar := mesh.NewColorMesh(v, i, []mgl32.Vec3{BarColor}, wrapper)
+	// The rectangle is on the xz plane, it is rotated to the screen.
+	bar.RotateX(-90)
+	bar.SetPosition(BarPosition)
+	v, i, _ = rectangle.NewExact(BarWidth, BarHeight).ColoredMeshInput([]mgl32.Vec3{BarFillColor})
+	fill := mesh.NewColorMesh(v, i, []mgl32.Vec3{BarFillColor}, wrapper)
+	fill.RotateX(-90)
+	s.bar = bar
+	s.fill = fill
+	s.updateBar()
+	s.updateLabel()
+
+	barModel := model.New()
+	barModel.AddMesh(bar)
+	barModel.AddMesh(fill)
+	colorShader := shader.NewShader(baseDir()+"/shaders/color.vert", baseDir()+"/shaders/color.frag", wrapper)
+	fontShader := shader.NewFontShader(wrapper)
+	s.AddShader(colorShader)
+	s.AddShader(fontShader)
+	s.AddModelToShader(barModel, colorShader)
+	s.AddModelToShader(cs, fontShader)
+	s.Setup(setupLoadingScreen)
+
+	l.Start()
+	return s
+}
+func setupLoadingScreen(wrapper interfaces.GLWrapper) {
+	wrapper.Enable(glwrapper.DEPTH_TEST)
+	wrapper.DepthFunc(glwrapper.LESS)
+	wrapper.Enable(glwrapper.BLEND)
+	wrapper.BlendFunc(glwrapper.SRC_APLHA, glwrapper.ONE_MINUS_SRC_ALPHA)
+	wrapper.ClearColor(BackgroundColor.X(), BackgroundColor.Y(), BackgroundColor.Z(), 1.0)
+}
+
+// updateBar scales the fill of the progress bar to the progress of the loader.
+// The left side of the fill is always at the left side of the bar.
+func (s *Screen) updateBar() {
+	progress := s.loader.Progress()
+	s.fill.SetScale(mgl32.Vec3{progress, 1.0, 1.0})
+	s.fill.SetPosition(mgl32.Vec3{BarPosition.X() - BarWidth*(1-progress)/2, BarPosition.Y(), BarPosition.Z() + fillZ})
+}
+
+// updateLabel prints the name of the currently loading asset above the bar.
+func (s *Screen) updateLabel() {
+	label := "Loading..."
+	if current := s.loader.Current(); current != "" {
+		label = "Loading: " + path.Base(current)
+	}
+	if label == s.label {
+		return
+	}
+	s.label = label
+	s.charset.CleanSurface(s.bar)
+	s.charset.PrintTo(label, -BarWidth/2, BarHeight/2+LabelHeight, labelZ, s.scale, s.GetWrapper(), s.bar, []mgl32.Vec3{LabelColor})
+}
+
+// Update uploads the loaded assets, then updates the progress bar and the label.
+// When every asset is resident, it calls the onFinish function.
+func (s *Screen) Update(dt float64, p interfaces.Pointer, keyStore interfaces.RoKeyStore, buttonStore interfaces.RoButtonStore) {
+	s.Screen.Update(dt, p, keyStore, buttonStore)
+	s.loader.Update()
+	s.updateBar()
+	s.updateLabel()
+	if s.loader.Done() && !s.finished {
+		s.finished = true
+		if s.onFinish != nil {
+			s.onFinish()
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package focus
+
+import (
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/material"
+	"github.com/akosgarai/playground_engine/pkg/mesh"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// The keys of the focus handling. The tab with the shift moves the focus
+	// backwards. The left and right arrows set the value of the booleans.
+	NEXT_BUTTON        = glfw.KeyTab
+	SHIFT_BUTTON       = glfw.KeyLeftShift
+	SHIFT_RIGHT_BUTTON = glfw.KeyRightShift
+	ACTIVATE_BUTTON    = glfw.KeyEnter
+	ACTIVATE_KP_BUTTON = glfw.KeyKPEnter
+	LEFT_BUTTON        = glfw.KeyLeft
+	RIGHT_BUTTON       = glfw.KeyRight
+	// The activation is the click of this button on the focused item.
+	CLICK_BUTTON = glfw.MouseButtonLeft
+)
+
+// FocusMaterial is the material of the surface of the focused item.
+var FocusMaterial = material.Emerald
+
+// keyboard stores the previous state of the focus keys. It's shared between
+// the screens, so that the key, that activated a screen, isn't handled again
+// on the new screen.
+var keyboard = make(map[glfw.Key]bool)
+
+// pressed returns true if the key is down, but it was up in the previous update.
+func pressed(keyStore interfaces.RoKeyStore, key glfw.Key) bool {
+	down := keyStore.Get(key)
+	wasDown := keyboard[key]
+	keyboard[key] = down
+	return down && !wasDown
+}
+
+// keys returns the pressed events of the focus keys. The direction is 1 for the
+// tab, -1 for the shift + tab and 0 otherwise. The arrow is -1 for the left, 1
+// for the right and 0 otherwise.
+func keys(keyStore interfaces.RoKeyStore) (int, bool, int) {
+	direction := 0
+	if pressed(keyStore, NEXT_BUTTON) {
+		direction = 1
+		if keyStore.Get(SHIFT_BUTTON) || keyStore.Get(SHIFT_RIGHT_BUTTON) {
+			direction = -1
+		}
+	}
+	activate := pressed(keyStore, ACTIVATE_BUTTON)
+	if pressed(keyStore, ACTIVATE_KP_BUTTON) {
+		activate = true
+	}
+	arrow := 0
+	if pressed(keyStore, LEFT_BUTTON) {
+		arrow--
+	}
+	if pressed(keyStore, RIGHT_BUTTON) {
+		arrow++
+	}
+	return direction, activate, arrow
+}
+
+// neighbour returns the index of the next (direction 1) or the previous
+// (direction -1) item. After the last item it starts again from the first
+// one. If nothing is focused, the tab focuses the first item, the shift + tab
+// the last one.
+func neighbour(current, count, direction int) int {
+	if count == 0 {
+		return -1
+	}
+	if current < 0 || current >= count {
+		if direction < 0 {
+			return count - 1
+		}
+		return 0
+	}
+	return (current + direction + count) % count
+}
+
+// pointer is a fake mouse pointer, that is placed over the focused item, so
+// that the screen handles the activation like the click of the item.
+type pointer struct {
+	x, y float64
+}
+
+// newPointer returns a pointer over the given position of the screen. It's
+// the inverse of the cursor transformation of the form and the menu screens.
+func newPointer(position mgl32.Vec3, frameWidth, aspRatio float32) *pointer {
+	return &pointer{
+		x: float64(-position.X() * 2 / frameWidth),
+		y: float64(position.Y() * aspRatio * 2 / frameWidth),
+	}
+}
+
+// GetCurrent returns the position of the pointer.
+func (p *pointer) GetCurrent() (float64, float64) {
+	return p.x, p.y
+}
+
+// GetDelta returns 0 movement.
+func (p *pointer) GetDelta() (float64, float64) {
+	return 0, 0
+}
+
+// click is a fake button store, where only the click button is pressed.
+type click struct{}
+
+// Get returns true for the click button.
+func (c click) Get(button glfw.MouseButton) bool {
+	return button == CLICK_BUTTON
+}
+
+// surfacePosition returns the position of the mesh in the coordinate system of
+// the screen, the same way as it's calculated in the closest mesh search.
+func surfacePosition(m interfaces.Mesh) mgl32.Vec3 {
+	position := m.GetPosition()
+	if !m.IsParentMesh() {
+		position = mgl32.TransformCoordinate(position, m.GetParentTranslationTransformation())
+	}
+	return position
+}
+
+// highlight stores the focused surface and its original material, so that it
+// could be restored when the focus leaves it.
+type highlight struct {
+	surface  *mesh.TexturedMaterialMesh
+	original *material.Material
+}
+
+// set replaces the material of the surface with the FocusMaterial. The previous
+// surface gets back its material. The material, that is set by the screen
+// after the last call, is stored as the original one.
+func (h *highlight) set(surface interfaces.Mesh) {
+	tmMesh, _ := surface.(*mesh.TexturedMaterialMesh)
+	if h.surface != nil && h.surface != tmMesh {
+		h.surface.Material = h.original
+	}
+	if tmMesh == nil {
+		h.surface = nil
+		return
+	}
+	if h.surface != tmMesh || tmMesh.Material != FocusMaterial {
+		h.original = tmMesh.Material
+	}
+	h.surface = tmMesh
+	tmMesh.Material = FocusMaterial
+}
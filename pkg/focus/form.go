@@ -0,0 +1,78 @@
+package focus
+
+import (
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/model"
+	"github.com/akosgarai/playground_engine/pkg/theme"
+)
+
+// Form is implemented by the form screen of the engine and its wrappers, eg.
+// the form screen of the tooltip package.
+type Form interface {
+	interfaces.Screen
+	GetFormItem(string) interfaces.FormItem
+	SetFormItemValue(interfaces.FormItem, interface{})
+	GetAspectRatio() float32
+}
+
+// FormScreen adds the keyboard focus to a form screen. The tab and shift + tab
+// keys move the focus in the order of the config keys. The enter activates the
+// focused item like the click: it toggles the booleans and starts the typing
+// into the other inputs. The left and right arrows set the booleans to false
+// and true. The surface of the focused item is drawn with the FocusMaterial.
+type FormScreen struct {
+	Form
+	frameWidth float32
+	items      []interfaces.FormItem
+	current    int // -1 means that nothing is focused.
+	highlight  highlight
+}
+
+// NewFormScreen returns the focus handler of the form. The keys are the config
+// keys of the form items in the traversal order, it's the config order of the
+// form. Every key has to be on the form. The frame width is coming from the
+// theme of the form.
+func NewFormScreen(form Form, keys []string, t *theme.Theme) *FormScreen {
+	f := &FormScreen{
+		Form:       form,
+		frameWidth: t.GetFrameWidth(),
+		current:    -1,
+	}
+	for _, key := range keys {
+		f.items = append(f.items, form.GetFormItem(key))
+	}
+	return f
+}
+
+// Focused returns the focused item. If nothing is focused, it returns nil.
+func (f *FormScreen) Focused() interfaces.FormItem {
+	if f.current < 0 || f.current >= len(f.items) {
+		return nil
+	}
+	return f.items[f.current]
+}
+
+// Update handles the focus keys, then it calls the update of the form. In case
+// of activation the pointer of the form is placed over the focused item with
+// pressed button.
+func (f *FormScreen) Update(dt float64, p interfaces.Pointer, keyStore interfaces.RoKeyStore, buttonStore interfaces.RoButtonStore) {
+	direction, activate, arrow := keys(keyStore)
+	if direction != 0 {
+		f.current = neighbour(f.current, len(f.items), direction)
+	}
+	if focused := f.Focused(); focused != nil {
+		if boolItem, ok := focused.(*model.FormItemBool); ok && arrow != 0 && boolItem.GetValue() != (arrow > 0) {
+			activate = true
+		}
+		if activate {
+			p = newPointer(surfacePosition(focused.GetSurface()), f.frameWidth, f.GetAspectRatio())
+			buttonStore = click{}
+		}
+	}
+	f.Form.Update(dt, p, keyStore, buttonStore)
+	if focused := f.Focused(); focused != nil {
+		f.highlight.set(focused.GetSurface())
+	} else {
+		f.highlight.set(nil)
+	}
+}
@@ -0,0 +1,80 @@
+package focus
+
+import (
+	"sort"
+
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/model"
+	"github.com/akosgarai/playground_engine/pkg/screen"
+	"github.com/akosgarai/playground_engine/pkg/theme"
+)
+
+// MenuScreen adds the keyboard focus to a menu screen. The tab and shift + tab
+// keys move the focus between the displayed options from top to bottom. The
+// enter activates the focused option like the click. The surface of the focused
+// option is drawn with the FocusMaterial.
+type MenuScreen struct {
+	*screen.MenuScreen
+	frameWidth float32
+	current    int // -1 means that nothing is focused.
+	highlight  highlight
+}
+
+// NewMenuScreen returns the focus handler of the menu. The frame width is
+// coming from the theme of the menu.
+func NewMenuScreen(menu *screen.MenuScreen, t *theme.Theme) *MenuScreen {
+	return &MenuScreen{
+		MenuScreen: menu,
+		frameWidth: t.GetFrameWidth(),
+		current:    -1,
+	}
+}
+
+// surfaces returns the surfaces of the displayed options from top to bottom.
+// The menu screen stores them in the meshes of its background model, that is
+// returned as the closest model after its update.
+func (m *MenuScreen) surfaces() []interfaces.Mesh {
+	closestModel, _, _ := m.GetClosestModelMeshDistance()
+	background, ok := closestModel.(*model.BaseModel)
+	if !ok {
+		return nil
+	}
+	var result []interfaces.Mesh
+	for index := 0; ; index++ {
+		msh, err := background.GetMeshByIndex(index)
+		if err != nil {
+			break
+		}
+		result = append(result, msh)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return surfacePosition(result[i]).Y() > surfacePosition(result[j]).Y()
+	})
+	return result
+}
+
+// Update handles the focus keys, then it calls the update of the menu. In case
+// of activation the pointer of the menu is placed over the focused option with
+// pressed button. The options could change after the update (eg. the state is
+// changed by the activated option), the focus is kept on the same index.
+func (m *MenuScreen) Update(dt float64, p interfaces.Pointer, keyStore interfaces.RoKeyStore, buttonStore interfaces.RoButtonStore) {
+	direction, activate, _ := keys(keyStore)
+	surfaces := m.surfaces()
+	if direction != 0 {
+		m.current = neighbour(m.current, len(surfaces), direction)
+	}
+	if activate && m.current >= 0 && m.current < len(surfaces) {
+		p = newPointer(surfacePosition(surfaces[m.current]), m.frameWidth, m.GetAspectRatio())
+		buttonStore = click{}
+	}
+	m.MenuScreen.Update(dt, p, keyStore, buttonStore)
+	surfaces = m.surfaces()
+	if m.current >= len(surfaces) {
+		m.current = len(surfaces) - 1
+	}
+	if m.current >= 0 {
+		m.highlight.set(surfaces[m.current])
+	} else {
+		m.highlight.set(nil)
+	}
+}
@@ -0,0 +1,39 @@
+package glext
+
+import (
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+const (
+	// The anisotropic filtering parameters, they are missing from the glwrapper package.
+	MAX_TEXTURE_MAX_ANISOTROPY = gl.MAX_TEXTURE_MAX_ANISOTROPY
+	TEXTURE_MAX_ANISOTROPY     = gl.TEXTURE_MAX_ANISOTROPY
+)
+
+// GLWrapper is the gl wrapper of the engine extended with the functions
+// that are needed by the packages of this repo, but are missing from the
+// interface of the engine.
+type GLWrapper interface {
+	interfaces.GLWrapper
+	DeleteTextures(n int32, textures *uint32)
+	GetFloatv(pname uint32, data *float32)
+}
+
+// Wrapper implements the GLWrapper interface. It could be used everywhere
+// instead of the wrapper of the engine.
+type Wrapper struct {
+	glwrapper.Wrapper
+}
+
+// Wrapper for gl.DeleteTextures function.
+func (w Wrapper) DeleteTextures(n int32, textures *uint32) {
+	gl.DeleteTextures(n, textures)
+}
+
+// Wrapper for gl.GetFloatv function.
+func (w Wrapper) GetFloatv(pname uint32, data *float32) {
+	gl.GetFloatv(pname, data)
+}
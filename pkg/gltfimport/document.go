@@ -0,0 +1,512 @@
+package gltfimport
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// Binary container header values.
+	glbMagic     = 0x46546C67 // "glTF"
+	glbVersion   = 2
+	glbChunkJSON = 0x4E4F534A // "JSON"
+	glbChunkBIN  = 0x004E4942 // "BIN\x00"
+
+	// Accessor component types.
+	componentByte          = 5120
+	componentUnsignedByte  = 5121
+	componentShort         = 5122
+	componentUnsignedShort = 5123
+	componentUnsignedInt   = 5125
+	componentFloat         = 5126
+
+	// Primitive modes. Only the triangle based ones are supported.
+	modeTriangles     = 4
+	modeTriangleStrip = 5
+	modeTriangleFan   = 6
+)
+
+// The number of components of the accessor types.
+var typeComponents = map[string]int{
+	"SCALAR": 1,
+	"VEC2":   2,
+	"VEC3":   3,
+	"VEC4":   4,
+	"MAT2":   4,
+	"MAT3":   9,
+	"MAT4":   16,
+}
+
+// The byte size of the accessor component types.
+var componentSizes = map[int]int{
+	componentByte:          1,
+	componentUnsignedByte:  1,
+	componentShort:         2,
+	componentUnsignedShort: 2,
+	componentUnsignedInt:   4,
+	componentFloat:         4,
+}
+
+// document is the json part of the gltf asset. Only the fields that
+// are used by the importer are listed.
+type document struct {
+	Asset struct {
+		Version string `json:"version"`
+	} `json:"asset"`
+	Scene       *int             `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+	Materials   []gltfMaterial   `json:"materials"`
+	Textures    []gltfTexture    `json:"textures"`
+	Images      []gltfImage      `json:"images"`
+	Samplers    []gltfSampler    `json:"samplers"`
+	Skins       []gltfSkin       `json:"skins"`
+	Animations  []gltfAnimation  `json:"animations"`
+}
+
+type gltfScene struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes"`
+}
+type gltfNode struct {
+	Name        string    `json:"name"`
+	Children    []int     `json:"children"`
+	Mesh        *int      `json:"mesh"`
+	Skin        *int      `json:"skin"`
+	Matrix      []float32 `json:"matrix"`
+	Translation []float32 `json:"translation"`
+	Rotation    []float32 `json:"rotation"`
+	Scale       []float32 `json:"scale"`
+}
+type gltfMesh struct {
+	Name       string          `json:"name"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *int           `json:"mode"`
+}
+type gltfAccessor struct {
+	BufferView    *int        `json:"bufferView"`
+	ByteOffset    int         `json:"byteOffset"`
+	ComponentType int         `json:"componentType"`
+	Normalized    bool        `json:"normalized"`
+	Count         int         `json:"count"`
+	Type          string      `json:"type"`
+	Sparse        *gltfSparse `json:"sparse"`
+}
+type gltfSparse struct {
+	Count   int `json:"count"`
+	Indices struct {
+		BufferView    int `json:"bufferView"`
+		ByteOffset    int `json:"byteOffset"`
+		ComponentType int `json:"componentType"`
+	} `json:"indices"`
+	Values struct {
+		BufferView int `json:"bufferView"`
+		ByteOffset int `json:"byteOffset"`
+	} `json:"values"`
+}
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+type gltfBuffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+type gltfTextureInfo struct {
+	Index    int `json:"index"`
+	TexCoord int `json:"texCoord"`
+}
+type gltfMaterial struct {
+	Name                 string `json:"name"`
+	PbrMetallicRoughness *struct {
+		BaseColorFactor          []float32        `json:"baseColorFactor"`
+		BaseColorTexture         *gltfTextureInfo `json:"baseColorTexture"`
+		MetallicFactor           *float32         `json:"metallicFactor"`
+		RoughnessFactor          *float32         `json:"roughnessFactor"`
+		MetallicRoughnessTexture *gltfTextureInfo `json:"metallicRoughnessTexture"`
+	} `json:"pbrMetallicRoughness"`
+}
+type gltfTexture struct {
+	Sampler *int `json:"sampler"`
+	Source  *int `json:"source"`
+}
+type gltfImage struct {
+	Name       string `json:"name"`
+	URI        string `json:"uri"`
+	MimeType   string `json:"mimeType"`
+	BufferView *int   `json:"bufferView"`
+}
+type gltfSampler struct {
+	MagFilter int `json:"magFilter"`
+	MinFilter int `json:"minFilter"`
+	WrapS     int `json:"wrapS"`
+	WrapT     int `json:"wrapT"`
+}
+
+type gltfSkin struct {
+	Name                string `json:"name"`
+	InverseBindMatrices *int   `json:"inverseBindMatrices"`
+	Joints              []int  `json:"joints"`
+	Skeleton            *int   `json:"skeleton"`
+}
+type gltfAnimation struct {
+	Name     string `json:"name"`
+	Channels []struct {
+		Sampler int `json:"sampler"`
+		Target  struct {
+			Node *int   `json:"node"`
+			Path string `json:"path"`
+		} `json:"target"`
+	} `json:"channels"`
+	Samplers []struct {
+		Input         int    `json:"input"`
+		Interpolation string `json:"interpolation"`
+		Output        int    `json:"output"`
+	} `json:"samplers"`
+}
+
+// asset is the parsed gltf file with the loaded buffers.
+type asset struct {
+	doc      document
+	buffers  [][]byte
+	basePath string
+}
+
+// loadAsset reads the given .gltf or .glb file. The external and the
+// data uri buffers are loaded also.
+func loadAsset(basePath, fileName string) (*asset, error) {
+	content, err := ioutil.ReadFile(filepath.Join(basePath, fileName))
+	if err != nil {
+		return nil, err
+	}
+	a := &asset{basePath: basePath}
+	var binChunk []byte
+	if len(content) >= 12 && binary.LittleEndian.Uint32(content[0:4]) == glbMagic {
+		var jsonChunk []byte
+		jsonChunk, binChunk, err = splitGLB(content)
+		if err != nil {
+			return nil, err
+		}
+		content = jsonChunk
+	}
+	if err := json.Unmarshal(content, &a.doc); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(a.doc.Asset.Version, "2") {
+		return nil, fmt.Errorf("Unsupported gltf version '%s'.", a.doc.Asset.Version)
+	}
+	for index, buffer := range a.doc.Buffers {
+		var data []byte
+		if buffer.URI == "" {
+			// The first buffer of the binary container refers to the BIN chunk.
+			if index != 0 || binChunk == nil {
+				return nil, fmt.Errorf("Missing data of buffer '%d'.", index)
+			}
+			data = binChunk
+		} else {
+			data, err = a.readURI(buffer.URI)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(data) < buffer.ByteLength {
+			return nil, fmt.Errorf("Buffer '%d' is shorter than its byteLength.", index)
+		}
+		a.buffers = append(a.buffers, data[:buffer.ByteLength])
+	}
+	return a, nil
+}
+
+// splitGLB validates the header of the binary container and returns its
+// json and binary chunks.
+func splitGLB(content []byte) ([]byte, []byte, error) {
+	if binary.LittleEndian.Uint32(content[4:8]) != glbVersion {
+		return nil, nil, errors.New("Unsupported glb container version.")
+	}
+	length := int(binary.LittleEndian.Uint32(content[8:12]))
+	if length > len(content) {
+		return nil, nil, errors.New("Truncated glb container.")
+	}
+	var jsonChunk, binChunk []byte
+	offset := 12
+	for offset+8 <= length {
+		chunkLength := int(binary.LittleEndian.Uint32(content[offset : offset+4]))
+		chunkType := binary.LittleEndian.Uint32(content[offset+4 : offset+8])
+		offset += 8
+		if offset+chunkLength > length {
+			return nil, nil, errors.New("Truncated glb chunk.")
+		}
+		switch chunkType {
+		case glbChunkJSON:
+			jsonChunk = content[offset : offset+chunkLength]
+		case glbChunkBIN:
+			if binChunk == nil {
+				binChunk = content[offset : offset+chunkLength]
+			}
+		}
+		offset += chunkLength
+	}
+	if jsonChunk == nil {
+		return nil, nil, errors.New("Missing json chunk in glb container.")
+	}
+	return jsonChunk, binChunk, nil
+}
+
+// readURI returns the content of the given uri. It handles the base64
+// encoded data uris and the files relative to the asset directory.
+func (a *asset) readURI(uri string) ([]byte, error) {
+	if isDataURI(uri) {
+		comma := strings.Index(uri, ",")
+		if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+			return nil, errors.New("Only base64 data uris are supported.")
+		}
+		return base64.StdEncoding.DecodeString(uri[comma+1:])
+	}
+	fileName, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(filepath.Join(a.basePath, filepath.FromSlash(fileName)))
+}
+
+// isDataURI returns true if the given uri contains the data itself.
+func isDataURI(uri string) bool {
+	return strings.HasPrefix(uri, "data:")
+}
+
+// bufferViewData returns the bytes of the given buffer view.
+func (a *asset) bufferViewData(index int) ([]byte, int, error) {
+	if index < 0 || index >= len(a.doc.BufferViews) {
+		return nil, 0, fmt.Errorf("Invalid buffer view '%d'.", index)
+	}
+	view := a.doc.BufferViews[index]
+	if view.Buffer < 0 || view.Buffer >= len(a.buffers) {
+		return nil, 0, fmt.Errorf("Invalid buffer '%d'.", view.Buffer)
+	}
+	if view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteStride < 0 {
+		return nil, 0, fmt.Errorf("Invalid buffer view '%d'.", index)
+	}
+	buffer := a.buffers[view.Buffer]
+	if view.ByteOffset+view.ByteLength > len(buffer) {
+		return nil, 0, fmt.Errorf("Buffer view '%d' is out of range.", index)
+	}
+	return buffer[view.ByteOffset : view.ByteOffset+view.ByteLength], view.ByteStride, nil
+}
+
+// readComponent returns the value of the component that starts in the
+// beginning of the given data as float. The normalized integers are mapped
+// to the [0,1] or [-1,1] ranges.
+func readComponent(data []byte, componentType int, normalized bool) float32 {
+	switch componentType {
+	case componentByte:
+		v := float32(int8(data[0]))
+		if normalized {
+			return mgl32.Clamp(v/127.0, -1, 1)
+		}
+		return v
+	case componentUnsignedByte:
+		v := float32(data[0])
+		if normalized {
+			return v / 255.0
+		}
+		return v
+	case componentShort:
+		v := float32(int16(binary.LittleEndian.Uint16(data)))
+		if normalized {
+			return mgl32.Clamp(v/32767.0, -1, 1)
+		}
+		return v
+	case componentUnsignedShort:
+		v := float32(binary.LittleEndian.Uint16(data))
+		if normalized {
+			return v / 65535.0
+		}
+		return v
+	case componentUnsignedInt:
+		return float32(binary.LittleEndian.Uint32(data))
+	}
+	return math.Float32frombits(binary.LittleEndian.Uint32(data))
+}
+
+// readIndex returns the integer value of the component that starts in the
+// beginning of the given data.
+func readIndex(data []byte, componentType int) uint32 {
+	switch componentType {
+	case componentUnsignedByte:
+		return uint32(data[0])
+	case componentUnsignedShort:
+		return uint32(binary.LittleEndian.Uint16(data))
+	}
+	return binary.LittleEndian.Uint32(data)
+}
+
+// accessorElements returns the bytes of the elements of the given accessor.
+// Every slice starts at the first component of the element, the sparse
+// substitution is also applied. The slice is nil if the element is zero.
+func (a *asset) accessorElements(index int) (gltfAccessor, [][]byte, error) {
+	if index < 0 || index >= len(a.doc.Accessors) {
+		return gltfAccessor{}, nil, fmt.Errorf("Invalid accessor '%d'.", index)
+	}
+	accessor := a.doc.Accessors[index]
+	if accessor.ByteOffset < 0 || accessor.Count < 0 {
+		return accessor, nil, fmt.Errorf("Invalid accessor '%d'.", index)
+	}
+	components, ok := typeComponents[accessor.Type]
+	if !ok {
+		return accessor, nil, fmt.Errorf("Invalid accessor type '%s'.", accessor.Type)
+	}
+	componentSize, ok := componentSizes[accessor.ComponentType]
+	if !ok {
+		return accessor, nil, fmt.Errorf("Invalid component type '%d'.", accessor.ComponentType)
+	}
+	elementSize := components * componentSize
+	result := make([][]byte, accessor.Count)
+	if accessor.BufferView != nil {
+		data, stride, err := a.bufferViewData(*accessor.BufferView)
+		if err != nil {
+			return accessor, nil, err
+		}
+		if stride == 0 {
+			stride = elementSize
+		}
+		if accessor.Count > 0 && accessor.ByteOffset+(accessor.Count-1)*stride+elementSize > len(data) {
+			return accessor, nil, fmt.Errorf("Accessor '%d' is out of range.", index)
+		}
+		for i := range result {
+			result[i] = data[accessor.ByteOffset+i*stride:]
+		}
+	}
+	if accessor.Sparse != nil {
+		sparse := accessor.Sparse
+		if sparse.Count < 0 || sparse.Indices.ByteOffset < 0 || sparse.Values.ByteOffset < 0 {
+			return accessor, nil, fmt.Errorf("Invalid sparse data of accessor '%d'.", index)
+		}
+		indexData, _, err := a.bufferViewData(sparse.Indices.BufferView)
+		if err != nil {
+			return accessor, nil, err
+		}
+		valueData, _, err := a.bufferViewData(sparse.Values.BufferView)
+		if err != nil {
+			return accessor, nil, err
+		}
+		indexSize := componentSizes[sparse.Indices.ComponentType]
+		if indexSize == 0 || sparse.Indices.ByteOffset+sparse.Count*indexSize > len(indexData) ||
+			sparse.Values.ByteOffset+sparse.Count*elementSize > len(valueData) {
+			return accessor, nil, fmt.Errorf("Sparse data of accessor '%d' is out of range.", index)
+		}
+		for i := 0; i < sparse.Count; i++ {
+			target := int(readIndex(indexData[sparse.Indices.ByteOffset+i*indexSize:], sparse.Indices.ComponentType))
+			if target >= len(result) {
+				return accessor, nil, fmt.Errorf("Sparse index '%d' of accessor '%d' is out of range.", target, index)
+			}
+			result[target] = valueData[sparse.Values.ByteOffset+i*elementSize:]
+		}
+	}
+	return accessor, result, nil
+}
+
+// readAccessor returns the elements of the given accessor as float slices.
+// The sparse substitution is also applied.
+func (a *asset) readAccessor(index int) ([][]float32, error) {
+	accessor, elements, err := a.accessorElements(index)
+	if err != nil {
+		return nil, err
+	}
+	components := typeComponents[accessor.Type]
+	componentSize := componentSizes[accessor.ComponentType]
+	result := make([][]float32, len(elements))
+	for i, element := range elements {
+		result[i] = make([]float32, components)
+		if element == nil {
+			continue
+		}
+		for c := 0; c < components; c++ {
+			result[i][c] = readComponent(element[c*componentSize:], accessor.ComponentType, accessor.Normalized)
+		}
+	}
+	return result, nil
+}
+
+// readIndices returns the values of the given scalar accessor as uint32 slice.
+// The values are read as integers, so that the indices above 2^24 are also
+// exact.
+func (a *asset) readIndices(index int) ([]uint32, error) {
+	accessor, elements, err := a.accessorElements(index)
+	if err != nil {
+		return nil, err
+	}
+	if accessor.Type != "SCALAR" {
+		return nil, fmt.Errorf("Invalid index accessor type '%s'.", accessor.Type)
+	}
+	switch accessor.ComponentType {
+	case componentUnsignedByte, componentUnsignedShort, componentUnsignedInt:
+		break
+	default:
+		return nil, fmt.Errorf("Invalid index component type '%d'.", accessor.ComponentType)
+	}
+	result := make([]uint32, len(elements))
+	for i, element := range elements {
+		if element != nil {
+			result[i] = readIndex(element, accessor.ComponentType)
+		}
+	}
+	return result, nil
+}
+
+// imageData returns the encoded content of the given image. The image
+// could be stored in a buffer view, in a data uri or in an external file.
+func (a *asset) imageData(index int) ([]byte, error) {
+	if index < 0 || index >= len(a.doc.Images) {
+		return nil, fmt.Errorf("Invalid image '%d'.", index)
+	}
+	img := a.doc.Images[index]
+	if img.BufferView != nil {
+		data, _, err := a.bufferViewData(*img.BufferView)
+		return data, err
+	}
+	return a.readURI(img.URI)
+}
+
+// localTransformation returns the local transformation matrix of the node.
+// The matrix property is used if it's set, otherwise it's composed from the
+// translation, rotation and scale properties.
+func (n *gltfNode) localTransformation() mgl32.Mat4 {
+	if len(n.Matrix) == 16 {
+		var m mgl32.Mat4
+		copy(m[:], n.Matrix)
+		return m
+	}
+	result := mgl32.Ident4()
+	if len(n.Translation) == 3 {
+		result = mgl32.Translate3D(n.Translation[0], n.Translation[1], n.Translation[2])
+	}
+	if len(n.Rotation) == 4 {
+		q := mgl32.Quat{W: n.Rotation[3], V: mgl32.Vec3{n.Rotation[0], n.Rotation[1], n.Rotation[2]}}
+		result = result.Mul4(q.Normalize().Mat4())
+	}
+	if len(n.Scale) == 3 {
+		result = result.Mul4(mgl32.Scale3D(n.Scale[0], n.Scale[1], n.Scale[2]))
+	}
+	return result
+}
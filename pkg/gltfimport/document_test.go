@@ -0,0 +1,147 @@
+package gltfimport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// The buffer views of the test asset. The 7th view has negative offset.
+const testBufferViews = `[
+	{"buffer": 0, "byteOffset": 0, "byteLength": 24},
+	{"buffer": 0, "byteOffset": 0, "byteLength": 24, "byteStride": 12},
+	{"buffer": 0, "byteOffset": 24, "byteLength": 4},
+	{"buffer": 0, "byteOffset": 28, "byteLength": 4},
+	{"buffer": 0, "byteOffset": 32, "byteLength": 8},
+	{"buffer": 0, "byteOffset": 40, "byteLength": 4},
+	{"buffer": 0, "byteOffset": 44, "byteLength": 12},
+	{"buffer": 0, "byteOffset": -4, "byteLength": 4}
+]`
+
+// The accessors of the test asset. The first ones are valid, the ones
+// after the 10th are invalid.
+const testAccessors = `[
+	{"bufferView": 0, "componentType": 5126, "count": 2, "type": "VEC3"},
+	{"bufferView": 1, "componentType": 5126, "count": 2, "type": "VEC2"},
+	{"bufferView": 2, "componentType": 5121, "normalized": true, "count": 3, "type": "SCALAR"},
+	{"bufferView": 2, "componentType": 5120, "normalized": true, "count": 4, "type": "SCALAR"},
+	{"bufferView": 3, "componentType": 5123, "normalized": true, "count": 2, "type": "SCALAR"},
+	{"componentType": 5126, "count": 3, "type": "VEC3", "sparse": {"count": 1, "indices": {"bufferView": 5, "componentType": 5121}, "values": {"bufferView": 6}}},
+	{"bufferView": 0, "componentType": 5126, "count": 2, "type": "VEC3", "sparse": {"count": 1, "indices": {"bufferView": 5, "componentType": 5121}, "values": {"bufferView": 6}}},
+	{"bufferView": 2, "componentType": 5121, "count": 4, "type": "SCALAR"},
+	{"bufferView": 3, "componentType": 5123, "count": 2, "type": "SCALAR"},
+	{"bufferView": 4, "componentType": 5125, "count": 2, "type": "SCALAR"},
+	{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+	{"bufferView": 0, "byteOffset": -4, "componentType": 5126, "count": 1, "type": "VEC3"},
+	{"bufferView": 0, "componentType": 5126, "count": -1, "type": "VEC3"},
+	{"bufferView": 7, "componentType": 5121, "count": 1, "type": "SCALAR"},
+	{"bufferView": 0, "componentType": 5126, "count": 1, "type": "VEC5"},
+	{"bufferView": 0, "componentType": 1234, "count": 1, "type": "VEC3"},
+	{"componentType": 5126, "count": 1, "type": "VEC3", "sparse": {"count": 1, "indices": {"bufferView": 5, "componentType": 5121}, "values": {"bufferView": 6}}},
+	{"componentType": 5126, "count": 3, "type": "VEC3", "sparse": {"count": -1, "indices": {"bufferView": 5, "componentType": 5121}, "values": {"bufferView": 6}}},
+	{"bufferView": 0, "componentType": 5126, "count": 2, "type": "SCALAR"}
+]`
+
+// testAsset returns the asset of the accessor tests. The buffer contains
+// float, byte, short, int values and the sparse indices and values.
+func testAsset(t *testing.T) *asset {
+	var buffer bytes.Buffer
+	for _, value := range []interface{}{
+		[]float32{1, 2, 3, 4, 5, 6},
+		[]uint8{0, 255, 128, 0},
+		[]uint16{0, 65535},
+		[]uint32{16777217, 7},
+		[]uint8{1, 0, 0, 0},
+		[]float32{9, 9, 9},
+	} {
+		if err := binary.Write(&buffer, binary.LittleEndian, value); err != nil {
+			t.Fatalf("Buffer write shouldn't fail. '%s'.", err.Error())
+		}
+	}
+	a := &asset{buffers: [][]byte{buffer.Bytes()}}
+	if err := json.Unmarshal([]byte(`{"accessors": `+testAccessors+`, "bufferViews": `+testBufferViews+`}`), &a.doc); err != nil {
+		t.Fatalf("Document parse shouldn't fail. '%s'.", err.Error())
+	}
+	return a
+}
+
+func TestReadAccessor(t *testing.T) {
+	a := testAsset(t)
+	testData := []struct {
+		name     string
+		accessor int
+		expected [][]float32
+	}{
+		{"float vec3", 0, [][]float32{{1, 2, 3}, {4, 5, 6}}},
+		{"strided vec2", 1, [][]float32{{1, 2}, {4, 5}}},
+		{"normalized unsigned byte", 2, [][]float32{{0}, {1}, {128.0 / 255.0}}},
+		{"normalized byte", 3, [][]float32{{0}, {-1.0 / 127.0}, {-1}, {0}}},
+		{"normalized unsigned short", 4, [][]float32{{0}, {1}}},
+		{"sparse without buffer view", 5, [][]float32{{0, 0, 0}, {9, 9, 9}, {0, 0, 0}}},
+		{"sparse with buffer view", 6, [][]float32{{1, 2, 3}, {9, 9, 9}}},
+		{"unsigned int", 9, [][]float32{{16777216}, {7}}},
+	}
+	for _, tt := range testData {
+		result, err := a.readAccessor(tt.accessor)
+		if err != nil {
+			t.Errorf("%s: readAccessor shouldn't fail. '%s'.", tt.name, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("%s: Invalid values. Instead of '%v', we have '%v'.", tt.name, tt.expected, result)
+		}
+	}
+}
+
+func TestReadAccessorError(t *testing.T) {
+	a := testAsset(t)
+	testData := []struct {
+		name     string
+		accessor int
+	}{
+		{"invalid index", 99},
+		{"negative index", -1},
+		{"out of range", 10},
+		{"negative byte offset", 11},
+		{"negative count", 12},
+		{"negative buffer view offset", 13},
+		{"invalid type", 14},
+		{"invalid component type", 15},
+		{"sparse index out of range", 16},
+		{"negative sparse count", 17},
+	}
+	for _, tt := range testData {
+		if _, err := a.readAccessor(tt.accessor); err == nil {
+			t.Errorf("%s: readAccessor should fail.", tt.name)
+		}
+	}
+}
+
+func TestReadIndices(t *testing.T) {
+	a := testAsset(t)
+	testData := []struct {
+		name     string
+		accessor int
+		expected []uint32
+		valid    bool
+	}{
+		{"unsigned byte", 7, []uint32{0, 255, 128, 0}, true},
+		{"unsigned short", 8, []uint32{0, 65535}, true},
+		{"unsigned int above 2^24", 9, []uint32{16777217, 7}, true},
+		{"vector type", 0, nil, false},
+		{"float component", 18, nil, false},
+		{"out of range", 10, nil, false},
+	}
+	for _, tt := range testData {
+		result, err := a.readIndices(tt.accessor)
+		if tt.valid != (err == nil) {
+			t.Errorf("%s: Invalid error state. Expected valid: '%v', error: '%v'.", tt.name, tt.valid, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("%s: Invalid indices. Instead of '%v', we have '%v'.", tt.name, tt.expected, result)
+		}
+	}
+}
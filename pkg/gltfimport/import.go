@@ -0,0 +1,579 @@
+package gltfimport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"path/filepath"
+
+	"github.com/akosgarai/opengl_playground/pkg/animation"
+	"github.com/akosgarai/opengl_playground/pkg/texturecache"
+
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/material"
+	"github.com/akosgarai/playground_engine/pkg/mesh"
+	"github.com/akosgarai/playground_engine/pkg/primitives/vertex"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	DEBUG = false
+
+	// The ambient color of the materials is the base color multiplied with this factor.
+	AmbientFactor = float32(0.2)
+	// The maximal shininess value, that belongs to the 0 roughness.
+	MaxShininess = float32(128.0)
+
+	// The default sampler values of the gltf spec. (REPEAT, LINEAR)
+	defaultWrap   = 10497
+	defaultFilter = glwrapper.LINEAR
+)
+
+// Node represents a node of the gltf node hierarchy.
+type Node struct {
+	// The name of the node in the gltf document.
+	Name string
+	// The index of the parent node. It's -1 for the root nodes.
+	Parent int
+	// The indices of the child nodes.
+	Children []int
+	// The local transformation of the node.
+	Transformation mgl32.Mat4
+	// The meshes that were made from the primitives of the mesh of the node.
+	Meshes []interfaces.Mesh
+	// The index of the skin of the meshes. It's -1 if the meshes are not skinned.
+	Skin int
+}
+
+// parentSetter is implemented by every mesh type of the mesh package.
+type parentSetter interface {
+	SetParent(interfaces.Mesh)
+}
+
+type Import struct {
+	fileName      string
+	basePath      string
+	meshes        []interfaces.Mesh
+	nodes         []*Node
+	skeletons     []*animation.Skeleton
+	clips         []*animation.Clip
+	skinnedMeshes map[int][]*animation.SkinnedMesh
+	asset         *asset
+	images        map[int]*image.RGBA
+	cache         *texturecache.Cache
+	glWrapper     interfaces.GLWrapper
+}
+
+// New returns an importer that reads the fileName (.gltf or .glb) from the basePath directory.
+func New(basePath, fileName string, wrapper interfaces.GLWrapper) *Import {
+	return &Import{
+		fileName:      fileName,
+		basePath:      basePath,
+		meshes:        []interfaces.Mesh{},
+		nodes:         []*Node{},
+		skinnedMeshes: make(map[int][]*animation.SkinnedMesh),
+		images:        make(map[int]*image.RGBA),
+		glWrapper:     wrapper,
+	}
+}
+
+// SetCache sets the texture cache. If it is set, the textures are added
+// through the cache, so that the same image with the same sampler is
+// uploaded only once and the quality options of the cache are applied.
+func (i *Import) SetCache(c *texturecache.Cache) {
+	i.cache = c
+}
+
+// GetMeshes returns the imported meshes. The meshes of the child nodes are
+// connected to the first mesh of the closest ancestor node that has mesh.
+// The skinned meshes are also returned, without animation they are in bind pose.
+func (i *Import) GetMeshes() []interfaces.Mesh {
+	return i.meshes
+}
+
+// GetNodes returns the node hierarchy of the imported file. The indices
+// are the same as in the gltf document.
+func (i *Import) GetNodes() []*Node {
+	return i.nodes
+}
+
+// Load parses the file and decodes the images. It doesn't call gl functions,
+// so that it could be run on a worker goroutine. The meshes are made by the Upload.
+func (i *Import) Load() error {
+	var err error
+	if DEBUG {
+		fmt.Printf("Loading gltf file: '%s'.\n", filepath.Join(i.basePath, i.fileName))
+	}
+	i.asset, err = loadAsset(i.basePath, i.fileName)
+	if err != nil {
+		return fmt.Errorf("Error during gltf file parse. '%s'", err.Error())
+	}
+	for index := range i.asset.doc.Images {
+		// The invalid images are not cached, their errors are
+		// reported during the mesh construction.
+		i.getImage(index)
+	}
+	return nil
+}
+
+// Upload makes the meshes from the nodes of the default scene. It has
+// to be called from the thread of the gl context, after the Load.
+func (i *Import) Upload() {
+	errProcess := i.makeMeshes()
+	if len(errProcess) != 0 {
+		fmt.Println("Error during mesh construction.")
+		for _, err := range errProcess {
+			fmt.Printf(" - %s\n", err.Error())
+		}
+		return
+	}
+	fmt.Println("Import process finished.")
+}
+
+// Import loads the file and makes the meshes from the nodes of the default scene.
+func (i *Import) Import() {
+	fmt.Println("Import process started.")
+	if err := i.Load(); err != nil {
+		fmt.Print(err.Error())
+		panic(err)
+	}
+	i.Upload()
+}
+
+// rootNodes returns the root nodes of the default scene. If the document
+// doesn't contain scenes, every node without parent is returned.
+func (i *Import) rootNodes() []int {
+	doc := &i.asset.doc
+	if len(doc.Scenes) > 0 {
+		scene := 0
+		if doc.Scene != nil && *doc.Scene < len(doc.Scenes) {
+			scene = *doc.Scene
+		}
+		return doc.Scenes[scene].Nodes
+	}
+	var roots []int
+	for index, node := range i.nodes {
+		if node.Parent == -1 {
+			roots = append(roots, index)
+		}
+	}
+	return roots
+}
+
+// makeMeshes builds the node hierarchy and walks it from the root nodes.
+func (i *Import) makeMeshes() []error {
+	var result []error
+	doc := &i.asset.doc
+	for _, n := range doc.Nodes {
+		i.nodes = append(i.nodes, &Node{
+			Name:           n.Name,
+			Parent:         -1,
+			Children:       n.Children,
+			Transformation: n.localTransformation(),
+			Skin:           -1,
+		})
+	}
+	for index, node := range i.nodes {
+		for _, child := range node.Children {
+			if child < 0 || child >= len(i.nodes) {
+				result = append(result, fmt.Errorf("Invalid child '%d' of node '%d'.", child, index))
+				continue
+			}
+			i.nodes[child].Parent = index
+		}
+	}
+	result = append(result, i.makeSkeletons()...)
+	result = append(result, i.makeClips()...)
+	visited := make(map[int]bool)
+	for _, root := range i.rootNodes() {
+		result = append(result, i.visitNode(root, mgl32.Ident4(), nil, mgl32.Vec3{}, visited)...)
+	}
+	return result
+}
+
+// visitNode makes the meshes of the given node and its descendants. The rotation
+// and the scale of the world transformation are applied to the vertices, the
+// translation is used as mesh position, relative to the position of the parent mesh.
+func (i *Import) visitNode(index int, parentTransformation mgl32.Mat4, parentMesh interfaces.Mesh, parentOrigin mgl32.Vec3, visited map[int]bool) []error {
+	var result []error
+	if index < 0 || index >= len(i.nodes) {
+		return []error{fmt.Errorf("Invalid node '%d'.", index)}
+	}
+	if visited[index] {
+		return []error{fmt.Errorf("Node '%d' is visited multiple times.", index)}
+	}
+	visited[index] = true
+	node := i.nodes[index]
+	world := parentTransformation.Mul4(node.Transformation)
+	origin := world.Col(3).Vec3()
+	linear := world
+	linear.SetCol(3, mgl32.Vec4{0, 0, 0, 1})
+
+	gltfNode := &i.asset.doc.Nodes[index]
+	if gltfNode.Mesh != nil {
+		if *gltfNode.Mesh < 0 || *gltfNode.Mesh >= len(i.asset.doc.Meshes) {
+			result = append(result, fmt.Errorf("Invalid mesh '%d' of node '%d'.", *gltfNode.Mesh, index))
+		} else if gltfNode.Skin != nil {
+			result = append(result, i.makeSkinnedMeshes(index, *gltfNode.Skin)...)
+		} else {
+			for primitiveIndex, primitive := range i.asset.doc.Meshes[*gltfNode.Mesh].Primitives {
+				m, err := i.makeMesh(&primitive, linear)
+				if err != nil {
+					result = append(result, fmt.Errorf("Node '%d' primitive '%d': %s", index, primitiveIndex, err.Error()))
+					continue
+				}
+				m.SetPosition(origin.Sub(parentOrigin))
+				if parentMesh != nil {
+					m.(parentSetter).SetParent(parentMesh)
+				}
+				node.Meshes = append(node.Meshes, m)
+				i.meshes = append(i.meshes, m)
+			}
+		}
+	}
+	if len(node.Meshes) > 0 && node.Skin == -1 {
+		parentMesh = node.Meshes[0]
+		parentOrigin = origin
+	}
+	for _, child := range node.Children {
+		result = append(result, i.visitNode(child, world, parentMesh, parentOrigin, visited)...)
+	}
+	return result
+}
+
+// makeSkinnedMeshes makes the skinned meshes of the given node. The transformation of
+// the node is ignored, the meshes are placed by the joints of the skin, so that they
+// are not connected to the parent meshes. The primitives without joints and weights
+// are skipped.
+func (i *Import) makeSkinnedMeshes(index, skin int) []error {
+	var result []error
+	node := i.nodes[index]
+	node.Skin = skin
+	for primitiveIndex, primitive := range i.asset.doc.Meshes[*i.asset.doc.Nodes[index].Mesh].Primitives {
+		if !isSkinned(&primitive) {
+			result = append(result, fmt.Errorf("Node '%d' primitive '%d': Missing JOINTS_0 or WEIGHTS_0 attribute.", index, primitiveIndex))
+			continue
+		}
+		m, err := i.makeSkinnedMesh(&primitive, skin)
+		if err != nil {
+			result = append(result, fmt.Errorf("Node '%d' primitive '%d': %s", index, primitiveIndex, err.Error()))
+			continue
+		}
+		node.Meshes = append(node.Meshes, m)
+		i.meshes = append(i.meshes, m)
+		i.skinnedMeshes[skin] = append(i.skinnedMeshes[skin], m)
+	}
+	return result
+}
+
+// getTriangleIndices returns the indices of the primitive as triangle list.
+func (i *Import) getTriangleIndices(p *gltfPrimitive, vertexCount int) ([]uint32, error) {
+	var indices []uint32
+	if p.Indices != nil {
+		var err error
+		indices, err = i.asset.readIndices(*p.Indices)
+		if err != nil {
+			return nil, err
+		}
+		for _, index := range indices {
+			if int(index) >= vertexCount {
+				return nil, fmt.Errorf("Index '%d' is out of range.", index)
+			}
+		}
+	} else {
+		for index := 0; index < vertexCount; index++ {
+			indices = append(indices, uint32(index))
+		}
+	}
+	mode := modeTriangles
+	if p.Mode != nil {
+		mode = *p.Mode
+	}
+	switch mode {
+	case modeTriangles:
+		return indices[:len(indices)-len(indices)%3], nil
+	case modeTriangleStrip:
+		var triangles []uint32
+		for index := 0; index+2 < len(indices); index++ {
+			if index%2 == 0 {
+				triangles = append(triangles, indices[index], indices[index+1], indices[index+2])
+			} else {
+				triangles = append(triangles, indices[index+1], indices[index], indices[index+2])
+			}
+		}
+		return triangles, nil
+	case modeTriangleFan:
+		var triangles []uint32
+		for index := 1; index+1 < len(indices); index++ {
+			triangles = append(triangles, indices[index], indices[index+1], indices[0])
+		}
+		return triangles, nil
+	}
+	return nil, fmt.Errorf("Unsupported primitive mode '%d'.", mode)
+}
+
+// checkAttribute returns error if the attribute doesn't have the given number of
+// elements or its elements don't have the given number of components.
+func checkAttribute(name string, values [][]float32, count, components int) error {
+	if len(values) != count {
+		return fmt.Errorf("Invalid %s attribute, it has %d elements instead of %d.", name, len(values), count)
+	}
+	if count > 0 && len(values[0]) != components {
+		return fmt.Errorf("Invalid %s attribute, its elements have %d components instead of %d.", name, len(values[0]), components)
+	}
+	return nil
+}
+
+// getVertices returns the vertices of the primitive. The positions and the normals
+// are transformed with the given transformation. The second return value is true
+// if the normal vectors are set, the third one is true if the tex coords are set.
+// The NORMAL and the TEXCOORD attributes have to have the same count as the
+// POSITION attribute.
+func (i *Import) getVertices(p *gltfPrimitive, transformation mgl32.Mat4, texCoord int) (vertex.Vertices, bool, bool, error) {
+	positionAccessor, ok := p.Attributes["POSITION"]
+	if !ok {
+		return nil, false, false, errors.New("Missing POSITION attribute.")
+	}
+	positions, err := i.asset.readAccessor(positionAccessor)
+	if err != nil {
+		return nil, false, false, err
+	}
+	if err = checkAttribute("POSITION", positions, len(positions), 3); err != nil {
+		return nil, false, false, err
+	}
+	var normals, texCoords [][]float32
+	hasNormals, hasTexCoords := false, false
+	if normalAccessor, ok := p.Attributes["NORMAL"]; ok {
+		if normals, err = i.asset.readAccessor(normalAccessor); err != nil {
+			return nil, false, false, err
+		}
+		if err = checkAttribute("NORMAL", normals, len(positions), 3); err != nil {
+			return nil, false, false, err
+		}
+		hasNormals = true
+	}
+	texCoordName := fmt.Sprintf("TEXCOORD_%d", texCoord)
+	if texCoordAccessor, ok := p.Attributes[texCoordName]; ok {
+		if texCoords, err = i.asset.readAccessor(texCoordAccessor); err != nil {
+			return nil, false, false, err
+		}
+		if err = checkAttribute(texCoordName, texCoords, len(positions), 2); err != nil {
+			return nil, false, false, err
+		}
+		hasTexCoords = true
+	}
+	normalTransformation := transformation.Mat3().Inv().Transpose()
+
+	vertices := make(vertex.Vertices, len(positions))
+	for index, position := range positions {
+		vertices[index].Position = mgl32.TransformCoordinate(mgl32.Vec3{position[0], position[1], position[2]}, transformation)
+		if hasNormals {
+			normal := normalTransformation.Mul3x1(mgl32.Vec3{normals[index][0], normals[index][1], normals[index][2]})
+			if normal.Len() > 0 {
+				normal = normal.Normalize()
+			}
+			vertices[index].Normal = normal
+		}
+		if hasTexCoords {
+			vertices[index].TexCoords = mgl32.Vec2{texCoords[index][0], texCoords[index][1]}
+		}
+	}
+	return vertices, hasNormals, hasTexCoords, nil
+}
+
+// flatShaded returns new vertices and indices where every triangle has its own
+// vertices with the face normal. It's used when the normals are missing.
+func flatShaded(vertices vertex.Vertices, indices []uint32) (vertex.Vertices, []uint32) {
+	var resultVertices vertex.Vertices
+	var resultIndices []uint32
+	for index := 0; index+2 < len(indices); index += 3 {
+		a, b, c := vertices[indices[index]], vertices[indices[index+1]], vertices[indices[index+2]]
+		normal := b.Position.Sub(a.Position).Cross(c.Position.Sub(a.Position))
+		if normal.Len() > 0 {
+			normal = normal.Normalize()
+		}
+		for _, v := range []vertex.Vertex{a, b, c} {
+			v.Normal = normal
+			resultIndices = append(resultIndices, uint32(len(resultVertices)))
+			resultVertices = append(resultVertices, v)
+		}
+	}
+	return resultVertices, resultIndices
+}
+
+// getMaterial maps the metallic-roughness parameters of the gltf material to
+// the phong like material of the engine. The default values of the spec are
+// used for the missing parameters. The second return value is the base color.
+func (i *Import) getMaterial(m *gltfMaterial) (*material.Material, mgl32.Vec3) {
+	baseColor := mgl32.Vec3{1, 1, 1}
+	metallic := float32(1.0)
+	roughness := float32(1.0)
+	if m != nil && m.PbrMetallicRoughness != nil {
+		pbr := m.PbrMetallicRoughness
+		if len(pbr.BaseColorFactor) >= 3 {
+			baseColor = mgl32.Vec3{pbr.BaseColorFactor[0], pbr.BaseColorFactor[1], pbr.BaseColorFactor[2]}
+		}
+		if pbr.MetallicFactor != nil {
+			metallic = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			roughness = *pbr.RoughnessFactor
+		}
+	}
+	// The dielectric surfaces reflect 4% of the light, the metallic ones reflect the base color.
+	dielectric := mgl32.Vec3{0.04, 0.04, 0.04}
+	specular := dielectric.Add(baseColor.Sub(dielectric).Mul(metallic))
+	shininess := MaxShininess * (1 - roughness) * (1 - roughness)
+	if shininess < 1 {
+		shininess = 1
+	}
+	return material.New(baseColor.Mul(AmbientFactor), baseColor, specular, shininess), baseColor
+}
+
+// getImage returns the decoded image. The images are decoded only once.
+func (i *Import) getImage(index int) (*image.RGBA, error) {
+	if rgba, ok := i.images[index]; ok {
+		return rgba, nil
+	}
+	data, err := i.asset.imageData(index)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	i.images[index] = rgba
+	return rgba, nil
+}
+
+// imagePath returns the path of the image. For the embedded images it is
+// the path of the gltf file with the image index as fragment.
+func (i *Import) imagePath(index int) string {
+	img := i.asset.doc.Images[index]
+	if img.BufferView == nil && img.URI != "" && !isDataURI(img.URI) {
+		return filepath.Join(i.basePath, filepath.FromSlash(img.URI))
+	}
+	return fmt.Sprintf("%s#images/%d", filepath.Join(i.basePath, i.fileName), index)
+}
+
+// addTexture appends the texture with the given gltf texture index to the textures.
+func (i *Import) addTexture(textures *texture.Textures, textureIndex int, uniformName string) error {
+	doc := &i.asset.doc
+	if textureIndex < 0 || textureIndex >= len(doc.Textures) {
+		return fmt.Errorf("Invalid texture '%d'.", textureIndex)
+	}
+	tex := doc.Textures[textureIndex]
+	if tex.Source == nil {
+		return fmt.Errorf("Missing image source of texture '%d'.", textureIndex)
+	}
+	rgba, err := i.getImage(*tex.Source)
+	if err != nil {
+		return err
+	}
+	wrapS, wrapT := int32(defaultWrap), int32(defaultWrap)
+	minFilter, magFilter := int32(defaultFilter), int32(defaultFilter)
+	if tex.Sampler != nil && *tex.Sampler >= 0 && *tex.Sampler < len(doc.Samplers) {
+		sampler := doc.Samplers[*tex.Sampler]
+		if sampler.WrapS != 0 {
+			wrapS = int32(sampler.WrapS)
+		}
+		if sampler.WrapT != 0 {
+			wrapT = int32(sampler.WrapT)
+		}
+		if sampler.MinFilter != 0 {
+			minFilter = int32(sampler.MinFilter)
+		}
+		if sampler.MagFilter != 0 {
+			magFilter = int32(sampler.MagFilter)
+		}
+	}
+	if DEBUG {
+		fmt.Printf("Setup %s map: '%s'.\n", uniformName, i.imagePath(*tex.Source))
+	}
+	// The texture package sets the R and S wrap parameters, so the T value is passed as R.
+	if i.cache != nil {
+		return i.cache.AddTextureRGBA(textures, i.imagePath(*tex.Source), rgba, wrapT, wrapS, minFilter, magFilter, uniformName)
+	}
+	textures.AddTextureRGBA(i.imagePath(*tex.Source), rgba, wrapT, wrapS, minFilter, magFilter, uniformName, i.glWrapper)
+	return nil
+}
+
+// makeMesh transforms the primitive to mesh. The type of the mesh depends
+// on the available attributes:
+// - normals and base color texture: TexturedMaterialMesh
+// - normals without texture: MaterialMesh
+// - texture without normals: TexturedColoredMesh
+// - neither of them: MaterialMesh with flat shaded normals.
+func (i *Import) makeMesh(p *gltfPrimitive, transformation mgl32.Mat4) (interfaces.Mesh, error) {
+	var gltfMat *gltfMaterial
+	if p.Material != nil {
+		if *p.Material < 0 || *p.Material >= len(i.asset.doc.Materials) {
+			return nil, fmt.Errorf("Invalid material '%d'.", *p.Material)
+		}
+		gltfMat = &i.asset.doc.Materials[*p.Material]
+	}
+	var baseColorTexture *gltfTextureInfo
+	if gltfMat != nil && gltfMat.PbrMetallicRoughness != nil {
+		baseColorTexture = gltfMat.PbrMetallicRoughness.BaseColorTexture
+	}
+	texCoord := 0
+	if baseColorTexture != nil {
+		texCoord = baseColorTexture.TexCoord
+	}
+	vertices, hasNormals, hasTexCoords, err := i.getVertices(p, transformation, texCoord)
+	if err != nil {
+		return nil, err
+	}
+	indices, err := i.getTriangleIndices(p, len(vertices))
+	if err != nil {
+		return nil, err
+	}
+	if len(indices) == 0 {
+		return nil, errors.New("Missing triangles.")
+	}
+	// The mirroring transformations flip the winding order.
+	if transformation.Det() < 0 {
+		for index := 0; index+2 < len(indices); index += 3 {
+			indices[index+1], indices[index+2] = indices[index+2], indices[index+1]
+		}
+	}
+	mat, baseColor := i.getMaterial(gltfMat)
+	var tex texture.Textures
+	if baseColorTexture != nil && hasTexCoords {
+		if err := i.addTexture(&tex, baseColorTexture.Index, "tex.diffuse"); err != nil {
+			return nil, err
+		}
+		// The specular map is the same texture, it's uploaded only once. The
+		// cache reuses the resident texture with a new reference.
+		if i.cache != nil {
+			if err := i.addTexture(&tex, baseColorTexture.Index, "tex.specular"); err != nil {
+				return nil, err
+			}
+		} else {
+			specular := *tex[0]
+			specular.Id = glwrapper.TEXTURE0 + uint32(len(tex))
+			specular.UniformName = "tex.specular"
+			tex = append(tex, &specular)
+		}
+	}
+	if len(tex) > 0 {
+		if hasNormals {
+			return mesh.NewTexturedMaterialMesh(vertices, indices, tex, mat, i.glWrapper), nil
+		}
+		return mesh.NewTexturedColoredMesh(vertices, indices, tex, []mgl32.Vec3{baseColor}, i.glWrapper), nil
+	}
+	if !hasNormals {
+		vertices, indices = flatShaded(vertices, indices)
+	}
+	return mesh.NewMaterialMesh(vertices, indices, mat, i.glWrapper), nil
+}
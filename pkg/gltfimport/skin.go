@@ -0,0 +1,323 @@
+package gltfimport
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/akosgarai/opengl_playground/pkg/animation"
+
+	"github.com/akosgarai/playground_engine/pkg/texture"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// localTransform returns the local transformation of the node decomposed to
+// translation, rotation and scale. If the matrix property is set, it's decomposed
+// with the assumption that it doesn't contain shear.
+func (n *gltfNode) localTransform() animation.Transform {
+	result := animation.IdentityTransform()
+	if len(n.Matrix) == 16 {
+		m := n.localTransformation()
+		result.Translation = m.Col(3).Vec3()
+		result.Scale = mgl32.Vec3{m.Col(0).Vec3().Len(), m.Col(1).Vec3().Len(), m.Col(2).Vec3().Len()}
+		for c := 0; c < 3; c++ {
+			if result.Scale[c] != 0 {
+				m.SetCol(c, m.Col(c).Mul(1/result.Scale[c]))
+			}
+		}
+		if m.Mat3().Det() < 0 {
+			// The mirroring is moved from the rotation to the scale.
+			result.Scale[0] = -result.Scale[0]
+			m.SetCol(0, m.Col(0).Mul(-1))
+		}
+		m.SetCol(3, mgl32.Vec4{0, 0, 0, 1})
+		result.Rotation = mgl32.Mat4ToQuat(m).Normalize()
+		return result
+	}
+	if len(n.Translation) == 3 {
+		result.Translation = mgl32.Vec3{n.Translation[0], n.Translation[1], n.Translation[2]}
+	}
+	if len(n.Rotation) == 4 {
+		result.Rotation = mgl32.Quat{W: n.Rotation[3], V: mgl32.Vec3{n.Rotation[0], n.Rotation[1], n.Rotation[2]}}.Normalize()
+	}
+	if len(n.Scale) == 3 {
+		result.Scale = mgl32.Vec3{n.Scale[0], n.Scale[1], n.Scale[2]}
+	}
+	return result
+}
+
+// worldTransformation returns the world transformation of the node.
+func (i *Import) worldTransformation(index int) mgl32.Mat4 {
+	result := mgl32.Ident4()
+	// The depth is limited, so that the invalid hierarchies can't cause infinite loop.
+	for depth := 0; index >= 0 && depth <= len(i.nodes); depth++ {
+		result = i.nodes[index].Transformation.Mul4(result)
+		index = i.nodes[index].Parent
+	}
+	return result
+}
+
+// makeSkeletons builds the skeletons of the skins. The joint targets are the
+// node indices, so that the channels of the clips could refer to them.
+func (i *Import) makeSkeletons() []error {
+	var result []error
+	doc := &i.asset.doc
+	for skinIndex, skin := range doc.Skins {
+		s, err := i.makeSkeleton(&skin)
+		if err != nil {
+			result = append(result, fmt.Errorf("Skin '%d': %s", skinIndex, err.Error()))
+		}
+		i.skeletons = append(i.skeletons, s)
+	}
+	return result
+}
+
+// makeSkeleton returns the skeleton of the skin. The parent of a joint is its
+// closest ancestor node that is a joint. The world transformation of the parent
+// node of the root joints is stored as the base of the joint.
+func (i *Import) makeSkeleton(skin *gltfSkin) (*animation.Skeleton, error) {
+	if len(skin.Joints) == 0 {
+		return nil, errors.New("Missing joints.")
+	}
+	jointIndices := make(map[int]int)
+	for index, node := range skin.Joints {
+		if node < 0 || node >= len(i.nodes) {
+			return nil, fmt.Errorf("Invalid joint node '%d'.", node)
+		}
+		jointIndices[node] = index
+	}
+	var inverseBindMatrices [][]float32
+	if skin.InverseBindMatrices != nil {
+		var err error
+		if inverseBindMatrices, err = i.asset.readAccessor(*skin.InverseBindMatrices); err != nil {
+			return nil, err
+		}
+		if len(inverseBindMatrices) < len(skin.Joints) || len(inverseBindMatrices[0]) != 16 {
+			return nil, errors.New("Invalid inverse bind matrices.")
+		}
+	}
+	joints := make([]animation.Joint, len(skin.Joints))
+	for index, node := range skin.Joints {
+		joints[index] = animation.Joint{
+			Name:        i.nodes[node].Name,
+			Target:      node,
+			Parent:      -1,
+			Rest:        i.asset.doc.Nodes[node].localTransform(),
+			InverseBind: mgl32.Ident4(),
+			Base:        mgl32.Ident4(),
+		}
+		if inverseBindMatrices != nil {
+			copy(joints[index].InverseBind[:], inverseBindMatrices[index])
+		}
+		parent := i.nodes[node].Parent
+		for depth := 0; parent >= 0 && depth <= len(i.nodes); depth++ {
+			if jointIndex, ok := jointIndices[parent]; ok {
+				joints[index].Parent = jointIndex
+				break
+			}
+			parent = i.nodes[parent].Parent
+		}
+		if joints[index].Parent == -1 && i.nodes[node].Parent >= 0 {
+			joints[index].Base = i.worldTransformation(i.nodes[node].Parent)
+		}
+	}
+	return animation.NewSkeleton(joints)
+}
+
+// makeClips builds the clips of the animations. The channels of the morph
+// target weights are not supported, they are skipped.
+func (i *Import) makeClips() []error {
+	var result []error
+	doc := &i.asset.doc
+	for animationIndex, a := range doc.Animations {
+		name := a.Name
+		if name == "" {
+			name = fmt.Sprintf("animation_%d", animationIndex)
+		}
+		var channels []animation.Channel
+		for channelIndex, c := range a.Channels {
+			if c.Target.Node == nil || c.Target.Path == "weights" {
+				continue
+			}
+			if c.Sampler < 0 || c.Sampler >= len(a.Samplers) {
+				result = append(result, fmt.Errorf("Animation '%d' channel '%d': Invalid sampler '%d'.", animationIndex, channelIndex, c.Sampler))
+				continue
+			}
+			sampler := a.Samplers[c.Sampler]
+			times, err := i.asset.readAccessor(sampler.Input)
+			if err != nil {
+				result = append(result, fmt.Errorf("Animation '%d' channel '%d': %s", animationIndex, channelIndex, err.Error()))
+				continue
+			}
+			values, err := i.asset.readAccessor(sampler.Output)
+			if err != nil {
+				result = append(result, fmt.Errorf("Animation '%d' channel '%d': %s", animationIndex, channelIndex, err.Error()))
+				continue
+			}
+			channel := animation.Channel{
+				Target:        *c.Target.Node,
+				Path:          c.Target.Path,
+				Interpolation: sampler.Interpolation,
+				Values:        values,
+			}
+			if channel.Interpolation == "" {
+				channel.Interpolation = animation.INTERPOLATION_LINEAR
+			}
+			for _, time := range times {
+				channel.Times = append(channel.Times, time[0])
+			}
+			channels = append(channels, channel)
+		}
+		clip, err := animation.NewClip(name, channels)
+		if err != nil {
+			result = append(result, err)
+			continue
+		}
+		i.clips = append(i.clips, clip)
+	}
+	return result
+}
+
+// getSkinAttributes returns the joint indices and the normalized weights of the
+// vertices of the primitive. The joint indices are checked against the number
+// of the joints of the skin.
+func (i *Import) getSkinAttributes(p *gltfPrimitive, vertexCount, jointCount int) ([][4]int, [][4]float32, error) {
+	jointValues, err := i.asset.readAccessor(p.Attributes["JOINTS_0"])
+	if err != nil {
+		return nil, nil, err
+	}
+	weightValues, err := i.asset.readAccessor(p.Attributes["WEIGHTS_0"])
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(jointValues) != vertexCount || len(weightValues) != vertexCount {
+		return nil, nil, errors.New("Invalid JOINTS_0 or WEIGHTS_0 attribute.")
+	}
+	if vertexCount > 0 && (len(jointValues[0]) != 4 || len(weightValues[0]) != 4) {
+		return nil, nil, errors.New("Invalid JOINTS_0 or WEIGHTS_0 attribute.")
+	}
+	joints := make([][4]int, vertexCount)
+	weights := make([][4]float32, vertexCount)
+	for index := range joints {
+		var sum float32
+		for k := 0; k < 4; k++ {
+			joints[index][k] = int(jointValues[index][k])
+			weights[index][k] = weightValues[index][k]
+			if weights[index][k] == 0 {
+				// The unused joints could have any index, it's reset to a valid one.
+				joints[index][k] = 0
+			} else if joints[index][k] >= jointCount {
+				return nil, nil, fmt.Errorf("Joint '%d' is out of range.", joints[index][k])
+			}
+			sum += weights[index][k]
+		}
+		if sum > 0 {
+			for k := 0; k < 4; k++ {
+				weights[index][k] /= sum
+			}
+		}
+	}
+	return joints, weights, nil
+}
+
+// isSkinned returns true if the primitive has joint and weight attributes.
+func isSkinned(p *gltfPrimitive) bool {
+	_, hasJoints := p.Attributes["JOINTS_0"]
+	_, hasWeights := p.Attributes["WEIGHTS_0"]
+	return hasJoints && hasWeights
+}
+
+// makeSkinnedMesh transforms the skinned primitive to skinned mesh. The vertices
+// are not transformed, the joint matrices place them to the world. The missing
+// normals are replaced with flat shaded normals, the missing texture with white
+// texture, so that every skinned mesh could be drawn with the same shader.
+func (i *Import) makeSkinnedMesh(p *gltfPrimitive, skin int) (*animation.SkinnedMesh, error) {
+	if skin < 0 || skin >= len(i.skeletons) || i.skeletons[skin] == nil {
+		return nil, fmt.Errorf("Invalid skin '%d'.", skin)
+	}
+	skeleton := i.skeletons[skin]
+	var gltfMat *gltfMaterial
+	if p.Material != nil {
+		if *p.Material < 0 || *p.Material >= len(i.asset.doc.Materials) {
+			return nil, fmt.Errorf("Invalid material '%d'.", *p.Material)
+		}
+		gltfMat = &i.asset.doc.Materials[*p.Material]
+	}
+	var baseColorTexture *gltfTextureInfo
+	if gltfMat != nil && gltfMat.PbrMetallicRoughness != nil {
+		baseColorTexture = gltfMat.PbrMetallicRoughness.BaseColorTexture
+	}
+	texCoord := 0
+	if baseColorTexture != nil {
+		texCoord = baseColorTexture.TexCoord
+	}
+	vertices, hasNormals, hasTexCoords, err := i.getVertices(p, mgl32.Ident4(), texCoord)
+	if err != nil {
+		return nil, err
+	}
+	joints, weights, err := i.getSkinAttributes(p, len(vertices), len(skeleton.Joints))
+	if err != nil {
+		return nil, err
+	}
+	indices, err := i.getTriangleIndices(p, len(vertices))
+	if err != nil {
+		return nil, err
+	}
+	if len(indices) == 0 {
+		return nil, errors.New("Missing triangles.")
+	}
+	if !hasNormals {
+		var flatJoints [][4]int
+		var flatWeights [][4]float32
+		for _, index := range indices {
+			flatJoints = append(flatJoints, joints[index])
+			flatWeights = append(flatWeights, weights[index])
+		}
+		vertices, indices = flatShaded(vertices, indices)
+		joints, weights = flatJoints, flatWeights
+	}
+	mat, _ := i.getMaterial(gltfMat)
+	var tex texture.Textures
+	if baseColorTexture != nil && hasTexCoords {
+		if err := i.addTexture(&tex, baseColorTexture.Index, "tex.diffuse"); err != nil {
+			return nil, err
+		}
+		if err := i.addTexture(&tex, baseColorTexture.Index, "tex.specular"); err != nil {
+			return nil, err
+		}
+	}
+	return animation.NewSkinnedMesh(vertices, indices, joints, weights, tex, mat, i.glWrapper)
+}
+
+// GetSkeletons returns the skeletons of the skins. The indices are the
+// same as in the gltf document. The invalid skins are nil.
+func (i *Import) GetSkeletons() []*animation.Skeleton {
+	return i.skeletons
+}
+
+// GetClips returns the clips of the animations.
+func (i *Import) GetClips() []*animation.Clip {
+	return i.clips
+}
+
+// GetSkinnedMeshes returns the skinned meshes of the given skin.
+func (i *Import) GetSkinnedMeshes(skin int) []*animation.SkinnedMesh {
+	return i.skinnedMeshes[skin]
+}
+
+// NewAnimatedModel returns an animated model with the skinned meshes of the given
+// skin. Every clip is added to its player, but none of them is started.
+func (i *Import) NewAnimatedModel(skin int) (*animation.Model, error) {
+	if skin < 0 || skin >= len(i.skeletons) || i.skeletons[skin] == nil {
+		return nil, fmt.Errorf("Invalid skin '%d'.", skin)
+	}
+	player := animation.NewPlayer(i.skeletons[skin])
+	for _, clip := range i.clips {
+		player.AddClip(clip)
+	}
+	m := animation.NewModel(player)
+	for _, msh := range i.skinnedMeshes[skin] {
+		m.AddSkinnedMesh(msh)
+	}
+	return m, nil
+}
@@ -0,0 +1,70 @@
+package lod
+
+import (
+	"github.com/akosgarai/playground_engine/pkg/primitives/vertex"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Level is a level of detail of a mesh.
+type Level struct {
+	Vertices vertex.Vertices
+	Indices  []uint32
+}
+
+// Triangles returns the number of the triangles of the level.
+func (l Level) Triangles() int {
+	return len(l.Indices) / 3
+}
+
+// Simplify reduces the number of the triangles of the mesh to the target with
+// quadric edge collapse. The collapses that would flip triangles or make non
+// manifold geometry are skipped, so that the result could have more triangles
+// than the target. The vertex attributes are not interpolated, the remaining
+// vertices keep their own normals, tex coords and colors.
+func Simplify(v vertex.Vertices, indices []uint32, targetTriangles int) Level {
+	s := newSimplifier(v, indices)
+	s.run(targetTriangles)
+	vertices, result := s.result()
+	return Level{Vertices: vertices, Indices: result}
+}
+
+// Generate returns the levels of the mesh. The first level is the original
+// mesh, the others are simplified to the given ratios of the original triangle
+// count (eg. 0.5, 0.25, 0.1). Every level is simplified from the previous one.
+func Generate(v vertex.Vertices, indices []uint32, ratios []float32) []Level {
+	levels := []Level{{Vertices: v, Indices: indices}}
+	triangles := len(indices) / 3
+	for _, ratio := range ratios {
+		previous := levels[len(levels)-1]
+		levels = append(levels, Simplify(previous.Vertices, previous.Indices, int(float32(triangles)*ratio)))
+	}
+	return levels
+}
+
+// BoundingSphere returns the center and the radius of a sphere that contains
+// the vertices. The center is the center of the bounding box.
+func BoundingSphere(v vertex.Vertices) (mgl32.Vec3, float32) {
+	if len(v) == 0 {
+		return mgl32.Vec3{}, 0
+	}
+	min, max := v[0].Position, v[0].Position
+	for _, vert := range v {
+		for c := 0; c < 3; c++ {
+			if vert.Position[c] < min[c] {
+				min[c] = vert.Position[c]
+			}
+			if vert.Position[c] > max[c] {
+				max[c] = vert.Position[c]
+			}
+		}
+	}
+	center := min.Add(max).Mul(0.5)
+	radius := float32(0)
+	for _, vert := range v {
+		if d := vert.Position.Sub(center).Len(); d > radius {
+			radius = d
+		}
+	}
+	return center, radius
+}
@@ -0,0 +1,226 @@
+package lod
+
+import (
+	"errors"
+	"math"
+
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/model"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// The default hysteresis of the level selection. The projected size has
+	// to cross the threshold with this ratio to change the level.
+	DefaultHysteresis = float32(0.15)
+	// The default threshold of the first coarser level. The thresholds of
+	// the next levels are the halves of the previous ones.
+	DefaultFirstThreshold = float32(0.5)
+)
+
+var (
+	emptyLevelsError      = errors.New("The model needs at least one level.")
+	thresholdsLengthError = errors.New("The number of the thresholds has to be the number of the levels - 1.")
+	thresholdsOrderError  = errors.New("The thresholds have to be positive and decreasing.")
+	hysteresisError       = errors.New("The hysteresis has to be in the [0, 1) interval.")
+)
+
+// Model is a model with multiple levels of detail. Every level is a mesh, the
+// first one is the most detailed. Only the mesh of the current level is drawn,
+// but the movement and the rotation is applied to every level, so that they
+// are in the same place when the level is changed.
+type Model struct {
+	*model.BaseModel
+	levels []interfaces.Mesh
+	// The thresholds of the projected screen size. If the size is less than
+	// thresholds[i], the i+1. level is used.
+	thresholds []float32
+	hysteresis float32
+	current    int
+	// The bounding sphere of the meshes in their coordinate system.
+	center mgl32.Vec3
+	radius float32
+}
+
+// NewModel returns a lod model with the given level meshes. The first level
+// is used until the first selection. The bounding sphere is a unit sphere and
+// the thresholds are the defaults. It returns error if the levels are missing.
+func NewModel(levels []interfaces.Mesh) (*Model, error) {
+	if len(levels) == 0 {
+		return nil, emptyLevelsError
+	}
+	m := &Model{
+		BaseModel:  model.New(),
+		levels:     levels,
+		thresholds: make([]float32, len(levels)-1),
+		hysteresis: DefaultHysteresis,
+		radius:     1.0,
+	}
+	threshold := DefaultFirstThreshold
+	for i := range m.thresholds {
+		m.thresholds[i] = threshold
+		threshold /= 2
+	}
+	m.AddMesh(levels[0])
+	return m, nil
+}
+
+// SetThresholds sets the projected size thresholds of the levels. The size is
+// the projected radius of the bounding sphere in the normalized device coordinates,
+// so that 1 means half of the screen height. The i. value is the size where the
+// i+1. level is displayed, so that it needs len(levels)-1 decreasing values.
+func (m *Model) SetThresholds(thresholds []float32) error {
+	if len(thresholds) != len(m.levels)-1 {
+		return thresholdsLengthError
+	}
+	for i, threshold := range thresholds {
+		if threshold <= 0 || (i > 0 && threshold >= thresholds[i-1]) {
+			return thresholdsOrderError
+		}
+	}
+	m.thresholds = append([]float32{}, thresholds...)
+	return nil
+}
+
+// GetThresholds returns the projected size thresholds of the levels.
+func (m *Model) GetThresholds() []float32 {
+	return m.thresholds
+}
+
+// SetHysteresis sets the hysteresis of the level selection.
+func (m *Model) SetHysteresis(h float32) error {
+	if h < 0 || h >= 1 {
+		return hysteresisError
+	}
+	m.hysteresis = h
+	return nil
+}
+
+// GetHysteresis returns the hysteresis of the level selection.
+func (m *Model) GetHysteresis() float32 {
+	return m.hysteresis
+}
+
+// SetBoundingSphere sets the bounding sphere of the meshes. It's in the coordinate
+// system of the meshes, the model transformation is applied to it on selection.
+func (m *Model) SetBoundingSphere(center mgl32.Vec3, radius float32) {
+	m.center = center
+	m.radius = radius
+}
+
+// GetLevel returns the index of the current level.
+func (m *Model) GetLevel() int {
+	return m.current
+}
+
+// GetLevelCount returns the number of the levels.
+func (m *Model) GetLevelCount() int {
+	return len(m.levels)
+}
+
+// SetLevel sets the current level. The index is clamped to the valid range.
+func (m *Model) SetLevel(level int) {
+	if level < 0 {
+		level = 0
+	}
+	if level >= len(m.levels) {
+		level = len(m.levels) - 1
+	}
+	if level == m.current {
+		return
+	}
+	m.current = level
+	m.Clear()
+	m.AddMesh(m.levels[level])
+}
+
+// ScreenSize returns the projected radius of the bounding sphere from the camera
+// in normalized device coordinates. If the camera is inside the sphere, it returns
+// the maximal float value.
+func (m *Model) ScreenSize(cam interfaces.Camera) float32 {
+	transformation := m.levels[m.current].ModelTransformation()
+	center := mgl32.TransformCoordinate(m.center, transformation)
+	scale := float32(0)
+	for c := 0; c < 3; c++ {
+		if l := transformation.Col(c).Vec3().Len(); l > scale {
+			scale = l
+		}
+	}
+	radius := m.radius * scale
+	distance := center.Sub(cam.GetPosition()).Len()
+	if distance <= radius {
+		return math.MaxFloat32
+	}
+	// The At(1,1) of the projection is the cotangent of the half vertical fov.
+	return radius * cam.GetProjectionMatrix().At(1, 1) / distance
+}
+
+// Select sets the level based on the projected size from the camera. The level
+// is changed only if the size crosses the threshold with the hysteresis, so
+// that the level doesn't flicker when the size is around the threshold.
+func (m *Model) Select(cam interfaces.Camera) {
+	size := m.ScreenSize(cam)
+	level := m.current
+	for level > 0 && size > m.thresholds[level-1]*(1+m.hysteresis) {
+		level--
+	}
+	for level < len(m.thresholds) && size < m.thresholds[level]*(1-m.hysteresis) {
+		level++
+	}
+	m.SetLevel(level)
+}
+
+// Update function calls the Update function of every level.
+func (m *Model) Update(dt float64) {
+	for _, msh := range m.levels {
+		msh.Update(dt)
+	}
+}
+
+// SetPosition sets the position of every level.
+func (m *Model) SetPosition(p mgl32.Vec3) {
+	for _, msh := range m.levels {
+		msh.SetPosition(p)
+	}
+}
+
+// GetPosition returns the position of the current level.
+func (m *Model) GetPosition() mgl32.Vec3 {
+	return m.levels[m.current].GetPosition()
+}
+
+// SetSpeed sets the speed of every level.
+func (m *Model) SetSpeed(s float32) {
+	for _, msh := range m.levels {
+		msh.SetSpeed(s)
+	}
+}
+
+// SetDirection sets the direction of every level.
+func (m *Model) SetDirection(p mgl32.Vec3) {
+	for _, msh := range m.levels {
+		msh.SetDirection(p)
+	}
+}
+
+// RotateX rotates every level around the X axis.
+func (m *Model) RotateX(angleDeg float32) {
+	for _, msh := range m.levels {
+		msh.RotateX(angleDeg)
+	}
+}
+
+// RotateY rotates every level around the Y axis.
+func (m *Model) RotateY(angleDeg float32) {
+	for _, msh := range m.levels {
+		msh.RotateY(angleDeg)
+	}
+}
+
+// RotateZ rotates every level around the Z axis.
+func (m *Model) RotateZ(angleDeg float32) {
+	for _, msh := range m.levels {
+		msh.RotateZ(angleDeg)
+	}
+}
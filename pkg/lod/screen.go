@@ -0,0 +1,58 @@
+package lod
+
+import (
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/screen"
+)
+
+// Screen is a screen that selects the level of its lod models in every frame,
+// based on their projected size from the camera of the screen.
+type Screen struct {
+	*screen.Screen
+	// The lod models of the screen. The value is the number of the shaders
+	// that the model is attached to.
+	models map[*Model]int
+}
+
+// NewScreen returns an empty lod screen.
+func NewScreen() *Screen {
+	return &Screen{
+		Screen: screen.New(),
+		models: make(map[*Model]int),
+	}
+}
+
+// AddModelToShader attaches the model to a shader. The lod models are also
+// stored for the level selection.
+func (s *Screen) AddModelToShader(m interfaces.Model, sh interfaces.Shader) {
+	if lm, ok := m.(*Model); ok {
+		s.models[lm]++
+	}
+	s.Screen.AddModelToShader(m, sh)
+}
+
+// RemoveModelFromShader detaches the model from the shader. The lod model is
+// forgotten if it's not attached to any shader.
+func (s *Screen) RemoveModelFromShader(m interfaces.Model, sh interfaces.Shader) {
+	if lm, ok := m.(*Model); ok {
+		if s.models[lm] <= 1 {
+			delete(s.models, lm)
+		} else {
+			s.models[lm]--
+		}
+	}
+	s.Screen.RemoveModelFromShader(m, sh)
+}
+
+// Update function updates the screen, then it selects the level of the lod
+// models with the moved camera. Without camera the levels are not changed.
+func (s *Screen) Update(dt float64, p interfaces.Pointer, keyStore interfaces.RoKeyStore, buttonStore interfaces.RoButtonStore) {
+	s.Screen.Update(dt, p, keyStore, buttonStore)
+	cam := s.GetCamera()
+	if cam == nil {
+		return
+	}
+	for m := range s.models {
+		m.Select(cam)
+	}
+}
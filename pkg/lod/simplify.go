@@ -0,0 +1,455 @@
+package lod
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/akosgarai/playground_engine/pkg/primitives/vertex"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+var (
+	// The weight of the planes that keep the open borders and the attribute
+	// seams (eg. texture seams) in place. The bigger value preserves them better.
+	BorderWeight = float64(1000.0)
+	// The triangles whose normal would turn more than this (cosine) during
+	// a collapse, prevent the collapse.
+	MinNormalDot = float64(0.2)
+)
+
+// quadric is the symmetric 4x4 error matrix of the quadric error metric,
+// stored as its 10 unique values.
+type quadric [10]float64
+
+// planeQuadric returns the quadric of the ax+by+cz+d=0 plane.
+func planeQuadric(a, b, c, d, weight float64) quadric {
+	return quadric{
+		a * a * weight, a * b * weight, a * c * weight, a * d * weight,
+		b * b * weight, b * c * weight, b * d * weight,
+		c * c * weight, c * d * weight,
+		d * d * weight,
+	}
+}
+func (q *quadric) add(o quadric) {
+	for i := range q {
+		q[i] += o[i]
+	}
+}
+
+// error returns the sum of the squared distances of the point from the planes.
+func (q *quadric) error(v [3]float64) float64 {
+	x, y, z := v[0], v[1], v[2]
+	return q[0]*x*x + 2*q[1]*x*y + 2*q[2]*x*z + 2*q[3]*x +
+		q[4]*y*y + 2*q[5]*y*z + 2*q[6]*y +
+		q[7]*z*z + 2*q[8]*z + q[9]
+}
+
+// optimal returns the point with the minimal error. The second return
+// value is false if the matrix is singular (eg. on a flat surface).
+func (q *quadric) optimal() ([3]float64, bool) {
+	a := [3][3]float64{
+		{q[0], q[1], q[2]},
+		{q[1], q[4], q[5]},
+		{q[2], q[5], q[7]},
+	}
+	b := [3]float64{-q[3], -q[6], -q[8]}
+	det := determinant(a)
+	if math.Abs(det) < 1e-10 {
+		return [3]float64{}, false
+	}
+	// Cramer's rule.
+	var result [3]float64
+	for c := 0; c < 3; c++ {
+		m := a
+		for r := 0; r < 3; r++ {
+			m[r][c] = b[r]
+		}
+		result[c] = determinant(m) / det
+	}
+	return result, true
+}
+func determinant(m [3][3]float64) float64 {
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
+
+func sub(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+func length(a [3]float64) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+// collapse is a candidate edge collapse in the heap. The versions of the
+// endpoints are stored, so that the outdated candidates could be skipped.
+type collapse struct {
+	a, b               int
+	versionA, versionB int
+	cost               float64
+	target             [3]float64
+}
+type collapseHeap []collapse
+
+func (h collapseHeap) Len() int            { return len(h) }
+func (h collapseHeap) Less(i, j int) bool  { return h[i].cost < h[j].cost }
+func (h collapseHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *collapseHeap) Push(x interface{}) { *h = append(*h, x.(collapse)) }
+func (h *collapseHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// simplifier collapses the edges of the mesh. The vertices with the same position
+// are handled as one point, so that the collapses don't open cracks on the seams.
+type simplifier struct {
+	vertices vertex.Vertices
+	// The point of the vertices and the vertices of the points.
+	pointOf       []int
+	pointVertices [][]int
+	positions     [][3]float64
+	quadrics      []quadric
+	// The point that the point was collapsed into. It's itself for the living points.
+	parent  []int
+	version []int
+	// The points and the original vertices of the triangles.
+	triangles [][3]int
+	corners   [][3]uint32
+	removed   []bool
+	incident  [][]int
+	live      int
+	heap      collapseHeap
+}
+
+func newSimplifier(v vertex.Vertices, indices []uint32) *simplifier {
+	s := &simplifier{vertices: v, pointOf: make([]int, len(v))}
+	points := make(map[mgl32.Vec3]int)
+	for index, vert := range v {
+		point, ok := points[vert.Position]
+		if !ok {
+			point = len(s.positions)
+			points[vert.Position] = point
+			s.positions = append(s.positions, [3]float64{float64(vert.Position.X()), float64(vert.Position.Y()), float64(vert.Position.Z())})
+			s.pointVertices = append(s.pointVertices, nil)
+		}
+		s.pointOf[index] = point
+		s.pointVertices[point] = append(s.pointVertices[point], index)
+	}
+	count := len(s.positions)
+	s.quadrics = make([]quadric, count)
+	s.parent = make([]int, count)
+	s.version = make([]int, count)
+	s.incident = make([][]int, count)
+	for point := range s.parent {
+		s.parent[point] = point
+	}
+	for index := 0; index+2 < len(indices); index += 3 {
+		corners := [3]uint32{indices[index], indices[index+1], indices[index+2]}
+		triangle := [3]int{s.pointOf[corners[0]], s.pointOf[corners[1]], s.pointOf[corners[2]]}
+		if triangle[0] == triangle[1] || triangle[1] == triangle[2] || triangle[0] == triangle[2] {
+			continue
+		}
+		t := len(s.triangles)
+		s.triangles = append(s.triangles, triangle)
+		s.corners = append(s.corners, corners)
+		s.removed = append(s.removed, false)
+		for _, point := range triangle {
+			s.incident[point] = append(s.incident[point], t)
+		}
+	}
+	s.live = len(s.triangles)
+	s.initQuadrics()
+	for point := range s.positions {
+		s.pushEdges(point)
+	}
+	return s
+}
+
+// normal returns the not normalized normal vector of the triangle.
+func (s *simplifier) normal(triangle [3]int) [3]float64 {
+	p0, p1, p2 := s.positions[triangle[0]], s.positions[triangle[1]], s.positions[triangle[2]]
+	return cross(sub(p1, p0), sub(p2, p0))
+}
+
+// sameAttributes returns true if the vertices have the same tex coords and color.
+// The different normals (eg. hard edges of flat shading) are not seams.
+func (s *simplifier) sameAttributes(a, b uint32) bool {
+	return s.vertices[a].TexCoords == s.vertices[b].TexCoords && s.vertices[a].Color == s.vertices[b].Color
+}
+
+// initQuadrics sums the area weighted plane quadrics of the triangles to their
+// points. The open border edges and the seam edges (where the neighbour triangles
+// use vertices with different tex coords or colors) get a perpendicular plane
+// with big weight.
+func (s *simplifier) initQuadrics() {
+	type edgeUse struct {
+		count    int
+		triangle int
+		vertices [2]uint32
+		seam     bool
+	}
+	edges := make(map[[2]int]*edgeUse)
+	for t, triangle := range s.triangles {
+		n := s.normal(triangle)
+		area := length(n)
+		if area == 0 {
+			continue
+		}
+		n = [3]float64{n[0] / area, n[1] / area, n[2] / area}
+		q := planeQuadric(n[0], n[1], n[2], -dot(n, s.positions[triangle[0]]), area/2)
+		for k := 0; k < 3; k++ {
+			s.quadrics[triangle[k]].add(q)
+			a, b := triangle[k], triangle[(k+1)%3]
+			va, vb := s.corners[t][k], s.corners[t][(k+1)%3]
+			if a > b {
+				a, b = b, a
+				va, vb = vb, va
+			}
+			use, ok := edges[[2]int{a, b}]
+			if !ok {
+				edges[[2]int{a, b}] = &edgeUse{count: 1, triangle: t, vertices: [2]uint32{va, vb}}
+				continue
+			}
+			use.count++
+			if !s.sameAttributes(use.vertices[0], va) || !s.sameAttributes(use.vertices[1], vb) {
+				use.seam = true
+			}
+		}
+	}
+	for edge, use := range edges {
+		if use.count != 1 && !use.seam {
+			continue
+		}
+		p0, p1 := s.positions[edge[0]], s.positions[edge[1]]
+		e := sub(p1, p0)
+		edgeLength := length(e)
+		n := s.normal(s.triangles[use.triangle])
+		perpendicular := cross(e, n)
+		l := length(perpendicular)
+		if edgeLength == 0 || l == 0 {
+			continue
+		}
+		perpendicular = [3]float64{perpendicular[0] / l, perpendicular[1] / l, perpendicular[2] / l}
+		q := planeQuadric(perpendicular[0], perpendicular[1], perpendicular[2], -dot(perpendicular, p0), BorderWeight*edgeLength*edgeLength)
+		s.quadrics[edge[0]].add(q)
+		s.quadrics[edge[1]].add(q)
+	}
+}
+
+// neighbours returns the points that are connected to the point with living triangles.
+func (s *simplifier) neighbours(point int) map[int]bool {
+	result := make(map[int]bool)
+	for _, t := range s.incident[point] {
+		if s.removed[t] {
+			continue
+		}
+		for _, p := range s.triangles[t] {
+			if p != point {
+				result[p] = true
+			}
+		}
+	}
+	return result
+}
+
+// pushEdges inserts the collapse candidates of the edges of the point. Every
+// edge is pushed from its smaller endpoint, except after a collapse, when the
+// edges of the new point are pushed again.
+func (s *simplifier) pushEdges(point int) {
+	for neighbour := range s.neighbours(point) {
+		if neighbour < point {
+			continue
+		}
+		s.pushEdge(point, neighbour)
+	}
+}
+func (s *simplifier) pushEdge(a, b int) {
+	q := s.quadrics[a]
+	q.add(s.quadrics[b])
+	pa, pb := s.positions[a], s.positions[b]
+	candidates := [][3]float64{pa, pb, {(pa[0] + pb[0]) / 2, (pa[1] + pb[1]) / 2, (pa[2] + pb[2]) / 2}}
+	if optimal, ok := q.optimal(); ok {
+		candidates = append(candidates, optimal)
+	}
+	best := collapse{a: a, b: b, versionA: s.version[a], versionB: s.version[b], cost: math.Inf(1)}
+	for _, candidate := range candidates {
+		if cost := q.error(candidate); cost < best.cost {
+			best.cost = cost
+			best.target = candidate
+		}
+	}
+	heap.Push(&s.heap, best)
+}
+
+// valid returns true if the collapse doesn't flip triangles and doesn't make
+// non manifold geometry.
+func (s *simplifier) valid(c collapse) bool {
+	shared := 0
+	for _, t := range s.incident[c.a] {
+		if s.removed[t] {
+			continue
+		}
+		triangle := s.triangles[t]
+		if triangle[0] == c.b || triangle[1] == c.b || triangle[2] == c.b {
+			shared++
+		}
+	}
+	// The edge could have common neighbours only through its triangles.
+	neighboursA := s.neighbours(c.a)
+	common := 0
+	for neighbour := range s.neighbours(c.b) {
+		if neighboursA[neighbour] {
+			common++
+		}
+	}
+	if common > shared {
+		return false
+	}
+	for _, point := range []int{c.a, c.b} {
+		for _, t := range s.incident[point] {
+			if s.removed[t] {
+				continue
+			}
+			triangle := s.triangles[t]
+			if (triangle[0] == c.a || triangle[1] == c.a || triangle[2] == c.a) && (triangle[0] == c.b || triangle[1] == c.b || triangle[2] == c.b) {
+				continue
+			}
+			before := s.normal(triangle)
+			moved := triangle
+			for k := range moved {
+				if moved[k] == c.a || moved[k] == c.b {
+					moved[k] = -1
+				}
+			}
+			positions := [3][3]float64{}
+			for k := range moved {
+				if moved[k] == -1 {
+					positions[k] = c.target
+				} else {
+					positions[k] = s.positions[moved[k]]
+				}
+			}
+			after := cross(sub(positions[1], positions[0]), sub(positions[2], positions[0]))
+			lb, la := length(before), length(after)
+			if la == 0 {
+				return false
+			}
+			if lb > 0 && dot(before, after)/(la*lb) < MinNormalDot {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// apply collapses the b point into the a point.
+func (s *simplifier) apply(c collapse) {
+	s.positions[c.a] = c.target
+	s.quadrics[c.a].add(s.quadrics[c.b])
+	s.parent[c.b] = c.a
+	for _, t := range s.incident[c.b] {
+		if s.removed[t] {
+			continue
+		}
+		triangle := &s.triangles[t]
+		if triangle[0] == c.a || triangle[1] == c.a || triangle[2] == c.a {
+			s.removed[t] = true
+			s.live--
+			continue
+		}
+		for k := range triangle {
+			if triangle[k] == c.b {
+				triangle[k] = c.a
+			}
+		}
+		s.incident[c.a] = append(s.incident[c.a], t)
+	}
+	s.incident[c.b] = nil
+	// The removed triangles are dropped from the incident list.
+	living := s.incident[c.a][:0]
+	for _, t := range s.incident[c.a] {
+		if !s.removed[t] {
+			living = append(living, t)
+		}
+	}
+	s.incident[c.a] = living
+	s.version[c.a]++
+	s.version[c.b]++
+	for neighbour := range s.neighbours(c.a) {
+		s.pushEdge(c.a, neighbour)
+	}
+}
+
+// run collapses the cheapest edges until the number of the triangles is more than the target.
+func (s *simplifier) run(targetTriangles int) {
+	for s.live > targetTriangles && s.heap.Len() > 0 {
+		c := heap.Pop(&s.heap).(collapse)
+		if s.parent[c.a] != c.a || s.parent[c.b] != c.b || s.version[c.a] != c.versionA || s.version[c.b] != c.versionB {
+			continue
+		}
+		if !s.valid(c) {
+			continue
+		}
+		s.apply(c)
+	}
+}
+
+// root returns the living point that the point was collapsed into.
+func (s *simplifier) root(point int) int {
+	for s.parent[point] != point {
+		point = s.parent[point]
+	}
+	return point
+}
+
+// attributeDistance returns the difference of the attributes of the vertices.
+func attributeDistance(a, b vertex.Vertex) float32 {
+	return a.TexCoords.Sub(b.TexCoords).Len() + a.Normal.Sub(b.Normal).Len() + a.Color.Sub(b.Color).Len()
+}
+
+// result returns the vertices and the indices of the living triangles. A corner,
+// whose point was collapsed, uses the vertex of the living point with the closest
+// attributes (tex coords, normal, color).
+func (s *simplifier) result() (vertex.Vertices, []uint32) {
+	var vertices vertex.Vertices
+	var indices []uint32
+	newIndex := make(map[int]uint32)
+	for t, triangle := range s.triangles {
+		if s.removed[t] {
+			continue
+		}
+		for k, point := range triangle {
+			original := int(s.corners[t][k])
+			chosen := original
+			if s.pointOf[original] != point {
+				best := float32(math.MaxFloat32)
+				for _, candidate := range s.pointVertices[point] {
+					if d := attributeDistance(s.vertices[original], s.vertices[candidate]); d < best {
+						best = d
+						chosen = candidate
+					}
+				}
+			}
+			index, ok := newIndex[chosen]
+			if !ok {
+				index = uint32(len(vertices))
+				newIndex[chosen] = index
+				v := s.vertices[chosen]
+				p := s.positions[point]
+				v.Position = mgl32.Vec3{float32(p[0]), float32(p[1]), float32(p[2])}
+				vertices = append(vertices, v)
+			}
+			indices = append(indices, index)
+		}
+	}
+	return vertices, indices
+}
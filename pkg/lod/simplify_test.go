@@ -0,0 +1,116 @@
+package lod
+
+import (
+	"math"
+	"testing"
+
+	"github.com/akosgarai/playground_engine/pkg/primitives/vertex"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// grid returns a size * size tile surface in the xz plane. The height of the
+// points is returned by the height function.
+func grid(size int, height func(x, z int) float32) (vertex.Vertices, []uint32) {
+	var v vertex.Vertices
+	var indices []uint32
+	for z := 0; z <= size; z++ {
+		for x := 0; x <= size; x++ {
+			v = append(v, vertex.Vertex{
+				Position:  mgl32.Vec3{float32(x), height(x, z), float32(z)},
+				Normal:    mgl32.Vec3{0, 1, 0},
+				TexCoords: mgl32.Vec2{float32(x) / float32(size), float32(z) / float32(size)},
+			})
+		}
+	}
+	for z := 0; z < size; z++ {
+		for x := 0; x < size; x++ {
+			a := uint32(z*(size+1) + x)
+			b, c, d := a+1, a+uint32(size+1), a+uint32(size+2)
+			indices = append(indices, a, c, b, b, c, d)
+		}
+	}
+	return v, indices
+}
+
+// bounds returns the bounding box of the vertices.
+func bounds(v vertex.Vertices) (mgl32.Vec3, mgl32.Vec3) {
+	min, max := v[0].Position, v[0].Position
+	for _, vert := range v {
+		for c := 0; c < 3; c++ {
+			min[c] = float32(math.Min(float64(min[c]), float64(vert.Position[c])))
+			max[c] = float32(math.Max(float64(max[c]), float64(vert.Position[c])))
+		}
+	}
+	return min, max
+}
+
+func TestSimplify(t *testing.T) {
+	flat := func(x, z int) float32 { return 0 }
+	ridge := func(x, z int) float32 {
+		if x <= 4 {
+			return float32(x)
+		}
+		return float32(8 - x)
+	}
+	testData := []struct {
+		name string
+		size int
+		// The height function of the grid.
+		height func(x, z int) float32
+		target int
+		// The maximal number of the triangles of the result.
+		maxTriangles int
+		// The result has to be flat.
+		flat bool
+	}{
+		{"flat to half", 8, flat, 64, 64, true},
+		{"flat to minimum", 8, flat, 2, 16, true},
+		{"target above the count", 4, flat, 100, 32, true},
+		{"ridge to half", 8, ridge, 64, 64, false},
+	}
+	for _, tt := range testData {
+		v, indices := grid(tt.size, tt.height)
+		level := Simplify(v, indices, tt.target)
+		if len(level.Indices)%3 != 0 {
+			t.Errorf("%s: The number of the indices '%d' has to be divisible by 3.", tt.name, len(level.Indices))
+			continue
+		}
+		if level.Triangles() == 0 || level.Triangles() > tt.maxTriangles {
+			t.Errorf("%s: Invalid number of triangles '%d', the maximum is '%d'.", tt.name, level.Triangles(), tt.maxTriangles)
+		}
+		if tt.target >= len(indices)/3 && level.Triangles() != len(indices)/3 {
+			t.Errorf("%s: The mesh shouldn't be simplified below the target. It has '%d' triangles instead of '%d'.", tt.name, level.Triangles(), len(indices)/3)
+		}
+		for i := 0; i < len(level.Indices); i += 3 {
+			a, b, c := level.Indices[i], level.Indices[i+1], level.Indices[i+2]
+			if int(a) >= len(level.Vertices) || int(b) >= len(level.Vertices) || int(c) >= len(level.Vertices) {
+				t.Errorf("%s: The triangle '%d' has invalid index.", tt.name, i/3)
+				continue
+			}
+			pa, pb, pc := level.Vertices[a].Position, level.Vertices[b].Position, level.Vertices[c].Position
+			normal := pb.Sub(pa).Cross(pc.Sub(pa))
+			if normal.Len() < 1e-6 {
+				t.Errorf("%s: The triangle '%d' is degenerate.", tt.name, i/3)
+				continue
+			}
+			// The grid triangles are facing to the +y direction, they must not flip.
+			if normal.Y() <= 0 {
+				t.Errorf("%s: The triangle '%d' is flipped.", tt.name, i/3)
+			}
+		}
+		if tt.flat {
+			for _, vert := range level.Vertices {
+				if vert.Position.Y() != 0 {
+					t.Errorf("%s: The vertex '%v' has to be in the plane.", tt.name, vert.Position)
+				}
+			}
+		}
+		// The open borders are kept in place.
+		originalMin, originalMax := bounds(v)
+		min, max := bounds(level.Vertices)
+		if !min.ApproxEqual(originalMin) || !max.ApproxEqual(originalMax) {
+			t.Errorf("%s: The bounding box has to be the same. Instead of '%v' - '%v', we have '%v' - '%v'.", tt.name, originalMin, originalMax, min, max)
+		}
+	}
+}
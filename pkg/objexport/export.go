@@ -0,0 +1,448 @@
+package objexport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/material"
+	"github.com/akosgarai/playground_engine/pkg/mesh"
+	"github.com/akosgarai/playground_engine/pkg/primitives/vertex"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	DEBUG = false
+)
+
+var (
+	// The default shininess of the meshes without material.
+	DefaultShininess = float32(32)
+)
+
+// mtl is a material descriptor of the material file.
+type mtl struct {
+	name  string
+	ka    mgl32.Vec3
+	kd    mgl32.Vec3
+	ks    mgl32.Vec3
+	ns    float32
+	mapKa string
+	mapKd string
+	mapKs string
+}
+
+// object is a named mesh of the object file.
+type object struct {
+	name string
+	mesh interfaces.Mesh
+}
+
+// Export writes meshes and models to wavefront object and material files.
+type Export struct {
+	objects []object
+	// If it's true, the model transformation of the meshes is applied
+	// to the vertices, otherwise the vertices are written in mesh space.
+	worldSpace bool
+	// The name of the copied texture files, the keys are the source paths.
+	textureFiles map[string]string
+	// The source paths of the copied textures, the keys are the file names.
+	textureSources map[string]string
+}
+
+// New returns an empty exporter. The world space export is enabled by default.
+func New() *Export {
+	return &Export{
+		objects:    []object{},
+		worldSpace: true,
+	}
+}
+
+// SetWorldSpace sets the transformation mode. In world space mode the model
+// transformation (with the transformations of the parent meshes) is applied
+// to the positions and the normal vectors. In local mode the vertices are
+// written as they are stored in the meshes.
+func (e *Export) SetWorldSpace(worldSpace bool) {
+	e.worldSpace = worldSpace
+}
+
+// AddMesh adds the mesh to the export with the given object name. The
+// whitespaces and the comment signs of the name are replaced with '_'.
+func (e *Export) AddMesh(name string, m interfaces.Mesh) {
+	e.objects = append(e.objects, object{name: sanitizeName(name), mesh: m})
+}
+
+// sanitizeName returns the name that could be written as an object or material
+// name. The names are terminated by the whitespaces and the '#' starts a comment
+// in the wavefront files, so that these are replaced with '_'. The empty name
+// is replaced with 'object'.
+func sanitizeName(name string) string {
+	if name == "" {
+		return "object"
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '#' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// AddModel adds the meshes of the model to the export. The object names are
+// the model name with the index of the mesh. The models without indexable
+// meshes (eg. custom models) are skipped.
+func (e *Export) AddModel(name string, m interfaces.Model) {
+	indexable, ok := m.(interface {
+		GetMeshByIndex(int) (interfaces.Mesh, error)
+	})
+	if !ok {
+		return
+	}
+	for i := 0; ; i++ {
+		msh, err := indexable.GetMeshByIndex(i)
+		if err != nil {
+			break
+		}
+		e.AddMesh(fmt.Sprintf("%s_%d", name, i), msh)
+	}
+}
+
+// Export writes the '<name>.obj' and the '<name>.mtl' files to the given
+// directory and copies the textures next to them. The directory has to exist.
+func (e *Export) Export(directory, name string) error {
+	if _, err := os.Stat(directory); err != nil {
+		return err
+	}
+	e.textureFiles = make(map[string]string)
+	e.textureSources = make(map[string]string)
+
+	objectFile, err := os.Create(filepath.Join(directory, name+".obj"))
+	if err != nil {
+		return err
+	}
+	defer objectFile.Close()
+	materialFile, err := os.Create(filepath.Join(directory, name+".mtl"))
+	if err != nil {
+		return err
+	}
+	defer materialFile.Close()
+
+	objectWriter := bufio.NewWriter(objectFile)
+	materialWriter := bufio.NewWriter(materialFile)
+	fmt.Fprintf(objectWriter, "# Exported objects: %d\nmtllib %s.mtl\n", len(e.objects), name)
+
+	// The indices of the object file are global, these are the number of the written elements.
+	var positionCount, texCoordCount, normalCount int
+	for index, obj := range e.objects {
+		vertices, indices := e.meshData(obj.mesh)
+		if len(vertices) == 0 {
+			// empty or unsupported mesh
+			continue
+		}
+		materialName := fmt.Sprintf("%s_material", obj.name)
+		mat, hasMaterial := e.getMaterial(obj.mesh, materialName)
+		if hasMaterial {
+			if err := e.copyTextures(mat, directory); err != nil {
+				return err
+			}
+			writeMaterial(materialWriter, mat)
+		}
+		fmt.Fprintf(objectWriter, "\no %s\n", obj.name)
+		if hasMaterial {
+			fmt.Fprintf(objectWriter, "usemtl %s\n", materialName)
+		}
+		hasNormals, hasTexCoords := meshAttributes(obj.mesh)
+		for _, v := range vertices {
+			fmt.Fprintf(objectWriter, "v %f %f %f\n", v.Position.X(), v.Position.Y(), v.Position.Z())
+		}
+		if hasTexCoords {
+			for _, v := range vertices {
+				fmt.Fprintf(objectWriter, "vt %f %f\n", v.TexCoords.X(), v.TexCoords.Y())
+			}
+		}
+		if hasNormals {
+			for _, v := range vertices {
+				fmt.Fprintf(objectWriter, "vn %f %f %f\n", v.Normal.X(), v.Normal.Y(), v.Normal.Z())
+			}
+		}
+		if indices == nil {
+			// point mesh
+			for i := range vertices {
+				fmt.Fprintf(objectWriter, "p %d\n", positionCount+i+1)
+			}
+		} else {
+			for i := 0; i+2 < len(indices); i += 3 {
+				objectWriter.WriteString("f")
+				for _, vertexIndex := range indices[i : i+3] {
+					objectWriter.WriteString(" " + faceElement(int(vertexIndex), positionCount, texCoordCount, normalCount, hasTexCoords, hasNormals))
+				}
+				objectWriter.WriteString("\n")
+			}
+		}
+		positionCount += len(vertices)
+		if hasTexCoords {
+			texCoordCount += len(vertices)
+		}
+		if hasNormals {
+			normalCount += len(vertices)
+		}
+		if DEBUG {
+			fmt.Printf("Object %d '%s' exported.\n", index, obj.name)
+		}
+	}
+	if err := objectWriter.Flush(); err != nil {
+		return err
+	}
+	return materialWriter.Flush()
+}
+
+// faceElement returns the 'v', 'v/vt', 'v//vn' or 'v/vt/vn' element of the vertex.
+func faceElement(vertexIndex, positionOffset, texCoordOffset, normalOffset int, hasTexCoords, hasNormals bool) string {
+	element := fmt.Sprintf("%d", positionOffset+vertexIndex+1)
+	if hasTexCoords {
+		element += fmt.Sprintf("/%d", texCoordOffset+vertexIndex+1)
+	} else if hasNormals {
+		element += "/"
+	}
+	if hasNormals {
+		element += fmt.Sprintf("/%d", normalOffset+vertexIndex+1)
+	}
+	return element
+}
+
+// meshAttributes returns the vertex attributes that are used by the mesh type.
+func meshAttributes(m interfaces.Mesh) (bool, bool) {
+	switch m.(type) {
+	case *mesh.MaterialMesh:
+		return true, false
+	case *mesh.TexturedMaterialMesh, *mesh.TexturedMesh:
+		return true, true
+	case *mesh.TexturedColoredMesh:
+		return false, true
+	}
+	return false, false
+}
+
+// meshData returns the vertices and the indices of the mesh. In world space
+// mode the vertices are transformed with the model transformation of the
+// mesh. The indices are nil for the point meshes. The other meshes without
+// indices are drawn as triangles, their indices are sequential.
+func (e *Export) meshData(m interfaces.Mesh) (vertex.Vertices, []uint32) {
+	var vertices vertex.Vertices
+	var indices []uint32
+	switch msh := m.(type) {
+	case *mesh.MaterialMesh:
+		vertices, indices = msh.Vertices, msh.Indices
+	case *mesh.TexturedMaterialMesh:
+		vertices, indices = msh.Vertices, msh.Indices
+	case *mesh.TexturedMesh:
+		vertices, indices = msh.Vertices, msh.Indices
+	case *mesh.TexturedColoredMesh:
+		vertices, indices = msh.Vertices, msh.Indices
+	case *mesh.ColorMesh:
+		vertices, indices = msh.Vertices, msh.Indices
+	case *mesh.PointMesh:
+		vertices = msh.Vertices
+	}
+	if indices == nil {
+		if _, isPoint := m.(*mesh.PointMesh); !isPoint {
+			indices = sequentialIndices(len(vertices))
+		}
+	}
+	result := make(vertex.Vertices, len(vertices))
+	copy(result, vertices)
+	if !e.worldSpace {
+		return result, indices
+	}
+	transformation := m.ModelTransformation()
+	normalTransformation := transformation.Mat3().Inv().Transpose()
+	for i := range result {
+		result[i].Position = mgl32.TransformCoordinate(result[i].Position, transformation)
+		normal := normalTransformation.Mul3x1(result[i].Normal)
+		if normal.Len() > 0 {
+			normal = normal.Normalize()
+		}
+		result[i].Normal = normal
+	}
+	// The mirroring transformations flip the winding order.
+	if indices != nil && transformation.Det() < 0 {
+		flipped := make([]uint32, len(indices))
+		copy(flipped, indices)
+		for i := 0; i+2 < len(flipped); i += 3 {
+			flipped[i+1], flipped[i+2] = flipped[i+2], flipped[i+1]
+		}
+		indices = flipped
+	}
+	return result, indices
+}
+
+// sequentialIndices returns the indices of the triangles of a mesh without
+// indices. The incomplete last triangle is skipped.
+func sequentialIndices(vertexCount int) []uint32 {
+	indices := make([]uint32, vertexCount-vertexCount%3)
+	for i := range indices {
+		indices[i] = uint32(i)
+	}
+	return indices
+}
+
+// averageColor returns the average of the colors. It's white for empty input.
+func averageColor(colors []mgl32.Vec3) mgl32.Vec3 {
+	if len(colors) == 0 {
+		return mgl32.Vec3{1, 1, 1}
+	}
+	var sum mgl32.Vec3
+	for _, c := range colors {
+		sum = sum.Add(c)
+	}
+	return sum.Mul(1.0 / float32(len(colors)))
+}
+
+// setTextureMaps sets the texture maps of the material based on the uniform
+// names of the textures. The missing diffuse and specular maps are replaced
+// with the first texture.
+func setTextureMaps(mat *mtl, textures texture.Textures) {
+	for _, tex := range textures {
+		if strings.Contains(tex.UniformName, "diffuse") {
+			mat.mapKd = tex.FilePath
+		} else if strings.Contains(tex.UniformName, "specular") {
+			mat.mapKs = tex.FilePath
+		} else if strings.Contains(tex.UniformName, "ambient") {
+			mat.mapKa = tex.FilePath
+		}
+	}
+	if len(textures) > 0 {
+		if mat.mapKd == "" {
+			mat.mapKd = textures[0].FilePath
+		}
+		if mat.mapKs == "" {
+			mat.mapKs = mat.mapKd
+		}
+	}
+}
+
+// getMaterial returns the material descriptor of the mesh. The second return
+// value is false for the meshes without material (point meshes).
+func (e *Export) getMaterial(m interfaces.Mesh, name string) (*mtl, bool) {
+	fromMaterial := func(mat *material.Material) *mtl {
+		return &mtl{name: name, ka: mat.GetAmbient(), kd: mat.GetDiffuse(), ks: mat.GetSpecular(), ns: mat.GetShininess()}
+	}
+	fromColor := func(color mgl32.Vec3) *mtl {
+		return &mtl{name: name, ka: color, kd: color, ks: mgl32.Vec3{1, 1, 1}, ns: DefaultShininess}
+	}
+	switch msh := m.(type) {
+	case *mesh.MaterialMesh:
+		return fromMaterial(msh.Material), true
+	case *mesh.TexturedMaterialMesh:
+		mat := fromMaterial(msh.Material)
+		setTextureMaps(mat, msh.Textures)
+		return mat, true
+	case *mesh.TexturedMesh:
+		mat := fromColor(mgl32.Vec3{1, 1, 1})
+		setTextureMaps(mat, msh.Textures)
+		return mat, true
+	case *mesh.TexturedColoredMesh:
+		mat := fromColor(averageColor(msh.Color))
+		setTextureMaps(mat, msh.Textures)
+		return mat, true
+	case *mesh.ColorMesh:
+		return fromColor(averageColor(msh.Color)), true
+	}
+	return nil, false
+}
+
+// copyTextures copies the texture maps of the material to the directory and
+// replaces the paths with the file names. The textures that aren't stored in
+// files (eg. generated or embedded images) are skipped and removed from the material.
+func (e *Export) copyTextures(mat *mtl, directory string) error {
+	for _, mapPath := range []*string{&mat.mapKa, &mat.mapKd, &mat.mapKs} {
+		if *mapPath == "" {
+			continue
+		}
+		fileName, err := e.copyTexture(*mapPath, directory)
+		if err != nil {
+			return err
+		}
+		*mapPath = fileName
+	}
+	return nil
+}
+
+// copyTexture copies the texture file to the directory, if it hasn't been
+// copied yet. It returns the name of the copy. If the source file name is
+// already used by another texture, a numeric suffix is added.
+func (e *Export) copyTexture(sourcePath, directory string) (string, error) {
+	if fileName, ok := e.textureFiles[sourcePath]; ok {
+		return fileName, nil
+	}
+	if info, err := os.Stat(sourcePath); err != nil || info.IsDir() {
+		fmt.Printf("Texture '%s' is not a file, it's skipped.\n", sourcePath)
+		e.textureFiles[sourcePath] = ""
+		return "", nil
+	}
+	extension := filepath.Ext(sourcePath)
+	baseName := strings.TrimSuffix(filepath.Base(sourcePath), extension)
+	fileName := baseName + extension
+	for suffix := 1; ; suffix++ {
+		if _, used := e.textureSources[fileName]; !used {
+			break
+		}
+		fileName = fmt.Sprintf("%s_%d%s", baseName, suffix, extension)
+	}
+	if err := copyFile(sourcePath, filepath.Join(directory, fileName)); err != nil {
+		return "", err
+	}
+	e.textureFiles[sourcePath] = fileName
+	e.textureSources[fileName] = sourcePath
+	return fileName, nil
+}
+
+// copyFile copies the source file to the destination path. If the
+// texture is already in the destination directory, it is not copied.
+func copyFile(source, destination string) error {
+	absSource, errSource := filepath.Abs(source)
+	absDestination, errDestination := filepath.Abs(destination)
+	if errSource == nil && errDestination == nil && absSource == absDestination {
+		return nil
+	}
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// writeMaterial writes the 'newmtl' block of the material.
+func writeMaterial(w io.Writer, mat *mtl) {
+	fmt.Fprintf(w, "newmtl %s\n", mat.name)
+	fmt.Fprintf(w, "Ka %f %f %f\n", mat.ka.X(), mat.ka.Y(), mat.ka.Z())
+	fmt.Fprintf(w, "Kd %f %f %f\n", mat.kd.X(), mat.kd.Y(), mat.kd.Z())
+	fmt.Fprintf(w, "Ks %f %f %f\n", mat.ks.X(), mat.ks.Y(), mat.ks.Z())
+	fmt.Fprintf(w, "Ns %f\n", mat.ns)
+	if mat.mapKa != "" {
+		fmt.Fprintf(w, "map_Ka %s\n", mat.mapKa)
+	}
+	if mat.mapKd != "" {
+		fmt.Fprintf(w, "map_Kd %s\n", mat.mapKd)
+	}
+	if mat.mapKs != "" {
+		fmt.Fprintf(w, "map_Ks %s\n", mat.mapKs)
+	}
+	fmt.Fprintf(w, "\n")
+}
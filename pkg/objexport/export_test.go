@@ -0,0 +1,41 @@
+package objexport
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSanitizeName(t *testing.T) {
+	testData := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"simple", "cube", "cube"},
+		{"empty", "", "object"},
+		{"spaces", "red cube\t1", "red_cube_1"},
+		{"comment", "cube#1", "cube_1"},
+	}
+	for _, tt := range testData {
+		if result := sanitizeName(tt.input); result != tt.expected {
+			t.Errorf("%s: Invalid name. Instead of '%s', we have '%s'.", tt.name, tt.expected, result)
+		}
+	}
+}
+func TestSequentialIndices(t *testing.T) {
+	testData := []struct {
+		name     string
+		count    int
+		expected []uint32
+	}{
+		{"empty", 0, []uint32{}},
+		{"one triangle", 3, []uint32{0, 1, 2}},
+		{"incomplete triangle", 5, []uint32{0, 1, 2}},
+		{"two triangles", 6, []uint32{0, 1, 2, 3, 4, 5}},
+	}
+	for _, tt := range testData {
+		if result := sequentialIndices(tt.count); !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("%s: Invalid indices. Instead of '%v', we have '%v'.", tt.name, tt.expected, result)
+		}
+	}
+}
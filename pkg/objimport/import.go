@@ -0,0 +1,505 @@
+package objimport
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/akosgarai/opengl_playground/pkg/texturecache"
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/material"
+	"github.com/akosgarai/playground_engine/pkg/mesh"
+	"github.com/akosgarai/playground_engine/pkg/primitives/vertex"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	DEBUG = false
+)
+
+var (
+	// defaultMaterial is used for the faces without (or with unknown) material.
+	defaultMaterial = &mtl{
+		ka: mgl32.Vec3{0.2, 0.2, 0.2},
+		kd: mgl32.Vec3{0.8, 0.8, 0.8},
+		ks: mgl32.Vec3{0.5, 0.5, 0.5},
+		ns: 32,
+	}
+)
+
+// Model is a named part of the object file. Every object ('o') - group ('g')
+// pair is a separate model. The faces of the model are split to meshes by
+// their materials ('usemtl'). The vertices of the meshes are relative to the
+// center of the model, and the position of every mesh is the center, so that
+// the meshes of the model could be transformed together.
+type Model struct {
+	// The name of the object. Empty if the faces were defined before the first 'o' statement.
+	Object string
+	// The name of the group. Empty if the faces were defined before the first 'g' statement of the object.
+	Group string
+	// The center of the bounding box of the vertices.
+	Center mgl32.Vec3
+	// One mesh for every material that was used in the group.
+	Meshes []interfaces.Mesh
+}
+
+// Name returns the 'object/group' name of the model. If one of them is
+// missing, the other one is returned. If both of them are missing, the
+// name is 'default'.
+func (m *Model) Name() string {
+	var names []string
+	if m.Object != "" {
+		names = append(names, m.Object)
+	}
+	if m.Group != "" {
+		names = append(names, m.Group)
+	}
+	if len(names) == 0 {
+		return "default"
+	}
+	return strings.Join(names, "/")
+}
+
+type Import struct {
+	objectFile string
+	basePath   string
+	models     []*Model
+	prepared   []*modelData
+	images     map[string]*image.RGBA
+	object     *objFile
+	materials  map[string]*mtl
+	cache      *texturecache.Cache
+	glWrapper  interfaces.GLWrapper
+}
+
+// New returns an importer that reads the objectFileName from the basePath directory.
+func New(basePath, objectFileName string, wrapper interfaces.GLWrapper) *Import {
+	return &Import{
+		objectFile: objectFileName,
+		basePath:   basePath,
+		models:     []*Model{},
+		materials:  make(map[string]*mtl),
+		images:     make(map[string]*image.RGBA),
+		glWrapper:  wrapper,
+	}
+}
+
+// SetCache sets the texture cache. If it is set, the textures are added
+// through the cache, so that the same image is uploaded only once and the
+// quality options of the cache are applied.
+func (i *Import) SetCache(c *texturecache.Cache) {
+	i.cache = c
+}
+
+// GetModels returns the imported models in the order of their definition.
+func (i *Import) GetModels() []*Model {
+	return i.models
+}
+
+// GetMeshes returns the meshes of every imported model.
+func (i *Import) GetMeshes() []interfaces.Mesh {
+	var result []interfaces.Mesh
+	for _, m := range i.models {
+		result = append(result, m.Meshes...)
+	}
+	return result
+}
+
+func (i *Import) loadObjectFile() error {
+	var errObj error
+	objectFile := i.basePath + "/" + i.objectFile
+	if DEBUG {
+		fmt.Printf("Loading object file: '%s'.\n", objectFile)
+	}
+	i.object, errObj = parseObjFile(objectFile)
+	return errObj
+}
+func (i *Import) loadMaterialFiles() error {
+	for _, lib := range i.object.mtllibs {
+		materialFile := i.basePath + "/" + lib
+		if DEBUG {
+			fmt.Printf("Loading material file: '%s'.\n", materialFile)
+		}
+		materials, err := parseMaterialFile(materialFile)
+		if err != nil {
+			return err
+		}
+		for name, mtl := range materials {
+			i.materials[name] = mtl
+		}
+	}
+	return nil
+}
+
+// smoothingKey identifies the vertices that share their normal vector.
+type smoothingKey struct {
+	position       int
+	smoothingGroup int
+}
+
+// faceNormal returns the normal vector of the polygon. The length of the
+// vector is proportional to the area, so it could be used for weighted average.
+func (i *Import) faceNormal(f face) mgl32.Vec3 {
+	var normal mgl32.Vec3
+	// Newell's method, it works for the non planar polygons also.
+	for index, c := range f.corners {
+		current := i.object.positions[c.position]
+		next := i.object.positions[f.corners[(index+1)%len(f.corners)].position]
+		normal = normal.Add(mgl32.Vec3{
+			(current.Y() - next.Y()) * (current.Z() + next.Z()),
+			(current.Z() - next.Z()) * (current.X() + next.X()),
+			(current.X() - next.X()) * (current.Y() + next.Y()),
+		})
+	}
+	return normal.Mul(0.5)
+}
+
+// smoothNormals returns the averaged normal vectors of the smoothing groups.
+// Every face of the file counts, so the groups are smooth across the
+// material and the object boundaries also.
+func (i *Import) smoothNormals() map[smoothingKey]mgl32.Vec3 {
+	result := make(map[smoothingKey]mgl32.Vec3)
+	for _, p := range i.object.parts {
+		for _, mf := range p.materials {
+			for _, f := range mf.faces {
+				if f.smoothingGroup == 0 {
+					continue
+				}
+				normal := i.faceNormal(f)
+				for _, c := range f.corners {
+					key := smoothingKey{c.position, f.smoothingGroup}
+					result[key] = result[key].Add(normal)
+				}
+			}
+		}
+	}
+	for key, normal := range result {
+		if normal.Len() > 0 {
+			result[key] = normal.Normalize()
+		}
+	}
+	return result
+}
+
+// vertexKey identifies the vertices of a mesh. The face is set only for the
+// flat shaded vertices with generated normals, the smoothing group only for
+// the smooth shaded ones with generated normals.
+type vertexKey struct {
+	position       int
+	texCoord       int
+	normal         int
+	smoothingGroup int
+	face           int
+}
+
+// getVerticesAndIndices transforms the polygons to triangles. The missing
+// normals are generated. The second return value is true if every vertex
+// has texture coordinates.
+func (i *Import) getVerticesAndIndices(mf *materialFaces, smoothNormals map[smoothingKey]mgl32.Vec3) (vertex.Vertices, []uint32, bool) {
+	var vertices vertex.Vertices
+	var indices []uint32
+	indexMap := make(map[vertexKey]uint32)
+	hasTexCoords := true
+	for faceIndex, f := range mf.faces {
+		var flatNormal mgl32.Vec3
+		var faceIndices []uint32
+		for _, c := range f.corners {
+			key := vertexKey{position: c.position, texCoord: c.texCoord, normal: c.normal, face: -1}
+			if c.normal == -1 {
+				if f.smoothingGroup == 0 {
+					key.face = faceIndex
+				} else {
+					key.smoothingGroup = f.smoothingGroup
+				}
+			}
+			if _, ok := indexMap[key]; !ok {
+				indexMap[key] = uint32(len(vertices))
+				vert := vertex.Vertex{Position: i.object.positions[c.position]}
+				if c.texCoord != -1 {
+					vert.TexCoords = i.object.texCoords[c.texCoord]
+				} else {
+					hasTexCoords = false
+				}
+				if c.normal != -1 {
+					vert.Normal = i.object.normals[c.normal]
+				} else if f.smoothingGroup != 0 {
+					vert.Normal = smoothNormals[smoothingKey{c.position, f.smoothingGroup}]
+				} else {
+					if flatNormal.Len() == 0 {
+						flatNormal = i.faceNormal(f)
+						if flatNormal.Len() > 0 {
+							flatNormal = flatNormal.Normalize()
+						}
+					}
+					vert.Normal = flatNormal
+				}
+				vertices = append(vertices, vert)
+			}
+			faceIndices = append(faceIndices, indexMap[key])
+		}
+		// fan triangulation of the polygon
+		for index := 1; index+1 < len(faceIndices); index++ {
+			indices = append(indices, faceIndices[0], faceIndices[index], faceIndices[index+1])
+		}
+	}
+	return vertices, indices, hasTexCoords
+}
+func (i *Import) getMaterial(mtl *mtl) *material.Material {
+	return material.New(mtl.ka, mtl.kd, mtl.ks, mtl.ns)
+}
+
+// addTexture appends the texture of the decoded image to the textures. If the
+// cache is set, the texture is added through it.
+func (i *Import) addTexture(tex *texture.Textures, path, uniformName string) error {
+	if i.cache != nil {
+		return i.cache.AddTextureRGBA(tex, path, i.images[path], glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, uniformName)
+	}
+	tex.AddTextureRGBA(path, i.images[path], glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, uniformName, i.glWrapper)
+	return nil
+}
+
+// getTextures returns the diffuse and the specular maps from the decoded images.
+// If the specular map is missing, the diffuse map is used instead of it.
+func (i *Import) getTextures(mtl *mtl) (texture.Textures, error) {
+	var tex texture.Textures
+	if mtl.mapKd == "" {
+		return tex, nil
+	}
+	specularMap := mtl.mapKs
+	if specularMap == "" {
+		specularMap = mtl.mapKd
+	}
+	if DEBUG {
+		fmt.Printf("Setup diffuse map: '%s'.\n", i.basePath+"/"+mtl.mapKd)
+		fmt.Printf("Setup specular map: '%s'.\n", i.basePath+"/"+specularMap)
+	}
+	if err := i.addTexture(&tex, i.basePath+"/"+mtl.mapKd, "tex.diffuse"); err != nil {
+		return nil, err
+	}
+	if err := i.addTexture(&tex, i.basePath+"/"+specularMap, "tex.specular"); err != nil {
+		return nil, err
+	}
+	return tex, nil
+}
+
+// center returns the center of the bounding box of the vertices of the part.
+func (i *Import) center(p *part) mgl32.Vec3 {
+	first := true
+	var min, max mgl32.Vec3
+	for _, mf := range p.materials {
+		var positions []int
+		for _, f := range mf.faces {
+			for _, c := range f.corners {
+				positions = append(positions, c.position)
+			}
+		}
+		positions = append(positions, mf.points...)
+		for _, index := range positions {
+			position := i.object.positions[index]
+			if first {
+				min, max = position, position
+				first = false
+				continue
+			}
+			for axis := 0; axis < 3; axis++ {
+				if position[axis] < min[axis] {
+					min[axis] = position[axis]
+				}
+				if position[axis] > max[axis] {
+					max[axis] = position[axis]
+				}
+			}
+		}
+	}
+	return min.Add(max).Mul(0.5)
+}
+
+// meshData is the mesh of the faces or the points of a material, before the
+// gl setup. The vertices are relative to the center of the model.
+type meshData struct {
+	vertices vertex.Vertices
+	indices  []uint32
+	points   bool
+	textured bool
+	mtl      *mtl
+	// The error of the unknown material. The default material is used instead of it.
+	err error
+}
+
+// modelData is a model with the data of its meshes.
+type modelData struct {
+	model  *Model
+	meshes []*meshData
+}
+
+// prepareMesh returns the mesh data of the faces. The vertices are moved with
+// the -center vector. The faces with texture coordinates and diffuse map are
+// textured, the other faces are material meshes.
+func (i *Import) prepareMesh(mf *materialFaces, center mgl32.Vec3, smoothNormals map[smoothingKey]mgl32.Vec3) *meshData {
+	mtl, found := i.materials[mf.material]
+	if !found {
+		mtl = defaultMaterial
+	}
+	vertices, indices, hasTexCoords := i.getVerticesAndIndices(mf, smoothNormals)
+	for index := range vertices {
+		vertices[index].Position = vertices[index].Position.Sub(center)
+	}
+	data := &meshData{
+		vertices: vertices,
+		indices:  indices,
+		textured: hasTexCoords && mtl.mapKd != "",
+		mtl:      mtl,
+	}
+	if !found && mf.material != "" {
+		data.err = fmt.Errorf("Unknown material '%s', the default material is used.", mf.material)
+	}
+	return data
+}
+
+// preparePointMesh returns the mesh data of the 'p' statements.
+func (i *Import) preparePointMesh(mf *materialFaces, center mgl32.Vec3) *meshData {
+	data := &meshData{points: true}
+	for _, index := range mf.points {
+		data.vertices = append(data.vertices, vertex.Vertex{Position: i.object.positions[index].Sub(center)})
+	}
+	return data
+}
+
+// prepareModels calculates the mesh data of every model.
+func (i *Import) prepareModels() {
+	smoothNormals := i.smoothNormals()
+	for _, p := range i.object.parts {
+		data := &modelData{
+			model: &Model{
+				Object: p.object,
+				Group:  p.group,
+				Center: i.center(p),
+			},
+		}
+		for _, mf := range p.materials {
+			if len(mf.faces) > 0 {
+				data.meshes = append(data.meshes, i.prepareMesh(mf, data.model.Center, smoothNormals))
+			}
+			if len(mf.points) > 0 {
+				data.meshes = append(data.meshes, i.preparePointMesh(mf, data.model.Center))
+			}
+		}
+		i.prepared = append(i.prepared, data)
+	}
+}
+
+// decodeTextures decodes the texture maps of the textured meshes. Every
+// file is decoded only once.
+func (i *Import) decodeTextures() error {
+	for _, data := range i.prepared {
+		for _, md := range data.meshes {
+			if !md.textured {
+				continue
+			}
+			for _, fileName := range []string{md.mtl.mapKd, md.mtl.mapKs} {
+				if fileName == "" {
+					continue
+				}
+				path := i.basePath + "/" + fileName
+				if _, ok := i.images[path]; ok {
+					continue
+				}
+				rgba, err := texturecache.DecodeImage(path)
+				if err != nil {
+					return err
+				}
+				i.images[path] = rgba
+			}
+		}
+	}
+	return nil
+}
+
+// makeMesh returns the mesh of the mesh data.
+func (i *Import) makeMesh(data *meshData, center mgl32.Vec3) (interfaces.Mesh, error) {
+	var result interfaces.Mesh
+	if data.points {
+		pointMesh := mesh.NewPointMesh(i.glWrapper)
+		for _, v := range data.vertices {
+			pointMesh.AddVertex(v)
+		}
+		result = pointMesh
+	} else if data.textured {
+		tex, err := i.getTextures(data.mtl)
+		if err != nil {
+			return nil, err
+		}
+		result = mesh.NewTexturedMaterialMesh(data.vertices, data.indices, tex, i.getMaterial(data.mtl), i.glWrapper)
+	} else {
+		result = mesh.NewMaterialMesh(data.vertices, data.indices, i.getMaterial(data.mtl), i.glWrapper)
+	}
+	result.SetPosition(center)
+	return result, nil
+}
+func (i *Import) makeModels() []error {
+	var result []error
+	for _, data := range i.prepared {
+		m := data.model
+		for _, md := range data.meshes {
+			if md.err != nil {
+				result = append(result, fmt.Errorf("%s: %s", m.Name(), md.err.Error()))
+			}
+			msh, err := i.makeMesh(md, m.Center)
+			if err != nil {
+				result = append(result, fmt.Errorf("%s: %s", m.Name(), err.Error()))
+				continue
+			}
+			m.Meshes = append(m.Meshes, msh)
+		}
+		i.models = append(i.models, m)
+	}
+	i.prepared = nil
+	i.images = make(map[string]*image.RGBA)
+	return result
+}
+
+// Load parses the object and the material files, calculates the vertices
+// of the meshes and decodes the textures. It doesn't call gl functions, so
+// that it could be run on a worker goroutine. The models are made by the Upload.
+func (i *Import) Load() error {
+	errObj := i.loadObjectFile()
+	if errObj != nil {
+		return fmt.Errorf("Error during object file parse. '%s'", errObj.Error())
+	}
+	errMtl := i.loadMaterialFiles()
+	if errMtl != nil {
+		return fmt.Errorf("Error during material file parse. '%s'", errMtl.Error())
+	}
+	i.prepareModels()
+	if errTex := i.decodeTextures(); errTex != nil {
+		return fmt.Errorf("Error during texture decoding. '%s'", errTex.Error())
+	}
+	return nil
+}
+
+// Upload makes the models from the result of the Load. It has to be called
+// from the thread of the gl context.
+func (i *Import) Upload() {
+	errProcess := i.makeModels()
+	if len(errProcess) != 0 {
+		fmt.Println("Error during mesh construction.")
+		for _, err := range errProcess {
+			fmt.Printf(" - %s\n", err.Error())
+		}
+		return
+	}
+	fmt.Println("Import process finished.")
+}
+
+// Import loads the object and the material files and makes the models.
+func (i *Import) Import() {
+	fmt.Println("Import process started.")
+	if err := i.Load(); err != nil {
+		fmt.Print(err.Error())
+		panic(err)
+	}
+	i.Upload()
+}
@@ -0,0 +1,382 @@
+package objimport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// corner is a vertex of a face. The indices are 0 based, -1 means missing.
+type corner struct {
+	position int
+	texCoord int
+	normal   int
+}
+
+// face is a polygon of the object file with the smoothing group that was
+// active when it was defined. The 0 smoothing group means flat shading.
+type face struct {
+	corners        []corner
+	smoothingGroup int
+}
+
+// materialFaces contains the faces of a part that use the same material.
+type materialFaces struct {
+	material string
+	faces    []face
+	points   []int
+}
+
+// part contains the faces of an object ('o') - group ('g') pair.
+type part struct {
+	object    string
+	group     string
+	materials []*materialFaces
+}
+
+// mtl is the parsed material descriptor ('newmtl' block).
+type mtl struct {
+	name  string
+	ka    mgl32.Vec3
+	kd    mgl32.Vec3
+	ks    mgl32.Vec3
+	ns    float32
+	mapKa string
+	mapKd string
+	mapKs string
+}
+
+// objFile is the parsed object file.
+type objFile struct {
+	positions []mgl32.Vec3
+	texCoords []mgl32.Vec2
+	normals   []mgl32.Vec3
+	parts     []*part
+	mtllibs   []string
+}
+
+// parser keeps the state of the object file parsing process.
+type parser struct {
+	obj            *objFile
+	object         string
+	group          string
+	material       string
+	smoothingGroup int
+	current        *part
+}
+
+// logicalLines returns the lines of the reader. The lines that end
+// with backslash are joined with the next one. The comments are removed.
+func logicalLines(r io.Reader) ([]string, error) {
+	var result []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	pending := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if comment := strings.Index(line, "#"); comment >= 0 {
+			line = line[:comment]
+		}
+		line = strings.TrimSpace(line)
+		if strings.HasSuffix(line, "\\") {
+			pending += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		result = append(result, pending+line)
+		pending = ""
+	}
+	if pending != "" {
+		result = append(result, pending)
+	}
+	return result, scanner.Err()
+}
+
+// parseFloats parses the given fields as float32 values.
+func parseFloats(fields []string) ([]float32, error) {
+	result := make([]float32, len(fields))
+	for i, field := range fields {
+		value, err := strconv.ParseFloat(field, 32)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = float32(value)
+	}
+	return result, nil
+}
+
+// parseVec3 returns the first 3 fields as vector. The additional
+// fields (eg. w coordinate, vertex color) are skipped.
+func parseVec3(fields []string) (mgl32.Vec3, error) {
+	if len(fields) < 3 {
+		return mgl32.Vec3{}, fmt.Errorf("Not enough components: '%v'.", fields)
+	}
+	values, err := parseFloats(fields[:3])
+	if err != nil {
+		return mgl32.Vec3{}, err
+	}
+	return mgl32.Vec3{values[0], values[1], values[2]}, nil
+}
+
+// parseColor returns the color of the 'Ka', 'Kd', 'Ks' statements. If only
+// the red component is given, it is used as the green and the blue also.
+func parseColor(fields []string) (mgl32.Vec3, error) {
+	if len(fields) != 1 {
+		return parseVec3(fields)
+	}
+	values, err := parseFloats(fields)
+	if err != nil {
+		return mgl32.Vec3{}, err
+	}
+	return mgl32.Vec3{values[0], values[0], values[0]}, nil
+}
+
+// resolveIndex maps the 1 based (or negative relative) index to 0 based index.
+func resolveIndex(field string, length int) (int, error) {
+	index, err := strconv.Atoi(field)
+	if err != nil {
+		return -1, err
+	}
+	if index < 0 {
+		index = length + index
+	} else {
+		index = index - 1
+	}
+	if index < 0 || index >= length {
+		return -1, fmt.Errorf("Index '%s' is out of range.", field)
+	}
+	return index, nil
+}
+
+// parseCorner parses the 'v', 'v/vt', 'v//vn', 'v/vt/vn' face elements.
+func (p *parser) parseCorner(field string) (corner, error) {
+	c := corner{position: -1, texCoord: -1, normal: -1}
+	components := strings.Split(field, "/")
+	var err error
+	if c.position, err = resolveIndex(components[0], len(p.obj.positions)); err != nil {
+		return c, err
+	}
+	if len(components) > 1 && components[1] != "" {
+		if c.texCoord, err = resolveIndex(components[1], len(p.obj.texCoords)); err != nil {
+			return c, err
+		}
+	}
+	if len(components) > 2 && components[2] != "" {
+		if c.normal, err = resolveIndex(components[2], len(p.obj.normals)); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+// findPart returns the part of the current object and group. If the object -
+// group pair hasn't got faces yet, it returns nil.
+func (p *parser) findPart() *part {
+	for _, pt := range p.obj.parts {
+		if pt.object == p.object && pt.group == p.group {
+			return pt
+		}
+	}
+	return nil
+}
+
+// materialFaces returns the face list of the current object, group and material.
+// The part and the material list are created on demand, so that the empty
+// objects and groups are not listed.
+func (p *parser) materialFaces() *materialFaces {
+	if p.current == nil {
+		p.current = &part{object: p.object, group: p.group}
+		p.obj.parts = append(p.obj.parts, p.current)
+	}
+	for _, mf := range p.current.materials {
+		if mf.material == p.material {
+			return mf
+		}
+	}
+	mf := &materialFaces{material: p.material}
+	p.current.materials = append(p.current.materials, mf)
+	return mf
+}
+
+// parseLine processes one statement of the object file. The unknown
+// statements (eg. 'l', 'cstype') are skipped.
+func (p *parser) parseLine(fields []string) error {
+	switch fields[0] {
+	case "v":
+		v, err := parseVec3(fields[1:])
+		if err != nil {
+			return err
+		}
+		p.obj.positions = append(p.obj.positions, v)
+	case "vn":
+		vn, err := parseVec3(fields[1:])
+		if err != nil {
+			return err
+		}
+		p.obj.normals = append(p.obj.normals, vn)
+	case "vt":
+		if len(fields) < 2 {
+			return fmt.Errorf("Not enough components: '%v'.", fields)
+		}
+		values, err := parseFloats(fields[1:])
+		if err != nil {
+			return err
+		}
+		vt := mgl32.Vec2{values[0], 0}
+		if len(values) > 1 {
+			vt[1] = values[1]
+		}
+		p.obj.texCoords = append(p.obj.texCoords, vt)
+	case "f":
+		if len(fields) < 4 {
+			return fmt.Errorf("Face with less than 3 vertices: '%v'.", fields)
+		}
+		f := face{smoothingGroup: p.smoothingGroup}
+		for _, field := range fields[1:] {
+			c, err := p.parseCorner(field)
+			if err != nil {
+				return err
+			}
+			f.corners = append(f.corners, c)
+		}
+		mf := p.materialFaces()
+		mf.faces = append(mf.faces, f)
+	case "p":
+		mf := p.materialFaces()
+		for _, field := range fields[1:] {
+			index, err := resolveIndex(strings.Split(field, "/")[0], len(p.obj.positions))
+			if err != nil {
+				return err
+			}
+			mf.points = append(mf.points, index)
+		}
+	case "o":
+		p.object = strings.Join(fields[1:], " ")
+		p.group = ""
+		p.current = p.findPart()
+	case "g":
+		// The faces of a repeated group are appended to its existing part.
+		p.group = strings.Join(fields[1:], " ")
+		p.current = p.findPart()
+	case "usemtl":
+		p.material = strings.Join(fields[1:], " ")
+	case "s":
+		// The 'on' and 'off' values are the same as the 1 and 0 groups.
+		p.smoothingGroup = 0
+		if len(fields) > 1 {
+			switch fields[1] {
+			case "off":
+				// It's the 0 group, that is already set.
+			case "on":
+				p.smoothingGroup = 1
+			default:
+				group, err := strconv.Atoi(fields[1])
+				if err != nil {
+					return err
+				}
+				p.smoothingGroup = group
+			}
+		}
+	case "mtllib":
+		p.obj.mtllibs = append(p.obj.mtllibs, fields[1:]...)
+	}
+	return nil
+}
+
+// parseObjFile reads and parses the given object file.
+func parseObjFile(path string) (*objFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	lines, err := logicalLines(file)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{obj: &objFile{}}
+	for lineNumber, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if err := p.parseLine(fields); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", filepath.Base(path), lineNumber+1, err.Error())
+		}
+	}
+	return p.obj, nil
+}
+
+// textureFileName returns the filename of a texture map statement. The
+// options (eg. '-s 1 1 1') are skipped, the filename is the last field.
+func textureFileName(fields []string) string {
+	if len(fields) < 2 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
+
+// parseMaterialFile reads the material descriptors from the given file.
+func parseMaterialFile(path string) (map[string]*mtl, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	lines, err := logicalLines(file)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*mtl)
+	var current *mtl
+	for lineNumber, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "newmtl" {
+			current = &mtl{name: strings.Join(fields[1:], " "), ns: 1}
+			result[current.name] = current
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		switch fields[0] {
+		case "Ka", "Kd", "Ks":
+			color, err := parseColor(fields[1:])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %s", filepath.Base(path), lineNumber+1, err.Error())
+			}
+			switch fields[0] {
+			case "Ka":
+				current.ka = color
+			case "Kd":
+				current.kd = color
+			case "Ks":
+				current.ks = color
+			}
+		case "Ns":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("%s:%d: Missing Ns value.", filepath.Base(path), lineNumber+1)
+			}
+			values, err := parseFloats(fields[1:2])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %s", filepath.Base(path), lineNumber+1, err.Error())
+			}
+			current.ns = values[0]
+		case "map_Ka":
+			current.mapKa = textureFileName(fields)
+		case "map_Kd":
+			current.mapKd = textureFileName(fields)
+		case "map_Ks":
+			current.mapKs = textureFileName(fields)
+		}
+	}
+	return result, nil
+}
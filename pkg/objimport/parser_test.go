@@ -0,0 +1,234 @@
+package objimport
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// writeFile writes the content to a new temporary file and returns its path.
+// The returned function removes the file.
+func writeFile(t *testing.T, name, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "objimport")
+	if err != nil {
+		t.Fatalf("TempDir shouldn't fail. '%s'.", err.Error())
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("WriteFile shouldn't fail. '%s'.", err.Error())
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestLogicalLines(t *testing.T) {
+	testData := []struct {
+		input    string
+		expected []string
+	}{
+		{"v 1 2 3\nv 4 5 6", []string{"v 1 2 3", "v 4 5 6"}},
+		{"# comment\nv 1 2 3 # trailing comment", []string{"", "v 1 2 3"}},
+		{"f 1 2 \\\n 3\n", []string{"f 1 2  3"}},
+		{"f 1 \\\n2 \\\n3", []string{"f 1  2  3"}},
+		{"f 1 2 3 \\", []string{"f 1 2 3  "}},
+		{"  \t", []string{""}},
+	}
+	for _, tt := range testData {
+		result, err := logicalLines(strings.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("logicalLines shouldn't fail for '%q'. '%s'.", tt.input, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("Invalid lines for '%q'. Instead of '%q', we have '%q'.", tt.input, tt.expected, result)
+		}
+	}
+}
+
+func TestResolveIndex(t *testing.T) {
+	testData := []struct {
+		field    string
+		length   int
+		expected int
+		valid    bool
+	}{
+		{"1", 3, 0, true},
+		{"3", 3, 2, true},
+		{"-1", 3, 2, true},
+		{"-3", 3, 0, true},
+		{"0", 3, -1, false},
+		{"4", 3, -1, false},
+		{"-4", 3, -1, false},
+		{"a", 3, -1, false},
+	}
+	for _, tt := range testData {
+		result, err := resolveIndex(tt.field, tt.length)
+		if tt.valid != (err == nil) {
+			t.Errorf("Invalid error state for '%s'. Expected valid: '%v', error: '%v'.", tt.field, tt.valid, err)
+		}
+		if result != tt.expected {
+			t.Errorf("Invalid index for '%s'. Instead of '%d', we have '%d'.", tt.field, tt.expected, result)
+		}
+	}
+}
+
+// triangle returns the corners of a triangle with the given position indices.
+func triangle(a, b, c int) []corner {
+	return []corner{{a, -1, -1}, {b, -1, -1}, {c, -1, -1}}
+}
+
+func TestParseObjFile(t *testing.T) {
+	positions := "v 0 0 0\nv 1 0 0\nv 0 1 0\n"
+	testData := []struct {
+		name     string
+		content  string
+		expected *objFile
+	}{
+		{"triangle", positions + "f 1 2 3", &objFile{
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			parts:     []*part{{materials: []*materialFaces{{faces: []face{{corners: triangle(0, 1, 2)}}}}}},
+		}},
+		{"negative indices", positions + "f -3 -2 -1", &objFile{
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			parts:     []*part{{materials: []*materialFaces{{faces: []face{{corners: triangle(0, 1, 2)}}}}}},
+		}},
+		{"corner formats", positions + "vt 0.5\nvt 0 1 0\nvn 0 0 1 extra\nf 1/1 2//1 3/2/1", &objFile{
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			texCoords: []mgl32.Vec2{{0.5, 0}, {0, 1}},
+			normals:   []mgl32.Vec3{{0, 0, 1}},
+			parts:     []*part{{materials: []*materialFaces{{faces: []face{{corners: []corner{{0, 0, -1}, {1, -1, 0}, {2, 1, 0}}}}}}}},
+		}},
+		{"objects groups and materials", positions + "mtllib a.mtl b.mtl\no cube\ng side one\nusemtl red\nf 1 2 3\nusemtl blue\nf 3 2 1\no ball\nf 1 2 3", &objFile{
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			mtllibs:   []string{"a.mtl", "b.mtl"},
+			parts: []*part{
+				{object: "cube", group: "side one", materials: []*materialFaces{
+					{material: "red", faces: []face{{corners: triangle(0, 1, 2)}}},
+					{material: "blue", faces: []face{{corners: triangle(2, 1, 0)}}},
+				}},
+				{object: "ball", materials: []*materialFaces{{material: "blue", faces: []face{{corners: triangle(0, 1, 2)}}}}},
+			},
+		}},
+		{"smoothing groups", positions + "s 2\nf 1 2 3\ns off\nf 1 2 3\ns on\nf 1 2 3\ns 0\nf 1 2 3", &objFile{
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			parts: []*part{{materials: []*materialFaces{{faces: []face{
+				{corners: triangle(0, 1, 2), smoothingGroup: 2},
+				{corners: triangle(0, 1, 2)},
+				{corners: triangle(0, 1, 2), smoothingGroup: 1},
+				{corners: triangle(0, 1, 2)},
+			}}}}},
+		}},
+		{"points", positions + "p 1 -1 2/1", &objFile{
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			parts:     []*part{{materials: []*materialFaces{{points: []int{0, 2, 1}}}}},
+		}},
+		{"repeated groups", positions + "o cube\ng top\nf 1 2 3\ng side\nf 3 2 1\ng top\nf 2 3 1\no ball\nf 1 2 3\no cube\nf 1 3 2", &objFile{
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			parts: []*part{
+				{object: "cube", group: "top", materials: []*materialFaces{{faces: []face{{corners: triangle(0, 1, 2)}, {corners: triangle(1, 2, 0)}}}}},
+				{object: "cube", group: "side", materials: []*materialFaces{{faces: []face{{corners: triangle(2, 1, 0)}}}}},
+				{object: "ball", materials: []*materialFaces{{faces: []face{{corners: triangle(0, 1, 2)}}}}},
+				{object: "cube", materials: []*materialFaces{{faces: []face{{corners: triangle(0, 2, 1)}}}}},
+			},
+		}},
+		{"empty groups are skipped", positions + "o empty\ng first\ng second\nf 1 2 3\nl 1 2", &objFile{
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			parts:     []*part{{object: "empty", group: "second", materials: []*materialFaces{{faces: []face{{corners: triangle(0, 1, 2)}}}}}},
+		}},
+	}
+	for _, tt := range testData {
+		path, remove := writeFile(t, "test.obj", tt.content)
+		result, err := parseObjFile(path)
+		remove()
+		if err != nil {
+			t.Errorf("%s: parseObjFile shouldn't fail. '%s'.", tt.name, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("%s: Invalid object file. Instead of '%+v', we have '%+v'.", tt.name, tt.expected, result)
+		}
+	}
+}
+
+func TestParseObjFileError(t *testing.T) {
+	testData := []struct {
+		name    string
+		content string
+	}{
+		{"missing component", "v 1 2"},
+		{"invalid float", "v 1 2 a"},
+		{"missing texture component", "vt"},
+		{"face with 2 vertices", "v 0 0 0\nv 1 0 0\nf 1 2"},
+		{"position out of range", "v 0 0 0\nv 1 0 0\nf 1 2 3"},
+		{"texture coordinate out of range", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/1 2/1 3/1"},
+		{"normal out of range", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1//1 2//1 3//1"},
+		{"point out of range", "v 0 0 0\np 2"},
+		{"invalid smoothing group", "s smooth"},
+	}
+	for _, tt := range testData {
+		path, remove := writeFile(t, "test.obj", tt.content)
+		_, err := parseObjFile(path)
+		remove()
+		if err == nil {
+			t.Errorf("%s: parseObjFile should fail.", tt.name)
+		}
+	}
+}
+
+func TestParseMaterialFile(t *testing.T) {
+	testData := []struct {
+		name     string
+		content  string
+		expected map[string]*mtl
+	}{
+		{"colors", "Kd 1 1 1\nnewmtl red\nKa 0.1 0 0\nKd 1 0 0 # comment\nKs 0.5 0.5 0.5\nNs 32", map[string]*mtl{
+			"red": {name: "red", ka: mgl32.Vec3{0.1, 0, 0}, kd: mgl32.Vec3{1, 0, 0}, ks: mgl32.Vec3{0.5, 0.5, 0.5}, ns: 32},
+		}},
+		{"single component colors", "newmtl gray\nKa 0.1\nKd 0.5\nKs 1", map[string]*mtl{
+			"gray": {name: "gray", ka: mgl32.Vec3{0.1, 0.1, 0.1}, kd: mgl32.Vec3{0.5, 0.5, 0.5}, ks: mgl32.Vec3{1, 1, 1}, ns: 1},
+		}},
+		{"maps", "newmtl brick wall\nmap_Ka ambient.png\nmap_Kd -s 1 1 1 diffuse.png\nmap_Ks -bm 0.5 specular.png\nnewmtl empty", map[string]*mtl{
+			"brick wall": {name: "brick wall", ns: 1, mapKa: "ambient.png", mapKd: "diffuse.png", mapKs: "specular.png"},
+			"empty":      {name: "empty", ns: 1},
+		}},
+	}
+	for _, tt := range testData {
+		path, remove := writeFile(t, "test.mtl", tt.content)
+		result, err := parseMaterialFile(path)
+		remove()
+		if err != nil {
+			t.Errorf("%s: parseMaterialFile shouldn't fail. '%s'.", tt.name, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("%s: Invalid materials. Instead of '%+v', we have '%+v'.", tt.name, tt.expected, result)
+		}
+	}
+}
+
+func TestParseMaterialFileError(t *testing.T) {
+	testData := []struct {
+		name    string
+		content string
+	}{
+		{"invalid color", "newmtl red\nKd 1 0 a"},
+		{"invalid single component color", "newmtl red\nKd a"},
+		{"two component color", "newmtl red\nKd 1 0"},
+		{"missing color", "newmtl red\nKd"},
+		{"missing shininess", "newmtl red\nNs"},
+		{"invalid shininess", "newmtl red\nNs high"},
+	}
+	for _, tt := range testData {
+		path, remove := writeFile(t, "test.mtl", tt.content)
+		_, err := parseMaterialFile(path)
+		remove()
+		if err == nil {
+			t.Errorf("%s: parseMaterialFile should fail.", tt.name)
+		}
+	}
+}
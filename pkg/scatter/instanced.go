@@ -0,0 +1,190 @@
+package scatter
+
+import (
+	"path"
+	"runtime"
+
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/material"
+	"github.com/akosgarai/playground_engine/pkg/mesh"
+	"github.com/akosgarai/playground_engine/pkg/shader"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// The number of the float values of a vertex in the vertex buffer.
+	// position (3), normal (3), color (3).
+	vertexSize = 9
+	// The first attribute location of the instance transformation. The mat4
+	// uses 4 locations, one for each column.
+	instanceLocation = 3
+	// The culling is recalculated, if the focus moves more than this
+	// ratio of the cull distance.
+	refocusRatio = float32(0.1)
+)
+
+func baseDir() string {
+	_, filename, _, _ := runtime.Caller(1)
+	return path.Dir(filename)
+}
+
+// NewInstancedShader returns the shader of the instanced meshes. It supports the
+// directional, point and spot light uniforms and the fog of the splat shader.
+func NewInstancedShader(wrapper interfaces.GLWrapper) *shader.Shader {
+	return shader.NewShader(baseDir()+"/shaders/instanced.vert", baseDir()+"/shaders/instanced.frag", wrapper)
+}
+
+// InstancedMesh draws the same shape in many places with one instanced draw
+// call. The transformations of the instances are stored in an instance buffer,
+// that is allocated once for every instance. Only the instances within the cull
+// distance from the focus are uploaded, the buffer is refilled when the focus
+// moves. The embedded engine mesh is created without its gl setup, so that the
+// mesh has only one vertex array.
+type InstancedMesh struct {
+	*mesh.MaterialMesh
+	instances    []Instance
+	cullDistance float32
+	// The focus of the last culling and the number of the visible instances.
+	focus    mgl32.Vec3
+	culled   bool
+	visible  int32
+	matrices []float32
+
+	vao     uint32
+	vbo     uint32
+	ebo     uint32
+	ibo     uint32
+	wrapper interfaces.GLWrapper
+}
+
+// NewInstancedMesh returns the instanced mesh of the shape. The color of the
+// vertices are multiplied with the diffuse and the ambient components of the
+// material. If the cull distance is 0, every instance is drawn.
+func NewInstancedMesh(s *Shape, instances []Instance, cullDistance float32, mat *material.Material, wrapper interfaces.GLWrapper) *InstancedMesh {
+	m := &InstancedMesh{
+		MaterialMesh: &mesh.MaterialMesh{
+			Mesh:     mesh.Mesh{Vertices: s.Vertices},
+			Indices:  s.Indices,
+			Material: mat,
+		},
+		instances:    instances,
+		cullDistance: cullDistance,
+		matrices:     make([]float32, 0, 16*len(instances)),
+		wrapper:      wrapper,
+	}
+	m.SetScale(mgl32.Vec3{1, 1, 1})
+	m.setup()
+	return m
+}
+func (m *InstancedMesh) setup() {
+	m.vao = m.wrapper.GenVertexArrays()
+	m.vbo = m.wrapper.GenBuffers()
+	m.ebo = m.wrapper.GenBuffers()
+	m.ibo = m.wrapper.GenBuffers()
+
+	m.wrapper.BindVertexArray(m.vao)
+
+	var buffer []float32
+	for _, v := range m.Vertices {
+		buffer = append(buffer, v.Position.X(), v.Position.Y(), v.Position.Z())
+		buffer = append(buffer, v.Normal.X(), v.Normal.Y(), v.Normal.Z())
+		buffer = append(buffer, v.Color.X(), v.Color.Y(), v.Color.Z())
+	}
+	m.wrapper.BindBuffer(glwrapper.ARRAY_BUFFER, m.vbo)
+	m.wrapper.ArrayBufferData(buffer)
+
+	m.wrapper.BindBuffer(glwrapper.ELEMENT_ARRAY_BUFFER, m.ebo)
+	m.wrapper.ElementBufferData(m.Indices)
+
+	// setup coordinates
+	m.wrapper.VertexAttribPointer(0, 3, glwrapper.FLOAT, false, 4*vertexSize, m.wrapper.PtrOffset(0))
+	// setup normals
+	m.wrapper.VertexAttribPointer(1, 3, glwrapper.FLOAT, false, 4*vertexSize, m.wrapper.PtrOffset(4*3))
+	// setup colors
+	m.wrapper.VertexAttribPointer(2, 3, glwrapper.FLOAT, false, 4*vertexSize, m.wrapper.PtrOffset(4*6))
+
+	// setup instance transformations. The columns of the matrices are read
+	// once per instance instead of once per vertex. The buffer is allocated for
+	// every instance, the visible ones are uploaded to its beginning.
+	m.wrapper.BindBuffer(glwrapper.ARRAY_BUFFER, m.ibo)
+	if len(m.instances) > 0 {
+		gl.BufferData(gl.ARRAY_BUFFER, 4*16*len(m.instances), nil, gl.DYNAMIC_DRAW)
+	}
+	for column := 0; column < 4; column++ {
+		location := uint32(instanceLocation + column)
+		m.wrapper.VertexAttribPointer(location, 4, glwrapper.FLOAT, false, 4*16, m.wrapper.PtrOffset(4*4*column))
+		gl.VertexAttribDivisor(location, 1)
+	}
+
+	// close
+	m.wrapper.BindVertexArray(0)
+	m.wrapper.BindBuffer(glwrapper.ARRAY_BUFFER, 0)
+}
+
+// GetInstances returns every instance of the mesh.
+func (m *InstancedMesh) GetInstances() []Instance {
+	return m.instances
+}
+
+// GetVisibleCount returns the number of the instances, that are drawn.
+func (m *InstancedMesh) GetVisibleCount() int {
+	return int(m.visible)
+}
+
+// SetCullDistance sets the cull distance. If it's 0, every instance is drawn.
+func (m *InstancedMesh) SetCullDistance(d float32) {
+	m.cullDistance = d
+	m.culled = false
+}
+
+// SetFocus uploads the instances within the cull distance from the focus point,
+// if the focus moved enough since the last upload. Without cull distance every
+// instance is uploaded once.
+func (m *InstancedMesh) SetFocus(focus mgl32.Vec3) {
+	if m.culled && (m.cullDistance <= 0 || focus.Sub(m.focus).Len() < m.cullDistance*refocusRatio) {
+		return
+	}
+	m.focus = focus
+	m.culled = true
+	m.matrices = m.matrices[:0]
+	distanceSquare := m.cullDistance * m.cullDistance
+	for _, instance := range m.instances {
+		if m.cullDistance > 0 && instance.Position.Sub(focus).LenSqr() > distanceSquare {
+			continue
+		}
+		transformation := instance.Transformation
+		m.matrices = append(m.matrices, transformation[:]...)
+	}
+	m.visible = int32(len(m.matrices) / 16)
+	if m.visible == 0 {
+		return
+	}
+	m.wrapper.BindBuffer(glwrapper.ARRAY_BUFFER, m.ibo)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, 4*len(m.matrices), gl.Ptr(m.matrices))
+	m.wrapper.BindBuffer(glwrapper.ARRAY_BUFFER, 0)
+}
+
+// Draw function is responsible for the actual drawing. It sets up the model
+// and the material uniforms, then it draws the visible instances with one
+// instanced draw call. The instances are not drawn before the first focus.
+func (m *InstancedMesh) Draw(shader interfaces.Shader) {
+	if m.visible == 0 {
+		return
+	}
+	M := m.ModelTransformation()
+	shader.SetUniformMat4("model", M)
+	diffuse := m.Material.GetDiffuse()
+	ambient := m.Material.GetAmbient()
+	specular := m.Material.GetSpecular()
+	shininess := m.Material.GetShininess()
+	shader.SetUniform3f("material.diffuse", diffuse.X(), diffuse.Y(), diffuse.Z())
+	shader.SetUniform3f("material.ambient", ambient.X(), ambient.Y(), ambient.Z())
+	shader.SetUniform3f("material.specular", specular.X(), specular.Y(), specular.Z())
+	shader.SetUniform1f("material.shininess", shininess)
+	m.wrapper.BindVertexArray(m.vao)
+	gl.DrawElementsInstanced(gl.TRIANGLES, int32(len(m.Indices)), gl.UNSIGNED_INT, nil, m.visible)
+	m.wrapper.BindVertexArray(0)
+}
@@ -0,0 +1,58 @@
+package scatter
+
+import (
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/material"
+	"github.com/akosgarai/playground_engine/pkg/model"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Model is the set of the scattered item kinds. Every kind is an instanced
+// mesh, that is drawn with one draw call. The items don't collide with the camera.
+type Model struct {
+	*model.BaseModel
+	meshes []*InstancedMesh
+}
+
+// NewModel returns an empty scatter model.
+func NewModel() *Model {
+	return &Model{
+		BaseModel: model.New(),
+	}
+}
+
+// AddItems adds an item kind to the model with the given shape and instances.
+// The instances farther than the cull distance from the focus are not drawn.
+func (m *Model) AddItems(s *Shape, instances []Instance, cullDistance float32, mat *material.Material, wrapper interfaces.GLWrapper) *InstancedMesh {
+	instanced := NewInstancedMesh(s, instances, cullDistance, mat, wrapper)
+	m.meshes = append(m.meshes, instanced)
+	m.AddMesh(instanced)
+	return instanced
+}
+
+// SetFocus updates the visible instances of the kinds. It has to be called
+// with the camera position, eg. in every frame.
+func (m *Model) SetFocus(focus mgl32.Vec3) {
+	for _, instanced := range m.meshes {
+		instanced.SetFocus(focus)
+	}
+}
+
+// GetInstanceCount returns the number of the instances of every kind.
+func (m *Model) GetInstanceCount() int {
+	count := 0
+	for _, instanced := range m.meshes {
+		count += len(instanced.GetInstances())
+	}
+	return count
+}
+
+// GetVisibleCount returns the number of the drawn instances of every kind.
+func (m *Model) GetVisibleCount() int {
+	count := 0
+	for _, instanced := range m.meshes {
+		count += instanced.GetVisibleCount()
+	}
+	return count
+}
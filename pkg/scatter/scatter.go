@@ -0,0 +1,270 @@
+package scatter
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/akosgarai/opengl_playground/pkg/terrain"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// The default parameters of the rules.
+	DefaultMaxHeight      = float32(math.MaxFloat32)
+	DefaultMaxSlope       = float32(1.0)
+	DefaultNoiseScale     = float32(0.1)
+	DefaultNoiseThreshold = float32(0.4)
+	DefaultMinScale       = float32(0.8)
+	DefaultMaxScale       = float32(1.2)
+	// The default horizontal distance of the height samples of the slope.
+	DefaultSlopeStep = float32(0.25)
+)
+
+// Footprint is an area in the horizontal plane, that is kept free of items,
+// eg. the floor of a building or the base of a lamp.
+type Footprint interface {
+	Contains(x, z float32) bool
+}
+
+// Rectangle is an axis aligned rectangle footprint in the XZ plane.
+type Rectangle struct {
+	Min, Max mgl32.Vec2
+}
+
+// NewRectangle returns the rectangle footprint with the given center and size,
+// that is extended with the margin in every direction.
+func NewRectangle(center mgl32.Vec2, width, length, margin float32) *Rectangle {
+	half := mgl32.Vec2{width/2 + margin, length/2 + margin}
+	return &Rectangle{
+		Min: center.Sub(half),
+		Max: center.Add(half),
+	}
+}
+
+// Contains returns true if the point is inside the rectangle.
+func (r *Rectangle) Contains(x, z float32) bool {
+	return x >= r.Min.X() && x <= r.Max.X() && z >= r.Min.Y() && z <= r.Max.Y()
+}
+
+// Circle is a circle footprint in the XZ plane.
+type Circle struct {
+	Center mgl32.Vec2
+	Radius float32
+}
+
+// NewCircle returns the circle footprint.
+func NewCircle(center mgl32.Vec2, radius float32) *Circle {
+	return &Circle{
+		Center: center,
+		Radius: radius,
+	}
+}
+
+// Contains returns true if the point is inside the circle.
+func (c *Circle) Contains(x, z float32) bool {
+	dX, dZ := x-c.Center.X(), z-c.Center.Y()
+	return dX*dX+dZ*dZ <= c.Radius*c.Radius
+}
+
+// Rule is the placement rule of an item kind. The candidate points are on a
+// jittered grid, where the number of the points in unit area is the density.
+// A candidate is accepted, if the height and the slope of the ground is in the
+// ranges, and its probability depends on the density noise. Below the noise
+// threshold there is no item, above it the probability grows to 1 linearly.
+type Rule struct {
+	// The maximal number of the items in unit area.
+	Density float32
+	// The range of the ground height. It's measured in the up direction from the
+	// water level, or from the 0 height, if the water is not set.
+	MinHeight, MaxHeight float32
+	// The range of the slope (height difference in unit distance).
+	MinSlope, MaxSlope float32
+	// The frequency of the density noise and the threshold of the noise value.
+	NoiseScale     float32
+	NoiseThreshold float32
+	// The range of the uniform scale of the items.
+	MinScale, MaxScale float32
+	// The seed of the rule. The rules with different seeds are placed to
+	// different points and they have different density noises.
+	Seed int64
+}
+
+// NewRule returns a rule with the default parameters and the given density.
+func NewRule(density float32, seed int64) *Rule {
+	return &Rule{
+		Density:        density,
+		MinHeight:      0,
+		MaxHeight:      DefaultMaxHeight,
+		MinSlope:       0,
+		MaxSlope:       DefaultMaxSlope,
+		NoiseScale:     DefaultNoiseScale,
+		NoiseThreshold: DefaultNoiseThreshold,
+		MinScale:       DefaultMinScale,
+		MaxScale:       DefaultMaxScale,
+		Seed:           seed,
+	}
+}
+
+// Instance is a placed item.
+type Instance struct {
+	// The position of the item on the ground.
+	Position mgl32.Vec3
+	// The rotation around the up direction in radians.
+	Rotation float32
+	Scale    float32
+	// The model transformation of the item. It's calculated from the position,
+	// the rotation and the scale. The items are flipped upside down, if the up
+	// direction of the world is the -Y.
+	Transformation mgl32.Mat4
+}
+
+// Scatter places the items on a surface. The placement is deterministic, the
+// same surface, rule and seed give the same instances.
+type Scatter struct {
+	surface terrain.Surface
+	// The generator of the density noise. Its result is in the [0, 1] interval.
+	density terrain.HeightGenerator
+	// It's 1 if the +Y, -1 if the -Y is the up direction of the world.
+	up float32
+	// The items are placed only above the water, if it's set.
+	hasWater   bool
+	waterLevel float32
+	footprints []Footprint
+	slopeStep  float32
+}
+
+// New returns a scatter, that places the items on the given surface. The density
+// noise is the fractal of the seeded simplex noise, the up direction is the +Y.
+func New(surface terrain.Surface, seed int64) *Scatter {
+	return &Scatter{
+		surface:   surface,
+		density:   terrain.NewFractal(terrain.NewSimplex(seed)),
+		up:        1.0,
+		slopeStep: DefaultSlopeStep,
+	}
+}
+
+// SetDensityGenerator sets the generator of the density noise.
+func (s *Scatter) SetDensityGenerator(g terrain.HeightGenerator) {
+	s.density = g
+}
+
+// SetUpNegativeY sets the up direction of the world to the -Y, if the flag is set.
+func (s *Scatter) SetUpNegativeY(f bool) {
+	s.up = 1.0
+	if f {
+		s.up = -1.0
+	}
+}
+
+// SetWater sets the water level (in world Y). The items are placed only above it.
+func (s *Scatter) SetWater(level float32) {
+	s.hasWater = true
+	s.waterLevel = level
+}
+
+// RemoveWater turns off the water check.
+func (s *Scatter) RemoveWater() {
+	s.hasWater = false
+}
+
+// AddFootprint adds an area, that is kept free of items.
+func (s *Scatter) AddFootprint(f Footprint) {
+	s.footprints = append(s.footprints, f)
+}
+
+// SetSlopeStep sets the horizontal distance of the height samples of the slope.
+func (s *Scatter) SetSlopeStep(step float32) {
+	s.slopeStep = step
+}
+
+// groundAt returns the height and the slope of the ground at the horizontal
+// position. The last return value is false, if the surface is not available there.
+func (s *Scatter) groundAt(x, z float32) (float32, float32, bool) {
+	height := func(x, z float32) (float32, bool) {
+		h, err := s.surface.HeightAtPos(mgl32.Vec3{x, 0, z})
+		return h, err == nil
+	}
+	center, ok := height(x, z)
+	if !ok {
+		return 0, 0, false
+	}
+	d := s.slopeStep
+	east, okE := height(x+d, z)
+	west, okW := height(x-d, z)
+	south, okS := height(x, z+d)
+	north, okN := height(x, z-d)
+	if !okE || !okW || !okS || !okN {
+		return 0, 0, false
+	}
+	gX := (east - west) / (2 * d)
+	gZ := (south - north) / (2 * d)
+	return center, float32(math.Sqrt(float64(gX*gX + gZ*gZ))), true
+}
+
+// free returns true if the point is not inside any footprint.
+func (s *Scatter) free(x, z float32) bool {
+	for _, f := range s.footprints {
+		if f.Contains(x, z) {
+			return false
+		}
+	}
+	return true
+}
+
+// Place returns the instances of the rule in the [min, max] area of the XZ plane.
+func (s *Scatter) Place(r *Rule, min, max mgl32.Vec2) []Instance {
+	var instances []Instance
+	if r.Density <= 0 || s.surface == nil {
+		return instances
+	}
+	cell := float32(1.0 / math.Sqrt(float64(r.Density)))
+	random := rand.New(rand.NewSource(r.Seed))
+	// The noise of the rules are moved away from each other.
+	offset := float32(r.Seed%1024) * 7.13
+	for z := min.Y(); z < max.Y(); z += cell {
+		for x := min.X(); x < max.X(); x += cell {
+			// The random values are generated for every candidate, so that the
+			// result of a cell doesn't depend on the rejection of the others.
+			jitterX, jitterZ := random.Float32(), random.Float32()
+			chance, rotation, scale := random.Float32(), random.Float32(), random.Float32()
+			pX, pZ := x+jitterX*cell, z+jitterZ*cell
+			if pX > max.X() || pZ > max.Y() || !s.free(pX, pZ) {
+				continue
+			}
+			noise := s.density.Height(pX*r.NoiseScale+offset, pZ*r.NoiseScale+offset)
+			if r.NoiseThreshold >= 1 || chance > (noise-r.NoiseThreshold)/(1-r.NoiseThreshold) {
+				continue
+			}
+			ground, slope, ok := s.groundAt(pX, pZ)
+			if !ok || slope < r.MinSlope || slope > r.MaxSlope {
+				continue
+			}
+			height := s.up * ground
+			if s.hasWater {
+				height = s.up * (ground - s.waterLevel)
+				if height <= 0 {
+					continue
+				}
+			}
+			if height < r.MinHeight || height > r.MaxHeight {
+				continue
+			}
+			instances = append(instances, s.instance(mgl32.Vec3{pX, ground, pZ}, rotation*2*math.Pi, r.MinScale+scale*(r.MaxScale-r.MinScale)))
+		}
+	}
+	return instances
+}
+
+// instance returns the instance with its transformation.
+func (s *Scatter) instance(position mgl32.Vec3, rotation, scale float32) Instance {
+	return Instance{
+		Position: position,
+		Rotation: rotation,
+		Scale:    scale,
+		Transformation: mgl32.Translate3D(position.X(), position.Y(), position.Z()).
+			Mul4(mgl32.HomogRotate3DY(rotation)).
+			Mul4(mgl32.Scale3D(scale, s.up*scale, scale)),
+	}
+}
@@ -0,0 +1,127 @@
+package scatter
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/akosgarai/playground_engine/pkg/primitives/vertex"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Shape is the geometry of an item kind. The vertices have position, normal
+// and color. The base of the shape is on the Y=0 plane, its up direction is the +Y.
+type Shape struct {
+	Vertices vertex.Vertices
+	Indices  []uint32
+}
+
+// addTriangle adds the triangle with flat normal. The normal points to the
+// side, where the vertices are counter clockwise.
+func (s *Shape) addTriangle(a, b, c, color mgl32.Vec3) {
+	normal := b.Sub(a).Cross(c.Sub(a))
+	if normal.Len() > 0 {
+		normal = normal.Normalize()
+	}
+	s.addTriangleWithNormal(a, b, c, normal, color)
+}
+func (s *Shape) addTriangleWithNormal(a, b, c, normal, color mgl32.Vec3) {
+	for _, p := range []mgl32.Vec3{a, b, c} {
+		s.Indices = append(s.Indices, uint32(len(s.Vertices)))
+		s.Vertices.Add(vertex.Vertex{
+			Position: p,
+			Normal:   normal,
+			Color:    color,
+		})
+	}
+}
+
+// ring returns the points of a horizontal circle in the given height.
+func ring(segments int, radius, height float32) []mgl32.Vec3 {
+	points := make([]mgl32.Vec3, segments)
+	for i := 0; i < segments; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(segments)
+		points[i] = mgl32.Vec3{radius * float32(math.Cos(angle)), height, radius * float32(math.Sin(angle))}
+	}
+	return points
+}
+
+// GrassTuft returns a tuft of 3 crossed blades with the given height. The
+// normals of the blades point up, so that they are lit like the ground.
+func GrassTuft(height float32, color mgl32.Vec3) *Shape {
+	s := &Shape{}
+	up := mgl32.Vec3{0, 1, 0}
+	bottom := color.Mul(0.6)
+	width := height * 0.6
+	for i := 0; i < 3; i++ {
+		angle := float64(i) * math.Pi / 3
+		side := mgl32.Vec3{float32(math.Cos(angle)), 0, float32(math.Sin(angle))}.Mul(width / 2)
+		left, right := side.Mul(-1), side
+		top := mgl32.Vec3{0, height, 0}
+		// Two narrow blades in every direction.
+		s.addTriangleWithNormal(left, left.Mul(0.2), top.Add(left.Mul(0.6)), up, bottom)
+		s.addTriangleWithNormal(right.Mul(0.2), right, top.Add(right.Mul(0.6)), up, bottom)
+		s.addTriangleWithNormal(left.Mul(0.3), right.Mul(0.3), top.Mul(1.2), up, color)
+	}
+	return s
+}
+
+// Tree returns a conifer tree with the given height. It has a hexagonal
+// trunk, and a cone crown, that begins at the third of its height.
+func Tree(height float32, trunkColor, crownColor mgl32.Vec3) *Shape {
+	s := &Shape{}
+	trunkBottom := ring(6, height*0.05, 0)
+	trunkTop := ring(6, height*0.05, height*0.4)
+	for i := range trunkBottom {
+		next := (i + 1) % len(trunkBottom)
+		s.addTriangle(trunkBottom[i], trunkTop[i], trunkBottom[next], trunkColor)
+		s.addTriangle(trunkBottom[next], trunkTop[i], trunkTop[next], trunkColor)
+	}
+	crownBottom := ring(8, height*0.3, height/3)
+	top := mgl32.Vec3{0, height, 0}
+	center := mgl32.Vec3{0, height / 3, 0}
+	for i := range crownBottom {
+		next := (i + 1) % len(crownBottom)
+		s.addTriangle(crownBottom[i], top, crownBottom[next], crownColor)
+		s.addTriangle(crownBottom[next], center, crownBottom[i], crownColor.Mul(0.5))
+	}
+	return s
+}
+
+// Rock returns a flattened irregular rock with the given size. The radius of
+// its points are randomized with the seed. The bottom of the rock is below the
+// Y=0 plane, so that it sinks into the ground on the slopes.
+func Rock(size float32, seed int64, color mgl32.Vec3) *Shape {
+	s := &Shape{}
+	random := rand.New(rand.NewSource(seed))
+	const segments, rings = 7, 4
+	radius := size / 2
+	// The points of the rings from the bottom to the top, the poles are single points.
+	var points [][]mgl32.Vec3
+	for r := 0; r <= rings; r++ {
+		polar := math.Pi * float64(r) / rings
+		y := -float32(math.Cos(polar))*radius*0.6 + radius*0.3
+		var row []mgl32.Vec3
+		for i := 0; i < segments; i++ {
+			jitter := 0.75 + 0.5*random.Float32()
+			rowRadius := float32(math.Sin(polar)) * radius * jitter
+			angle := 2 * math.Pi * float64(i) / segments
+			row = append(row, mgl32.Vec3{rowRadius * float32(math.Cos(angle)), y, rowRadius * float32(math.Sin(angle))})
+		}
+		points = append(points, row)
+	}
+	for r := 0; r < rings; r++ {
+		// The lighter top and the darker bottom.
+		shade := color.Mul(0.7 + 0.3*float32(r)/rings)
+		for i := 0; i < segments; i++ {
+			next := (i + 1) % segments
+			if r > 0 {
+				s.addTriangle(points[r][i], points[r+1][i], points[r][next], shade)
+			}
+			if r < rings-1 {
+				s.addTriangle(points[r][next], points[r+1][i], points[r+1][next], shade)
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,567 @@
+package terrain
+
+import (
+	"fmt"
+	"image"
+	"math/rand"
+	"path"
+	"runtime"
+	"time"
+
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/mesh"
+	"github.com/akosgarai/playground_engine/pkg/model"
+	"github.com/akosgarai/playground_engine/pkg/primitives/vertex"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	defaultTerrainWidth  = 10
+	defaultTerrainLength = 10
+	defaultIterations    = 1
+	defaultMinHeight     = float32(0.0)
+	defaultMaxHeight     = float32(0.0)
+	defaultSeed          = int64(0)
+)
+
+// Builder is a helper structure for generating terrain. It has the same settings
+// as the TerrainBuilder of the engine, but the height map could also come from
+// a grayscale image, a height generator or it could be set directly. Without
+// these, the heights are generated randomly like in the engine.
+type Builder struct {
+	width, length, iterations int
+	minH, maxH                float32
+	seed                      int64
+	heightMap                 HeightMap
+	heightMapImage            image.Image
+	generator                 HeightGenerator
+	minHIsDefault             bool
+	peakProbability           int
+	cliffProbability          int
+	wrapper                   interfaces.GLWrapper
+	tex                       texture.Textures
+	liquidTex                 texture.Textures
+	scale                     mgl32.Vec3
+	debugMode                 bool
+	position                  mgl32.Vec3
+	liquidAmplitude           float32
+	liquidFrequency           float32
+	liquidEta                 float32
+	liquidWaterLevel          float32
+	liquidDetailMultiplier    int
+	splat                     *Splat
+	splatMap                  *image.RGBA
+	chunkSize                 int
+	chunkRadius               int
+	chunkWorkers              int
+	erosions                  []Eroder
+}
+
+// NewBuilder returns a Builder with default settings.
+func NewBuilder() *Builder {
+	return &Builder{
+		width:                  defaultTerrainWidth,
+		length:                 defaultTerrainLength,
+		iterations:             defaultIterations,
+		minH:                   defaultMinHeight,
+		maxH:                   defaultMaxHeight,
+		seed:                   defaultSeed,
+		scale:                  mgl32.Vec3{1, 1, 1},
+		liquidDetailMultiplier: 1,
+		chunkSize:              DefaultChunkSize,
+		chunkRadius:            DefaultChunkRadius,
+		chunkWorkers:           DefaultChunkWorkers,
+	}
+}
+
+// SetWidth updates the width.
+func (t *Builder) SetWidth(width int) {
+	t.width = width
+}
+
+// SetLength updates the length.
+func (t *Builder) SetLength(length int) {
+	t.length = length
+}
+
+// SetIterations updates the iterations.
+func (t *Builder) SetIterations(iterations int) {
+	t.iterations = iterations
+}
+
+// SetMinHeight updates the minH. In case of image height map, the black pixels
+// are mapped to this value.
+func (t *Builder) SetMinHeight(height float32) {
+	t.minH = height
+}
+
+// SetMaxHeight updates the maxH. In case of image height map, the white pixels
+// are mapped to this value.
+func (t *Builder) SetMaxHeight(height float32) {
+	t.maxH = height
+}
+
+// SetSeed updates the seed.
+func (t *Builder) SetSeed(seed int64) {
+	t.seed = seed
+}
+
+// RandomSeed sets up a random seed value. It returns the generated seed.
+func (t *Builder) RandomSeed() int64 {
+	t.seed = time.Now().UnixNano()
+	return t.seed
+}
+
+// SetPeakProbability sets the peakProbability value.
+func (t *Builder) SetPeakProbability(p int) {
+	t.peakProbability = p
+}
+
+// SetCliffProbability sets the cliffProbability value.
+func (t *Builder) SetCliffProbability(p int) {
+	t.cliffProbability = p
+}
+
+// MinHeightIsDefault sets the minHIsDefault flag.
+func (t *Builder) MinHeightIsDefault(f bool) {
+	t.minHIsDefault = f
+}
+
+// SetHeightMap sets the height map of the terrain. The width and the length
+// of the terrain are set from the map, the random generation is skipped.
+func (t *Builder) SetHeightMap(h HeightMap) {
+	t.heightMap = h
+	t.heightMapImage = nil
+	t.width = h.Width()
+	t.length = h.Length()
+}
+
+// SetHeightMapImage sets the grayscale image of the height map. It's resampled
+// to the width and the length of the terrain, the black pixels are mapped to
+// the min height, the white ones to the max height.
+func (t *Builder) SetHeightMapImage(img image.Image) {
+	t.heightMapImage = img
+	t.heightMap = nil
+}
+
+// SetHeightMapFile loads the grayscale png image of the height map. It returns
+// error if the image couldn't be loaded.
+func (t *Builder) SetHeightMapFile(filename string) error {
+	img, err := LoadHeightMapImage(filename)
+	if err != nil {
+		return err
+	}
+	t.SetHeightMapImage(img)
+	return nil
+}
+
+// SetHeightGenerator sets the generator of the heights. The generator is sampled
+// in the grid points, its values are mapped to the min and max heights. The
+// iterations, the peak and the cliff probabilities are not used.
+func (t *Builder) SetHeightGenerator(g HeightGenerator) {
+	t.generator = g
+}
+
+// SetChunkSize sets the number of the tiles in the side of a chunk of the
+// chunked terrain.
+func (t *Builder) SetChunkSize(size int) {
+	t.chunkSize = size
+}
+
+// SetChunkRadius sets the radius of the chunked terrain. The chunks within
+// this distance (in chunks) from the focus are loaded.
+func (t *Builder) SetChunkRadius(radius int) {
+	t.chunkRadius = radius
+}
+
+// SetChunkWorkers sets the number of the goroutines, that generate the chunks.
+func (t *Builder) SetChunkWorkers(workers int) {
+	t.chunkWorkers = workers
+}
+
+// AddErosion adds an erosion pass. The passes are applied to the height map
+// in the order of the addition. They are not applied to the chunked terrain,
+// because the chunks are eroded independently, that breaks the borders.
+func (t *Builder) AddErosion(e Eroder) {
+	t.erosions = append(t.erosions, e)
+}
+
+// SetGlWrapper sets the wrapper.
+func (t *Builder) SetGlWrapper(w interfaces.GLWrapper) {
+	t.wrapper = w
+}
+
+// SetScale sets the scale.
+func (t *Builder) SetScale(s mgl32.Vec3) {
+	t.scale = s
+}
+
+// SetPosition sets the position.
+func (t *Builder) SetPosition(p mgl32.Vec3) {
+	t.position = p
+}
+
+// SetDebugMode updates the debug flag
+func (t *Builder) SetDebugMode(v bool) {
+	t.debugMode = v
+}
+
+// SetLiquidEta sets the liquidEta.
+func (t *Builder) SetLiquidEta(e float32) {
+	t.liquidEta = e
+}
+
+// SetLiquidAmplitude sets the liquidAmplitude.
+func (t *Builder) SetLiquidAmplitude(a float32) {
+	t.liquidAmplitude = a
+}
+
+// SetLiquidFrequency sets the liquidFrequency.
+func (t *Builder) SetLiquidFrequency(f float32) {
+	t.liquidFrequency = f
+}
+
+// SetLiquidWaterLevel sets the liquidWaterLevel.
+func (t *Builder) SetLiquidWaterLevel(f float32) {
+	t.liquidWaterLevel = f
+}
+
+// SetLiquidDetailMultiplier sets the liquidDetailMultiplier.
+func (t *Builder) SetLiquidDetailMultiplier(f int) {
+	t.liquidDetailMultiplier = f
+}
+
+// SurfaceTextureGrass sets the surface texture to grass. The texture is loaded
+// from the assets directory of the caller.
+func (t *Builder) SurfaceTextureGrass() {
+	_, filename, _, _ := runtime.Caller(1)
+	fileDir := path.Dir(filename)
+	t.tex.AddTexture(fileDir+"/assets/grass.jpg", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.diffuse", t.wrapper)
+	t.tex.AddTexture(fileDir+"/assets/grass.jpg", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.specular", t.wrapper)
+}
+
+// SurfaceTextureSplat sets the surface texture to the sand, grass, rock and snow
+// layers, that are blended with the splat material. The 'sand.jpg', 'grass.jpg',
+// 'rock.jpg' and 'snow.jpg' textures are loaded from the assets directory of the
+// caller. The terrain has to be drawn with the splat shader.
+func (t *Builder) SurfaceTextureSplat() {
+	_, filename, _, _ := runtime.Caller(1)
+	t.addSplatTextures(path.Dir(filename) + "/assets")
+	if t.splat == nil {
+		t.splat = NewSplat()
+	}
+}
+
+// SetSplat sets the parameters of the splat material.
+func (t *Builder) SetSplat(s *Splat) {
+	t.splat = s
+}
+
+// SetSplatMapImage sets the splat map. Its channels are used as the weights
+// of the layers instead of the height and slope based blending. The image
+// covers the whole terrain.
+func (t *Builder) SetSplatMapImage(img *image.RGBA) {
+	t.splatMap = img
+}
+
+// SetSplatMapFile loads the png image of the splat map. It returns error if
+// the image couldn't be loaded.
+func (t *Builder) SetSplatMapFile(filename string) error {
+	img, err := LoadSplatMapImage(filename)
+	if err != nil {
+		return err
+	}
+	t.SetSplatMapImage(img)
+	return nil
+}
+
+// LiquidTextureWater sets the liquid texture to water. The texture is loaded
+// from the assets directory of the caller.
+func (t *Builder) LiquidTextureWater() {
+	_, filename, _, _ := runtime.Caller(1)
+	fileDir := path.Dir(filename)
+	t.liquidTex.AddTexture(fileDir+"/assets/water.png", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.diffuse", t.wrapper)
+	t.liquidTex.AddTexture(fileDir+"/assets/water.png", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "material.specular", t.wrapper)
+}
+
+// buildHeightMap returns the height map of the terrain. The directly set map is
+// used as it is, the image is resampled, the generator is sampled, otherwise the
+// heights are generated randomly. Then the erosion passes are applied.
+func (t *Builder) buildHeightMap() HeightMap {
+	var heightMap HeightMap
+	if t.heightMap != nil {
+		heightMap = t.heightMap
+	} else if t.heightMapImage != nil {
+		heightMap = HeightMapFromImage(t.heightMapImage, t.width, t.length, t.minH, t.maxH)
+	} else if t.generator != nil {
+		heightMap = t.sampleGenerator()
+	} else {
+		heightMap = t.generateHeightMap()
+	}
+	if len(t.erosions) > 0 {
+		heightMap = t.erode(heightMap)
+	}
+	return heightMap
+}
+
+// erode returns the eroded copy of the height map. The erosion passes handle
+// the bigger heights as the higher ones, so that the map is inverted during the
+// erosion, if the max height is less than the min height (the -Y is the up).
+func (t *Builder) erode(heightMap HeightMap) HeightMap {
+	sign := float32(1.0)
+	if t.maxH < t.minH {
+		sign = -1.0
+	}
+	eroded := NewHeightMap(heightMap.Width(), heightMap.Length(), 0)
+	for l := range heightMap {
+		for w := range heightMap[l] {
+			eroded[l][w] = sign * heightMap[l][w]
+		}
+	}
+	for _, e := range t.erosions {
+		e.Erode(eroded)
+	}
+	for l := range eroded {
+		for w := range eroded[l] {
+			eroded[l][w] *= sign
+		}
+	}
+	return eroded
+}
+
+// sampleGenerator returns the height map from the generator. The grid points
+// are sampled in the coordinate system of the mesh.
+func (t *Builder) sampleGenerator() HeightMap {
+	h := NewHeightMap(t.width, t.length, t.minH)
+	for l := 0; l <= t.length; l++ {
+		for w := 0; w <= t.width; w++ {
+			x := -float32(t.width)/2.0 + float32(w)
+			z := -float32(t.length)/2.0 + float32(l)
+			h[l][w] = t.minH + t.generator.Height(x, z)*(t.maxH-t.minH)
+		}
+	}
+	return h
+}
+
+// generateHeightMap returns the random height map. It's the same algorithm as
+// the TerrainBuilder of the engine uses, so that the same seed gives the same map.
+func (t *Builder) generateHeightMap() HeightMap {
+	defaultHeight := float32(0.0)
+	if t.minHIsDefault {
+		defaultHeight = t.minH
+	}
+	h := NewHeightMap(t.width, t.length, defaultHeight)
+	iterationStep := (t.maxH - t.minH) / float32(t.iterations)
+	if t.debugMode {
+		fmt.Printf("Setup random seed to '%d'.\n", t.seed)
+	}
+	// The local source gives the same sequence as the seeded global source
+	// of the engine, without changing the global state.
+	r := rand.New(rand.NewSource(t.seed))
+	for i := 0; i < t.iterations; i++ {
+		height := t.minH + float32(i)*iterationStep
+		for l := 0; l <= t.length; l++ {
+			for w := 0; w <= t.width; w++ {
+				if h[l][w] != defaultHeight {
+					continue
+				}
+				random := r.Intn(100)
+				if t.adjacentElevation(r, h, w, l, height-iterationStep) || random < t.peakProbability {
+					h[l][w] = height
+				}
+			}
+		}
+	}
+	return h
+}
+func (t *Builder) adjacentElevation(r *rand.Rand, h HeightMap, cW, cL int, elevation float32) bool {
+	for l := max(0, cL-1); l <= min(t.length-1, cL+1); l++ {
+		for w := max(0, cW-1); w <= min(t.width-1, cW+1); w++ {
+			if h[l][w] == elevation {
+				return r.Intn(100) > t.cliffProbability
+			}
+		}
+	}
+	return false
+}
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// vertices returns the vertices of the height map. The texture is repeated
+// textureWidth * textureHeight times on the surface.
+func vertices(heightMap HeightMap, textureWidth, textureHeight int) vertex.Vertices {
+	width, length := heightMap.Width(), heightMap.Length()
+	textureStepX := float32(1.0) / float32(textureWidth)
+	textureStepY := float32(1.0) / float32(textureHeight)
+	var vertices vertex.Vertices
+	for l := 0; l <= length; l++ {
+		for w := 0; w <= width; w++ {
+			textureModX := w % (textureWidth + 1)
+			textureModY := l % (textureHeight + 1)
+			vertices = append(vertices, vertex.Vertex{
+				Position: mgl32.Vec3{
+					-float32(width)/2.0 + float32(w),
+					heightMap[l][w],
+					-float32(length)/2.0 + float32(l)},
+				Normal:    heightMap.Normal(w, l),
+				TexCoords: mgl32.Vec2{float32(textureModX) * textureStepX, float32(textureModY) * textureStepY},
+			})
+		}
+	}
+	return vertices
+}
+
+// indices returns the indices of the triangles of the width * length grid.
+func indices(width, length int) []uint32 {
+	var indices []uint32
+	for l := 0; l < length; l++ {
+		for w := 0; w < width; w++ {
+			i0 := uint32(l*(width+1) + w)
+			i1 := uint32(1) + i0
+			i2 := uint32(width+1) + i0
+			i3 := uint32(1) + i2
+			indices = append(indices, i0, i1, i2, i2, i1, i3)
+		}
+	}
+	return indices
+}
+
+// Build returns a Terrain
+func (t *Builder) Build() *Terrain {
+	return t.buildTerrain(t.buildHeightMap())
+}
+
+// BuildWithLiquid returns a Terrain and a Liquid that is generated for the terrain.
+func (t *Builder) BuildWithLiquid() (*Terrain, *Liquid) {
+	heightMap := t.buildHeightMap()
+	return t.buildTerrain(heightMap), t.buildLiquid(heightMap)
+}
+func (t *Builder) buildTerrain(heightMap HeightMap) *Terrain {
+	if t.debugMode {
+		fmt.Printf("terrain.Builder.heightMap after build:\n'%v'\n", heightMap)
+	}
+	tex := t.tex
+	if t.splat != nil && t.splatMap != nil {
+		// The map is added to a copy, so that the textures of the builder are not modified.
+		tex = append(texture.Textures{}, t.tex...)
+		tex.AddTextureRGBA("splat-map", t.splatMap, glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "splat.map", t.wrapper)
+	}
+	terrainVertices := vertices(heightMap, 1, 1)
+	terrainMesh := newTerrainMesh(terrainVertices, terrainVertices.Get(vertex.POSITION_NORMAL_TEXCOORD), indices(heightMap.Width(), heightMap.Length()), tex, t.wrapper)
+	terrainMesh.SetScale(t.scale)
+	terrainMesh.SetPosition(t.position)
+	m := model.New()
+	m.AddMesh(terrainMesh)
+	if t.splat != nil {
+		// The heights are normalized with the range of the height map, if the
+		// min and max heights are the same.
+		minH, maxH := t.minH, t.maxH
+		if minH == maxH {
+			minH, maxH = heightMap.MinMax()
+		}
+		t.setSplatUniforms(m, minH, maxH, heightMap.Width(), heightMap.Length(), t.splatMap != nil)
+	}
+	return &Terrain{BaseModel: m, heightMap: heightMap, mesh: terrainMesh}
+}
+
+// setSplatUniforms sets the splat material uniforms of the terrain model.
+func (t *Builder) setSplatUniforms(m *model.BaseModel, minH, maxH float32, width, length int, useMap bool) {
+	t.splat.SetUniforms(m)
+	m.SetUniformFloat("splat.minHeight", minH)
+	m.SetUniformFloat("splat.maxHeight", maxH)
+	m.SetUniformFloat("splat.width", float32(width))
+	m.SetUniformFloat("splat.length", float32(length))
+	mapFlag := float32(0.0)
+	if useMap {
+		mapFlag = 1.0
+	}
+	m.SetUniformFloat("splat.useMap", mapFlag)
+}
+
+// BuildChunked returns an endless ChunkedTerrain, that is generated from the
+// height generator around its focus point. If the generator is not set, the
+// fractal of the simplex noise of the seed is used. The width, the length, the
+// height map and the splat map of the builder are not used.
+func (t *Builder) BuildChunked() *ChunkedTerrain {
+	generator := t.generator
+	if generator == nil {
+		generator = NewFractal(NewSimplex(t.seed))
+	}
+	m := model.New()
+	if t.splat != nil {
+		t.setSplatUniforms(m, t.minH, t.maxH, t.chunkSize, t.chunkSize, false)
+	}
+	c := &ChunkedTerrain{
+		BaseModel: m,
+		generator: generator,
+		size:      t.chunkSize,
+		radius:    t.chunkRadius,
+		minH:      t.minH,
+		maxH:      t.maxH,
+		scale:     t.scale,
+		position:  t.position,
+		tex:       t.tex,
+		wrapper:   t.wrapper,
+		indices:   indices(t.chunkSize, t.chunkSize),
+		chunks:    make(map[ChunkKey]*Chunk),
+		pending:   make(map[ChunkKey]bool),
+	}
+	c.start(t.chunkWorkers)
+	return c
+}
+
+// buildLiquid returns the liquid surface. Its grid is denser than the terrain
+// grid, it has scale * liquidDetailMultiplier (at least 1) points in the unit
+// length of the height map. The vertex heights are the depth of the terrain
+// under the water level.
+func (t *Builder) buildLiquid(heightMap HeightMap) *Liquid {
+	detailX := t.scale.X() * float32(t.liquidDetailMultiplier)
+	if detailX < 1 {
+		detailX = 1
+	}
+	detailZ := t.scale.Z() * float32(t.liquidDetailMultiplier)
+	if detailZ < 1 {
+		detailZ = 1
+	}
+	waterWidth := int(float32(heightMap.Width())*detailX + 0.5)
+	waterLength := int(float32(heightMap.Length())*detailZ + 0.5)
+	// The distance of the grid points in the unit of the height map.
+	stepX := float32(heightMap.Width()) / float32(waterWidth)
+	stepZ := float32(heightMap.Length()) / float32(waterLength)
+	waterHeightMap := NewHeightMap(waterWidth, waterLength, 0)
+	for l := 0; l <= waterLength; l++ {
+		for w := 0; w <= waterWidth; w++ {
+			waterHeightMap[l][w] = t.liquidWaterLevel - heightMap.Sample(float32(w)*stepX, float32(l)*stepZ)
+		}
+	}
+	if t.debugMode {
+		fmt.Printf("terrain.Builder.buildLiquid.heightMap after init:\n'%v'\n", waterHeightMap)
+	}
+	liquidMesh := mesh.NewTexturedMesh(vertices(waterHeightMap, waterWidth, waterLength), indices(waterWidth, waterLength), t.liquidTex, t.wrapper)
+	// The liquid covers the same area as the terrain mesh.
+	liquidMesh.SetScale(mgl32.Vec3{stepX * t.scale.X(), 1.0, stepZ * t.scale.Z()})
+	liquidMesh.SetPosition(mgl32.Vec3{t.position.X(), t.position.Y() + t.liquidWaterLevel, t.position.Z()})
+
+	m := model.New()
+	m.AddMesh(liquidMesh)
+	m.SetTransparent(true)
+	m.SetUniformFloat("Eta", t.liquidEta)
+	m.SetUniformFloat("amplitude", t.liquidAmplitude)
+	m.SetUniformFloat("frequency", t.liquidFrequency)
+	m.SetUniformFloat("waterLevel", t.liquidWaterLevel)
+
+	return &Liquid{BaseModel: m, heightMap: waterHeightMap}
+}
@@ -0,0 +1,354 @@
+package terrain
+
+import (
+	"math"
+
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/model"
+	"github.com/akosgarai/playground_engine/pkg/primitives/vertex"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+
+	"github.com/akosgarai/coldet"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// The default settings of the chunked terrain.
+	DefaultChunkSize    = 32
+	DefaultChunkRadius  = 2
+	DefaultChunkWorkers = 2
+	// The maximal number of the chunks, that are uploaded to the gpu in one update.
+	chunkUploadsPerUpdate = 2
+	// The number of the float values of a vertex in the vertex buffer.
+	// position (3), normal (3), tex coords (2).
+	chunkVertexSize = 8
+)
+
+// ChunkKey is the grid coordinate of a chunk. The chunk covers the
+// [X*size, (X+1)*size] x [Z*size, (Z+1)*size] tiles.
+type ChunkKey struct {
+	X, Z int
+}
+
+// distance returns the chebyshev distance of the chunks.
+func (k ChunkKey) distance(o ChunkKey) int {
+	dX, dZ := k.X-o.X, k.Z-o.Z
+	if dX < 0 {
+		dX = -dX
+	}
+	if dZ < 0 {
+		dZ = -dZ
+	}
+	if dX > dZ {
+		return dX
+	}
+	return dZ
+}
+
+// chunkData is the cpu side result of the chunk generation.
+type chunkData struct {
+	key       ChunkKey
+	heightMap HeightMap
+	vertices  vertex.Vertices
+	buffer    []float32
+}
+
+// ChunkMesh is the textured mesh of a chunk. Its buffers are deleted, when the
+// chunk is unloaded.
+type ChunkMesh struct {
+	*TerrainMesh
+}
+
+func newChunkMesh(data *chunkData, i []uint32, t texture.Textures, wrapper interfaces.GLWrapper) *ChunkMesh {
+	return &ChunkMesh{
+		TerrainMesh: newTerrainMesh(data.vertices, data.buffer, i, t, wrapper),
+	}
+}
+
+// Chunk is a loaded part of the chunked terrain.
+type Chunk struct {
+	Key       ChunkKey
+	Mesh      *ChunkMesh
+	heightMap HeightMap
+}
+
+// GetHeightMap returns the height map of the chunk.
+func (c *Chunk) GetHeightMap() HeightMap {
+	return c.heightMap
+}
+
+// ChunkedTerrain is an endless terrain, that is split into square chunks. The
+// chunks are generated on demand around the focus point from a seeded height
+// generator. The heights and the vertices are calculated on worker goroutines,
+// the gpu upload happens in the Update function on the main thread. The chunks
+// that are farther than radius+1 chunks from the focus are unloaded, the buffers
+// of their meshes are deleted. The neighbouring chunks sample the
+// generator in the same points on their borders, and the normals are calculated
+// from a one point wide border around the chunk, so that the borders are seamless.
+type ChunkedTerrain struct {
+	*model.BaseModel
+	generator  HeightGenerator
+	size       int
+	radius     int
+	minH, maxH float32
+	scale      mgl32.Vec3
+	position   mgl32.Vec3
+	tex        texture.Textures
+	wrapper    interfaces.GLWrapper
+	indices    []uint32
+
+	chunks  map[ChunkKey]*Chunk
+	pending map[ChunkKey]bool
+	focus   ChunkKey
+	jobs    chan ChunkKey
+	results chan *chunkData
+	done    chan struct{}
+}
+
+// start launches the workers of the chunk generation.
+func (c *ChunkedTerrain) start(workers int) {
+	capacity := (2*c.radius + 3) * (2*c.radius + 3)
+	c.jobs = make(chan ChunkKey, capacity)
+	c.results = make(chan *chunkData, capacity)
+	c.done = make(chan struct{})
+	for i := 0; i < workers; i++ {
+		go c.work()
+	}
+}
+func (c *ChunkedTerrain) work() {
+	for {
+		select {
+		case key := <-c.jobs:
+			data := c.generate(key)
+			select {
+			case c.results <- data:
+			case <-c.done:
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Close stops the workers of the chunk generation and deletes the buffers of
+// the loaded chunks. It has to be called on the thread of the gl context. The
+// terrain can't be drawn after it.
+func (c *ChunkedTerrain) Close() {
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+	for key, chunk := range c.chunks {
+		chunk.Mesh.Delete()
+		delete(c.chunks, key)
+	}
+	c.Clear()
+}
+
+// generate returns the heights and the vertices of the chunk. The generator is
+// sampled in the tile coordinates, so that the common points of the neighbouring
+// chunks have the same height.
+func (c *ChunkedTerrain) generate(key ChunkKey) *chunkData {
+	n := c.size
+	// The map has one point wide border, so that the normals of the border points
+	// are calculated from the same heights in the neighbouring chunks.
+	border := NewHeightMap(n+2, n+2, 0)
+	for l := 0; l <= n+2; l++ {
+		for w := 0; w <= n+2; w++ {
+			x := float32(key.X*n + w - 1)
+			z := float32(key.Z*n + l - 1)
+			border[l][w] = c.minH + c.generator.Height(x, z)*(c.maxH-c.minH)
+		}
+	}
+	heightMap := NewHeightMap(n, n, 0)
+	var vertices vertex.Vertices
+	for l := 0; l <= n; l++ {
+		for w := 0; w <= n; w++ {
+			heightMap[l][w] = border[l+1][w+1]
+			vertices = append(vertices, vertex.Vertex{
+				Position: mgl32.Vec3{
+					-float32(n)/2.0 + float32(w),
+					heightMap[l][w],
+					-float32(n)/2.0 + float32(l)},
+				Normal: border.Normal(w+1, l+1),
+				// The texture is mirrored in every tile, like in the builder. The parity
+				// comes from the tile coordinates, so that it's continuous between the chunks.
+				TexCoords: mgl32.Vec2{float32(parity(key.X*n + w)), float32(parity(key.Z*n + l))},
+			})
+		}
+	}
+	return &chunkData{
+		key:       key,
+		heightMap: heightMap,
+		vertices:  vertices,
+		buffer:    vertices.Get(vertex.POSITION_NORMAL_TEXCOORD),
+	}
+}
+func parity(i int) int {
+	return ((i % 2) + 2) % 2
+}
+
+// chunkPosition returns the world position of the center of the chunk.
+func (c *ChunkedTerrain) chunkPosition(key ChunkKey) mgl32.Vec3 {
+	half := float32(c.size) / 2.0
+	return mgl32.Vec3{
+		c.position.X() + (float32(key.X*c.size)+half)*c.scale.X(),
+		c.position.Y(),
+		c.position.Z() + (float32(key.Z*c.size)+half)*c.scale.Z(),
+	}
+}
+
+// toTile returns the tile coordinates of the world position.
+func (c *ChunkedTerrain) toTile(pos mgl32.Vec3) (float32, float32) {
+	return (pos.X() - c.position.X()) / c.scale.X(), (pos.Z() - c.position.Z()) / c.scale.Z()
+}
+
+// GetKeyAt returns the key of the chunk, that contains the world position.
+func (c *ChunkedTerrain) GetKeyAt(pos mgl32.Vec3) ChunkKey {
+	x, z := c.toTile(pos)
+	return ChunkKey{
+		X: int(math.Floor(float64(x / float32(c.size)))),
+		Z: int(math.Floor(float64(z / float32(c.size)))),
+	}
+}
+
+// GetChunk returns the loaded chunk with the given key. The second return
+// value is false, if the chunk is not loaded.
+func (c *ChunkedTerrain) GetChunk(key ChunkKey) (*Chunk, bool) {
+	chunk, ok := c.chunks[key]
+	return chunk, ok
+}
+
+// GetChunkCount returns the number of the loaded chunks.
+func (c *ChunkedTerrain) GetChunkCount() int {
+	return len(c.chunks)
+}
+
+// GetChunkSize returns the number of the tiles in the side of a chunk.
+func (c *ChunkedTerrain) GetChunkSize() int {
+	return c.size
+}
+
+// GetRadius returns the load radius in chunks.
+func (c *ChunkedTerrain) GetRadius() int {
+	return c.radius
+}
+
+// SetTextures sets the textures of the loaded and the future chunks.
+func (c *ChunkedTerrain) SetTextures(t texture.Textures) {
+	c.tex = t
+	for _, chunk := range c.chunks {
+		chunk.Mesh.Textures = t
+	}
+}
+
+// SetFocus sets the focus point of the streaming, eg. the camera position. The
+// chunks that are too far from the focus are unloaded, the missing chunks within
+// the radius are requested from the workers, the closest ones first.
+func (c *ChunkedTerrain) SetFocus(pos mgl32.Vec3) {
+	c.focus = c.GetKeyAt(pos)
+	unloaded := false
+	for key, chunk := range c.chunks {
+		if key.distance(c.focus) > c.radius+1 {
+			delete(c.chunks, key)
+			chunk.Mesh.Delete()
+			unloaded = true
+		}
+	}
+	if unloaded {
+		c.Clear()
+		for _, chunk := range c.chunks {
+			c.AddMesh(chunk.Mesh)
+		}
+	}
+	for d := 0; d <= c.radius; d++ {
+		for x := c.focus.X - d; x <= c.focus.X+d; x++ {
+			for z := c.focus.Z - d; z <= c.focus.Z+d; z++ {
+				key := ChunkKey{X: x, Z: z}
+				if key.distance(c.focus) != d {
+					continue
+				}
+				if _, ok := c.chunks[key]; ok || c.pending[key] {
+					continue
+				}
+				select {
+				case c.jobs <- key:
+					c.pending[key] = true
+				default:
+					// The queue is full, the rest is requested later.
+					return
+				}
+			}
+		}
+	}
+}
+
+// Update function uploads the generated chunks to the gpu. The chunks that
+// are not needed anymore are dropped.
+func (c *ChunkedTerrain) Update(dt float64) {
+	// The results after the Close are dropped.
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+	for i := 0; i < chunkUploadsPerUpdate; i++ {
+		select {
+		case data := <-c.results:
+			delete(c.pending, data.key)
+			if data.key.distance(c.focus) > c.radius+1 {
+				continue
+			}
+			c.load(data)
+		default:
+			return
+		}
+	}
+}
+
+// load creates the chunk and its mesh from the generated data.
+func (c *ChunkedTerrain) load(data *chunkData) {
+	m := newChunkMesh(data, c.indices, c.tex, c.wrapper)
+	m.SetScale(c.scale)
+	m.SetPosition(c.chunkPosition(data.key))
+	c.chunks[data.key] = &Chunk{Key: data.key, Mesh: m, heightMap: data.heightMap}
+	c.AddMesh(m)
+}
+
+// HeightAtPos returns the height of the surface at the given world position, and nil.
+// If the chunk of the position is not loaded, the height is calculated from the
+// generator, that gives the same result as the chunk after its load.
+func (c *ChunkedTerrain) HeightAtPos(pos mgl32.Vec3) (float32, error) {
+	key := c.GetKeyAt(pos)
+	x, z := c.toTile(pos)
+	var height float32
+	if chunk, ok := c.chunks[key]; ok {
+		height = chunk.heightMap.Sample(x-float32(key.X*c.size), z-float32(key.Z*c.size))
+	} else {
+		height = c.generatedHeight(x, z)
+	}
+	return c.position.Y() + height*c.scale.Y(), nil
+}
+
+// generatedHeight returns the bilinear interpolated height of the generator
+// between the grid points around the tile coordinates.
+func (c *ChunkedTerrain) generatedHeight(x, z float32) float32 {
+	x0, z0 := float32(math.Floor(float64(x))), float32(math.Floor(float64(z)))
+	wX, wZ := x-x0, z-z0
+	sample := func(x, z float32) float32 {
+		return c.minH + c.generator.Height(x, z)*(c.maxH-c.minH)
+	}
+	return (sample(x0, z0)*(1-wX)+sample(x0+1, z0)*wX)*(1-wZ) + (sample(x0, z0+1)*(1-wX)+sample(x0+1, z0+1)*wX)*wZ
+}
+
+// CollideTestWithSphere is the collision detection function for chunked terrain vs sphere.
+func (c *ChunkedTerrain) CollideTestWithSphere(boundingSphere *coldet.Sphere) bool {
+	height, err := c.HeightAtPos(mgl32.Vec3{boundingSphere.X(), boundingSphere.Y(), boundingSphere.Z()})
+	if err != nil {
+		return false
+	}
+	boundingPoint := coldet.NewBoundingPoint([3]float32{boundingSphere.X(), height, boundingSphere.Z()})
+	return coldet.CheckPointInSphere(*boundingPoint, *boundingSphere)
+}
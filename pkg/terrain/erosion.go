@@ -0,0 +1,247 @@
+package terrain
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	// The default parameters of the hydraulic erosion.
+	DefaultDropletLifetime  = 30
+	DefaultInertia          = float32(0.05)
+	DefaultSedimentCapacity = float32(4.0)
+	DefaultMinCapacity      = float32(0.01)
+	DefaultErodeSpeed       = float32(0.3)
+	DefaultDepositSpeed     = float32(0.3)
+	DefaultEvaporateSpeed   = float32(0.01)
+	DefaultGravity          = float32(4.0)
+	DefaultThermalStrength  = float32(0.5)
+	// The initial speed and water of the droplets.
+	dropletSpeed = float32(1.0)
+	dropletWater = float32(1.0)
+)
+
+// Eroder is a post-processing pass of the height map. The bigger heights are
+// the higher points of the map. The passes are deterministic, the same input
+// and parameters give the same result.
+type Eroder interface {
+	Erode(h HeightMap)
+}
+
+// HydraulicErosion is the particle based hydraulic erosion. The droplets are
+// dropped to random positions of the map, they flow down on the surface, they
+// erode the soil, when they are fast and carry less sediment than their
+// capacity, and they deposit it, when they slow down or flow up.
+type HydraulicErosion struct {
+	// The number of the droplets. It's the amount of the rain.
+	Droplets int
+	// The seed of the random positions of the droplets.
+	Seed int64
+	// The maximal number of the steps of a droplet.
+	Lifetime int
+	// The ratio of the previous direction in the new direction of a droplet.
+	Inertia float32
+	// The sediment capacity multiplier and its minimal value.
+	SedimentCapacity float32
+	MinCapacity      float32
+	// The ratio of the free capacity, that is eroded in a step.
+	ErodeSpeed float32
+	// The ratio of the surplus sediment, that is deposited in a step.
+	DepositSpeed float32
+	// The ratio of the water, that evaporates in a step.
+	EvaporateSpeed float32
+	Gravity        float32
+}
+
+// NewHydraulicErosion returns a hydraulic erosion with the default parameters.
+func NewHydraulicErosion(droplets int, seed int64) *HydraulicErosion {
+	return &HydraulicErosion{
+		Droplets:         droplets,
+		Seed:             seed,
+		Lifetime:         DefaultDropletLifetime,
+		Inertia:          DefaultInertia,
+		SedimentCapacity: DefaultSedimentCapacity,
+		MinCapacity:      DefaultMinCapacity,
+		ErodeSpeed:       DefaultErodeSpeed,
+		DepositSpeed:     DefaultDepositSpeed,
+		EvaporateSpeed:   DefaultEvaporateSpeed,
+		Gravity:          DefaultGravity,
+	}
+}
+
+// heightAndGradient returns the bilinear interpolated height and its gradient
+// at the given position, that has to be inside the map.
+func heightAndGradient(h HeightMap, x, z float32) (float32, float32, float32) {
+	w, l := int(x), int(z)
+	u, v := x-float32(w), z-float32(l)
+	nw, ne := h[l][w], h[l][w+1]
+	sw, se := h[l+1][w], h[l+1][w+1]
+	gX := (ne-nw)*(1-v) + (se-sw)*v
+	gZ := (sw-nw)*(1-u) + (se-ne)*u
+	height := (nw*(1-u)+ne*u)*(1-v) + (sw*(1-u)+se*u)*v
+	return height, gX, gZ
+}
+
+// addToCell adds the amount to the corners of the cell of the position, with
+// the bilinear weights of the position.
+func addToCell(h HeightMap, x, z, amount float32) {
+	w, l := int(x), int(z)
+	u, v := x-float32(w), z-float32(l)
+	h[l][w] += amount * (1 - u) * (1 - v)
+	h[l][w+1] += amount * u * (1 - v)
+	h[l+1][w] += amount * (1 - u) * v
+	h[l+1][w+1] += amount * u * v
+}
+
+// Erode simulates the droplets one after the other.
+func (e *HydraulicErosion) Erode(h HeightMap) {
+	width, length := h.Width(), h.Length()
+	if width < 1 || length < 1 {
+		return
+	}
+	r := rand.New(rand.NewSource(e.Seed))
+	for i := 0; i < e.Droplets; i++ {
+		x := r.Float32() * float32(width)
+		z := r.Float32() * float32(length)
+		dirX, dirZ := float32(0), float32(0)
+		speed, water, sediment := dropletSpeed, dropletWater, float32(0)
+		inside := true
+		for step := 0; step < e.Lifetime; step++ {
+			height, gX, gZ := heightAndGradient(h, x, z)
+			// The droplet flows down, but it keeps a part of its direction.
+			dirX = dirX*e.Inertia - gX*(1-e.Inertia)
+			dirZ = dirZ*e.Inertia - gZ*(1-e.Inertia)
+			dirLength := float32(math.Sqrt(float64(dirX*dirX + dirZ*dirZ)))
+			if dirLength == 0 {
+				// It stopped on a flat surface.
+				break
+			}
+			dirX, dirZ = dirX/dirLength, dirZ/dirLength
+			newX, newZ := x+dirX, z+dirZ
+			if newX < 0 || newX >= float32(width) || newZ < 0 || newZ >= float32(length) {
+				// It flew out of the map, the sediment is lost.
+				inside = false
+				break
+			}
+			newHeight, _, _ := heightAndGradient(h, newX, newZ)
+			deltaHeight := newHeight - height
+			capacity := -deltaHeight * speed * water * e.SedimentCapacity
+			if capacity < e.MinCapacity {
+				capacity = e.MinCapacity
+			}
+			if deltaHeight > 0 || sediment > capacity {
+				// Flowing up, it fills the pit behind itself, otherwise
+				// it deposits a part of the surplus sediment.
+				amount := (sediment - capacity) * e.DepositSpeed
+				if deltaHeight > 0 {
+					amount = sediment
+					if deltaHeight < amount {
+						amount = deltaHeight
+					}
+				}
+				sediment -= amount
+				addToCell(h, x, z, amount)
+			} else {
+				// It doesn't erode more than the height difference, so that it doesn't dig holes.
+				amount := (capacity - sediment) * e.ErodeSpeed
+				if -deltaHeight < amount {
+					amount = -deltaHeight
+				}
+				sediment += amount
+				addToCell(h, x, z, -amount)
+			}
+			speedSquare := speed*speed - deltaHeight*e.Gravity
+			if speedSquare < 0 {
+				speedSquare = 0
+			}
+			speed = float32(math.Sqrt(float64(speedSquare)))
+			water *= 1 - e.EvaporateSpeed
+			x, z = newX, newZ
+		}
+		if inside {
+			// The evaporated droplet leaves its sediment in its last position.
+			addToCell(h, x, z, sediment)
+		}
+	}
+}
+
+// ThermalErosion is the thermal weathering of the steep slopes. The material
+// of the points, that are higher than their neighbours with more than the talus
+// slope, slides down to the lower neighbours.
+type ThermalErosion struct {
+	Iterations int
+	// The maximal stable height difference between the neighbouring points
+	// in one tile distance. It's the tangent of the talus angle.
+	Talus float32
+	// The ratio of the unstable material, that slides down in an iteration.
+	Strength float32
+}
+
+// NewThermalErosion returns a thermal erosion with the default strength.
+func NewThermalErosion(iterations int, talus float32) *ThermalErosion {
+	return &ThermalErosion{
+		Iterations: iterations,
+		Talus:      talus,
+		Strength:   DefaultThermalStrength,
+	}
+}
+
+// The neighbours of a point and their distances.
+var thermalNeighbours = [8][3]int{
+	{-1, -1, 1}, {0, -1, 0}, {1, -1, 1},
+	{-1, 0, 0}, {1, 0, 0},
+	{-1, 1, 1}, {0, 1, 0}, {1, 1, 1},
+}
+
+// Erode runs the iterations. In an iteration the changes are collected first,
+// so that the result doesn't depend on the order of the points.
+func (e *ThermalErosion) Erode(h HeightMap) {
+	width, length := h.Width(), h.Length()
+	delta := NewHeightMap(width, length, 0)
+	diagonal := float32(math.Sqrt2)
+	var differences [8]float32
+	for i := 0; i < e.Iterations; i++ {
+		for l := 0; l <= length; l++ {
+			for w := 0; w <= width; w++ {
+				total, maxDifference := float32(0), float32(0)
+				for n, neighbour := range thermalNeighbours {
+					differences[n] = 0
+					nW, nL := w+neighbour[0], l+neighbour[1]
+					if nW < 0 || nW > width || nL < 0 || nL > length {
+						continue
+					}
+					talus := e.Talus
+					if neighbour[2] == 1 {
+						talus *= diagonal
+					}
+					difference := h[l][w] - h[nL][nW]
+					if difference > talus {
+						differences[n] = difference - talus
+						total += differences[n]
+						if differences[n] > maxDifference {
+							maxDifference = differences[n]
+						}
+					}
+				}
+				if total == 0 {
+					continue
+				}
+				// The half of the maximal unstable difference could move, so that
+				// the point doesn't become lower than its neighbours.
+				moved := e.Strength * maxDifference / 2
+				delta[l][w] -= moved
+				for n, neighbour := range thermalNeighbours {
+					if differences[n] > 0 {
+						delta[l+neighbour[1]][w+neighbour[0]] += moved * differences[n] / total
+					}
+				}
+			}
+		}
+		for l := 0; l <= length; l++ {
+			for w := 0; w <= width; w++ {
+				h[l][w] += delta[l][w]
+				delta[l][w] = 0
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package terrain
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testHeightMap returns a small height map with hills of the simplex noise.
+func testHeightMap() HeightMap {
+	h := NewHeightMap(32, 32, 0)
+	noise := NewSimplex(1)
+	for l := 0; l <= h.Length(); l++ {
+		for w := 0; w <= h.Width(); w++ {
+			h[l][w] = 4 * noise.Noise(float32(w)*0.15, float32(l)*0.15)
+		}
+	}
+	return h
+}
+
+// erode runs the hydraulic and the thermal passes on a new test height map.
+func erode(seed int64) HeightMap {
+	h := testHeightMap()
+	NewHydraulicErosion(500, seed).Erode(h)
+	NewThermalErosion(5, 0.5).Erode(h)
+	return h
+}
+
+func TestErosionIsDeterministic(t *testing.T) {
+	first := erode(42)
+	second := erode(42)
+	if !reflect.DeepEqual(first, second) {
+		t.Error("The erosion with the same seed has to give the same result.")
+	}
+	if reflect.DeepEqual(first, testHeightMap()) {
+		t.Error("The erosion has to modify the height map.")
+	}
+}
+
+func TestErosionDependsOnSeed(t *testing.T) {
+	if reflect.DeepEqual(erode(42), erode(43)) {
+		t.Error("The erosion with different seeds has to give different results.")
+	}
+}
@@ -0,0 +1,119 @@
+package terrain
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/akosgarai/opengl_playground/pkg/objexport"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ExportInfo is the content of the json sidecar file of the exported terrain.
+// The terrain could be reproduced from the height map image with the same
+// width, length, min and max heights, scale and position.
+type ExportInfo struct {
+	HeightMap string     `json:"heightMap"`
+	NormalMap string     `json:"normalMap"`
+	Object    string     `json:"object"`
+	Width     int        `json:"width"`
+	Length    int        `json:"length"`
+	MinHeight float32    `json:"minHeight"`
+	MaxHeight float32    `json:"maxHeight"`
+	Scale     mgl32.Vec3 `json:"scale"`
+	Position  mgl32.Vec3 `json:"position"`
+	// The settings that were used for generating the terrain.
+	Settings map[string]interface{} `json:"settings,omitempty"`
+}
+
+// HeightMapImage returns the height map as a 16 bit grayscale image. The minimal
+// height is black, the maximal is white. It also returns the min and max heights,
+// so that the map could be restored with the HeightMapFromImage function.
+func HeightMapImage(h HeightMap) (*image.Gray16, float32, float32) {
+	min, max := h.MinMax()
+	img := image.NewGray16(image.Rect(0, 0, h.Width()+1, h.Length()+1))
+	for l := 0; l <= h.Length(); l++ {
+		for w := 0; w <= h.Width(); w++ {
+			var value float32
+			if max > min {
+				value = (h[l][w] - min) / (max - min)
+			}
+			img.SetGray16(w, l, color.Gray16{Y: uint16(value*65535.0 + 0.5)})
+		}
+	}
+	return img, min, max
+}
+
+// NormalMapImage returns the normal vectors of the height map as an image. The
+// components are mapped from [-1, 1] to [0, 255]. The red channel is the X, the
+// green is the Z, the blue is the up (-Y) component of the normal vector.
+func NormalMapImage(h HeightMap) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, h.Width()+1, h.Length()+1))
+	toByte := func(f float32) uint8 {
+		return uint8((f*0.5+0.5)*255.0 + 0.5)
+	}
+	for l := 0; l <= h.Length(); l++ {
+		for w := 0; w <= h.Width(); w++ {
+			n := h.Normal(w, l)
+			img.SetNRGBA(w, l, color.NRGBA{R: toByte(n.X()), G: toByte(n.Z()), B: toByte(-n.Y()), A: 255})
+		}
+	}
+	return img
+}
+
+// savePNG writes the image to the file in png format.
+func savePNG(filename string, img image.Image) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Export writes the terrain to the given directory. The '<name>.png' is the 16 bit
+// height map, the '<name>_normal.png' is the normal map, the '<name>.obj' and the
+// '<name>.mtl' files are the wavefront export of the mesh in world space, and the
+// '<name>.json' contains the ExportInfo with the given settings. The directory
+// has to exist.
+func Export(t *Terrain, directory, name string, settings map[string]interface{}) error {
+	heightImage, min, max := HeightMapImage(t.heightMap)
+	info := ExportInfo{
+		HeightMap: name + ".png",
+		NormalMap: name + "_normal.png",
+		Object:    name + ".obj",
+		Width:     t.heightMap.Width(),
+		Length:    t.heightMap.Length(),
+		MinHeight: min,
+		MaxHeight: max,
+		Position:  t.GetTerrain().GetPosition(),
+		Settings:  settings,
+	}
+	scaleTr := t.GetTerrain().ScaleTransformation()
+	info.Scale = mgl32.Vec3{scaleTr[0], scaleTr[5], scaleTr[10]}
+	if err := savePNG(filepath.Join(directory, info.HeightMap), heightImage); err != nil {
+		return err
+	}
+	if err := savePNG(filepath.Join(directory, info.NormalMap), NormalMapImage(t.heightMap)); err != nil {
+		return err
+	}
+	exporter := objexport.New()
+	// The exporter knows the vertices of the engine's meshes.
+	exporter.AddMesh(name, t.mesh.TexturedMesh)
+	if err := exporter.Export(directory, name); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(directory, name+".json"), content, 0644)
+}
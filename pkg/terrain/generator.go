@@ -0,0 +1,139 @@
+package terrain
+
+const (
+	// The default parameters of the fractal generators.
+	DefaultOctaves    = 6
+	DefaultFrequency  = float32(0.05)
+	DefaultLacunarity = float32(2.0)
+	DefaultGain       = float32(0.5)
+)
+
+// HeightGenerator is the source of the heights of the builder. The coordinates
+// are in the coordinate system of the terrain mesh (one unit is one tile), the
+// result is in the [0, 1] interval, it's mapped to the min and max heights.
+type HeightGenerator interface {
+	Height(x, z float32) float32
+}
+
+// Fractal is the fractal brownian motion (fBm) of a noise. It sums the octaves
+// of the noise, every octave has lacunarity times bigger frequency and gain
+// times smaller amplitude than the previous one.
+type Fractal struct {
+	Noise      Noise
+	Octaves    int
+	Frequency  float32
+	Lacunarity float32
+	Gain       float32
+}
+
+// NewFractal returns a fractal generator of the noise with the default parameters.
+func NewFractal(n Noise) *Fractal {
+	return &Fractal{
+		Noise:      n,
+		Octaves:    DefaultOctaves,
+		Frequency:  DefaultFrequency,
+		Lacunarity: DefaultLacunarity,
+		Gain:       DefaultGain,
+	}
+}
+
+// octaveOffset moves the octaves away from each other, so that the zero
+// values of the noises are not in the same place.
+func octaveOffset(octave int) float32 {
+	return float32(octave) * 17.31
+}
+
+// Height returns the normalized sum of the octaves.
+func (f *Fractal) Height(x, z float32) float32 {
+	sum, norm := float32(0), float32(0)
+	amplitude, frequency := float32(1), f.Frequency
+	for i := 0; i < f.Octaves; i++ {
+		offset := octaveOffset(i)
+		sum += amplitude * f.Noise.Noise(x*frequency+offset, z*frequency+offset)
+		norm += amplitude
+		amplitude *= f.Gain
+		frequency *= f.Lacunarity
+	}
+	if norm == 0 {
+		return 0.5
+	}
+	return 0.5 + 0.5*sum/norm
+}
+
+// Ridged is the ridged multifractal of a noise. The octaves are the inverted
+// absolute values of the noise, so that the zero values are sharp ridges. Every
+// octave is weighted with the previous one, so that the valleys are smooth.
+type Ridged struct {
+	Noise      Noise
+	Octaves    int
+	Frequency  float32
+	Lacunarity float32
+	Gain       float32
+}
+
+// NewRidged returns a ridged multifractal generator of the noise with the
+// default parameters.
+func NewRidged(n Noise) *Ridged {
+	return &Ridged{
+		Noise:      n,
+		Octaves:    DefaultOctaves,
+		Frequency:  DefaultFrequency,
+		Lacunarity: DefaultLacunarity,
+		Gain:       DefaultGain,
+	}
+}
+
+// Height returns the normalized sum of the ridged octaves.
+func (r *Ridged) Height(x, z float32) float32 {
+	sum, norm := float32(0), float32(0)
+	amplitude, frequency, weight := float32(1), r.Frequency, float32(1)
+	for i := 0; i < r.Octaves; i++ {
+		offset := octaveOffset(i)
+		signal := r.Noise.Noise(x*frequency+offset, z*frequency+offset)
+		if signal < 0 {
+			signal = -signal
+		}
+		signal = (1 - signal) * (1 - signal) * weight
+		// The next octave is weighted with this one.
+		weight = signal * 2
+		if weight > 1 {
+			weight = 1
+		}
+		sum += amplitude * signal
+		norm += amplitude
+		amplitude *= r.Gain
+		frequency *= r.Lacunarity
+	}
+	if norm == 0 {
+		return 0
+	}
+	return sum / norm
+}
+
+// DomainWarp distorts the coordinates of a generator with a noise, so that the
+// features of the terrain are bent and twisted.
+type DomainWarp struct {
+	Generator HeightGenerator
+	Noise     Noise
+	// The maximal displacement of the coordinates in tiles.
+	Strength  float32
+	Frequency float32
+}
+
+// NewDomainWarp returns a domain warp of the generator with the noise.
+func NewDomainWarp(g HeightGenerator, n Noise, strength, frequency float32) *DomainWarp {
+	return &DomainWarp{
+		Generator: g,
+		Noise:     n,
+		Strength:  strength,
+		Frequency: frequency,
+	}
+}
+
+// Height returns the height of the generator at the displaced coordinates.
+func (d *DomainWarp) Height(x, z float32) float32 {
+	// The displacements of the two axes are sampled from distant places.
+	dX := d.Noise.Noise(x*d.Frequency, z*d.Frequency)
+	dZ := d.Noise.Noise(x*d.Frequency+5.2, z*d.Frequency+1.3)
+	return d.Generator.Height(x+d.Strength*dX, z+d.Strength*dZ)
+}
@@ -0,0 +1,105 @@
+package terrain
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// HeightMap stores the heights of the terrain grid points. It is indexed with
+// [l][w], where l is in the [0, length], w is in the [0, width] interval, so
+// that a map with width * length tiles has (width+1) * (length+1) points.
+type HeightMap [][]float32
+
+// NewHeightMap returns a height map with width * length tiles. Every point
+// has the given default height.
+func NewHeightMap(width, length int, defaultHeight float32) HeightMap {
+	h := make(HeightMap, length+1)
+	for l := 0; l <= length; l++ {
+		h[l] = make([]float32, width+1)
+		for w := 0; w <= width; w++ {
+			h[l][w] = defaultHeight
+		}
+	}
+	return h
+}
+
+// Width returns the number of the tiles in the x direction.
+func (h HeightMap) Width() int {
+	if len(h) == 0 {
+		return 0
+	}
+	return len(h[0]) - 1
+}
+
+// Length returns the number of the tiles in the z direction.
+func (h HeightMap) Length() int {
+	return len(h) - 1
+}
+
+// MinMax returns the minimal and the maximal height of the map.
+func (h HeightMap) MinMax() (float32, float32) {
+	if len(h) == 0 || len(h[0]) == 0 {
+		return 0, 0
+	}
+	min, max := h[0][0], h[0][0]
+	for l := range h {
+		for _, height := range h[l] {
+			if height < min {
+				min = height
+			}
+			if height > max {
+				max = height
+			}
+		}
+	}
+	return min, max
+}
+
+// Sample returns the bilinear interpolated height at the given grid coordinates.
+// The coordinates are clamped to the map.
+func (h HeightMap) Sample(x, z float32) float32 {
+	w0, w1, wX := gridCell(x, h.Width())
+	l0, l1, wZ := gridCell(z, h.Length())
+	return (h[l0][w0]*(1-wX)+h[l0][w1]*wX)*(1-wZ) + (h[l1][w0]*(1-wX)+h[l1][w1]*wX)*wZ
+}
+
+// gridCell returns the indices of the neighbour grid points of the coordinate
+// and the weight of the second one. The coordinate is clamped to [0, size].
+func gridCell(c float32, size int) (int, int, float32) {
+	if c <= 0 || size == 0 {
+		return 0, 0, 0
+	}
+	if c >= float32(size) {
+		return size, size, 0
+	}
+	i := int(c)
+	return i, i + 1, c - float32(i)
+}
+
+// Normal returns the normal vector of the grid point in the coordinate system of
+// the terrain mesh. It's calculated from the central differences of the heights
+// (one sided on the edges). It points to the -y direction, like the normal vectors
+// of the engine's terrain, that is the up direction of the engine's shaders.
+func (h HeightMap) Normal(w, l int) mgl32.Vec3 {
+	w0, w1 := w-1, w+1
+	if w0 < 0 {
+		w0 = 0
+	}
+	if w1 > h.Width() {
+		w1 = h.Width()
+	}
+	l0, l1 := l-1, l+1
+	if l0 < 0 {
+		l0 = 0
+	}
+	if l1 > h.Length() {
+		l1 = h.Length()
+	}
+	var dX, dZ float32
+	if w1 > w0 {
+		dX = (h[l][w1] - h[l][w0]) / float32(w1-w0)
+	}
+	if l1 > l0 {
+		dZ = (h[l1][w] - h[l0][w]) / float32(l1-l0)
+	}
+	return mgl32.Vec3{dX, -1, dZ}.Normalize()
+}
@@ -0,0 +1,67 @@
+package terrain
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// testPlane returns a height map, where the height is a * w + b * l.
+func testPlane(width, length int, a, b float32) HeightMap {
+	h := NewHeightMap(width, length, 0)
+	for l := 0; l <= length; l++ {
+		for w := 0; w <= width; w++ {
+			h[l][w] = a*float32(w) + b*float32(l)
+		}
+	}
+	return h
+}
+
+func TestHeightMapSample(t *testing.T) {
+	// The heights of the 2 * 1 tiles:
+	// l=0: 0 1 2
+	// l=1: 4 5 6
+	h := HeightMap{{0, 1, 2}, {4, 5, 6}}
+	testData := []struct {
+		name     string
+		x, z     float32
+		expected float32
+	}{
+		{"grid point", 1, 0, 1},
+		{"last grid point", 2, 1, 6},
+		{"middle of the edge", 0.5, 0, 0.5},
+		{"middle of the tile", 0.5, 0.5, 2.5},
+		{"fraction", 1.25, 0.75, 4.25},
+		{"clamped below", -3, -1, 0},
+		{"clamped above", 5, 2, 6},
+		{"clamped x", 10, 0.5, 4},
+	}
+	for _, tt := range testData {
+		if value := h.Sample(tt.x, tt.z); !mgl32.FloatEqual(value, tt.expected) {
+			t.Errorf("%s: Invalid sample at (%f, %f). Instead of '%f', we have '%f'.", tt.name, tt.x, tt.z, tt.expected, value)
+		}
+	}
+}
+
+func TestHeightMapNormal(t *testing.T) {
+	testData := []struct {
+		name     string
+		h        HeightMap
+		w, l     int
+		expected mgl32.Vec3
+	}{
+		{"flat", testPlane(4, 4, 0, 0), 2, 2, mgl32.Vec3{0, -1, 0}},
+		{"flat corner", testPlane(4, 4, 0, 0), 0, 4, mgl32.Vec3{0, -1, 0}},
+		{"slope along w", testPlane(4, 4, 1, 0), 2, 2, mgl32.Vec3{1, -1, 0}.Normalize()},
+		{"slope along w on the edge", testPlane(4, 4, 1, 0), 0, 2, mgl32.Vec3{1, -1, 0}.Normalize()},
+		{"slope along l", testPlane(4, 4, 0, 2), 2, 2, mgl32.Vec3{0, -1, 2}.Normalize()},
+		{"slope along l on the edge", testPlane(4, 4, 0, 2), 2, 4, mgl32.Vec3{0, -1, 2}.Normalize()},
+		{"diagonal slope", testPlane(4, 4, -1, 1), 1, 3, mgl32.Vec3{-1, -1, 1}.Normalize()},
+		{"single point", NewHeightMap(0, 0, 3), 0, 0, mgl32.Vec3{0, -1, 0}},
+	}
+	for _, tt := range testData {
+		if normal := tt.h.Normal(tt.w, tt.l); !normal.ApproxEqual(tt.expected) {
+			t.Errorf("%s: Invalid normal at (%d, %d). Instead of '%v', we have '%v'.", tt.name, tt.w, tt.l, tt.expected, normal)
+		}
+	}
+}
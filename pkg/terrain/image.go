@@ -0,0 +1,65 @@
+package terrain
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+)
+
+// LoadHeightMapImage decodes the png height map image. The 8 and 16 bit
+// grayscale images are used as they are, the colored images are converted
+// to grayscale.
+func LoadHeightMapImage(filename string) (image.Image, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return png.Decode(file)
+}
+
+// HeightMapFromImage returns a height map with width * length tiles from the
+// grayscale image. The black pixels are mapped to minH, the white ones to maxH
+// (the maxH could be less than minH for the inverted maps). The image is
+// resampled with bilinear interpolation, the corners of the map are the corner
+// pixels of the image. The first row of the image is the l = 0 row of the map.
+func HeightMapFromImage(img image.Image, width, length int, minH, maxH float32) HeightMap {
+	bounds := img.Bounds()
+	imgWidth, imgHeight := bounds.Dx(), bounds.Dy()
+	h := NewHeightMap(width, length, minH)
+	if imgWidth == 0 || imgHeight == 0 {
+		return h
+	}
+	// The normalized gray values of the image.
+	values := NewHeightMap(imgWidth-1, imgHeight-1, 0)
+	for y := 0; y < imgHeight; y++ {
+		for x := 0; x < imgWidth; x++ {
+			values[y][x] = grayValue(img, bounds.Min.X+x, bounds.Min.Y+y)
+		}
+	}
+	for l := 0; l <= length; l++ {
+		for w := 0; w <= width; w++ {
+			var x, z float32
+			if width > 0 {
+				x = float32(w) / float32(width) * float32(imgWidth-1)
+			}
+			if length > 0 {
+				z = float32(l) / float32(length) * float32(imgHeight-1)
+			}
+			h[l][w] = minH + values.Sample(x, z)*(maxH-minH)
+		}
+	}
+	return h
+}
+
+// grayValue returns the gray value of the pixel in the [0, 1] interval.
+func grayValue(img image.Image, x, y int) float32 {
+	switch gray := img.(type) {
+	case *image.Gray:
+		return float32(gray.GrayAt(x, y).Y) / 255.0
+	case *image.Gray16:
+		return float32(gray.Gray16At(x, y).Y) / 65535.0
+	}
+	return float32(color.Gray16Model.Convert(img.At(x, y)).(color.Gray16).Y) / 65535.0
+}
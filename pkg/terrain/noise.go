@@ -0,0 +1,119 @@
+package terrain
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Noise is a seeded 2D gradient noise. The values are in the [-1, 1] interval.
+type Noise interface {
+	Noise(x, z float32) float32
+}
+
+// permutation returns the doubled random permutation of the [0, 255] numbers,
+// so that the indices of the hashing don't need to be wrapped.
+func permutation(seed int64) [512]int {
+	var p [512]int
+	r := rand.New(rand.NewSource(seed))
+	for i, v := range r.Perm(256) {
+		p[i] = v
+		p[i+256] = v
+	}
+	return p
+}
+
+// The gradient directions of the 2D noises.
+var gradients = [8][2]float32{
+	{1, 1}, {-1, 1}, {1, -1}, {-1, -1},
+	{1, 0}, {-1, 0}, {0, 1}, {0, -1},
+}
+
+func floor(f float32) int {
+	return int(math.Floor(float64(f)))
+}
+
+// Perlin is the improved Perlin noise.
+type Perlin struct {
+	perm [512]int
+}
+
+// NewPerlin returns a Perlin noise with the permutation of the seed.
+func NewPerlin(seed int64) *Perlin {
+	return &Perlin{perm: permutation(seed)}
+}
+
+func fade(t float32) float32 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+func lerp(a, b, t float32) float32 {
+	return a + t*(b-a)
+}
+func (p *Perlin) grad(hash int, x, z float32) float32 {
+	g := gradients[hash&7]
+	return g[0]*x + g[1]*z
+}
+
+// Noise returns the noise value at the given coordinates.
+func (p *Perlin) Noise(x, z float32) float32 {
+	xi, zi := floor(x), floor(z)
+	xf, zf := x-float32(xi), z-float32(zi)
+	xi, zi = xi&255, zi&255
+	u, v := fade(xf), fade(zf)
+	aa := p.perm[p.perm[xi]+zi]
+	ab := p.perm[p.perm[xi]+zi+1]
+	ba := p.perm[p.perm[xi+1]+zi]
+	bb := p.perm[p.perm[xi+1]+zi+1]
+	x1 := lerp(p.grad(aa, xf, zf), p.grad(ba, xf-1, zf), u)
+	x2 := lerp(p.grad(ab, xf, zf-1), p.grad(bb, xf-1, zf-1), u)
+	return lerp(x1, x2, v)
+}
+
+// Simplex is the 2D simplex noise. It has less directional artifacts than
+// the Perlin noise.
+type Simplex struct {
+	perm [512]int
+}
+
+// NewSimplex returns a simplex noise with the permutation of the seed.
+func NewSimplex(seed int64) *Simplex {
+	return &Simplex{perm: permutation(seed)}
+}
+
+// The skewing and unskewing factors of the 2D simplex grid.
+var (
+	skew2   = float32(0.5 * (math.Sqrt(3.0) - 1.0))
+	unskew2 = float32((3.0 - math.Sqrt(3.0)) / 6.0)
+)
+
+// corner returns the contribution of a simplex corner.
+func (s *Simplex) corner(hash int, x, z float32) float32 {
+	t := 0.5 - x*x - z*z
+	if t < 0 {
+		return 0
+	}
+	g := gradients[hash&7]
+	t *= t
+	return t * t * (g[0]*x + g[1]*z)
+}
+
+// Noise returns the noise value at the given coordinates.
+func (s *Simplex) Noise(x, z float32) float32 {
+	// The cell of the skewed grid.
+	skew := (x + z) * skew2
+	i, j := floor(x+skew), floor(z+skew)
+	unskew := float32(i+j) * unskew2
+	x0, z0 := x-(float32(i)-unskew), z-(float32(j)-unskew)
+	// The second corner is in the lower or the upper triangle of the cell.
+	i1, j1 := 0, 1
+	if x0 > z0 {
+		i1, j1 = 1, 0
+	}
+	x1, z1 := x0-float32(i1)+unskew2, z0-float32(j1)+unskew2
+	x2, z2 := x0-1+2*unskew2, z0-1+2*unskew2
+	ii, jj := i&255, j&255
+	n0 := s.corner(s.perm[ii+s.perm[jj]], x0, z0)
+	n1 := s.corner(s.perm[ii+i1+s.perm[jj+j1]], x1, z1)
+	n2 := s.corner(s.perm[ii+1+s.perm[jj+1]], x2, z2)
+	// Scale the result to the [-1, 1] interval.
+	return 70.0 * (n0 + n1 + n2)
+}
@@ -0,0 +1,140 @@
+package terrain
+
+import (
+	"testing"
+)
+
+// constantNoise returns the same value everywhere.
+type constantNoise float32
+
+func (c constantNoise) Noise(x, z float32) float32 {
+	return float32(c)
+}
+
+// sampleGrid calls the function on the points of a grid, that doesn't fit to
+// the integer lattice.
+func sampleGrid(f func(x, z float32)) {
+	for z := float32(-20); z < 20; z += 0.73 {
+		for x := float32(-20); x < 20; x += 0.61 {
+			f(x, z)
+		}
+	}
+}
+
+func TestNoise(t *testing.T) {
+	testData := []struct {
+		name   string
+		create func(seed int64) Noise
+	}{
+		{"perlin", func(seed int64) Noise { return NewPerlin(seed) }},
+		{"simplex", func(seed int64) Noise { return NewSimplex(seed) }},
+	}
+	for _, tt := range testData {
+		first, second, other := tt.create(1), tt.create(1), tt.create(2)
+		differs := false
+		nonZero := false
+		sampleGrid(func(x, z float32) {
+			value := first.Noise(x, z)
+			if value < -1 || value > 1 {
+				t.Errorf("%s: The noise '%f' at (%f, %f) is out of the [-1, 1] interval.", tt.name, value, x, z)
+			}
+			if value != second.Noise(x, z) {
+				t.Errorf("%s: The noise with the same seed has to be the same at (%f, %f).", tt.name, x, z)
+			}
+			if value != other.Noise(x, z) {
+				differs = true
+			}
+			if value != 0 {
+				nonZero = true
+			}
+		})
+		if !differs {
+			t.Errorf("%s: The noise with different seeds has to be different.", tt.name)
+		}
+		if !nonZero {
+			t.Errorf("%s: The noise shouldn't be zero everywhere.", tt.name)
+		}
+	}
+}
+
+func TestPerlinLattice(t *testing.T) {
+	p := NewPerlin(3)
+	for z := -5; z <= 5; z++ {
+		for x := -5; x <= 5; x++ {
+			if value := p.Noise(float32(x), float32(z)); value != 0 {
+				t.Errorf("The perlin noise has to be 0 at the lattice point (%d, %d), instead of '%f'.", x, z, value)
+			}
+		}
+	}
+}
+
+func TestGeneratorRange(t *testing.T) {
+	testData := []struct {
+		name      string
+		generator HeightGenerator
+	}{
+		{"fractal perlin", NewFractal(NewPerlin(1))},
+		{"fractal simplex", NewFractal(NewSimplex(1))},
+		{"ridged perlin", NewRidged(NewPerlin(1))},
+		{"ridged simplex", NewRidged(NewSimplex(1))},
+		{"domain warp", NewDomainWarp(NewFractal(NewSimplex(1)), NewPerlin(2), 4, 0.1)},
+	}
+	for _, tt := range testData {
+		min, max := float32(1), float32(0)
+		sampleGrid(func(x, z float32) {
+			value := tt.generator.Height(x*10, z*10)
+			if value < 0 || value > 1 {
+				t.Errorf("%s: The height '%f' at (%f, %f) is out of the [0, 1] interval.", tt.name, value, x, z)
+			}
+			if value < min {
+				min = value
+			}
+			if value > max {
+				max = value
+			}
+		})
+		if min >= max {
+			t.Errorf("%s: The height has to vary, it's '%f' everywhere.", tt.name, min)
+		}
+	}
+}
+
+func TestGeneratorHeight(t *testing.T) {
+	withOctaves := func(f *Fractal, octaves int) *Fractal {
+		f.Octaves = octaves
+		return f
+	}
+	ridgedWithOctaves := func(r *Ridged, octaves int) *Ridged {
+		r.Octaves = octaves
+		return r
+	}
+	testData := []struct {
+		name      string
+		generator HeightGenerator
+		expected  float32
+	}{
+		{"fractal of zero noise", NewFractal(constantNoise(0)), 0.5},
+		{"fractal of maximal noise", NewFractal(constantNoise(1)), 1},
+		{"fractal of minimal noise", NewFractal(constantNoise(-1)), 0},
+		{"fractal without octaves", withOctaves(NewFractal(constantNoise(1)), 0), 0.5},
+		{"ridged of zero noise", NewRidged(constantNoise(0)), 1},
+		{"ridged of maximal noise", NewRidged(constantNoise(1)), 0},
+		{"ridged without octaves", ridgedWithOctaves(NewRidged(constantNoise(0)), 0), 0},
+		{"domain warp of zero noise", NewDomainWarp(NewFractal(constantNoise(0.5)), constantNoise(0), 10, 1), 0.75},
+	}
+	for _, tt := range testData {
+		if value := tt.generator.Height(3.3, -1.7); value != tt.expected {
+			t.Errorf("%s: Invalid height. Instead of '%f', we have '%f'.", tt.name, tt.expected, value)
+		}
+	}
+}
+
+func TestDomainWarpDisplacement(t *testing.T) {
+	g := NewFractal(NewSimplex(5))
+	warp := NewDomainWarp(g, constantNoise(1), 2, 1)
+	sampleGrid(func(x, z float32) {
+		if warp.Height(x, z) != g.Height(x+2, z+2) {
+			t.Errorf("The warped height at (%f, %f) has to be the height at the displaced coordinates.", x, z)
+		}
+	})
+}
@@ -0,0 +1,310 @@
+package terrain
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// The default parameters of the brushes.
+	DefaultBrushRadius     = float32(3.0)
+	DefaultBrushFalloff    = float32(0.5)
+	DefaultBrushStrength   = float32(1.0)
+	DefaultBrushNoiseScale = float32(0.5)
+	// The default number of the strokes, that could be undone.
+	DefaultUndoLimit = 32
+)
+
+// The modes of the brushes.
+const (
+	// BrushRaise increases the heights.
+	BrushRaise = iota
+	// BrushLower decreases the heights.
+	BrushLower
+	// BrushSmooth moves the heights to the average of their neighbours.
+	BrushSmooth
+	// BrushFlatten moves the heights to the height of the beginning of the stroke.
+	BrushFlatten
+	// BrushNoise adds the simplex noise to the heights.
+	BrushNoise
+)
+
+// Brush is the tool of the sculptor. Its effect is full inside the inner part of
+// the circle and it decreases to 0 on the edge smoothly.
+type Brush struct {
+	Mode int
+	// The radius of the circle in world units, measured in the XZ plane.
+	Radius float32
+	// The ratio of the radius, where the effect decreases. 0 is a hard edge,
+	// 1 decreases from the center.
+	Falloff float32
+	// The height change in one second in world units in the raise, lower and
+	// noise modes. In the smooth and flatten modes it's the ratio of the
+	// distance from the target height, that is done in one second.
+	Strength float32
+	// The frequency of the noise in the noise mode. The unit is the tile.
+	NoiseScale float32
+}
+
+// NewBrush returns a brush with the default parameters and the given mode.
+func NewBrush(mode int) *Brush {
+	return &Brush{
+		Mode:       mode,
+		Radius:     DefaultBrushRadius,
+		Falloff:    DefaultBrushFalloff,
+		Strength:   DefaultBrushStrength,
+		NoiseScale: DefaultBrushNoiseScale,
+	}
+}
+
+// weight returns the effect of the brush in the given distance from its center.
+func (b *Brush) weight(distance float32) float32 {
+	if b.Radius <= 0 || distance >= b.Radius {
+		return 0
+	}
+	inner := 1 - b.Falloff
+	d := distance / b.Radius
+	if d <= inner {
+		return 1
+	}
+	t := (d - inner) / b.Falloff
+	return 1 - t*t*(3-2*t)
+}
+
+// stroke is the undo record of the brush strokes. It stores the original
+// heights of the modified grid points and the bounds of the modified area.
+type stroke struct {
+	heights    map[[2]int]float32
+	minW, minL int
+	maxW, maxL int
+}
+
+// newStroke returns an empty stroke.
+func newStroke() *stroke {
+	return &stroke{
+		heights: make(map[[2]int]float32),
+		minW:    math.MaxInt32,
+		minL:    math.MaxInt32,
+		maxW:    -1,
+		maxL:    -1,
+	}
+}
+
+// store saves the original height of the grid point, if it's the first change
+// of the point in the stroke.
+func (s *stroke) store(w, l int, height float32) {
+	key := [2]int{w, l}
+	if _, ok := s.heights[key]; ok {
+		return
+	}
+	s.heights[key] = height
+	s.minW, s.maxW = min(s.minW, w), max(s.maxW, w)
+	s.minL, s.maxL = min(s.minL, l), max(s.maxL, l)
+}
+
+// Sculptor modifies the height map of a terrain with brushes. The vertices of
+// the modified area are updated in the vertex buffer of the terrain mesh. The
+// strokes could be undone. The liquid of the terrain is not updated.
+type Sculptor struct {
+	terrain *Terrain
+	brush   *Brush
+	noise   Noise
+	// The target of the flatten mode in the height map units.
+	flattenHeight float32
+	current       *stroke
+	undo          []*stroke
+	undoLimit     int
+}
+
+// NewSculptor returns the sculptor of the terrain with a raise brush. The noise
+// of the noise mode is the simplex noise of the seed.
+func NewSculptor(t *Terrain, seed int64) *Sculptor {
+	return &Sculptor{
+		terrain:   t,
+		brush:     NewBrush(BrushRaise),
+		noise:     NewSimplex(seed),
+		undoLimit: DefaultUndoLimit,
+	}
+}
+
+// SetBrush sets the brush of the sculptor.
+func (s *Sculptor) SetBrush(b *Brush) {
+	s.brush = b
+}
+
+// GetBrush returns the brush of the sculptor.
+func (s *Sculptor) GetBrush() *Brush {
+	return s.brush
+}
+
+// SetUndoLimit sets the number of the strokes, that could be undone.
+func (s *Sculptor) SetUndoLimit(limit int) {
+	s.undoLimit = limit
+	s.trimUndo()
+}
+
+// GetUndoCount returns the number of the strokes, that could be undone.
+func (s *Sculptor) GetUndoCount() int {
+	return len(s.undo)
+}
+
+// IsStroking returns true between the BeginStroke and the EndStroke calls.
+func (s *Sculptor) IsStroking() bool {
+	return s.current != nil
+}
+
+// BeginStroke starts a new stroke in the given world position, eg. the
+// intersection point of the mouse ray and the surface. The target of the
+// flatten mode is the height of the position.
+func (s *Sculptor) BeginStroke(pos mgl32.Vec3) {
+	s.EndStroke()
+	s.current = newStroke()
+	tMesh := s.terrain.GetTerrain()
+	s.flattenHeight = (pos.Y() - tMesh.GetPosition().Y()) / tMesh.ScaleTransformation()[5]
+}
+
+// Apply applies the brush in the given world position for dt milliseconds. It
+// begins a stroke, if it's not started.
+func (s *Sculptor) Apply(pos mgl32.Vec3, dt float64) {
+	if s.current == nil {
+		s.BeginStroke(pos)
+	}
+	x, z, ok := s.terrain.toGrid(pos)
+	if !ok {
+		return
+	}
+	h := s.terrain.heightMap
+	tMesh := s.terrain.GetTerrain()
+	scaleTr := tMesh.ScaleTransformation()
+	scaleX, scaleY, scaleZ := scaleTr[0], scaleTr[5], scaleTr[10]
+	seconds := float32(dt / 1000.0)
+	radiusW, radiusL := s.brush.Radius/scaleX, s.brush.Radius/scaleZ
+	minW, maxW := max(0, int(math.Ceil(float64(x-radiusW)))), min(h.Width(), int(x+radiusW))
+	minL, maxL := max(0, int(math.Ceil(float64(z-radiusL)))), min(h.Length(), int(z+radiusL))
+	if minW > maxW || minL > maxL {
+		return
+	}
+	// The smooth mode uses the heights before the current step, so that the
+	// result doesn't depend on the order of the points.
+	var original HeightMap
+	if s.brush.Mode == BrushSmooth {
+		original = make(HeightMap, len(h))
+		for l := max(0, minL-1); l <= min(h.Length(), maxL+1); l++ {
+			original[l] = append([]float32{}, h[l]...)
+		}
+	}
+	blend := func(weight float32) float32 {
+		return float32(math.Min(1, float64(s.brush.Strength*weight*seconds)))
+	}
+	for l := minL; l <= maxL; l++ {
+		for w := minW; w <= maxW; w++ {
+			dX, dZ := (float32(w)-x)*scaleX, (float32(l)-z)*scaleZ
+			weight := s.brush.weight(float32(math.Sqrt(float64(dX*dX + dZ*dZ))))
+			if weight <= 0 {
+				continue
+			}
+			s.current.store(w, l, h[l][w])
+			switch s.brush.Mode {
+			case BrushRaise:
+				h[l][w] += s.brush.Strength * weight * seconds / scaleY
+				break
+			case BrushLower:
+				h[l][w] -= s.brush.Strength * weight * seconds / scaleY
+				break
+			case BrushSmooth:
+				h[l][w] += (neighbourAverage(original, w, l) - h[l][w]) * blend(weight)
+				break
+			case BrushFlatten:
+				h[l][w] += (s.flattenHeight - h[l][w]) * blend(weight)
+				break
+			case BrushNoise:
+				noise := s.noise.Noise(float32(w)*s.brush.NoiseScale, float32(l)*s.brush.NoiseScale)
+				h[l][w] += noise * s.brush.Strength * weight * seconds / scaleY
+				break
+			}
+		}
+	}
+	s.update(minW, minL, maxW, maxL)
+}
+
+// neighbourAverage returns the average height of the grid point and its
+// neighbours, that are on the map.
+func neighbourAverage(h HeightMap, w, l int) float32 {
+	var sum float32
+	count := 0
+	for dL := -1; dL <= 1; dL++ {
+		for dW := -1; dW <= 1; dW++ {
+			nW, nL := w+dW, l+dL
+			if nL < 0 || nL >= len(h) || nW < 0 || nW >= len(h[nL]) {
+				continue
+			}
+			sum += h[nL][nW]
+			count++
+		}
+	}
+	return sum / float32(count)
+}
+
+// EndStroke finishes the current stroke. The stroke is added to the undo
+// stack, if it modified the surface.
+func (s *Sculptor) EndStroke() {
+	if s.current == nil {
+		return
+	}
+	if len(s.current.heights) > 0 {
+		s.undo = append(s.undo, s.current)
+		s.trimUndo()
+	}
+	s.current = nil
+}
+
+// trimUndo drops the oldest strokes over the undo limit.
+func (s *Sculptor) trimUndo() {
+	if s.undoLimit >= 0 && len(s.undo) > s.undoLimit {
+		s.undo = s.undo[len(s.undo)-s.undoLimit:]
+	}
+}
+
+// Undo restores the heights before the last stroke. It returns false, if
+// there isn't any stroke to undo.
+func (s *Sculptor) Undo() bool {
+	s.EndStroke()
+	if len(s.undo) == 0 {
+		return false
+	}
+	last := s.undo[len(s.undo)-1]
+	s.undo = s.undo[:len(s.undo)-1]
+	for key, height := range last.heights {
+		s.terrain.heightMap[key[1]][key[0]] = height
+	}
+	s.update(last.minW, last.minL, last.maxW, last.maxL)
+	return true
+}
+
+// update recalculates the positions and the normals of the vertices of the
+// modified area and uploads them. The normals of the neighbour points also
+// depend on the modified heights. The uploaded range is the full rows of the
+// area, because the rows are continuous in the vertex buffer.
+func (s *Sculptor) update(minW, minL, maxW, maxL int) {
+	h := s.terrain.heightMap
+	minW, maxW = max(0, minW-1), min(h.Width(), maxW+1)
+	minL, maxL = max(0, minL-1), min(h.Length(), maxL+1)
+	tMesh := s.terrain.mesh
+	for l := minL; l <= maxL; l++ {
+		for w := minW; w <= maxW; w++ {
+			i := l*(h.Width()+1) + w
+			tMesh.Vertices[i].Position = mgl32.Vec3{tMesh.Vertices[i].Position.X(), h[l][w], tMesh.Vertices[i].Position.Z()}
+			tMesh.Vertices[i].Normal = h.Normal(w, l)
+		}
+	}
+	tMesh.updateVertices(minL*(h.Width()+1), (maxL+1)*(h.Width()+1))
+}
+
+// SaveHeightMap writes the current height map to the file as a 16 bit grayscale
+// png. It returns the min and the max heights, that are needed for loading it
+// with the SetHeightMapFile function of the builder.
+func (s *Sculptor) SaveHeightMap(filename string) (float32, float32, error) {
+	img, minH, maxH := HeightMapImage(s.terrain.heightMap)
+	return minH, maxH, savePNG(filename, img)
+}
@@ -0,0 +1,232 @@
+# version 410
+out vec4 FragColor;
+
+struct Material {
+    float shininess;
+};
+
+// The layers of the terrain and the blending parameters. The heights are
+// normalized with the min and max heights of the terrain, so that the 0 is
+// the min, the 1 is the max height.
+struct Splat {
+    sampler2D sand;
+    sampler2D grass;
+    sampler2D rock;
+    sampler2D snow;
+    // The rgba channels of the splat map are the weights of the sand, grass,
+    // rock and snow layers. It's used instead of the thresholds if the useMap is set.
+    sampler2D map;
+    float useMap;
+
+    float minHeight;
+    float maxHeight;
+    float width;
+    float length;
+    // The layer textures are repeated once in every tiling tiles.
+    float tiling;
+    float specular;
+
+    float grassLevel;
+    float rockLevel;
+    float snowLevel;
+    float heightBlend;
+    // The slope is 0 on flat surfaces and 1 on vertical ones. The rock
+    // layer covers the surfaces that are steeper than the slopeLevel.
+    float slopeLevel;
+    float slopeBlend;
+};
+
+struct DirectionalLight {
+    vec3 direction;
+
+    vec3 ambient;
+    vec3 diffuse;
+    vec3 specular;
+};
+
+struct PointLight {
+    vec3 position;
+
+    vec3 ambient;
+    vec3 diffuse;
+    vec3 specular;
+
+    float constant;
+    float linear;
+    float quadratic;
+};
+
+struct SpotLight {
+    vec3 position;
+    vec3 direction;
+    float cutOff;
+    float outerCutOff;
+
+    vec3 ambient;
+    vec3 diffuse;
+    vec3 specular;
+
+    float constant;
+    float linear;
+    float quadratic;
+};
+
+struct Fog {
+    float minDistance;
+    float maxDistance;
+    vec3 color;
+};
+
+in vec3 FragPos;
+in vec3 Normal;
+in vec2 TexCoords;
+in vec3 LocalPos;
+in vec3 LocalNormal;
+
+#define MAX_DIRECTION_LIGHTS 16
+#define MAX_POINT_LIGHTS 16
+#define MAX_SPOT_LIGHTS 16
+
+uniform DirectionalLight dirLight[MAX_DIRECTION_LIGHTS];
+uniform PointLight pointLight[MAX_POINT_LIGHTS];
+uniform SpotLight spotLight[MAX_SPOT_LIGHTS];
+uniform Material material;
+uniform Splat splat;
+uniform int NumberOfDirectionalLightSources;
+uniform int NumberOfPointLightSources;
+uniform int NumberOfSpotLightSources;
+uniform Fog fog;
+
+uniform vec3 viewPosition;
+
+// function prototypes
+vec4 LayerWeights();
+vec3 CalculateDirectionalLight(DirectionalLight light, vec3 normal, vec3 viewDir, vec3 color);
+vec3 CalculatePointLight(PointLight light, vec3 normal, vec3 fragPos, vec3 viewDir, vec3 color);
+vec3 CalculateSpotLight(SpotLight light, vec3 normal, vec3 fragPos, vec3 viewDir, vec3 color);
+
+void main()
+{
+    // The layer textures are repeated with the fract function, because the
+    // textures are clamped to the edge.
+    vec2 layerCoords = fract(LocalPos.xz / max(splat.tiling, 0.0001));
+    vec4 weights = LayerWeights();
+    vec3 color = weights.x * texture(splat.sand, layerCoords).rgb +
+        weights.y * texture(splat.grass, layerCoords).rgb +
+        weights.z * texture(splat.rock, layerCoords).rgb +
+        weights.w * texture(splat.snow, layerCoords).rgb;
+
+    vec3 norm = normalize(Normal);
+    vec3 viewDirection = normalize(viewPosition - FragPos);
+
+    vec3 result = vec3(0);
+    // calculate Directional lighting
+    int nrDirLight = min(NumberOfDirectionalLightSources, MAX_DIRECTION_LIGHTS);
+    for (int i = 0; i < nrDirLight; i++) {
+        result += CalculateDirectionalLight(dirLight[i], norm, viewDirection, color);
+    }
+    // calculate Point lighting
+    int nrPointLight = min(NumberOfPointLightSources, MAX_POINT_LIGHTS);
+    for (int i = 0; i < nrPointLight; i++) {
+        result += CalculatePointLight(pointLight[i], norm, FragPos, viewDirection, color);
+    }
+    // calculate spot lighting
+    int nrSpotLight = min(NumberOfSpotLightSources, MAX_SPOT_LIGHTS);
+    for (int i = 0; i < nrSpotLight; i++) {
+        result += CalculateSpotLight(spotLight[i], norm, FragPos, viewDirection, color);
+    }
+    if (fog.minDistance > 0 && fog.maxDistance > 0) {
+        float distance = length(viewPosition - FragPos);
+        float fogFactor = (fog.maxDistance - distance) / (fog.maxDistance - fog.minDistance);
+        fogFactor = clamp(fogFactor, 0.0, 1.0);
+        result = mix(fog.color, result, fogFactor);
+    }
+    FragColor = vec4(result, 1.0);
+}
+
+// returns the weights of the sand, grass, rock and snow layers. The sum of the weights is 1.
+vec4 LayerWeights()
+{
+    if (splat.useMap > 0.5) {
+        vec2 mapCoords = LocalPos.xz / vec2(splat.width, splat.length) + 0.5;
+        vec4 weights = texture(splat.map, mapCoords);
+        float sum = weights.x + weights.y + weights.z + weights.w;
+        if (sum > 0.0) {
+            return weights / sum;
+        }
+        return vec4(0.0, 1.0, 0.0, 0.0);
+    }
+    float heightRange = splat.maxHeight - splat.minHeight;
+    float height = 0.0;
+    if (heightRange != 0.0) {
+        height = clamp((LocalPos.y - splat.minHeight) / heightRange, 0.0, 1.0);
+    }
+    float slope = 1.0 - abs(normalize(LocalNormal).y);
+    float hb = max(splat.heightBlend, 0.0001);
+    float sb = max(splat.slopeBlend, 0.0001);
+    // The layers are laid on each other from the bottom to the top.
+    vec4 weights = vec4(1.0, 0.0, 0.0, 0.0);
+    weights = mix(weights, vec4(0.0, 1.0, 0.0, 0.0), smoothstep(splat.grassLevel - hb, splat.grassLevel + hb, height));
+    weights = mix(weights, vec4(0.0, 0.0, 1.0, 0.0), smoothstep(splat.rockLevel - hb, splat.rockLevel + hb, height));
+    weights = mix(weights, vec4(0.0, 0.0, 0.0, 1.0), smoothstep(splat.snowLevel - hb, splat.snowLevel + hb, height));
+    // The steep surfaces are rocky in every height.
+    weights = mix(weights, vec4(0.0, 0.0, 1.0, 0.0), smoothstep(splat.slopeLevel - sb, splat.slopeLevel + sb, slope));
+    return weights;
+}
+
+// calculates the color when using a directional light.
+vec3 CalculateDirectionalLight(DirectionalLight light, vec3 normal, vec3 viewDir, vec3 color)
+{
+    vec3 lightDir = normalize(-light.direction);
+    // diffuse shading
+    float diff = max(dot(normal, lightDir), 0.0);
+    // specular shading
+    vec3 reflectDir = reflect(-lightDir, normal);
+    float spec = pow(max(dot(viewDir, reflectDir), 0.0), material.shininess);
+    // combine results
+    vec3 ambient = light.ambient * color;
+    vec3 diffuse = light.diffuse * diff * color;
+    vec3 specular = light.specular * spec * splat.specular * color;
+    return ambient + diffuse + specular;
+}
+// calculates the color when using a point light.
+vec3 CalculatePointLight(PointLight light, vec3 normal, vec3 fragPos, vec3 viewDir, vec3 color)
+{
+    vec3 lightDir = normalize(light.position - fragPos);
+    // diffuse shading
+    float diff = max(dot(normal, lightDir), 0.0);
+    // specular shading
+    vec3 reflectDir = reflect(-lightDir, normal);
+    float spec = pow(max(dot(viewDir, reflectDir), 0.0), material.shininess);
+    // attenuation
+    float distance = length(light.position - fragPos);
+    float attenuation = 1.0 / (light.constant + light.linear * distance + light.quadratic * (distance * distance));
+    // combine results
+    vec3 ambient = light.ambient * color;
+    vec3 diffuse = light.diffuse * diff * color;
+    vec3 specular = light.specular * spec * splat.specular * color;
+    return (ambient + diffuse + specular) * attenuation;
+}
+
+// calculates the color when using a spot light.
+vec3 CalculateSpotLight(SpotLight light, vec3 normal, vec3 fragPos, vec3 viewDir, vec3 color)
+{
+    vec3 lightDir = normalize(light.position - fragPos);
+    // diffuse shading
+    float diff = max(dot(normal, lightDir), 0.0);
+    // specular shading
+    vec3 reflectDir = reflect(-lightDir, normal);
+    float spec = pow(max(dot(viewDir, reflectDir), 0.0), material.shininess);
+    // attenuation
+    float distance = length(light.position - fragPos);
+    float attenuation = 1.0 / (light.constant + light.linear * distance + light.quadratic * (distance * distance));
+    // spotlight intensity
+    float theta = dot(lightDir, normalize(-light.direction));
+    float epsilon = light.cutOff - light.outerCutOff;
+    float intensity = clamp((theta - light.outerCutOff) / epsilon, 0.0, 1.0);
+    // combine results
+    vec3 ambient = light.ambient * color;
+    vec3 diffuse = light.diffuse * diff * color;
+    vec3 specular = light.specular * spec * splat.specular * color;
+    return (ambient + diffuse + specular) * attenuation * intensity;
+}
@@ -0,0 +1,180 @@
+# version 410
+out vec4 FragColor;
+
+struct Material {
+    float shininess;
+};
+
+struct Water {
+    // The scene above and under the water level.
+    sampler2D reflection;
+    sampler2D refraction;
+    // The x and z gradients and the normal vectors of the waves.
+    sampler2D dudv;
+    sampler2D normal;
+    float waveStrength;
+    // The wave maps are scrolled with this speed (map size / second).
+    float waveSpeed;
+    // The color of the water and its ratio in the result.
+    vec3 color;
+    float colorMix;
+};
+
+struct DirectionalLight {
+    vec3 direction;
+
+    vec3 ambient;
+    vec3 diffuse;
+    vec3 specular;
+};
+
+struct PointLight {
+    vec3 position;
+
+    vec3 ambient;
+    vec3 diffuse;
+    vec3 specular;
+
+    float constant;
+    float linear;
+    float quadratic;
+};
+
+struct SpotLight {
+    vec3 position;
+    vec3 direction;
+    float cutOff;
+    float outerCutOff;
+
+    vec3 ambient;
+    vec3 diffuse;
+    vec3 specular;
+
+    float constant;
+    float linear;
+    float quadratic;
+};
+
+struct Fog {
+    float minDistance;
+    float maxDistance;
+    vec3 color;
+};
+
+in vec3 FragPos;
+in vec4 ClipSpace;
+in vec2 WaveCoords;
+
+#define MAX_DIRECTION_LIGHTS 16
+#define MAX_POINT_LIGHTS 16
+#define MAX_SPOT_LIGHTS 16
+
+uniform DirectionalLight dirLight[MAX_DIRECTION_LIGHTS];
+uniform PointLight pointLight[MAX_POINT_LIGHTS];
+uniform SpotLight spotLight[MAX_SPOT_LIGHTS];
+uniform Material material;
+uniform Water water;
+uniform int NumberOfDirectionalLightSources;
+uniform int NumberOfPointLightSources;
+uniform int NumberOfSpotLightSources;
+uniform Fog fog;
+// The refraction ratio of the air and the liquid.
+uniform float Eta;
+uniform float time;
+
+uniform vec3 viewPosition;
+
+const float FresnelPower = 5.0;
+
+// function prototypes
+vec3 CalculateDirectionalLight(DirectionalLight light, vec3 normal, vec3 viewDir);
+vec3 CalculatePointLight(PointLight light, vec3 normal, vec3 fragPos, vec3 viewDir);
+vec3 CalculateSpotLight(SpotLight light, vec3 normal, vec3 fragPos, vec3 viewDir);
+
+void main()
+{
+    // The wave maps are repeated. Two layers are scrolled to different directions.
+    float move = water.waveSpeed * time;
+    vec2 coords1 = WaveCoords + vec2(move, 0.0);
+    vec2 coords2 = vec2(-WaveCoords.x, WaveCoords.y + move);
+    vec2 distortion = ((texture(water.dudv, coords1).rg * 2.0 - 1.0) +
+        (texture(water.dudv, coords2).rg * 2.0 - 1.0)) * water.waveStrength;
+
+    // The up direction of the surface is the side of the camera.
+    float up = viewPosition.y < FragPos.y ? -1.0 : 1.0;
+    vec3 waveNormal = texture(water.normal, coords1).rgb + texture(water.normal, coords2).rgb;
+    vec3 norm = normalize(vec3(waveNormal.r - 1.0, up * waveNormal.b, waveNormal.g - 1.0));
+    vec3 viewDirection = normalize(viewPosition - FragPos);
+
+    // The reflection is rendered with the mirrored camera, so that both textures
+    // are sampled in the screen coordinates of the surface. The refraction is
+    // distorted more with the bigger difference of the refraction indices.
+    vec2 screenCoords = (ClipSpace.xy / ClipSpace.w) * 0.5 + 0.5;
+    float eta = max(Eta, 0.0001);
+    vec2 reflectCoords = clamp(screenCoords + distortion, 0.001, 0.999);
+    vec2 refractCoords = clamp(screenCoords + distortion * abs(1.0 - eta) / eta, 0.001, 0.999);
+    vec3 reflection = texture(water.reflection, reflectCoords).rgb;
+    vec3 refraction = texture(water.refraction, refractCoords).rgb;
+
+    float F = ((1.0-eta) * (1.0-eta)) / ((1.0+eta) * (1.0+eta));
+    float Ratio = F + (1.0 - F) * pow(1.0 - max(dot(viewDirection, norm), 0.0), FresnelPower);
+    vec3 result = mix(refraction, reflection, clamp(Ratio, 0.0, 1.0));
+    result = mix(result, water.color, water.colorMix);
+
+    // The specular highlights of the lights on the waves.
+    int nrDirLight = min(NumberOfDirectionalLightSources, MAX_DIRECTION_LIGHTS);
+    for (int i = 0; i < nrDirLight; i++) {
+        result += CalculateDirectionalLight(dirLight[i], norm, viewDirection);
+    }
+    int nrPointLight = min(NumberOfPointLightSources, MAX_POINT_LIGHTS);
+    for (int i = 0; i < nrPointLight; i++) {
+        result += CalculatePointLight(pointLight[i], norm, FragPos, viewDirection);
+    }
+    int nrSpotLight = min(NumberOfSpotLightSources, MAX_SPOT_LIGHTS);
+    for (int i = 0; i < nrSpotLight; i++) {
+        result += CalculateSpotLight(spotLight[i], norm, FragPos, viewDirection);
+    }
+    if (fog.minDistance > 0 && fog.maxDistance > 0) {
+        float distance = length(viewPosition - FragPos);
+        float fogFactor = (fog.maxDistance - distance) / (fog.maxDistance - fog.minDistance);
+        fogFactor = clamp(fogFactor, 0.0, 1.0);
+        result = mix(fog.color, result, fogFactor);
+    }
+    FragColor = vec4(result, 1.0);
+}
+
+// calculates the specular highlight of a directional light.
+vec3 CalculateDirectionalLight(DirectionalLight light, vec3 normal, vec3 viewDir)
+{
+    vec3 lightDir = normalize(-light.direction);
+    vec3 reflectDir = reflect(-lightDir, normal);
+    float spec = pow(max(dot(viewDir, reflectDir), 0.0), material.shininess);
+    return light.specular * spec;
+}
+// calculates the specular highlight of a point light.
+vec3 CalculatePointLight(PointLight light, vec3 normal, vec3 fragPos, vec3 viewDir)
+{
+    vec3 lightDir = normalize(light.position - fragPos);
+    vec3 reflectDir = reflect(-lightDir, normal);
+    float spec = pow(max(dot(viewDir, reflectDir), 0.0), material.shininess);
+    // attenuation
+    float distance = length(light.position - fragPos);
+    float attenuation = 1.0 / (light.constant + light.linear * distance + light.quadratic * (distance * distance));
+    return light.specular * spec * attenuation;
+}
+
+// calculates the specular highlight of a spot light.
+vec3 CalculateSpotLight(SpotLight light, vec3 normal, vec3 fragPos, vec3 viewDir)
+{
+    vec3 lightDir = normalize(light.position - fragPos);
+    vec3 reflectDir = reflect(-lightDir, normal);
+    float spec = pow(max(dot(viewDir, reflectDir), 0.0), material.shininess);
+    // attenuation
+    float distance = length(light.position - fragPos);
+    float attenuation = 1.0 / (light.constant + light.linear * distance + light.quadratic * (distance * distance));
+    // spotlight intensity
+    float theta = dot(lightDir, normalize(-light.direction));
+    float epsilon = light.cutOff - light.outerCutOff;
+    float intensity = clamp((theta - light.outerCutOff) / epsilon, 0.0, 1.0);
+    return light.specular * spec * attenuation * intensity;
+}
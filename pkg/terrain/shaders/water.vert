@@ -0,0 +1,37 @@
+# version 410
+layout(location = 0) in vec3 vVertex;
+layout(location = 1) in vec3 vNormal;
+layout(location = 2) in vec2 vTexCoord;
+
+out vec3 FragPos;
+// The clip space position of the surface, the reflection and the refraction
+// textures are sampled with its screen coordinates.
+out vec4 ClipSpace;
+out vec2 WaveCoords;
+
+struct Water {
+    // The size of the wave maps in world units.
+    float tiling;
+};
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+uniform float time;
+uniform float amplitude;
+uniform float frequency;
+uniform Water water;
+
+const float PI = 3.14159;
+
+void main()
+{
+    // The vertices of the liquid mesh are on the bottom, the surface is on the
+    // water level, that is the position of the mesh.
+    FragPos = vec3(model * vec4(vVertex.x, 0.0, vVertex.z, 1.0));
+    WaveCoords = FragPos.xz / max(water.tiling, 0.0001);
+    // The sine waves of the liquid shader of the engine.
+    FragPos.y += amplitude * sin(-PI * length(FragPos) * frequency + time);
+    ClipSpace = projection * view * vec4(FragPos, 1.0);
+    gl_Position = ClipSpace;
+}
@@ -0,0 +1,108 @@
+package terrain
+
+import (
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path"
+	"runtime"
+
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/model"
+	"github.com/akosgarai/playground_engine/pkg/shader"
+)
+
+const (
+	// The default parameters of the texture splatting. The levels are
+	// normalized heights, the 0 is the min, the 1 is the max height.
+	DefaultGrassLevel  = float32(0.15)
+	DefaultRockLevel   = float32(0.6)
+	DefaultSnowLevel   = float32(0.85)
+	DefaultHeightBlend = float32(0.05)
+	DefaultSlopeLevel  = float32(0.45)
+	DefaultSlopeBlend  = float32(0.1)
+	DefaultTiling      = float32(4.0)
+	DefaultSpecular    = float32(0.1)
+)
+
+func baseDir() string {
+	_, filename, _, _ := runtime.Caller(1)
+	return path.Dir(filename)
+}
+
+// NewSplatShader returns the shader of the splatted terrains. It supports the
+// directional, point and spot light uniforms and the fog of the texture
+// blending with fog shader of the engine.
+func NewSplatShader(wrapper interfaces.GLWrapper) *shader.Shader {
+	return shader.NewShader(baseDir()+"/shaders/splat.vert", baseDir()+"/shaders/splat.frag", wrapper)
+}
+
+// Splat is the material of the terrains with sand, grass, rock and snow layers.
+// The layers are blended by the height and the slope of the surface in the
+// fragment shader. The levels are the normalized heights, where the layers
+// begin, the height blend is the half width of the transition between them.
+// The slope is 0 on the flat surfaces and 1 on the vertical ones, the surfaces
+// steeper than the slope level are covered with rock.
+type Splat struct {
+	GrassLevel  float32
+	RockLevel   float32
+	SnowLevel   float32
+	HeightBlend float32
+	SlopeLevel  float32
+	SlopeBlend  float32
+	// The layer textures are repeated once in every Tiling tiles.
+	Tiling   float32
+	Specular float32
+}
+
+// NewSplat returns a splat material with the default parameters.
+func NewSplat() *Splat {
+	return &Splat{
+		GrassLevel:  DefaultGrassLevel,
+		RockLevel:   DefaultRockLevel,
+		SnowLevel:   DefaultSnowLevel,
+		HeightBlend: DefaultHeightBlend,
+		SlopeLevel:  DefaultSlopeLevel,
+		SlopeBlend:  DefaultSlopeBlend,
+		Tiling:      DefaultTiling,
+		Specular:    DefaultSpecular,
+	}
+}
+
+// SetUniforms sets the parameters of the splatting to the uniforms of the model.
+func (s *Splat) SetUniforms(m *model.BaseModel) {
+	m.SetUniformFloat("splat.grassLevel", s.GrassLevel)
+	m.SetUniformFloat("splat.rockLevel", s.RockLevel)
+	m.SetUniformFloat("splat.snowLevel", s.SnowLevel)
+	m.SetUniformFloat("splat.heightBlend", s.HeightBlend)
+	m.SetUniformFloat("splat.slopeLevel", s.SlopeLevel)
+	m.SetUniformFloat("splat.slopeBlend", s.SlopeBlend)
+	m.SetUniformFloat("splat.tiling", s.Tiling)
+	m.SetUniformFloat("splat.specular", s.Specular)
+}
+
+// LoadSplatMapImage returns the decoded png splat map. Its red, green, blue and
+// alpha channels are the weights of the sand, grass, rock and snow layers.
+func LoadSplatMapImage(filename string) (*image.RGBA, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba, nil
+}
+
+// addSplatTextures loads the layer textures from the directory.
+func (t *Builder) addSplatTextures(directory string) {
+	for _, layer := range []string{"sand", "grass", "rock", "snow"} {
+		t.tex.AddTexture(directory+"/"+layer+".jpg", glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "splat."+layer, t.wrapper)
+	}
+}
@@ -0,0 +1,264 @@
+package terrain
+
+import (
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/mesh"
+	"github.com/akosgarai/playground_engine/pkg/model"
+	"github.com/akosgarai/playground_engine/pkg/primitives/vertex"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+
+	"github.com/akosgarai/coldet"
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// The ray of the RayIntersection is marched with this ratio of the tile size.
+	rayStepRatio = float32(0.5)
+	// The number of the bisection steps of the ray intersection point.
+	rayRefineSteps = 16
+)
+
+// TerrainMesh is the textured mesh of the terrain. It has its own vertex buffer,
+// so that the modified vertices could be uploaded without rebuilding the mesh.
+// The embedded engine mesh is created without its gl setup, so that the vertices
+// are uploaded only once.
+type TerrainMesh struct {
+	*mesh.TexturedMesh
+	vao     uint32
+	vbo     uint32
+	ebo     uint32
+	wrapper interfaces.GLWrapper
+}
+
+// newTerrainMesh returns the terrain mesh with the given vertices, indices and
+// textures. The buffer is the vertex buffer content of the vertices, so that it
+// could be calculated out of the main thread.
+func newTerrainMesh(v vertex.Vertices, buffer []float32, i []uint32, t texture.Textures, wrapper interfaces.GLWrapper) *TerrainMesh {
+	m := &TerrainMesh{
+		TexturedMesh: &mesh.TexturedMesh{
+			Mesh:     mesh.Mesh{Vertices: v},
+			Indices:  i,
+			Textures: t,
+		},
+		wrapper: wrapper,
+	}
+	m.SetScale(mgl32.Vec3{1, 1, 1})
+	m.vao = m.wrapper.GenVertexArrays()
+	m.vbo = m.wrapper.GenBuffers()
+	m.ebo = m.wrapper.GenBuffers()
+
+	m.wrapper.BindVertexArray(m.vao)
+
+	m.wrapper.BindBuffer(glwrapper.ARRAY_BUFFER, m.vbo)
+	m.wrapper.ArrayBufferData(buffer)
+
+	m.wrapper.BindBuffer(glwrapper.ELEMENT_ARRAY_BUFFER, m.ebo)
+	m.wrapper.ElementBufferData(m.Indices)
+
+	// setup coordinates
+	m.wrapper.VertexAttribPointer(0, 3, glwrapper.FLOAT, false, 4*chunkVertexSize, m.wrapper.PtrOffset(0))
+	// setup normals
+	m.wrapper.VertexAttribPointer(1, 3, glwrapper.FLOAT, false, 4*chunkVertexSize, m.wrapper.PtrOffset(4*3))
+	// setup texture position
+	m.wrapper.VertexAttribPointer(2, 2, glwrapper.FLOAT, false, 4*chunkVertexSize, m.wrapper.PtrOffset(4*6))
+
+	// close
+	m.wrapper.BindVertexArray(0)
+	return m
+}
+
+// Delete frees the vertex array and the buffers of the mesh. The mesh can't be
+// drawn after it. The textures are not deleted, they could be shared.
+func (m *TerrainMesh) Delete() {
+	gl.DeleteVertexArrays(1, &m.vao)
+	buffers := []uint32{m.vbo, m.ebo}
+	gl.DeleteBuffers(int32(len(buffers)), &buffers[0])
+	m.vao, m.vbo, m.ebo = 0, 0, 0
+}
+
+// updateVertices uploads the [first, last) vertices to the vertex buffer.
+func (m *TerrainMesh) updateVertices(first, last int) {
+	if first < 0 {
+		first = 0
+	}
+	if last > len(m.Vertices) {
+		last = len(m.Vertices)
+	}
+	if first >= last {
+		return
+	}
+	buffer := m.Vertices[first:last].Get(vertex.POSITION_NORMAL_TEXCOORD)
+	m.wrapper.BindBuffer(glwrapper.ARRAY_BUFFER, m.vbo)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 4*chunkVertexSize*first, 4*len(buffer), gl.Ptr(buffer))
+	m.wrapper.BindBuffer(glwrapper.ARRAY_BUFFER, 0)
+}
+
+// Draw function is responsible for the actual drawing. It binds the textures,
+// sets up the model uniform and the shininess, then it draws the mesh with
+// triangles. Finally it cleans up.
+func (m *TerrainMesh) Draw(shader interfaces.Shader) {
+	for _, item := range m.Textures {
+		item.Bind()
+		shader.SetUniform1i(item.UniformName, int32(item.Id-glwrapper.TEXTURE0))
+	}
+	M := m.ModelTransformation()
+	shader.SetUniformMat4("model", M)
+	shader.SetUniform1f("material.shininess", float32(32))
+	m.wrapper.BindVertexArray(m.vao)
+	m.wrapper.DrawTriangleElements(int32(len(m.Indices)))
+
+	m.Textures.UnBind()
+	m.wrapper.BindVertexArray(0)
+	m.wrapper.ActiveTexture(0)
+}
+
+// Terrain is a model with one textured mesh, that is generated from a height map.
+type Terrain struct {
+	*model.BaseModel
+	heightMap HeightMap
+	mesh      *TerrainMesh
+}
+
+// GetTerrain returns the mesh of the terrain.
+func (t *Terrain) GetTerrain() interfaces.Mesh {
+	msh, _ := t.GetMeshByIndex(0)
+	return msh
+}
+
+// GetHeightMap returns the height map of the terrain.
+func (t *Terrain) GetHeightMap() HeightMap {
+	return t.heightMap
+}
+
+// SetTextures sets the textures of the terrain mesh.
+func (t *Terrain) SetTextures(tex texture.Textures) {
+	t.mesh.Textures = tex
+}
+
+// Delete frees the gl objects of the terrain mesh. The terrain can't be drawn
+// after it, but its height map is still available.
+func (t *Terrain) Delete() {
+	t.mesh.Delete()
+}
+
+// toGrid returns the grid coordinates of the world position. The second return
+// value is false if the position is not above or under the terrain.
+func (t *Terrain) toGrid(pos mgl32.Vec3) (float32, float32, bool) {
+	tMesh := t.GetTerrain()
+	scaleTr := tMesh.ScaleTransformation()
+	local := pos.Sub(tMesh.GetPosition())
+	x := local.X()/scaleTr[0] + float32(t.heightMap.Width())/2.0
+	z := local.Z()/scaleTr[10] + float32(t.heightMap.Length())/2.0
+	if x < 0 || x > float32(t.heightMap.Width()) || z < 0 || z > float32(t.heightMap.Length()) {
+		return 0, 0, false
+	}
+	return x, z, true
+}
+
+// HeightAtPos returns the height of the surface at the given world position, and nil.
+// The position and the scale of the terrain mesh is applied to the height. In case of
+// the position is not under or above the surface, it returns -1 and error.
+func (t *Terrain) HeightAtPos(pos mgl32.Vec3) (float32, error) {
+	x, z, ok := t.toGrid(pos)
+	if !ok {
+		return -1, model.ErrorNotAboveTheSurface
+	}
+	tMesh := t.GetTerrain()
+	return tMesh.GetPosition().Y() + t.heightMap.Sample(x, z)*tMesh.ScaleTransformation()[5], nil
+}
+
+// RayIntersection returns the first intersection point of the ray and the surface
+// within the max distance from the origin. The ray is marched with the half of
+// the tile size, then the intersection is refined with bisection. The bumps that
+// are thinner than the step could be missed. The second return value is false,
+// if the ray doesn't hit the surface.
+func (t *Terrain) RayIntersection(origin, direction mgl32.Vec3, maxDistance float32) (mgl32.Vec3, bool) {
+	if direction.Len() == 0 {
+		return mgl32.Vec3{}, false
+	}
+	direction = direction.Normalize()
+	scaleTr := t.GetTerrain().ScaleTransformation()
+	step := rayStepRatio * scaleTr[0]
+	if scaleTr[10] < scaleTr[0] {
+		step = rayStepRatio * scaleTr[10]
+	}
+	// above returns the distance of the point from the surface in the Y
+	// direction. The second return value is false out of the terrain.
+	above := func(d float32) (float32, bool) {
+		p := origin.Add(direction.Mul(d))
+		h, err := t.HeightAtPos(p)
+		return p.Y() - h, err == nil
+	}
+	prevDistance, prevDiff, prevOk := float32(0), float32(0), false
+	for d := float32(0); d <= maxDistance+step; d += step {
+		if d > maxDistance {
+			d = maxDistance
+		}
+		diff, ok := above(d)
+		if ok && prevOk && (diff <= 0) != (prevDiff <= 0) {
+			near, far := prevDistance, d
+			for i := 0; i < rayRefineSteps; i++ {
+				middle := (near + far) / 2
+				middleDiff, _ := above(middle)
+				if (middleDiff <= 0) == (prevDiff <= 0) {
+					near = middle
+				} else {
+					far = middle
+				}
+			}
+			return origin.Add(direction.Mul((near + far) / 2)), true
+		}
+		prevDistance, prevDiff, prevOk = d, diff, ok
+		if d == maxDistance {
+			break
+		}
+	}
+	return mgl32.Vec3{}, false
+}
+
+// CollideTestWithSphere is the collision detection function for heightmap vs sphere.
+func (t *Terrain) CollideTestWithSphere(boundingSphere *coldet.Sphere) bool {
+	height, err := t.HeightAtPos(mgl32.Vec3{boundingSphere.X(), boundingSphere.Y(), boundingSphere.Z()})
+	if err != nil {
+		return false
+	}
+	boundingPoint := coldet.NewBoundingPoint([3]float32{boundingSphere.X(), height, boundingSphere.Z()})
+	return coldet.CheckPointInSphere(*boundingPoint, *boundingSphere)
+}
+
+// Update function does nothing.
+func (t *Terrain) Update(dt float64) {
+}
+
+// Liquid is a transparent model with one textured mesh, that covers the
+// terrain on the water level. It has to be drawn with the liquid shader.
+type Liquid struct {
+	*model.BaseModel
+	heightMap HeightMap
+	// The liquid is not drawn into its own reflection and refraction.
+	hidden bool
+}
+
+// GetLiquid returns the mesh of the liquid.
+func (l *Liquid) GetLiquid() interfaces.Mesh {
+	msh, _ := l.GetMeshByIndex(0)
+	return msh
+}
+
+// Draw function draws the liquid, if it's not hidden.
+func (l *Liquid) Draw(shader interfaces.Shader) {
+	if !l.hidden {
+		l.BaseModel.Draw(shader)
+	}
+}
+
+// CollideTestWithSphere is the collision detection function for liquid vs sphere.
+func (l *Liquid) CollideTestWithSphere(boundingSphere *coldet.Sphere) bool {
+	return false
+}
+
+// Update function does nothing.
+func (l *Liquid) Update(dt float64) {
+}
@@ -0,0 +1,236 @@
+package terrain
+
+import (
+	"math"
+
+	"github.com/akosgarai/playground_engine/pkg/camera"
+
+	"github.com/akosgarai/coldet"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// The default settings of the walk camera.
+	DefaultEyeHeight = float32(0.5)
+	DefaultMaxSlope  = float32(1.0)
+	// The radius of the bounding sphere of the camera, it's the same as the
+	// radius of the engine camera.
+	walkCameraRadius = float32(0.1)
+)
+
+// Surface is the ground of the walk camera. Both the Terrain and the
+// ChunkedTerrain are surfaces.
+type Surface interface {
+	HeightAtPos(pos mgl32.Vec3) (float32, error)
+}
+
+// WalkCamera is an FPS camera with walk mode. In walk mode it moves only in the
+// horizontal plane, and its eye is kept at eye height above the surface. It
+// doesn't walk up to slopes that are steeper than the max slope, and it doesn't
+// walk into water that is deeper than the max water depth. Without walk mode
+// it flies like the FPS camera of the engine.
+type WalkCamera struct {
+	*camera.FPSCamera
+	surface   Surface
+	walking   bool
+	eyeHeight float32
+	// It's 1 if the +Y, -1 if the -Y is the up direction of the world.
+	up       float32
+	maxSlope float32
+	// The water is checked only if it's set.
+	hasWater      bool
+	waterLevel    float32
+	maxWaterDepth float32
+}
+
+// NewWalkCamera returns a walk camera, that walks on the given surface. The
+// walk mode is turned on, the up direction is the +Y.
+func NewWalkCamera(cam *camera.FPSCamera, surface Surface) *WalkCamera {
+	return &WalkCamera{
+		FPSCamera: cam,
+		surface:   surface,
+		walking:   true,
+		eyeHeight: DefaultEyeHeight,
+		up:        1.0,
+		maxSlope:  DefaultMaxSlope,
+	}
+}
+
+// SetWalking turns the walk mode on or off.
+func (c *WalkCamera) SetWalking(w bool) {
+	c.walking = w
+}
+
+// IsWalking returns true if the walk mode is on.
+func (c *WalkCamera) IsWalking() bool {
+	return c.walking
+}
+
+// SetSurface sets the surface of the walk mode.
+func (c *WalkCamera) SetSurface(s Surface) {
+	c.surface = s
+}
+
+// SetEyeHeight sets the distance of the eye from the surface.
+func (c *WalkCamera) SetEyeHeight(h float32) {
+	c.eyeHeight = h
+}
+
+// SetUpNegativeY sets the up direction of the world to the -Y, if the flag is set.
+func (c *WalkCamera) SetUpNegativeY(f bool) {
+	c.up = 1.0
+	if f {
+		c.up = -1.0
+	}
+}
+
+// SetMaxSlope sets the maximal slope (the height difference in unit horizontal
+// distance), that the camera could walk up to. The downhill is not limited.
+func (c *WalkCamera) SetMaxSlope(s float32) {
+	c.maxSlope = s
+}
+
+// SetWater sets the water level (in world Y) and the maximal depth of the
+// water, that the camera could walk into.
+func (c *WalkCamera) SetWater(level, maxDepth float32) {
+	c.hasWater = true
+	c.waterLevel = level
+	c.maxWaterDepth = maxDepth
+}
+
+// RemoveWater turns off the water check.
+func (c *WalkCamera) RemoveWater() {
+	c.hasWater = false
+}
+
+// eyePosition returns the eye position above the ground at the horizontal
+// position. The second return value is false, if the surface is not available.
+func (c *WalkCamera) eyePosition(pos mgl32.Vec3) (mgl32.Vec3, float32, bool) {
+	if c.surface == nil {
+		return pos, 0, false
+	}
+	ground, err := c.surface.HeightAtPos(pos)
+	if err != nil {
+		return pos, 0, false
+	}
+	return mgl32.Vec3{pos.X(), ground + c.up*c.eyeHeight, pos.Z()}, ground, true
+}
+
+// target returns the position of the camera after the horizontal movement with
+// the given offset. The second return value is false, if the movement is not
+// allowed. Outside the surface the camera moves horizontally in its height.
+func (c *WalkCamera) target(offset mgl32.Vec3) (mgl32.Vec3, bool) {
+	current := c.GetPosition()
+	offset = mgl32.Vec3{offset.X(), 0, offset.Z()}
+	next := current.Add(offset)
+	eye, ground, ok := c.eyePosition(next)
+	if !ok {
+		return next, true
+	}
+	if c.hasWater && c.up*(c.waterLevel-ground) > c.maxWaterDepth {
+		return current, false
+	}
+	if _, currentGround, onSurface := c.eyePosition(current); onSurface {
+		distance := offset.Len()
+		if distance > 0 && c.up*(ground-currentGround)/distance > c.maxSlope {
+			return current, false
+		}
+	}
+	return eye, true
+}
+
+// horizontal returns the horizontal part of the direction with the given length.
+func horizontal(direction mgl32.Vec3, length float32) mgl32.Vec3 {
+	h := mgl32.Vec3{direction.X(), 0, direction.Z()}
+	if h.Len() == 0 {
+		return h
+	}
+	return h.Normalize().Mul(float32(math.Abs(float64(length))))
+}
+
+// walkOffset returns the horizontal offset of the walk. The length of the step
+// doesn't depend on the pitch of the camera.
+func (c *WalkCamera) walkOffset(amount float32) mgl32.Vec3 {
+	sphere := c.FPSCamera.BoundingObjectAfterWalk(amount)
+	return horizontal(mgl32.Vec3{sphere.X(), sphere.Y(), sphere.Z()}.Sub(c.GetPosition()), amount)
+}
+
+// strafeOffset returns the horizontal offset of the strafe.
+func (c *WalkCamera) strafeOffset(amount float32) mgl32.Vec3 {
+	sphere := c.FPSCamera.BoundingObjectAfterStrafe(amount)
+	return horizontal(mgl32.Vec3{sphere.X(), sphere.Y(), sphere.Z()}.Sub(c.GetPosition()), amount)
+}
+
+func boundingSphere(p mgl32.Vec3) *coldet.Sphere {
+	return coldet.NewBoundingSphere([3]float32{p.X(), p.Y(), p.Z()}, walkCameraRadius)
+}
+
+// BoundingObjectAfterWalk returns the bounding object of the new position. In
+// walk mode it's above the surface, so that the terrain doesn't block the walk.
+func (c *WalkCamera) BoundingObjectAfterWalk(amount float32) *coldet.Sphere {
+	if !c.walking {
+		return c.FPSCamera.BoundingObjectAfterWalk(amount)
+	}
+	next, _ := c.target(c.walkOffset(amount))
+	return boundingSphere(next)
+}
+
+// BoundingObjectAfterStrafe returns the bounding object of the new position.
+func (c *WalkCamera) BoundingObjectAfterStrafe(amount float32) *coldet.Sphere {
+	if !c.walking {
+		return c.FPSCamera.BoundingObjectAfterStrafe(amount)
+	}
+	next, _ := c.target(c.strafeOffset(amount))
+	return boundingSphere(next)
+}
+
+// BoundingObjectAfterLift returns the bounding object of the new position. In
+// walk mode the camera doesn't lift.
+func (c *WalkCamera) BoundingObjectAfterLift(amount float32) *coldet.Sphere {
+	if !c.walking {
+		return c.FPSCamera.BoundingObjectAfterLift(amount)
+	}
+	return c.GetBoundingObject()
+}
+
+// Walk updates the position (forward, back directions). In walk mode the step
+// is horizontal, and the eye follows the surface.
+func (c *WalkCamera) Walk(amount float32) {
+	if !c.walking {
+		c.FPSCamera.Walk(amount)
+		return
+	}
+	if next, ok := c.target(c.walkOffset(amount)); ok {
+		c.SetPosition(next)
+	}
+}
+
+// Strafe updates the position (left, right directions).
+func (c *WalkCamera) Strafe(amount float32) {
+	if !c.walking {
+		c.FPSCamera.Strafe(amount)
+		return
+	}
+	if next, ok := c.target(c.strafeOffset(amount)); ok {
+		c.SetPosition(next)
+	}
+}
+
+// Lift updates the position (up, down directions). In walk mode it does nothing.
+func (c *WalkCamera) Lift(amount float32) {
+	if !c.walking {
+		c.FPSCamera.Lift(amount)
+	}
+}
+
+// Follow moves the eye to the eye height above the surface in walk mode. It
+// has to be called when the surface is changed under the camera, eg. after the
+// chunk of the camera is loaded.
+func (c *WalkCamera) Follow() {
+	if !c.walking {
+		return
+	}
+	if eye, _, ok := c.eyePosition(c.GetPosition()); ok {
+		c.SetPosition(eye)
+	}
+}
@@ -0,0 +1,341 @@
+package terrain
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/mesh"
+	"github.com/akosgarai/playground_engine/pkg/shader"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// The default parameters of the water rendering.
+	DefaultWaveStrength = float32(0.02)
+	DefaultWaveSpeed    = float32(0.03)
+	DefaultWaveTiling   = float32(2.0)
+	DefaultWaterColor   = float32(0.2)
+	DefaultWaveMapSize  = 128
+	// The clip plane is moved with this distance, so that the shore doesn't have gaps.
+	clipOffset = float32(0.02)
+	// The number of the sine waves of the wave maps.
+	waveCount = 8
+)
+
+// The render passes of the water camera.
+const (
+	passScene = iota
+	passReflection
+	passRefraction
+)
+
+// NewWaterShader returns the shader of the liquids with reflection and refraction.
+// It supports the directional, point and spot light uniforms and the fog of the
+// texture blending with fog shader of the engine.
+func NewWaterShader(wrapper interfaces.GLWrapper) *shader.Shader {
+	return shader.NewShader(baseDir()+"/shaders/water.vert", baseDir()+"/shaders/water.frag", wrapper)
+}
+
+// WaterCamera is the camera of the screen, that is mirrored to the water plane
+// in the reflection pass. In the reflection and the refraction passes its near
+// plane is replaced with the water plane, so that the scene is clipped on the
+// water level with every shader.
+type WaterCamera struct {
+	interfaces.Camera
+	pass  int
+	level float32
+}
+
+// reflection returns the transformation, that mirrors the world to the water plane.
+func (c *WaterCamera) reflection() mgl32.Mat4 {
+	return mgl32.Translate3D(0, c.level, 0).Mul4(mgl32.Scale3D(1, -1, 1)).Mul4(mgl32.Translate3D(0, -c.level, 0))
+}
+
+// GetViewMatrix returns the view matrix of the current pass.
+func (c *WaterCamera) GetViewMatrix() mgl32.Mat4 {
+	view := c.Camera.GetViewMatrix()
+	if c.pass == passReflection {
+		return view.Mul4(c.reflection())
+	}
+	return view
+}
+
+// GetPosition returns the position of the camera. In the reflection pass it's
+// mirrored to the water plane.
+func (c *WaterCamera) GetPosition() mgl32.Vec3 {
+	position := c.Camera.GetPosition()
+	if c.pass == passReflection {
+		return mgl32.Vec3{position.X(), 2*c.level - position.Y(), position.Z()}
+	}
+	return position
+}
+
+// GetProjectionMatrix returns the projection matrix of the current pass. The
+// reflection keeps the side of the camera, the refraction keeps the other side
+// of the water plane.
+func (c *WaterCamera) GetProjectionMatrix() mgl32.Mat4 {
+	projection := c.Camera.GetProjectionMatrix()
+	if c.pass == passScene {
+		return projection
+	}
+	side := float32(1.0)
+	if c.Camera.GetPosition().Y() < c.level {
+		side = -1.0
+	}
+	if c.pass == passRefraction {
+		side = -side
+	}
+	plane := mgl32.Vec4{0, side, 0, -side*c.level + clipOffset}
+	return obliqueProjection(projection, c.GetViewMatrix(), plane)
+}
+
+// obliqueProjection returns the projection, where the near plane is replaced with
+// the world space clip plane. The points on the positive side of the plane are
+// kept. The camera has to be on the negative side.
+func obliqueProjection(projection, view mgl32.Mat4, plane mgl32.Vec4) mgl32.Mat4 {
+	clip := view.Inv().Transpose().Mul4x1(plane)
+	sign := func(f float32) float32 {
+		if f < 0 {
+			return -1
+		}
+		return 1
+	}
+	q := projection.Inv().Mul4x1(mgl32.Vec4{sign(clip.X()), sign(clip.Y()), 1, 1})
+	c := clip.Mul(2.0 / clip.Dot(q))
+	projection.SetRow(2, c.Sub(projection.Row(3)))
+	return projection
+}
+
+// framebuffer is a render target with color texture and depth buffer.
+type framebuffer struct {
+	fbo     uint32
+	texture uint32
+	depth   uint32
+}
+
+func newFramebuffer(width, height int32) *framebuffer {
+	f := &framebuffer{}
+	gl.GenFramebuffers(1, &f.fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.fbo)
+
+	gl.GenTextures(1, &f.texture)
+	gl.BindTexture(gl.TEXTURE_2D, f.texture)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, width, height, 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, f.texture, 0)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	gl.GenRenderbuffers(1, &f.depth)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, f.depth)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT24, width, height)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, f.depth)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	return f
+}
+
+// delete frees the framebuffer, its texture and its depth buffer.
+func (f *framebuffer) delete() {
+	gl.DeleteFramebuffers(1, &f.fbo)
+	gl.DeleteTextures(1, &f.texture)
+	gl.DeleteRenderbuffers(1, &f.depth)
+}
+
+// Water renders the reflection and the refraction of the scene into textures,
+// then the liquid is drawn with them with the water shader. The textures are
+// distorted with a scrolling dudv map, the reflection and the refraction are
+// combined with the fresnel term of the eta of the liquid. The Render function
+// has to be called before the draw of the screen in every frame.
+type Water struct {
+	liquid                 *Liquid
+	camera                 *WaterCamera
+	reflection, refraction *framebuffer
+	width, height          int32
+	wrapper                interfaces.GLWrapper
+}
+
+// NewWater returns the water renderer of the liquid. The camera is the camera of
+// the screen, the GetCamera function returns its wrapper, that has to be set to
+// the screen instead of it. The render targets have the given size, that has
+// to be the framebuffer size of the window. On HiDPI displays it's bigger than
+// the window size. The wave maps are generated with the seed.
+func NewWater(l *Liquid, cam interfaces.Camera, width, height int, seed int64, wrapper interfaces.GLWrapper) *Water {
+	w := &Water{
+		liquid: l,
+		camera: &WaterCamera{
+			Camera: cam,
+			pass:   passScene,
+			level:  l.GetLiquid().GetPosition().Y(),
+		},
+		reflection: newFramebuffer(int32(width), int32(height)),
+		refraction: newFramebuffer(int32(width), int32(height)),
+		width:      int32(width),
+		height:     int32(height),
+		wrapper:    wrapper,
+	}
+	liquidMesh := l.GetLiquid().(*mesh.TexturedMesh)
+	w.addTexture(liquidMesh, w.reflection.texture, "water.reflection")
+	w.addTexture(liquidMesh, w.refraction.texture, "water.refraction")
+	dudv, normal := waveMaps(DefaultWaveMapSize, seed)
+	liquidMesh.Textures.AddTextureRGBA("", dudv, glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "water.dudv", wrapper)
+	liquidMesh.Textures.AddTextureRGBA("", normal, glwrapper.CLAMP_TO_EDGE, glwrapper.CLAMP_TO_EDGE, glwrapper.LINEAR, glwrapper.LINEAR, "water.normal", wrapper)
+	// The wave maps are tileable, they are repeated on the surface.
+	for _, t := range liquidMesh.Textures[len(liquidMesh.Textures)-2:] {
+		t.Bind()
+		wrapper.TexParameteri(glwrapper.TEXTURE_2D, glwrapper.TEXTURE_WRAP_S, gl.REPEAT)
+		wrapper.TexParameteri(glwrapper.TEXTURE_2D, glwrapper.TEXTURE_WRAP_T, gl.REPEAT)
+		t.UnBind()
+	}
+	w.SetWaveStrength(DefaultWaveStrength)
+	w.SetWaveSpeed(DefaultWaveSpeed)
+	w.SetWaveTiling(DefaultWaveTiling)
+	w.SetColor(mgl32.Vec3{0.0, 0.3, 0.4}, DefaultWaterColor)
+	return w
+}
+
+// addTexture adds the render target texture to the textures of the liquid.
+func (w *Water) addTexture(m *mesh.TexturedMesh, name uint32, uniformName string) {
+	m.Textures = append(m.Textures, &texture.Texture{
+		TextureName: name,
+		TargetId:    glwrapper.TEXTURE_2D,
+		Id:          glwrapper.TEXTURE0 + uint32(len(m.Textures)),
+		UniformName: uniformName,
+		Wrapper:     w.wrapper,
+	})
+}
+
+// SetSize recreates the render targets with the given size, if it's changed. It
+// has to be called with the framebuffer size of the window after resizing.
+func (w *Water) SetSize(width, height int) {
+	if int32(width) == w.width && int32(height) == w.height {
+		return
+	}
+	w.width, w.height = int32(width), int32(height)
+	w.reflection = w.replaceFramebuffer(w.reflection, "water.reflection")
+	w.refraction = w.replaceFramebuffer(w.refraction, "water.refraction")
+}
+
+// replaceFramebuffer deletes the render target and returns a new one with the
+// current size. Its texture replaces the old one in the textures of the liquid.
+func (w *Water) replaceFramebuffer(old *framebuffer, uniformName string) *framebuffer {
+	old.delete()
+	f := newFramebuffer(w.width, w.height)
+	for _, t := range w.liquid.GetLiquid().(*mesh.TexturedMesh).Textures {
+		if t.UniformName == uniformName {
+			t.TextureName = f.texture
+		}
+	}
+	return f
+}
+
+// Delete frees the render targets. The water mustn't be rendered after it.
+func (w *Water) Delete() {
+	w.reflection.delete()
+	w.refraction.delete()
+}
+
+// GetCamera returns the camera of the water, it has to be the camera of the screen.
+func (w *Water) GetCamera() *WaterCamera {
+	return w.camera
+}
+
+// SetWaveStrength sets the distortion of the reflection and the refraction.
+func (w *Water) SetWaveStrength(s float32) {
+	w.liquid.SetUniformFloat("water.waveStrength", s)
+}
+
+// SetWaveSpeed sets the speed of the scrolling of the wave maps (map size / second).
+func (w *Water) SetWaveSpeed(s float32) {
+	w.liquid.SetUniformFloat("water.waveSpeed", s)
+}
+
+// SetWaveTiling sets the size of the wave maps in world units.
+func (w *Water) SetWaveTiling(t float32) {
+	w.liquid.SetUniformFloat("water.tiling", t)
+}
+
+// SetColor sets the color of the water and its ratio in the final color.
+func (w *Water) SetColor(c mgl32.Vec3, ratio float32) {
+	w.liquid.SetUniformVector("water.color", c)
+	w.liquid.SetUniformFloat("water.colorMix", ratio)
+}
+
+// Render draws the screen into the reflection and the refraction textures. The
+// liquid is not drawn in these passes.
+func (w *Water) Render(scrn interfaces.Screen) {
+	w.camera.level = w.liquid.GetLiquid().GetPosition().Y()
+	w.liquid.hidden = true
+	for _, pass := range []struct {
+		pass   int
+		target *framebuffer
+	}{{passReflection, w.reflection}, {passRefraction, w.refraction}} {
+		w.camera.pass = pass.pass
+		gl.BindFramebuffer(gl.FRAMEBUFFER, pass.target.fbo)
+		w.wrapper.Viewport(0, 0, w.width, w.height)
+		w.wrapper.Clear(glwrapper.COLOR_BUFFER_BIT | glwrapper.DEPTH_BUFFER_BIT)
+		scrn.Draw(w.wrapper)
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	w.wrapper.Viewport(0, 0, w.width, w.height)
+	w.camera.pass = passScene
+	w.liquid.hidden = false
+}
+
+// waveMaps returns the dudv and the normal maps of the waves. The waves are the
+// sum of sine waves with integer frequencies, so that the maps are tileable.
+// The red and green channels of the dudv map are the x and z gradients, the
+// normal map contains the x, z and the up components of the normal vectors.
+func waveMaps(size int, seed int64) (*image.RGBA, *image.RGBA) {
+	random := rand.New(rand.NewSource(seed))
+	type wave struct {
+		fX, fZ, phase, amplitude float64
+	}
+	var waves []wave
+	for len(waves) < waveCount {
+		fX, fZ := float64(random.Intn(9)-4), float64(random.Intn(9)-4)
+		if fX == 0 && fZ == 0 {
+			continue
+		}
+		waves = append(waves, wave{fX, fZ, random.Float64() * 2 * math.Pi, 1 / math.Sqrt(fX*fX+fZ*fZ)})
+	}
+	gradients := make([][2]float64, size*size)
+	maxGradient := 0.0
+	for z := 0; z < size; z++ {
+		for x := 0; x < size; x++ {
+			u, v := float64(x)/float64(size), float64(z)/float64(size)
+			var gX, gZ float64
+			for _, w := range waves {
+				d := w.amplitude * 2 * math.Pi * math.Cos(2*math.Pi*(w.fX*u+w.fZ*v)+w.phase)
+				gX += d * w.fX
+				gZ += d * w.fZ
+			}
+			gradients[z*size+x] = [2]float64{gX, gZ}
+			maxGradient = math.Max(maxGradient, math.Max(math.Abs(gX), math.Abs(gZ)))
+		}
+	}
+	dudv := image.NewRGBA(image.Rect(0, 0, size, size))
+	normal := image.NewRGBA(image.Rect(0, 0, size, size))
+	toByte := func(f float64) uint8 {
+		return uint8(math.Round((0.5 + 0.5*f) * 255))
+	}
+	for z := 0; z < size; z++ {
+		for x := 0; x < size; x++ {
+			gX, gZ := gradients[z*size+x][0]/maxGradient, gradients[z*size+x][1]/maxGradient
+			dudv.Set(x, z, color.RGBA{toByte(gX), toByte(gZ), 0, 255})
+			n := mgl32.Vec3{float32(-gX), float32(-gZ), 2}.Normalize()
+			normal.Set(x, z, color.RGBA{toByte(float64(n.X())), toByte(float64(n.Y())), uint8(math.Round(float64(n.Z()) * 255)), 255})
+		}
+	}
+	return dudv, normal
+}
@@ -0,0 +1,306 @@
+package texturecache
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"sync"
+
+	"github.com/akosgarai/opengl_playground/pkg/glext"
+	"github.com/akosgarai/opengl_playground/pkg/texturecontainer"
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+)
+
+const (
+	DEBUG = false
+)
+
+// Key identifies a cached texture. The same image with different sampler
+// parameters is stored as a different texture.
+type Key struct {
+	FilePath            string
+	WrapR               int32
+	WrapS               int32
+	MinificationFilter  int32
+	MagnificationFilter int32
+	Options             Options
+}
+
+// Entry is a resident texture of the cache.
+type Entry struct {
+	Key
+	// The generated name of the gl texture.
+	TextureName uint32
+	Width       int
+	Height      int
+	// The format and the number of the levels of the uploaded data.
+	Format texturecontainer.Format
+	Levels int
+	// The size of the uploaded data.
+	Size int
+	// The number of the textures that use this entry.
+	References int
+}
+
+// Bytes returns the estimated gpu memory of the texture. The generated
+// mipmap levels are added as the third of the base level.
+func (e *Entry) Bytes() int {
+	if e.Options.Mipmaps && e.Levels == 1 {
+		return e.Size * 4 / 3
+	}
+	return e.Size
+}
+
+// Cache stores the uploaded textures, so that an image with the same sampler
+// parameters is uploaded only once. The entries are reference counted, the gl
+// texture is deleted when the last reference is released. The gl functions have
+// to be called from the thread of the gl context, the Contains and the Reserve
+// functions could be called from any goroutine.
+type Cache struct {
+	wrapper glext.GLWrapper
+	entries map[Key]*Entry
+	// The entries by the texture names, for the release.
+	names map[uint32]*Entry
+	mutex sync.Mutex
+}
+
+// New returns an empty cache. The wrapper has to be able to delete the textures.
+func New(wrapper glext.GLWrapper) *Cache {
+	return &Cache{
+		wrapper: wrapper,
+		entries: make(map[Key]*Entry),
+		names:   make(map[uint32]*Entry),
+	}
+}
+
+// Contains returns true if the texture with the global options is resident.
+func (c *Cache) Contains(filePath string, wrapR, wrapS, minificationFilter, magnificationFilter int32) bool {
+	return c.ContainsWithOptions(filePath, wrapR, wrapS, minificationFilter, magnificationFilter, quality)
+}
+
+// ContainsWithOptions returns true if the texture with the given options is resident.
+func (c *Cache) ContainsWithOptions(filePath string, wrapR, wrapS, minificationFilter, magnificationFilter int32, opts Options) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, ok := c.entries[Key{filePath, wrapR, wrapS, minificationFilter, magnificationFilter, opts}]
+	return ok
+}
+
+// Reserve acquires a reference of the resident texture with the global options.
+// It returns false, if the texture is not resident. The check and the reference
+// are atomic, so that the texture could not be deleted by a release between them.
+// The reserved reference has to be taken over by the AddReserved function. It
+// could be called from any goroutine.
+func (c *Cache) Reserve(filePath string, wrapR, wrapS, minificationFilter, magnificationFilter int32) bool {
+	return c.ReserveWithOptions(filePath, wrapR, wrapS, minificationFilter, magnificationFilter, quality)
+}
+
+// ReserveWithOptions is the same as the Reserve function, but the given
+// options are used instead of the global ones.
+func (c *Cache) ReserveWithOptions(filePath string, wrapR, wrapS, minificationFilter, magnificationFilter int32, opts Options) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[Key{filePath, wrapR, wrapS, minificationFilter, magnificationFilter, opts}]
+	if ok {
+		entry.References++
+	}
+	return ok
+}
+
+// AddReserved appends the reserved texture with the global options to the given
+// textures. The reference of the reservation is used by the new texture.
+func (c *Cache) AddReserved(t *texture.Textures, filePath string, wrapR, wrapS, minificationFilter, magnificationFilter int32, uniformName string) error {
+	return c.AddReservedWithOptions(t, filePath, wrapR, wrapS, minificationFilter, magnificationFilter, uniformName, quality)
+}
+
+// AddReservedWithOptions is the same as the AddReserved function, but the given
+// options are used instead of the global ones.
+func (c *Cache) AddReservedWithOptions(t *texture.Textures, filePath string, wrapR, wrapS, minificationFilter, magnificationFilter int32, uniformName string, opts Options) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[Key{filePath, wrapR, wrapS, minificationFilter, magnificationFilter, opts}]
+	if !ok {
+		return fmt.Errorf("The texture '%s' is not reserved.", filePath)
+	}
+	c.appendTexture(t, entry, uniformName)
+	return nil
+}
+
+// AddTexture appends the texture of the file to the given textures. If the
+// texture is not resident, the file (image, dds or ktx2 container) is loaded
+// and uploaded, otherwise the
+// resident texture is reused with the new uniform name. The global options
+// are applied to the texture.
+func (c *Cache) AddTexture(t *texture.Textures, filePath string, wrapR, wrapS, minificationFilter, magnificationFilter int32, uniformName string) error {
+	return c.AddTextureWithOptions(t, filePath, wrapR, wrapS, minificationFilter, magnificationFilter, uniformName, quality)
+}
+
+// AddTextureWithOptions is the same as the AddTexture function, but the given
+// options are applied instead of the global ones.
+func (c *Cache) AddTextureWithOptions(t *texture.Textures, filePath string, wrapR, wrapS, minificationFilter, magnificationFilter int32, uniformName string, opts Options) error {
+	if c.ReserveWithOptions(filePath, wrapR, wrapS, minificationFilter, magnificationFilter, opts) {
+		return c.AddReservedWithOptions(t, filePath, wrapR, wrapS, minificationFilter, magnificationFilter, uniformName, opts)
+	}
+	data, err := texturecontainer.Load(filePath)
+	if err != nil {
+		return err
+	}
+	return c.AddTextureContainerWithOptions(t, filePath, data, wrapR, wrapS, minificationFilter, magnificationFilter, uniformName, opts)
+}
+
+// AddTextureRGBA is the same as the AddTexture function, but the image is already
+// decoded. The rgba could be nil if the texture is resident.
+func (c *Cache) AddTextureRGBA(t *texture.Textures, filePath string, rgba *image.RGBA, wrapR, wrapS, minificationFilter, magnificationFilter int32, uniformName string) error {
+	return c.AddTextureRGBAWithOptions(t, filePath, rgba, wrapR, wrapS, minificationFilter, magnificationFilter, uniformName, quality)
+}
+
+// AddTextureRGBAWithOptions is the same as the AddTextureRGBA function, but the
+// given options are applied instead of the global ones.
+func (c *Cache) AddTextureRGBAWithOptions(t *texture.Textures, filePath string, rgba *image.RGBA, wrapR, wrapS, minificationFilter, magnificationFilter int32, uniformName string, opts Options) error {
+	var data *texturecontainer.Texture
+	if rgba != nil {
+		var err error
+		if data, err = texturecontainer.FromImage(rgba, texturecontainer.FORMAT_RGBA8, false); err != nil {
+			return err
+		}
+	}
+	return c.AddTextureContainerWithOptions(t, filePath, data, wrapR, wrapS, minificationFilter, magnificationFilter, uniformName, opts)
+}
+
+// AddTextureContainer is the same as the AddTexture function, but the container is
+// already decoded. The data could be nil if the texture is resident. The pre-built
+// mipmap levels of the container are uploaded instead of the generated ones.
+func (c *Cache) AddTextureContainer(t *texture.Textures, filePath string, data *texturecontainer.Texture, wrapR, wrapS, minificationFilter, magnificationFilter int32, uniformName string) error {
+	return c.AddTextureContainerWithOptions(t, filePath, data, wrapR, wrapS, minificationFilter, magnificationFilter, uniformName, quality)
+}
+
+// AddTextureContainerWithOptions is the same as the AddTextureContainer function,
+// but the given options are applied instead of the global ones.
+func (c *Cache) AddTextureContainerWithOptions(t *texture.Textures, filePath string, data *texturecontainer.Texture, wrapR, wrapS, minificationFilter, magnificationFilter int32, uniformName string, opts Options) error {
+	key := Key{filePath, wrapR, wrapS, minificationFilter, magnificationFilter, opts}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		if data == nil {
+			return fmt.Errorf("The texture '%s' is not resident and the image is missing.", filePath)
+		}
+		var err error
+		if entry, err = c.upload(key, data); err != nil {
+			return err
+		}
+		c.entries[key] = entry
+		c.names[entry.TextureName] = entry
+		if DEBUG {
+			fmt.Printf("Texture uploaded: '%s' (%d).\n", filePath, entry.TextureName)
+		}
+	}
+	entry.References++
+	c.appendTexture(t, entry, uniformName)
+	return nil
+}
+
+// appendTexture appends the texture of the entry to the given textures with
+// the next texture unit.
+func (c *Cache) appendTexture(t *texture.Textures, entry *Entry, uniformName string) {
+	*t = append(*t, &texture.Texture{
+		TextureName: entry.TextureName,
+		TargetId:    glwrapper.TEXTURE_2D,
+		Id:          glwrapper.TEXTURE0 + uint32(len(*t)),
+		UniformName: uniformName,
+		Wrapper:     c.wrapper,
+		FilePath:    entry.FilePath,
+	})
+}
+
+// upload creates the gl texture. It is the same as the AddTextureRGBA function
+// of the engine, but the minification filter, the mipmaps and the anisotropy
+// depend on the options of the key. The mipmaps are generated only if the
+// container doesn't have pre-built levels.
+func (c *Cache) upload(key Key, data *texturecontainer.Texture) (*Entry, error) {
+	var name uint32
+	c.wrapper.GenTextures(1, &name)
+	tex := &texture.Texture{
+		TextureName: name,
+		TargetId:    glwrapper.TEXTURE_2D,
+		Id:          glwrapper.TEXTURE0,
+		Wrapper:     c.wrapper,
+	}
+	tex.Bind()
+	defer tex.UnBind()
+
+	c.wrapper.TexParameteri(glwrapper.TEXTURE_2D, glwrapper.TEXTURE_WRAP_R, key.WrapR)
+	c.wrapper.TexParameteri(glwrapper.TEXTURE_2D, glwrapper.TEXTURE_WRAP_S, key.WrapS)
+	c.wrapper.TexParameteri(glwrapper.TEXTURE_2D, glwrapper.TEXTURE_MAG_FILTER, key.MagnificationFilter)
+	if err := data.Upload(c.wrapper, glwrapper.TEXTURE_2D); err != nil {
+		c.wrapper.DeleteTextures(1, &name)
+		return nil, err
+	}
+	key.Options.apply(c.wrapper, glwrapper.TEXTURE_2D, key.MinificationFilter, data.HasMipmaps())
+
+	return &Entry{
+		Key:         key,
+		TextureName: name,
+		Width:       data.Width,
+		Height:      data.Height,
+		Format:      data.Format,
+		Levels:      len(data.Levels),
+		Size:        data.Size(),
+	}, nil
+}
+
+// Release decrements the reference counter of the cached textures of the given
+// textures. The gl texture is deleted when its counter reaches zero. The textures
+// that are not in the cache are skipped.
+func (c *Cache) Release(t texture.Textures) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, tex := range t {
+		entry, ok := c.names[tex.TextureName]
+		if !ok {
+			continue
+		}
+		entry.References--
+		if entry.References > 0 {
+			continue
+		}
+		c.wrapper.DeleteTextures(1, &entry.TextureName)
+		delete(c.names, entry.TextureName)
+		delete(c.entries, entry.Key)
+		if DEBUG {
+			fmt.Printf("Texture deleted: '%s' (%d).\n", entry.FilePath, entry.TextureName)
+		}
+	}
+}
+
+// Resident returns the resident textures ordered by their file paths.
+func (c *Cache) Resident() []Entry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var result []Entry
+	for _, entry := range c.entries {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].FilePath == result[j].FilePath {
+			return result[i].TextureName < result[j].TextureName
+		}
+		return result[i].FilePath < result[j].FilePath
+	})
+	return result
+}
+
+// Log returns the debug listing of the resident textures: the file path,
+// the name, the size, the references and the estimated memory of the textures.
+func (c *Cache) Log() string {
+	resident := c.Resident()
+	total := 0
+	logString := fmt.Sprintf("Resident textures: %d\n", len(resident))
+	for _, entry := range resident {
+		total += entry.Bytes()
+		logString += fmt.Sprintf("\t%s (%d): %dx%d %s, levels: %d, refs: %d, %.2f MB\n", entry.FilePath, entry.TextureName, entry.Width, entry.Height, entry.Format, entry.Levels, entry.References, float64(entry.Bytes())/(1<<20))
+	}
+	logString += fmt.Sprintf("Total: %.2f MB\n", float64(total)/(1<<20))
+	return logString
+}
@@ -0,0 +1,13 @@
+package texturecache
+
+import (
+	"image"
+
+	"github.com/akosgarai/opengl_playground/pkg/texturecontainer"
+)
+
+// DecodeImage loads the image file (png or jpeg) and converts it to RGBA.
+// It doesn't call gl functions, so that it could be called from any goroutine.
+func DecodeImage(filePath string) (*image.RGBA, error) {
+	return texturecontainer.DecodeImage(filePath)
+}
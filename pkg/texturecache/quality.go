@@ -0,0 +1,131 @@
+package texturecache
+
+import (
+	"github.com/akosgarai/opengl_playground/pkg/glext"
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+const (
+	// The minification filters that are missing from the glwrapper package.
+	NEAREST                = gl.NEAREST
+	NEAREST_MIPMAP_NEAREST = gl.NEAREST_MIPMAP_NEAREST
+	LINEAR_MIPMAP_NEAREST  = gl.LINEAR_MIPMAP_NEAREST
+	NEAREST_MIPMAP_LINEAR  = gl.NEAREST_MIPMAP_LINEAR
+	LINEAR_MIPMAP_LINEAR   = gl.LINEAR_MIPMAP_LINEAR
+
+	// The texture quality levels of the SetQuality function.
+	QUALITY_LOW    = 0
+	QUALITY_MEDIUM = 1
+	QUALITY_HIGH   = 2
+)
+
+// Options are the optional sampler parameters of the textures.
+type Options struct {
+	// If it is true, the mipmaps are generated. Without mipmaps, the
+	// mipmap minification filters are replaced with their base filter.
+	Mipmaps bool
+	// If it is not 0, it is used as the minification filter instead
+	// of the given one (eg. LINEAR_MIPMAP_LINEAR).
+	MinificationFilter int32
+	// The maximum anisotropy of the sampling. It is clamped to the maximum
+	// supported value. The 1 (or less) value means disabled anisotropic filtering.
+	Anisotropy float32
+}
+
+var (
+	// The quality presets of the SetQuality function.
+	QualityOptions = map[int]Options{
+		QUALITY_LOW:    {Mipmaps: false},
+		QUALITY_MEDIUM: {Mipmaps: true, MinificationFilter: LINEAR_MIPMAP_NEAREST, Anisotropy: 1},
+		QUALITY_HIGH:   {Mipmaps: true, MinificationFilter: LINEAR_MIPMAP_LINEAR, Anisotropy: 16},
+	}
+	// The options that are applied to every texture of the caches. The default
+	// is the low quality, that is the same as the textures of the engine.
+	quality = QualityOptions[QUALITY_LOW]
+	// The maximum supported anisotropy. It is queried on the first use.
+	maxAnisotropy = float32(-1)
+)
+
+// SetQuality sets the global texture quality to the given preset. The unknown
+// levels are ignored. It is applied to the textures that are added to a cache
+// after it, eg. by the importers and the asset loader with a cache. The textures
+// that are created without the cache (eg. by the model builders of the engine)
+// are not affected, the ApplyQuality function of the cache could be used for them.
+func SetQuality(level int) {
+	if opts, ok := QualityOptions[level]; ok {
+		quality = opts
+	}
+}
+
+// SetOptions sets the global texture options.
+func SetOptions(opts Options) {
+	quality = opts
+}
+
+// GetOptions returns the global texture options.
+func GetOptions() Options {
+	return quality
+}
+
+// minificationFilter returns the filter that is used with the options.
+func (o Options) minificationFilter(filter int32) int32 {
+	if o.MinificationFilter != 0 {
+		filter = o.MinificationFilter
+	}
+	if o.Mipmaps {
+		return filter
+	}
+	switch filter {
+	case NEAREST_MIPMAP_NEAREST, NEAREST_MIPMAP_LINEAR:
+		return NEAREST
+	case LINEAR_MIPMAP_NEAREST, LINEAR_MIPMAP_LINEAR:
+		return glwrapper.LINEAR
+	}
+	return filter
+}
+
+// apply sets the options of the bound texture. If the prebuilt flag is
+// true, the texture has uploaded mipmap levels, so they are not generated.
+func (o Options) apply(wrapper glext.GLWrapper, target uint32, filter int32, prebuilt bool) {
+	if o.Mipmaps && !prebuilt {
+		wrapper.GenerateMipmap(target)
+	}
+	wrapper.TexParameteri(target, glwrapper.TEXTURE_MIN_FILTER, o.minificationFilter(filter))
+	if o.Anisotropy > 1 {
+		if maxAnisotropy < 0 {
+			maxAnisotropy = 0
+			wrapper.GetFloatv(glext.MAX_TEXTURE_MAX_ANISOTROPY, &maxAnisotropy)
+		}
+		anisotropy := o.Anisotropy
+		if anisotropy > maxAnisotropy {
+			anisotropy = maxAnisotropy
+		}
+		if anisotropy > 1 {
+			wrapper.TexParameterfv(target, glext.TEXTURE_MAX_ANISOTROPY, &anisotropy)
+		}
+	}
+}
+
+// ApplyOptions applies the options to the given 2D textures. It could be
+// used for the textures that are created without the cache (eg. by the model
+// builders of the engine), that generate only the base level. The textures
+// are not added to the cache. The minificationFilter is used if the
+// MinificationFilter of the options is not set.
+func (c *Cache) ApplyOptions(t texture.Textures, opts Options, minificationFilter int32) {
+	for _, tex := range t {
+		if tex.TargetId != glwrapper.TEXTURE_2D {
+			continue
+		}
+		tex.Bind()
+		opts.apply(c.wrapper, tex.TargetId, minificationFilter, false)
+		tex.UnBind()
+	}
+}
+
+// ApplyQuality applies the global options to the given 2D textures.
+func (c *Cache) ApplyQuality(t texture.Textures, minificationFilter int32) {
+	c.ApplyOptions(t, quality, minificationFilter)
+}
@@ -0,0 +1,252 @@
+package texturecontainer
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+)
+
+// Decompress decodes the block compressed data to RGBA pixels. It is the cpu
+// fallback for the drivers without s3tc support.
+func Decompress(format Format, width, height int, data []byte) ([]byte, error) {
+	if !format.Compressed() {
+		return nil, errors.New("The format is not compressed.")
+	}
+	if len(data) < format.LevelSize(width, height) {
+		return nil, errors.New("The compressed data is too short.")
+	}
+	pix := make([]byte, width*height*4)
+	blockBytes := format.BlockBytes()
+	var block [64]byte
+	offset := 0
+	for by := 0; by < height; by += 4 {
+		for bx := 0; bx < width; bx += 4 {
+			src := data[offset : offset+blockBytes]
+			offset += blockBytes
+			switch format {
+			case FORMAT_BC1:
+				decodeColorBlock(src, &block, true)
+			case FORMAT_BC2:
+				decodeColorBlock(src[8:], &block, false)
+				decodeExplicitAlpha(src[:8], &block)
+			case FORMAT_BC3:
+				decodeColorBlock(src[8:], &block, false)
+				decodeInterpolatedAlpha(src[:8], &block)
+			}
+			// The pixels of the block that are outside of the level are skipped.
+			for y := 0; y < 4 && by+y < height; y++ {
+				for x := 0; x < 4 && bx+x < width; x++ {
+					copy(pix[((by+y)*width+bx+x)*4:], block[(y*4+x)*4:(y*4+x)*4+4])
+				}
+			}
+		}
+	}
+	return pix, nil
+}
+
+// rgb565 expands the 16 bit color to 8 bit channels.
+func rgb565(c uint16) [3]int {
+	r := int(c>>11) & 0x1f
+	g := int(c>>5) & 0x3f
+	b := int(c) & 0x1f
+	return [3]int{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2}
+}
+
+// colorPalette returns the 4 colors of the color block. In the BC1 format, if the
+// first endpoint is not greater than the second one, the 3 color mode is used,
+// where the last color is transparent black.
+func colorPalette(c0, c1 uint16, bc1 bool) [4][4]int {
+	e0, e1 := rgb565(c0), rgb565(c1)
+	var palette [4][4]int
+	for ch := 0; ch < 3; ch++ {
+		palette[0][ch] = e0[ch]
+		palette[1][ch] = e1[ch]
+		if c0 > c1 || !bc1 {
+			palette[2][ch] = (2*e0[ch] + e1[ch]) / 3
+			palette[3][ch] = (e0[ch] + 2*e1[ch]) / 3
+		} else {
+			palette[2][ch] = (e0[ch] + e1[ch]) / 2
+		}
+	}
+	palette[0][3], palette[1][3], palette[2][3] = 255, 255, 255
+	if c0 > c1 || !bc1 {
+		palette[3][3] = 255
+	}
+	return palette
+}
+func decodeColorBlock(src []byte, block *[64]byte, bc1 bool) {
+	c0 := binary.LittleEndian.Uint16(src[0:])
+	c1 := binary.LittleEndian.Uint16(src[2:])
+	indices := binary.LittleEndian.Uint32(src[4:])
+	palette := colorPalette(c0, c1, bc1)
+	for i := 0; i < 16; i++ {
+		color := palette[(indices>>(2*uint(i)))&3]
+		for ch := 0; ch < 4; ch++ {
+			block[i*4+ch] = byte(color[ch])
+		}
+	}
+}
+func decodeExplicitAlpha(src []byte, block *[64]byte) {
+	alpha := binary.LittleEndian.Uint64(src)
+	for i := 0; i < 16; i++ {
+		block[i*4+3] = byte((alpha>>(4*uint(i)))&0xf) * 17
+	}
+}
+
+// alphaPalette returns the 8 alpha values of the BC3 alpha block.
+func alphaPalette(a0, a1 int) [8]int {
+	palette := [8]int{a0, a1}
+	if a0 > a1 {
+		for i := 1; i < 7; i++ {
+			palette[i+1] = ((7-i)*a0 + i*a1) / 7
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			palette[i+1] = ((5-i)*a0 + i*a1) / 5
+		}
+		palette[6], palette[7] = 0, 255
+	}
+	return palette
+}
+func decodeInterpolatedAlpha(src []byte, block *[64]byte) {
+	palette := alphaPalette(int(src[0]), int(src[1]))
+	// The 3 bit indices are stored on 48 bits.
+	var indices uint64
+	for i := 7; i >= 2; i-- {
+		indices = indices<<8 | uint64(src[i])
+	}
+	for i := 0; i < 16; i++ {
+		block[i*4+3] = byte(palette[(indices>>(3*uint(i)))&7])
+	}
+}
+
+// Compress encodes the image with the BC1 or BC3 format. The endpoints of the
+// blocks are the corners of the bounding box of the colors, it is fast, and
+// good enough for the textures of the examples.
+func Compress(format Format, img *image.RGBA) []byte {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	blockBytes := format.BlockBytes()
+	data := make([]byte, format.LevelSize(width, height))
+	var block [64]byte
+	offset := 0
+	for by := 0; by < height; by += 4 {
+		for bx := 0; bx < width; bx += 4 {
+			// The pixels outside of the image are clamped to the edge.
+			for y := 0; y < 4; y++ {
+				for x := 0; x < 4; x++ {
+					pixel := img.PixOffset(img.Rect.Min.X+min(bx+x, width-1), img.Rect.Min.Y+min(by+y, height-1))
+					copy(block[(y*4+x)*4:(y*4+x)*4+4], img.Pix[pixel:pixel+4])
+				}
+			}
+			dst := data[offset : offset+blockBytes]
+			offset += blockBytes
+			if format == FORMAT_BC3 {
+				encodeInterpolatedAlpha(&block, dst[:8])
+				encodeColorBlock(&block, dst[8:], false)
+			} else {
+				encodeColorBlock(&block, dst, true)
+			}
+		}
+	}
+	return data
+}
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+func to565(c [3]int) uint16 {
+	return uint16(c[0]>>3)<<11 | uint16(c[1]>>2)<<5 | uint16(c[2]>>3)
+}
+func encodeColorBlock(block *[64]byte, dst []byte, bc1 bool) {
+	lo, hi := [3]int{255, 255, 255}, [3]int{0, 0, 0}
+	transparent := false
+	for i := 0; i < 16; i++ {
+		if bc1 && block[i*4+3] < 128 {
+			transparent = true
+			continue
+		}
+		for ch := 0; ch < 3; ch++ {
+			c := int(block[i*4+ch])
+			if c < lo[ch] {
+				lo[ch] = c
+			}
+			if c > hi[ch] {
+				hi[ch] = c
+			}
+		}
+	}
+	c0, c1 := to565(hi), to565(lo)
+	// The 4 color mode needs c0 > c1, the 3 color mode (with transparent) needs c0 <= c1.
+	if transparent {
+		if c0 > c1 {
+			c0, c1 = c1, c0
+		}
+	} else if c0 < c1 {
+		c0, c1 = c1, c0
+	}
+	palette := colorPalette(c0, c1, bc1)
+	var indices uint32
+	for i := 0; i < 16; i++ {
+		index := 0
+		if transparent && block[i*4+3] < 128 {
+			index = 3
+		} else if c0 != c1 {
+			best := -1
+			colors := 4
+			if transparent {
+				colors = 3
+			}
+			for p := 0; p < colors; p++ {
+				d := 0
+				for ch := 0; ch < 3; ch++ {
+					diff := int(block[i*4+ch]) - palette[p][ch]
+					d += diff * diff
+				}
+				if best < 0 || d < best {
+					best, index = d, p
+				}
+			}
+		}
+		indices |= uint32(index) << (2 * uint(i))
+	}
+	binary.LittleEndian.PutUint16(dst[0:], c0)
+	binary.LittleEndian.PutUint16(dst[2:], c1)
+	binary.LittleEndian.PutUint32(dst[4:], indices)
+}
+func encodeInterpolatedAlpha(block *[64]byte, dst []byte) {
+	lo, hi := 255, 0
+	for i := 0; i < 16; i++ {
+		a := int(block[i*4+3])
+		if a < lo {
+			lo = a
+		}
+		if a > hi {
+			hi = a
+		}
+	}
+	dst[0], dst[1] = byte(hi), byte(lo)
+	palette := alphaPalette(hi, lo)
+	var indices uint64
+	for i := 0; i < 16; i++ {
+		index := 0
+		if hi != lo {
+			best := -1
+			for p := 0; p < 8; p++ {
+				d := int(block[i*4+3]) - palette[p]
+				if d < 0 {
+					d = -d
+				}
+				if best < 0 || d < best {
+					best, index = d, p
+				}
+			}
+		}
+		indices |= uint64(index) << (3 * uint(i))
+	}
+	for i := 2; i < 8; i++ {
+		dst[i] = byte(indices)
+		indices >>= 8
+	}
+}
@@ -0,0 +1,92 @@
+package texturecontainer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// testImage returns an image with the given size, the pixels are set by the
+// pixel function.
+func testImage(width, height int, pixel func(x, y int) color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, pixel(x, y))
+		}
+	}
+	return img
+}
+
+// maxDifference returns the maximum difference of the channels of the pixels.
+func maxDifference(a, b []byte) int {
+	result := 0
+	for i := range a {
+		d := int(a[i]) - int(b[i])
+		if d < 0 {
+			d = -d
+		}
+		if d > result {
+			result = d
+		}
+	}
+	return result
+}
+
+func TestCompressDecompress(t *testing.T) {
+	testData := []struct {
+		name      string
+		format    Format
+		img       *image.RGBA
+		tolerance int
+	}{
+		{"bc1 solid", FORMAT_BC1, testImage(8, 8, func(x, y int) color.RGBA { return color.RGBA{255, 0, 0, 255} }), 0},
+		{"bc1 two colors", FORMAT_BC1, testImage(4, 4, func(x, y int) color.RGBA {
+			if x < 2 {
+				return color.RGBA{0, 0, 0, 255}
+			}
+			return color.RGBA{255, 255, 255, 255}
+		}), 0},
+		{"bc1 gradient", FORMAT_BC1, testImage(16, 16, func(x, y int) color.RGBA { return color.RGBA{uint8(x * 16), uint8(y * 16), 128, 255} }), 40},
+		{"bc1 partial blocks", FORMAT_BC1, testImage(6, 5, func(x, y int) color.RGBA { return color.RGBA{0, 255, 0, 255} }), 0},
+		{"bc3 solid alpha", FORMAT_BC3, testImage(8, 8, func(x, y int) color.RGBA { return color.RGBA{0, 0, 255, 128} }), 0},
+		{"bc3 alpha gradient", FORMAT_BC3, testImage(8, 8, func(x, y int) color.RGBA { return color.RGBA{255, 255, 255, uint8(x * 32)} }), 20},
+		{"bc3 partial blocks", FORMAT_BC3, testImage(5, 7, func(x, y int) color.RGBA { return color.RGBA{255, 0, 255, 64} }), 0},
+	}
+	for _, tt := range testData {
+		data := Compress(tt.format, tt.img)
+		if len(data) != tt.format.LevelSize(tt.img.Rect.Dx(), tt.img.Rect.Dy()) {
+			t.Errorf("%s: Invalid compressed size. Instead of '%d', we have '%d'.", tt.name, tt.format.LevelSize(tt.img.Rect.Dx(), tt.img.Rect.Dy()), len(data))
+			continue
+		}
+		pix, err := Decompress(tt.format, tt.img.Rect.Dx(), tt.img.Rect.Dy(), data)
+		if err != nil {
+			t.Errorf("%s: Decompress shouldn't fail. '%s'.", tt.name, err.Error())
+			continue
+		}
+		if len(pix) != len(tt.img.Pix) {
+			t.Errorf("%s: Invalid decompressed size. Instead of '%d', we have '%d'.", tt.name, len(tt.img.Pix), len(pix))
+			continue
+		}
+		if d := maxDifference(pix, tt.img.Pix); d > tt.tolerance {
+			t.Errorf("%s: The difference '%d' is greater than '%d'.", tt.name, d, tt.tolerance)
+		}
+	}
+}
+
+func TestDecompressError(t *testing.T) {
+	testData := []struct {
+		name   string
+		format Format
+		data   []byte
+	}{
+		{"uncompressed", FORMAT_RGBA8, make([]byte, 64)},
+		{"short bc1", FORMAT_BC1, make([]byte, 7)},
+		{"short bc3", FORMAT_BC3, make([]byte, 15)},
+	}
+	for _, tt := range testData {
+		if _, err := Decompress(tt.format, 4, 4, tt.data); err == nil {
+			t.Errorf("%s: Decompress should fail.", tt.name)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package texturecontainer
+
+import (
+	"bytes"
+	"image/color"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// testTextures returns the textures of the container tests.
+func testTextures(t *testing.T) map[string]*Texture {
+	img := testImage(8, 4, func(x, y int) color.RGBA { return color.RGBA{uint8(x * 30), uint8(y * 60), 200, uint8(255 - x*10)} })
+	result := make(map[string]*Texture)
+	for name, input := range map[string]struct {
+		format  Format
+		mipmaps bool
+	}{
+		"rgba8":         {FORMAT_RGBA8, false},
+		"rgba8 mipmaps": {FORMAT_RGBA8, true},
+		"bc1":           {FORMAT_BC1, false},
+		"bc1 mipmaps":   {FORMAT_BC1, true},
+		"bc3 mipmaps":   {FORMAT_BC3, true},
+	} {
+		tex, err := FromImage(img, input.format, input.mipmaps)
+		if err != nil {
+			t.Fatalf("%s: FromImage shouldn't fail. '%s'.", name, err.Error())
+		}
+		result[name] = tex
+	}
+	return result
+}
+
+func TestContainerRoundTrip(t *testing.T) {
+	containers := []struct {
+		name   string
+		encode func(io.Writer, *Texture) error
+		decode func([]byte) (*Texture, error)
+	}{
+		{"dds", EncodeDDS, DecodeDDS},
+		{"ktx2", EncodeKTX2, DecodeKTX2},
+		{"dds detected", EncodeDDS, Decode},
+		{"ktx2 detected", EncodeKTX2, Decode},
+	}
+	for _, c := range containers {
+		for name, tex := range testTextures(t) {
+			var buffer bytes.Buffer
+			if err := c.encode(&buffer, tex); err != nil {
+				t.Errorf("%s %s: Encode shouldn't fail. '%s'.", c.name, name, err.Error())
+				continue
+			}
+			decoded, err := c.decode(buffer.Bytes())
+			if err != nil {
+				t.Errorf("%s %s: Decode shouldn't fail. '%s'.", c.name, name, err.Error())
+				continue
+			}
+			if !reflect.DeepEqual(decoded, tex) {
+				t.Errorf("%s %s: The decoded texture has to be the same as the encoded one.", c.name, name)
+			}
+		}
+	}
+}
+
+func TestContainerDecodeError(t *testing.T) {
+	var dds, ktx2 bytes.Buffer
+	tex := testTextures(t)["bc1 mipmaps"]
+	if err := EncodeDDS(&dds, tex); err != nil {
+		t.Fatalf("EncodeDDS shouldn't fail. '%s'.", err.Error())
+	}
+	if err := EncodeKTX2(&ktx2, tex); err != nil {
+		t.Fatalf("EncodeKTX2 shouldn't fail. '%s'.", err.Error())
+	}
+	testData := []struct {
+		name   string
+		decode func([]byte) (*Texture, error)
+		data   []byte
+	}{
+		{"dds without magic", DecodeDDS, []byte("not a dds file")},
+		{"dds truncated header", DecodeDDS, dds.Bytes()[:64]},
+		{"dds truncated level", DecodeDDS, dds.Bytes()[:dds.Len()-1]},
+		{"ktx2 without identifier", DecodeKTX2, []byte("not a ktx2 file")},
+		{"ktx2 truncated header", DecodeKTX2, ktx2.Bytes()[:40]},
+		{"ktx2 truncated level", DecodeKTX2, ktx2.Bytes()[:ktx2.Len()-1]},
+		{"unknown container", Decode, []byte("unknown")},
+	}
+	for _, tt := range testData {
+		if _, err := tt.decode(tt.data); err == nil {
+			t.Errorf("%s: Decode should fail.", tt.name)
+		}
+	}
+}
+
+func TestContainerEncodeError(t *testing.T) {
+	empty := &Texture{Format: FORMAT_RGBA8, Width: 4, Height: 4}
+	short := &Texture{Format: FORMAT_BC1, Width: 4, Height: 4, Levels: []Level{{Width: 4, Height: 4, Data: make([]byte, 4)}}}
+	testData := []struct {
+		name   string
+		encode func(io.Writer, *Texture) error
+		tex    *Texture
+	}{
+		{"dds without levels", EncodeDDS, empty},
+		{"dds short level", EncodeDDS, short},
+		{"ktx2 without levels", EncodeKTX2, empty},
+		{"ktx2 short level", EncodeKTX2, short},
+	}
+	for _, tt := range testData {
+		var buffer bytes.Buffer
+		if err := tt.encode(&buffer, tt.tex); err == nil {
+			t.Errorf("%s: Encode should fail.", tt.name)
+		}
+	}
+}
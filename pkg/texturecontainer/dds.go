@@ -0,0 +1,261 @@
+package texturecontainer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	ddsHeaderSize      = 124
+	ddsPixelFormatSize = 32
+	ddsDX10HeaderSize  = 20
+
+	// The flags of the dds header.
+	ddsCaps        = 0x1
+	ddsHeight      = 0x2
+	ddsWidth       = 0x4
+	ddsPitch       = 0x8
+	ddsPixelFormat = 0x1000
+	ddsMipmapCount = 0x20000
+	ddsLinearSize  = 0x80000
+
+	// The flags of the pixel format.
+	ddsAlphaPixels = 0x1
+	ddsFourCC      = 0x4
+	ddsRGB         = 0x40
+
+	// The caps of the texture.
+	ddsCapsComplex = 0x8
+	ddsCapsTexture = 0x1000
+	ddsCapsMipmap  = 0x400000
+	// The cube map flag of the second caps.
+	ddsCaps2CubeMap = 0x200
+
+	// The dxgi formats of the dx10 header.
+	dxgiR8G8B8A8     = 28
+	dxgiR8G8B8A8SRGB = 29
+	dxgiBC1          = 71
+	dxgiBC1SRGB      = 72
+	dxgiBC2          = 74
+	dxgiBC2SRGB      = 75
+	dxgiBC3          = 77
+	dxgiBC3SRGB      = 78
+	dxgiB8G8R8A8     = 87
+	dxgiB8G8R8A8SRGB = 91
+)
+
+var (
+	ddsMagic = []byte("DDS ")
+
+	fourCCDXT1 = fourCC("DXT1")
+	fourCCDXT3 = fourCC("DXT3")
+	fourCCDXT5 = fourCC("DXT5")
+	fourCCDX10 = fourCC("DX10")
+)
+
+// ddsHeader is the header of the dds file, after the magic.
+type ddsHeader struct {
+	Size              uint32
+	Flags             uint32
+	Height            uint32
+	Width             uint32
+	PitchOrLinearSize uint32
+	Depth             uint32
+	MipMapCount       uint32
+	Reserved1         [11]uint32
+	PixelFormat       ddsPixelFormatHeader
+	Caps              uint32
+	Caps2             uint32
+	Caps3             uint32
+	Caps4             uint32
+	Reserved2         uint32
+}
+
+// ddsPixelFormatHeader is the pixel format part of the dds header.
+type ddsPixelFormatHeader struct {
+	Size        uint32
+	Flags       uint32
+	FourCC      uint32
+	RGBBitCount uint32
+	RBitMask    uint32
+	GBitMask    uint32
+	BBitMask    uint32
+	ABitMask    uint32
+}
+
+// ddsDX10Header is the extended header of the DX10 files.
+type ddsDX10Header struct {
+	DXGIFormat        uint32
+	ResourceDimension uint32
+	MiscFlag          uint32
+	ArraySize         uint32
+	MiscFlags2        uint32
+}
+
+func fourCC(code string) uint32 {
+	return binary.LittleEndian.Uint32([]byte(code))
+}
+
+// DecodeDDS decodes the dds container. The RGBA8 (with RGBA or BGRA
+// channel order), the DXT1, DXT3, DXT5 and the DX10 files with the same
+// formats are supported. The cube maps and the volume textures are not.
+func DecodeDDS(data []byte) (*Texture, error) {
+	if !bytes.HasPrefix(data, ddsMagic) {
+		return nil, errors.New("Missing dds magic.")
+	}
+	reader := bytes.NewReader(data[len(ddsMagic):])
+	var header ddsHeader
+	if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if header.Size != ddsHeaderSize || header.PixelFormat.Size != ddsPixelFormatSize {
+		return nil, errors.New("Invalid dds header size.")
+	}
+	if header.Caps2&ddsCaps2CubeMap != 0 || header.Depth > 1 {
+		return nil, errors.New("The dds cube maps and volume textures are not supported.")
+	}
+	format, swizzle, err := ddsFormat(reader, &header.PixelFormat)
+	if err != nil {
+		return nil, err
+	}
+	t := &Texture{
+		Format: format,
+		Width:  int(header.Width),
+		Height: int(header.Height),
+	}
+	levels := 1
+	if header.Flags&ddsMipmapCount != 0 && header.MipMapCount > 1 {
+		levels = int(header.MipMapCount)
+	}
+	offset := len(data) - reader.Len()
+	width, height := t.Width, t.Height
+	for i := 0; i < levels; i++ {
+		size := format.LevelSize(width, height)
+		if offset+size > len(data) {
+			return nil, fmt.Errorf("The dds level %d is truncated.", i)
+		}
+		level := Level{Width: width, Height: height, Data: data[offset : offset+size]}
+		if swizzle {
+			level.Data = swapRedBlue(level.Data)
+		}
+		t.Levels = append(t.Levels, level)
+		offset += size
+		width, height = maxInt(width/2, 1), maxInt(height/2, 1)
+	}
+	return t, nil
+}
+
+// ddsFormat returns the format of the pixel format header. The swizzle
+// flag is true if the red and the blue channels have to be swapped.
+func ddsFormat(reader io.Reader, pf *ddsPixelFormatHeader) (Format, bool, error) {
+	if pf.Flags&ddsFourCC != 0 {
+		switch pf.FourCC {
+		case fourCCDXT1:
+			return FORMAT_BC1, false, nil
+		case fourCCDXT3:
+			return FORMAT_BC2, false, nil
+		case fourCCDXT5:
+			return FORMAT_BC3, false, nil
+		case fourCCDX10:
+			var header ddsDX10Header
+			if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
+				return 0, false, err
+			}
+			if header.ArraySize > 1 {
+				return 0, false, errors.New("The dds texture arrays are not supported.")
+			}
+			switch header.DXGIFormat {
+			case dxgiR8G8B8A8, dxgiR8G8B8A8SRGB:
+				return FORMAT_RGBA8, false, nil
+			case dxgiB8G8R8A8, dxgiB8G8R8A8SRGB:
+				return FORMAT_RGBA8, true, nil
+			case dxgiBC1, dxgiBC1SRGB:
+				return FORMAT_BC1, false, nil
+			case dxgiBC2, dxgiBC2SRGB:
+				return FORMAT_BC2, false, nil
+			case dxgiBC3, dxgiBC3SRGB:
+				return FORMAT_BC3, false, nil
+			}
+			return 0, false, fmt.Errorf("Unsupported dxgi format: %d.", header.DXGIFormat)
+		}
+		return 0, false, fmt.Errorf("Unsupported dds fourcc: 0x%08x.", pf.FourCC)
+	}
+	if pf.Flags&ddsRGB != 0 && pf.RGBBitCount == 32 {
+		switch {
+		case pf.RBitMask == 0x000000ff && pf.GBitMask == 0x0000ff00 && pf.BBitMask == 0x00ff0000:
+			return FORMAT_RGBA8, false, nil
+		case pf.RBitMask == 0x00ff0000 && pf.GBitMask == 0x0000ff00 && pf.BBitMask == 0x000000ff:
+			return FORMAT_RGBA8, true, nil
+		}
+	}
+	return 0, false, errors.New("Unsupported dds pixel format.")
+}
+
+// swapRedBlue returns a copy of the BGRA pixels with RGBA channel order.
+func swapRedBlue(pix []byte) []byte {
+	result := make([]byte, len(pix))
+	for i := 0; i+3 < len(pix); i += 4 {
+		result[i], result[i+1], result[i+2], result[i+3] = pix[i+2], pix[i+1], pix[i], pix[i+3]
+	}
+	return result
+}
+
+// EncodeDDS writes the texture as dds container. The RGBA8 format is written
+// with channel masks, the BC1 as DXT1, the BC2 as DXT3, the BC3 as DXT5.
+func EncodeDDS(w io.Writer, t *Texture) error {
+	if err := t.validate(); err != nil {
+		return err
+	}
+	header := ddsHeader{
+		Size:   ddsHeaderSize,
+		Flags:  ddsCaps | ddsHeight | ddsWidth | ddsPixelFormat,
+		Height: uint32(t.Height),
+		Width:  uint32(t.Width),
+		Caps:   ddsCapsTexture,
+		PixelFormat: ddsPixelFormatHeader{
+			Size: ddsPixelFormatSize,
+		},
+	}
+	if len(t.Levels) > 1 {
+		header.Flags |= ddsMipmapCount
+		header.MipMapCount = uint32(len(t.Levels))
+		header.Caps |= ddsCapsComplex | ddsCapsMipmap
+	}
+	switch t.Format {
+	case FORMAT_RGBA8:
+		header.Flags |= ddsPitch
+		header.PitchOrLinearSize = uint32(t.Width * 4)
+		header.PixelFormat.Flags = ddsRGB | ddsAlphaPixels
+		header.PixelFormat.RGBBitCount = 32
+		header.PixelFormat.RBitMask = 0x000000ff
+		header.PixelFormat.GBitMask = 0x0000ff00
+		header.PixelFormat.BBitMask = 0x00ff0000
+		header.PixelFormat.ABitMask = 0xff000000
+	case FORMAT_BC1, FORMAT_BC2, FORMAT_BC3:
+		header.Flags |= ddsLinearSize
+		header.PitchOrLinearSize = uint32(t.Format.LevelSize(t.Width, t.Height))
+		header.PixelFormat.Flags = ddsFourCC
+		header.PixelFormat.FourCC = map[Format]uint32{
+			FORMAT_BC1: fourCCDXT1,
+			FORMAT_BC2: fourCCDXT3,
+			FORMAT_BC3: fourCCDXT5,
+		}[t.Format]
+	default:
+		return fmt.Errorf("The '%s' format could not be written to dds.", t.Format)
+	}
+	if _, err := w.Write(ddsMagic); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	for _, level := range t.Levels {
+		if _, err := w.Write(level.Data[:t.Format.LevelSize(level.Width, level.Height)]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package texturecontainer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// The header is the identifier, 9 uint32 fields and the index.
+	ktx2HeaderSize = 12 + 9*4 + 4*4 + 2*8
+	// The offset, the length and the uncompressed length of a level.
+	ktx2LevelIndexSize = 3 * 8
+
+	// The supported vulkan formats.
+	vkR8G8B8A8UNorm     = 37
+	vkR8G8B8A8SRGB      = 43
+	vkB8G8R8A8UNorm     = 44
+	vkB8G8R8A8SRGB      = 50
+	vkBC1RGBUNorm       = 131
+	vkBC1RGBSRGB        = 132
+	vkBC1RGBAUNorm      = 133
+	vkBC1RGBASRGB       = 134
+	vkBC2UNorm          = 135
+	vkBC2SRGB           = 136
+	vkBC3UNorm          = 137
+	vkBC3SRGB           = 138
+	ktx2SupercompressNo = 0
+
+	// The color models of the data format descriptor.
+	khrDFModelRGBSDA = 1
+	khrDFModelBC1A   = 128
+	khrDFModelBC2    = 129
+	khrDFModelBC3    = 130
+)
+
+var (
+	ktx2Identifier = []byte{0xab, 0x4b, 0x54, 0x58, 0x20, 0x32, 0x30, 0xbb, 0x0d, 0x0a, 0x1a, 0x0a}
+)
+
+// ktx2Header is the header of the ktx2 file, after the identifier.
+type ktx2Header struct {
+	VkFormat               uint32
+	TypeSize               uint32
+	PixelWidth             uint32
+	PixelHeight            uint32
+	PixelDepth             uint32
+	LayerCount             uint32
+	FaceCount              uint32
+	LevelCount             uint32
+	SupercompressionScheme uint32
+	DFDByteOffset          uint32
+	DFDByteLength          uint32
+	KVDByteOffset          uint32
+	KVDByteLength          uint32
+	SGDByteOffset          uint64
+	SGDByteLength          uint64
+}
+
+// ktx2LevelIndex is an entry of the level index.
+type ktx2LevelIndex struct {
+	ByteOffset             uint64
+	ByteLength             uint64
+	UncompressedByteLength uint64
+}
+
+// DecodeKTX2 decodes the ktx2 container. The RGBA8, BGRA8, BC1, BC2 and BC3
+// vulkan formats are supported without supercompression. The cube maps, the
+// arrays and the volume textures are not supported.
+func DecodeKTX2(data []byte) (*Texture, error) {
+	if !bytes.HasPrefix(data, ktx2Identifier) {
+		return nil, errors.New("Missing ktx2 identifier.")
+	}
+	reader := bytes.NewReader(data[len(ktx2Identifier):])
+	var header ktx2Header
+	if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if header.SupercompressionScheme != ktx2SupercompressNo {
+		return nil, errors.New("The ktx2 supercompression is not supported.")
+	}
+	if header.FaceCount > 1 || header.LayerCount > 1 || header.PixelDepth > 0 {
+		return nil, errors.New("The ktx2 cube maps, arrays and volume textures are not supported.")
+	}
+	var format Format
+	swizzle := false
+	switch header.VkFormat {
+	case vkR8G8B8A8UNorm, vkR8G8B8A8SRGB:
+		format = FORMAT_RGBA8
+	case vkB8G8R8A8UNorm, vkB8G8R8A8SRGB:
+		format, swizzle = FORMAT_RGBA8, true
+	case vkBC1RGBUNorm, vkBC1RGBSRGB, vkBC1RGBAUNorm, vkBC1RGBASRGB:
+		format = FORMAT_BC1
+	case vkBC2UNorm, vkBC2SRGB:
+		format = FORMAT_BC2
+	case vkBC3UNorm, vkBC3SRGB:
+		format = FORMAT_BC3
+	default:
+		return nil, fmt.Errorf("Unsupported ktx2 vulkan format: %d.", header.VkFormat)
+	}
+	levels := int(header.LevelCount)
+	// The 0 level count means that the mipmaps has to be generated.
+	if levels == 0 {
+		levels = 1
+	}
+	t := &Texture{
+		Format: format,
+		Width:  int(header.PixelWidth),
+		Height: int(header.PixelHeight),
+	}
+	width, height := t.Width, t.Height
+	for i := 0; i < levels; i++ {
+		var index ktx2LevelIndex
+		if err := binary.Read(reader, binary.LittleEndian, &index); err != nil {
+			return nil, err
+		}
+		if index.ByteOffset+index.ByteLength > uint64(len(data)) {
+			return nil, fmt.Errorf("The ktx2 level %d is truncated.", i)
+		}
+		level := Level{Width: width, Height: height, Data: data[index.ByteOffset : index.ByteOffset+index.ByteLength]}
+		if swizzle {
+			level.Data = swapRedBlue(level.Data)
+		}
+		t.Levels = append(t.Levels, level)
+		width, height = maxInt(width/2, 1), maxInt(height/2, 1)
+	}
+	return t, nil
+}
+
+// ktx2Format returns the vulkan format and the data format descriptor of the format.
+func ktx2Format(format Format) (uint32, []byte, error) {
+	// The basic descriptor block: the header, and the samples. A sample
+	// contains the bit offset, the bit length, the channel, the sample
+	// position and the lower and upper values.
+	type sample struct {
+		offset, length int
+		channel        uint8
+		upper          uint32
+	}
+	var vkFormat uint32
+	var model uint8
+	var dimension uint8
+	var samples []sample
+	switch format {
+	case FORMAT_RGBA8:
+		vkFormat, model = vkR8G8B8A8UNorm, khrDFModelRGBSDA
+		samples = []sample{{0, 8, 0, 255}, {8, 8, 1, 255}, {16, 8, 2, 255}, {24, 8, 15, 255}}
+	case FORMAT_BC1:
+		vkFormat, model, dimension = vkBC1RGBAUNorm, khrDFModelBC1A, 3
+		samples = []sample{{0, 64, 1, 0xffffffff}}
+	case FORMAT_BC2:
+		vkFormat, model, dimension = vkBC2UNorm, khrDFModelBC2, 3
+		samples = []sample{{0, 64, 15, 0xffffffff}, {64, 64, 0, 0xffffffff}}
+	case FORMAT_BC3:
+		vkFormat, model, dimension = vkBC3UNorm, khrDFModelBC3, 3
+		samples = []sample{{0, 64, 15, 0xffffffff}, {64, 64, 0, 0xffffffff}}
+	default:
+		return 0, nil, fmt.Errorf("The '%s' format could not be written to ktx2.", format)
+	}
+	blockSize := 24 + 16*len(samples)
+	buffer := new(bytes.Buffer)
+	// The total size of the descriptor, the vendor, the type and the version.
+	binary.Write(buffer, binary.LittleEndian, uint32(4+blockSize))
+	binary.Write(buffer, binary.LittleEndian, uint32(0))
+	binary.Write(buffer, binary.LittleEndian, uint16(2))
+	binary.Write(buffer, binary.LittleEndian, uint16(blockSize))
+	// The color model, the primaries (bt709), the transfer (linear) and the flags.
+	buffer.Write([]byte{model, 1, 1, 0})
+	buffer.Write([]byte{dimension, dimension, 0, 0})
+	buffer.Write([]byte{byte(format.BlockBytes()), 0, 0, 0, 0, 0, 0, 0})
+	for _, s := range samples {
+		binary.Write(buffer, binary.LittleEndian, uint16(s.offset))
+		buffer.Write([]byte{byte(s.length - 1), s.channel})
+		buffer.Write([]byte{0, 0, 0, 0})
+		binary.Write(buffer, binary.LittleEndian, uint32(0))
+		binary.Write(buffer, binary.LittleEndian, s.upper)
+	}
+	return vkFormat, buffer.Bytes(), nil
+}
+
+// EncodeKTX2 writes the texture as ktx2 container, without supercompression.
+func EncodeKTX2(w io.Writer, t *Texture) error {
+	if err := t.validate(); err != nil {
+		return err
+	}
+	vkFormat, dfd, err := ktx2Format(t.Format)
+	if err != nil {
+		return err
+	}
+	typeSize := uint32(1)
+	// The levels are aligned to the least common multiple of the block size and 4.
+	alignment := 4
+	if t.Format.BlockBytes()%4 == 0 {
+		alignment = t.Format.BlockBytes()
+	}
+	dfdOffset := ktx2HeaderSize + ktx2LevelIndexSize*len(t.Levels)
+	header := ktx2Header{
+		VkFormat:      vkFormat,
+		TypeSize:      typeSize,
+		PixelWidth:    uint32(t.Width),
+		PixelHeight:   uint32(t.Height),
+		FaceCount:     1,
+		LevelCount:    uint32(len(t.Levels)),
+		DFDByteOffset: uint32(dfdOffset),
+		DFDByteLength: uint32(len(dfd)),
+	}
+	// The level data is stored from the smallest level to the base level.
+	index := make([]ktx2LevelIndex, len(t.Levels))
+	offset := dfdOffset + len(dfd)
+	var padding []int
+	for i := len(t.Levels) - 1; i >= 0; i-- {
+		pad := (alignment - offset%alignment) % alignment
+		padding = append(padding, pad)
+		offset += pad
+		size := t.Format.LevelSize(t.Levels[i].Width, t.Levels[i].Height)
+		index[i] = ktx2LevelIndex{uint64(offset), uint64(size), uint64(size)}
+		offset += size
+	}
+	if _, err := w.Write(ktx2Identifier); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, index); err != nil {
+		return err
+	}
+	if _, err := w.Write(dfd); err != nil {
+		return err
+	}
+	for i := len(t.Levels) - 1; i >= 0; i-- {
+		if _, err := w.Write(make([]byte, padding[len(t.Levels)-1-i])); err != nil {
+			return err
+		}
+		if _, err := w.Write(t.Levels[i].Data[:index[i].ByteLength]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
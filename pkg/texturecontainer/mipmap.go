@@ -0,0 +1,50 @@
+package texturecontainer
+
+import (
+	"image"
+)
+
+// MipChain returns the mipmap levels of the image, from the image itself to
+// the 1x1 level. Every level is calculated from the previous one with box
+// filter, the odd rows and columns are merged to the last pixel.
+func MipChain(img *image.RGBA) []*image.RGBA {
+	levels := []*image.RGBA{img}
+	current := img
+	for current.Rect.Dx() > 1 || current.Rect.Dy() > 1 {
+		current = halve(current)
+		levels = append(levels, current)
+	}
+	return levels
+}
+func halve(src *image.RGBA) *image.RGBA {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := maxInt(sw/2, 1), maxInt(sh/2, 1)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		// The source rows and columns of the destination pixel.
+		y0, y1 := y*sh/dh, (y+1)*sh/dh
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*sw/dw, (x+1)*sw/dw
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					offset := src.PixOffset(src.Rect.Min.X+sx, src.Rect.Min.Y+sy)
+					for ch := 0; ch < 4; ch++ {
+						sum[ch] += int(src.Pix[offset+ch])
+					}
+				}
+			}
+			count := (y1 - y0) * (x1 - x0)
+			for ch := 0; ch < 4; ch++ {
+				dst.Pix[y*dst.Stride+x*4+ch] = byte((sum[ch] + count/2) / count)
+			}
+		}
+	}
+	return dst
+}
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
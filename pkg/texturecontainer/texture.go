@@ -0,0 +1,247 @@
+package texturecontainer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format is the pixel format of the texture levels.
+type Format int
+
+const (
+	// 8 bit per channel, uncompressed RGBA.
+	FORMAT_RGBA8 Format = iota
+	// Block compressed formats (S3TC). BC1 is DXT1, BC2 is DXT3, BC3 is DXT5.
+	FORMAT_BC1
+	FORMAT_BC2
+	FORMAT_BC3
+)
+
+var (
+	unsupportedContainerError = errors.New("Unsupported texture container.")
+)
+
+// String returns the name of the format.
+func (f Format) String() string {
+	switch f {
+	case FORMAT_RGBA8:
+		return "RGBA8"
+	case FORMAT_BC1:
+		return "BC1"
+	case FORMAT_BC2:
+		return "BC2"
+	case FORMAT_BC3:
+		return "BC3"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Compressed returns true for the block compressed formats.
+func (f Format) Compressed() bool {
+	return f == FORMAT_BC1 || f == FORMAT_BC2 || f == FORMAT_BC3
+}
+
+// BlockBytes returns the size of a 4x4 block for the compressed formats
+// and the size of a pixel for the uncompressed one.
+func (f Format) BlockBytes() int {
+	switch f {
+	case FORMAT_BC1:
+		return 8
+	case FORMAT_BC2, FORMAT_BC3:
+		return 16
+	}
+	return 4
+}
+
+// LevelSize returns the data size of a level with the given dimensions.
+func (f Format) LevelSize(width, height int) int {
+	if !f.Compressed() {
+		return width * height * 4
+	}
+	return ((width + 3) / 4) * ((height + 3) / 4) * f.BlockBytes()
+}
+
+// Level is a mipmap level of the texture. The rows are stored from the top
+// to the bottom, like in the images, the compressed blocks are in row order.
+type Level struct {
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Texture is the content of a texture container. The first level is the base
+// level, the others are the pre-built mipmap levels.
+type Texture struct {
+	Format Format
+	Width  int
+	Height int
+	Levels []Level
+}
+
+// Size returns the data size of the levels.
+func (t *Texture) Size() int {
+	size := 0
+	for _, level := range t.Levels {
+		size += len(level.Data)
+	}
+	return size
+}
+
+// validate checks the data size of the levels.
+func (t *Texture) validate() error {
+	if len(t.Levels) == 0 {
+		return errors.New("The texture doesn't have levels.")
+	}
+	for index, level := range t.Levels {
+		if len(level.Data) < t.Format.LevelSize(level.Width, level.Height) {
+			return fmt.Errorf("The data of the level %d is too short.", index)
+		}
+	}
+	return nil
+}
+
+// RGBA returns the given level as RGBA image. The compressed
+// levels are decoded on the cpu.
+func (t *Texture) RGBA(level int) (*image.RGBA, error) {
+	l := t.Levels[level]
+	img := image.NewRGBA(image.Rect(0, 0, l.Width, l.Height))
+	if !t.Format.Compressed() {
+		copy(img.Pix, l.Data)
+		return img, nil
+	}
+	pix, err := Decompress(t.Format, l.Width, l.Height, l.Data)
+	if err != nil {
+		return nil, err
+	}
+	img.Pix = pix
+	return img, nil
+}
+
+// FromImage returns an uncompressed or compressed texture from the image. If
+// the mipmaps flag is true, the mipmap chain is also built with box filter.
+func FromImage(img *image.RGBA, format Format, mipmaps bool) (*Texture, error) {
+	images := []*image.RGBA{img}
+	if mipmaps {
+		images = MipChain(img)
+	}
+	t := &Texture{
+		Format: format,
+		Width:  img.Rect.Dx(),
+		Height: img.Rect.Dy(),
+	}
+	for _, level := range images {
+		l := Level{Width: level.Rect.Dx(), Height: level.Rect.Dy()}
+		switch format {
+		case FORMAT_RGBA8:
+			l.Data = rgbaPixels(level)
+		case FORMAT_BC1, FORMAT_BC3:
+			l.Data = Compress(format, level)
+		default:
+			return nil, fmt.Errorf("The '%s' format could not be encoded.", format)
+		}
+		t.Levels = append(t.Levels, l)
+	}
+	return t, nil
+}
+
+// rgbaPixels returns the pixels of the image without padding.
+func rgbaPixels(img *image.RGBA) []byte {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	if img.Stride == width*4 && img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y) == 0 {
+		return img.Pix[:width*height*4]
+	}
+	pix := make([]byte, width*height*4)
+	for y := 0; y < height; y++ {
+		offset := img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y)
+		copy(pix[y*width*4:(y+1)*width*4], img.Pix[offset:offset+width*4])
+	}
+	return pix
+}
+
+// DecodeImage loads the image file (png or jpeg) and converts it to RGBA.
+// It doesn't call gl functions, so that it could be called from any goroutine.
+func DecodeImage(filePath string) (*image.RGBA, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba, nil
+}
+
+// IsContainer returns true if the extension of the file is .dds or .ktx2.
+func IsContainer(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".dds", ".ktx2":
+		return true
+	}
+	return false
+}
+
+// Resolve returns the path of the converted container of the image, if it
+// exists next to the image with the same name (the ktx2 is preferred to the
+// dds). Otherwise the original path is returned.
+func Resolve(filePath string) string {
+	if IsContainer(filePath) {
+		return filePath
+	}
+	base := strings.TrimSuffix(filePath, filepath.Ext(filePath))
+	for _, ext := range []string{".ktx2", ".dds"} {
+		if _, err := os.Stat(base + ext); err == nil {
+			return base + ext
+		}
+	}
+	return filePath
+}
+
+// Decode decodes the dds or ktx2 container. The type is
+// detected from the first bytes of the data.
+func Decode(data []byte) (*Texture, error) {
+	var t *Texture
+	var err error
+	switch {
+	case bytes.HasPrefix(data, ddsMagic):
+		t, err = DecodeDDS(data)
+	case bytes.HasPrefix(data, ktx2Identifier):
+		t, err = DecodeKTX2(data)
+	default:
+		return nil, unsupportedContainerError
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, t.validate()
+}
+
+// Load loads the texture file. The dds and ktx2 containers are decoded with
+// their levels, the images (png, jpeg) are returned as one level RGBA8 texture.
+// It doesn't call gl functions, so that it could be called from any goroutine.
+func Load(filePath string) (*Texture, error) {
+	if !IsContainer(filePath) {
+		img, err := DecodeImage(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return FromImage(img, FORMAT_RGBA8, false)
+	}
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data)
+}
@@ -0,0 +1,123 @@
+package texturecontainer
+
+import (
+	"github.com/akosgarai/opengl_playground/pkg/glext"
+	"github.com/akosgarai/playground_engine/pkg/glwrapper"
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+const (
+	// The s3tc extension, that is required for the upload of the compressed levels.
+	S3TC_EXTENSION = "GL_EXT_texture_compression_s3tc"
+)
+
+var (
+	// If it is true, the compressed levels are always decoded on the cpu,
+	// it could be used for testing the fallback on the supported drivers.
+	ForceDecode = false
+	// The s3tc support of the driver. It is queried on the first use.
+	s3tcSupport = -1
+	// The internal formats of the compressed formats.
+	compressedFormats = map[Format]uint32{
+		FORMAT_BC1: gl.COMPRESSED_RGBA_S3TC_DXT1_EXT,
+		FORMAT_BC2: gl.COMPRESSED_RGBA_S3TC_DXT3_EXT,
+		FORMAT_BC3: gl.COMPRESSED_RGBA_S3TC_DXT5_EXT,
+	}
+)
+
+// S3TCSupported returns true if the driver supports the s3tc compressed
+// formats. It has to be called from the thread of the gl context.
+func S3TCSupported() bool {
+	if s3tcSupport < 0 {
+		// The gl wrapper interface doesn't provide the GetIntegerv and GetStringi functions.
+		s3tcSupport = 0
+		var count int32
+		gl.GetIntegerv(gl.NUM_EXTENSIONS, &count)
+		for i := uint32(0); i < uint32(count); i++ {
+			if gl.GoStr(gl.GetStringi(gl.EXTENSIONS, i)) == S3TC_EXTENSION {
+				s3tcSupport = 1
+				break
+			}
+		}
+	}
+	return s3tcSupport == 1
+}
+
+// Upload uploads the levels of the texture to the bound texture of the given
+// target. The compressed levels are uploaded without decoding if the driver
+// supports them, otherwise they are decoded on the cpu and uploaded as RGBA.
+func (t *Texture) Upload(wrapper interfaces.GLWrapper, target uint32) error {
+	compressed := t.Format.Compressed() && !ForceDecode && S3TCSupported()
+	for index, level := range t.Levels {
+		if compressed {
+			size := t.Format.LevelSize(level.Width, level.Height)
+			// The gl wrapper interface doesn't provide the CompressedTexImage2D function.
+			gl.CompressedTexImage2D(target, int32(index), compressedFormats[t.Format], int32(level.Width), int32(level.Height), 0, int32(size), wrapper.Ptr(level.Data))
+			continue
+		}
+		pix := level.Data
+		if t.Format.Compressed() {
+			rgba, err := t.RGBA(index)
+			if err != nil {
+				return err
+			}
+			pix = rgba.Pix
+		}
+		wrapper.TexImage2D(target, int32(index), glwrapper.RGBA, int32(level.Width), int32(level.Height), 0, glwrapper.RGBA, uint32(glwrapper.UNSIGNED_BYTE), wrapper.Ptr(pix))
+	}
+	// Without this, the texture would be incomplete if the chain doesn't go down to 1x1.
+	if t.HasMipmaps() {
+		wrapper.TexParameteri(target, gl.TEXTURE_MAX_LEVEL, int32(len(t.Levels)-1))
+	}
+	return nil
+}
+
+// HasMipmaps returns true if the texture contains pre-built mipmap levels.
+func (t *Texture) HasMipmaps() bool {
+	return len(t.Levels) > 1
+}
+
+// AddTo is the same as the AddTextureRGBA function of the engine, but it uploads
+// the levels of the texture. If the texture doesn't have mipmap levels and the
+// minification filter needs them, the mipmaps are generated. The texture is
+// deleted if the upload fails.
+func (t *Texture) AddTo(textures *texture.Textures, filePath string, wrapR, wrapS, minificationFilter, magnificationFilter int32, uniformName string, wrapper glext.GLWrapper) error {
+	var name uint32
+	wrapper.GenTextures(1, &name)
+	tex := &texture.Texture{
+		TextureName: name,
+		TargetId:    glwrapper.TEXTURE_2D,
+		Id:          glwrapper.TEXTURE0 + uint32(len(*textures)),
+		UniformName: uniformName,
+		Wrapper:     wrapper,
+		FilePath:    filePath,
+	}
+	tex.Bind()
+	defer tex.UnBind()
+
+	wrapper.TexParameteri(glwrapper.TEXTURE_2D, glwrapper.TEXTURE_WRAP_R, wrapR)
+	wrapper.TexParameteri(glwrapper.TEXTURE_2D, glwrapper.TEXTURE_WRAP_S, wrapS)
+	wrapper.TexParameteri(glwrapper.TEXTURE_2D, glwrapper.TEXTURE_MIN_FILTER, minificationFilter)
+	wrapper.TexParameteri(glwrapper.TEXTURE_2D, glwrapper.TEXTURE_MAG_FILTER, magnificationFilter)
+	if err := t.Upload(wrapper, glwrapper.TEXTURE_2D); err != nil {
+		wrapper.DeleteTextures(1, &name)
+		return err
+	}
+	if !t.HasMipmaps() && mipmapFilter(minificationFilter) {
+		wrapper.GenerateMipmap(glwrapper.TEXTURE_2D)
+	}
+	*textures = append(*textures, tex)
+	return nil
+}
+
+// mipmapFilter returns true if the minification filter samples the mipmaps.
+func mipmapFilter(filter int32) bool {
+	switch filter {
+	case gl.NEAREST_MIPMAP_NEAREST, gl.LINEAR_MIPMAP_NEAREST, gl.NEAREST_MIPMAP_LINEAR, gl.LINEAR_MIPMAP_LINEAR:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package tooltip
+
+import (
+	"github.com/akosgarai/playground_engine/pkg/interfaces"
+	"github.com/akosgarai/playground_engine/pkg/material"
+	"github.com/akosgarai/playground_engine/pkg/mesh"
+	"github.com/akosgarai/playground_engine/pkg/model"
+	"github.com/akosgarai/playground_engine/pkg/primitives/rectangle"
+	"github.com/akosgarai/playground_engine/pkg/screen"
+	"github.com/akosgarai/playground_engine/pkg/shader"
+	"github.com/akosgarai/playground_engine/pkg/texture"
+	"github.com/akosgarai/playground_engine/pkg/theme"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The click of this button hides the tooltip, it's the button of the form screen.
+const pickButton = glfw.MouseButtonLeft
+
+// FormScreen is a form screen that displays the description of the hovered
+// form item in a tooltip. The tooltip is displayed after the Delay next to
+// the cursor and it is kept inside the frame of the form.
+type FormScreen struct {
+	*screen.FormScreen
+	frameWidth float32
+	material   *material.Material
+	textColor  mgl32.Vec3
+	// The textures of the box. They are shared between the tooltips.
+	textures  texture.Textures
+	shader    *shader.Shader
+	charset   *model.Charset
+	target    interfaces.Model
+	hoverTime float64
+	tooltip   *model.BaseModel // It is nil, if the tooltip is hidden.
+	wrapper   interfaces.GLWrapper
+}
+
+// NewFormScreen returns a tooltip form screen. The frame size, the material
+// of the box and the color of the text are coming from the theme of the form.
+// The tooltip meshes don't have bounding object, so that they don't change the
+// hover state of the form items.
+func NewFormScreen(form *screen.FormScreen, t *theme.Theme, wrapper interfaces.GLWrapper) *FormScreen {
+	cs, err := model.LoadCharset(rootDir()+FontFile, 32, 127, 40.0, 72, wrapper)
+	if err != nil {
+		panic(err)
+	}
+	cs.SetTransparent(true)
+	var tex texture.Textures
+	tex.TransparentTexture(1, 1, 128, "tex.diffuse", wrapper)
+	tex.TransparentTexture(1, 1, 128, "tex.specular", wrapper)
+	f := &FormScreen{
+		FormScreen: form,
+		frameWidth: t.GetFrameWidth(),
+		material:   t.GetMenuItemDefaultMaterial(),
+		textColor:  t.GetLabelColor(),
+		textures:   tex,
+		shader:     shader.NewMaterialShader(wrapper),
+		charset:    cs,
+		wrapper:    wrapper,
+	}
+	fontShader := shader.NewFontShader(wrapper)
+	form.AddShader(f.shader)
+	form.AddShader(fontShader)
+	form.AddModelToShader(cs, fontShader)
+	return f
+}
+
+// Update calls the update of the form screen, then it handles the tooltip. The
+// hovered item has to be the same for the Delay. The click hides the tooltip.
+func (f *FormScreen) Update(dt float64, p interfaces.Pointer, keyStore interfaces.RoKeyStore, buttonStore interfaces.RoButtonStore) {
+	f.FormScreen.Update(dt, p, keyStore, buttonStore)
+	closestModel, _, dist := f.GetClosestModelMeshDistance()
+	item, ok := closestModel.(interfaces.FormItem)
+	if !ok || dist > HoverDistance || buttonStore.Get(pickButton) {
+		f.hideTooltip()
+		f.target = nil
+		return
+	}
+	if f.target != closestModel {
+		f.hideTooltip()
+		f.target = closestModel
+		f.hoverTime = 0
+		return
+	}
+	f.hoverTime += dt
+	if f.tooltip == nil && f.hoverTime >= Delay {
+		posX, posY := p.GetCurrent()
+		f.showTooltip(item.GetDescription(), float32(posX), float32(posY))
+	}
+}
+
+// showTooltip builds the tooltip box next to the cursor. The cursor position is
+// transformed to the coordinate system of the form, the same way as in the form
+// screen. The box is displayed under or over the cursor (on the side of the center
+// of the screen) and it is kept inside the frame.
+func (f *FormScreen) showTooltip(description string, posX, posY float32) {
+	if description == "" {
+		return
+	}
+	aspRatio := f.GetAspectRatio()
+	windowWidth, _ := f.GetWindowSize()
+	textScale := screen.InputTextFontScale / windowWidth * aspRatio
+	wW, hW := f.charset.TextContainerSize("W", textScale)
+	lines := WrapTextToLines(f.charset, description, textScale, MaxWidth)
+	textWidth := float32(0.0)
+	for _, line := range lines {
+		if w := f.charset.TextWidth(line, textScale); w > textWidth {
+			textWidth = w
+		}
+	}
+	width := textWidth + wW
+	height := (float32(len(lines))*1.5 + 0.5) * hW
+	cursorX := -posX * f.frameWidth / 2
+	cursorY := posY / aspRatio * f.frameWidth / 2
+	if cursorY > 0 {
+		cursorY -= CursorOffset + height/2
+	} else {
+		cursorY += CursorOffset + height/2
+	}
+	halfWidth := f.GetFullWidth() / 2
+	halfHeight := halfWidth / aspRatio
+	x := Clamp(cursorX, -halfWidth+width/2, halfWidth-width/2)
+	y := Clamp(cursorY, -halfHeight+height/2, halfHeight-height/2)
+	// The box is set up like the detail content box of the form.
+	v, i, _ := rectangle.NewExact(width, height).MeshInput()
+	box := mesh.NewTexturedMaterialMesh(v, i, f.textures, f.material, f.wrapper)
+	box.RotateX(90)
+	box.SetPosition(mgl32.Vec3{x, y, Z})
+	box.RotateX(-180)
+	box.RotateY(180)
+	for index, line := range lines {
+		lineVerticalPosition := height/2 - float32(index+1)*1.5*hW
+		f.charset.PrintTo(line, (-width+wW)/2, lineVerticalPosition, screen.ZText, textScale, f.wrapper, box, []mgl32.Vec3{f.textColor})
+	}
+	f.tooltip = model.New()
+	f.tooltip.AddMesh(box)
+	f.AddModelToShader(f.tooltip, f.shader)
+}
+
+// hideTooltip removes the tooltip box and its text from the screen.
+func (f *FormScreen) hideTooltip() {
+	if f.tooltip == nil {
+		return
+	}
+	box, err := f.tooltip.GetMeshByIndex(0)
+	if err == nil {
+		f.charset.CleanSurface(box)
+	}
+	f.RemoveModelFromShader(f.tooltip, f.shader)
+	f.tooltip = nil
+}
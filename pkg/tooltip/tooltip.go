@@ -0,0 +1,61 @@
+package tooltip
+
+import (
+	"path"
+	"runtime"
+	"strings"
+
+	"github.com/akosgarai/playground_engine/pkg/model"
+)
+
+const (
+	// The tooltip is displayed after this delay (ms). The description is wrapped
+	// to the max width. The hover distance is the same as in the form screen.
+	Delay         = float64(500)
+	MaxWidth      = float32(0.8)
+	HoverDistance = float32(0.01)
+	CursorOffset  = float32(0.05)
+	Z             = float32(-0.02)
+	// The font of the text, relative to the root of the repository.
+	FontFile = "/assets/fonts/Desyrel/desyrel.regular.ttf"
+)
+
+// rootDir returns the root directory of the repository.
+func rootDir() string {
+	_, filename, _, _ := runtime.Caller(1)
+	return path.Dir(path.Dir(path.Dir(filename)))
+}
+
+// WrapTextToLines splits the text to lines that are not wider than the maxWidth.
+// The words that are wider than the maxWidth are printed in a separate line.
+func WrapTextToLines(cs *model.Charset, text string, scale, maxWidth float32) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line == "" {
+			line = word
+			continue
+		}
+		if cs.TextWidth(line+" "+word, scale) > maxWidth {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		line = line + " " + word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Clamp returns the value limited to the [min, max] interval.
+func Clamp(value, min, max float32) float32 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}